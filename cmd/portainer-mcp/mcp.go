@@ -1,14 +1,29 @@
 package main
 
 import (
+	"context"
 	"flag"
+	stdlog "log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/portainer/portainer-mcp/internal/audit"
+	"github.com/portainer/portainer-mcp/internal/config"
 	"github.com/portainer/portainer-mcp/internal/mcp"
+	"github.com/portainer/portainer-mcp/internal/metrics"
+	"github.com/portainer/portainer-mcp/internal/policy"
 	"github.com/portainer/portainer-mcp/internal/tooldef"
+	"github.com/portainer/portainer-mcp/internal/tracing"
+	"github.com/portainer/portainer-mcp/pkg/portainer/client"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
-const defaultToolsPath = "tools.yaml"
+// tracingShutdownTimeout bounds the time given to the export of the last spans on exit
+const tracingShutdownTimeout = 5 * time.Second
 
 var (
 	Version   string
@@ -17,32 +32,52 @@ var (
 )
 
 func main() {
+	configFlag := flag.String("config", os.Getenv(config.EnvPrefix+"CONFIG"), "The path to the YAML config file")
+	config.RegisterFlags(flag.CommandLine)
+
+	flag.Parse()
+
+	cfg, err := config.Load(*configFlag)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to load config file")
+	}
+
+	if err := cfg.ApplyEnv(os.LookupEnv); err != nil {
+		log.Fatal().Err(err).Msg("invalid environment variable")
+	}
+
+	if err := cfg.ApplyFlags(flag.CommandLine); err != nil {
+		log.Fatal().Err(err).Msg("invalid flag")
+	}
+
+	if err := cfg.Validate(); err != nil {
+		log.Fatal().Err(err).Msg("invalid configuration")
+	}
+
+	configureLogging(cfg.Logging)
+
 	log.Info().
 		Str("version", Version).
 		Str("build-date", BuildDate).
 		Str("commit", Commit).
 		Msg("Portainer MCP server")
 
-	serverFlag := flag.String("server", "", "The Portainer server URL")
-	tokenFlag := flag.String("token", "", "The authentication token for the Portainer server")
-	toolsFlag := flag.String("tools", "", "The path to the tools YAML file")
-	readOnlyFlag := flag.Bool("read-only", false, "Run in read-only mode")
-	disableVersionCheckFlag := flag.Bool("disable-version-check", false, "Disable Portainer server version check")
-
-	flag.Parse()
+	if !mcp.IsValidTransport(cfg.Transport.Type) {
+		log.Fatal().Str("transport", cfg.Transport.Type).Msg("invalid transport, must be one of stdio, sse or streamable-http")
+	}
 
-	if *serverFlag == "" || *tokenFlag == "" {
-		log.Fatal().Msg("Both -server and -token flags are required")
+	if cfg.SessionCredentials && cfg.Transport.Type == mcp.TransportStdio {
+		log.Fatal().Msg("session credentials require the sse or streamable-http transport")
 	}
 
-	toolsPath := *toolsFlag
-	if toolsPath == "" {
-		toolsPath = defaultToolsPath
+	token, err := cfg.ReadToken()
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to read token")
 	}
 
 	// We first check if the tools.yaml file exists
 	// We'll create it from the embedded version if it doesn't exist
-	exists, err := tooldef.CreateToolsFileIfNotExists(toolsPath)
+	exists, err := tooldef.CreateToolsFileIfNotExists(cfg.Tools)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to create tools.yaml file")
 	}
@@ -54,13 +89,155 @@ func main() {
 	}
 
 	log.Info().
-		Str("portainer-host", *serverFlag).
-		Str("tools-path", toolsPath).
-		Bool("read-only", *readOnlyFlag).
-		Bool("disable-version-check", *disableVersionCheckFlag).
+		Str("config", *configFlag).
+		Str("portainer-host", cfg.Server).
+		Str("username", cfg.Username).
+		Str("tools-path", cfg.Tools).
+		Bool("read-only", cfg.ReadOnly).
+		Bool("dry-run", cfg.DryRun).
+		Bool("confirm-destructive", cfg.ConfirmDestructive).
+		Bool("disable-version-check", cfg.DisableVersionCheck).
+		Bool("tls-skip-verify", cfg.TLS.SkipVerify).
+		Str("tls-ca-cert", cfg.TLS.CACert).
+		Str("tls-client-cert", cfg.TLS.ClientCert).
+		Str("tls-pinned-fingerprint", cfg.TLS.PinnedFingerprint).
+		Str("transport", cfg.Transport.Type).
+		Bool("session-credentials", cfg.SessionCredentials).
+		Bool("resources", cfg.Resources).
+		Str("audit-log", cfg.Audit.Log).
+		Str("policy", cfg.Policy).
+		Dur("cache-ttl", cfg.Cache.TTL).
+		Str("metrics-listen", cfg.Metrics.Listen).
+		Str("tracing-endpoint", cfg.Tracing.Endpoint).
+		Int64("max-concurrent-requests", cfg.Limits.MaxConcurrent).
+		Float64("rate-limit", cfg.Limits.Rate).
+		Float64("environment-rate-limit", cfg.Limits.EnvironmentRate).
+		Str("instance", cfg.InstanceName).
+		Strs("instances", instanceNames(cfg.Instances)).
 		Msg("starting MCP server")
 
-	server, err := mcp.NewPortainerMCPServer(*serverFlag, *tokenFlag, toolsPath, mcp.WithReadOnly(*readOnlyFlag), mcp.WithDisableVersionCheck(*disableVersionCheckFlag))
+	serverOptions := []mcp.ServerOption{
+		mcp.WithReadOnly(cfg.ReadOnly),
+		mcp.WithDryRun(cfg.DryRun),
+		mcp.WithConfirmDestructive(cfg.ConfirmDestructive),
+		mcp.WithDisableVersionCheck(cfg.DisableVersionCheck),
+		mcp.WithSessionCredentials(cfg.SessionCredentials),
+		mcp.WithSkipTLSVerify(cfg.TLS.SkipVerify),
+		mcp.WithCACertificate(cfg.TLS.CACert),
+		mcp.WithClientCertificate(cfg.TLS.ClientCert, cfg.TLS.ClientKey),
+		mcp.WithPinnedCertificate(cfg.TLS.PinnedFingerprint),
+		mcp.WithCache(cfg.Cache.TTL, cfg.Cache.TTLs),
+		mcp.WithInstanceName(cfg.InstanceName),
+		mcp.WithLimits(client.Limits{
+			MaxConcurrent:    int(cfg.Limits.MaxConcurrent),
+			Rate:             cfg.Limits.Rate,
+			Burst:            int(cfg.Limits.Burst),
+			EnvironmentRate:  cfg.Limits.EnvironmentRate,
+			EnvironmentBurst: int(cfg.Limits.EnvironmentBurst),
+		}),
+	}
+
+	if cfg.Username != "" {
+		password, err := cfg.ReadPassword()
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to read password")
+		}
+
+		serverOptions = append(serverOptions, mcp.WithCredentials(cfg.Username, password))
+	}
+
+	for _, instance := range cfg.Instances {
+		instanceToken, err := instance.ReadToken()
+		if err != nil {
+			log.Fatal().Err(err).Str("instance", instance.Name).Msg("failed to read token")
+		}
+
+		instancePassword, err := instance.ReadPassword()
+		if err != nil {
+			log.Fatal().Err(err).Str("instance", instance.Name).Msg("failed to read password")
+		}
+
+		if instance.TLS.SkipVerify {
+			log.Warn().Str("instance", instance.Name).Msg("TLS verification of the Portainer server is disabled, the API token is sent to whichever server answers on the URL")
+		}
+
+		serverOptions = append(serverOptions, mcp.WithInstance(mcp.Instance{
+			Name:                instance.Name,
+			ServerURL:           instance.Server,
+			Token:               instanceToken,
+			Username:            instance.Username,
+			Password:            instancePassword,
+			ReadOnly:            cfg.ReadOnly || instance.ReadOnly,
+			DisableVersionCheck: cfg.DisableVersionCheck || instance.DisableVersionCheck,
+			TLS: mcp.TLSOptions{
+				SkipVerify:        instance.TLS.SkipVerify,
+				CACertFile:        instance.TLS.CACert,
+				ClientCertFile:    instance.TLS.ClientCert,
+				ClientKeyFile:     instance.TLS.ClientKey,
+				PinnedFingerprint: instance.TLS.PinnedFingerprint,
+			},
+		}))
+	}
+
+	if cfg.TLS.SkipVerify {
+		log.Warn().Msg("TLS verification of the Portainer server is disabled, the API token is sent to whichever server answers on the URL")
+	}
+
+	if cfg.Audit.Log != "" {
+		auditLogger, err := audit.NewLogger(cfg.Audit.Log, cfg.Audit.MaxSize*1024*1024)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to open audit log")
+		}
+		defer auditLogger.Close()
+
+		serverOptions = append(serverOptions, mcp.WithAuditLogger(auditLogger))
+	}
+
+	// The context of the servers running alongside the MCP server, cancelled when main returns
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if cfg.Metrics.Listen != "" {
+		m := metrics.New()
+		serverOptions = append(serverOptions, mcp.WithMetrics(m))
+
+		served := make(chan struct{})
+		go func() {
+			defer close(served)
+
+			log.Info().Str("listen", cfg.Metrics.Listen).Msg("serving metrics")
+			if err := m.Serve(ctx, cfg.Metrics.Listen); err != nil {
+				log.Error().Err(err).Msg("failed to serve metrics")
+			}
+		}()
+
+		// The metrics server is shut down before exiting
+		defer func() {
+			cancel()
+			<-served
+		}()
+	}
+
+	if cfg.Tracing.Endpoint != "" {
+		provider, err := tracing.NewProvider(context.Background(), cfg.Tracing.Endpoint, Version)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to configure tracing")
+		}
+		defer shutdownTracing(provider)
+
+		serverOptions = append(serverOptions, mcp.WithTracerProvider(provider))
+	}
+
+	if cfg.Policy != "" {
+		p, err := policy.Load(cfg.Policy)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to load policy")
+		}
+
+		serverOptions = append(serverOptions, mcp.WithPolicy(p))
+	}
+
+	server, err := mcp.NewPortainerMCPServer(cfg.Server, token, cfg.Tools, serverOptions...)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to create server")
 	}
@@ -69,15 +246,104 @@ func main() {
 	server.AddEnvironmentGroupFeatures()
 	server.AddTagFeatures()
 	server.AddStackFeatures()
+	server.AddEdgeStackFeatures()
 	server.AddSettingsFeatures()
 	server.AddUserFeatures()
 	server.AddTeamFeatures()
 	server.AddAccessGroupFeatures()
 	server.AddDockerProxyFeatures()
 	server.AddKubernetesProxyFeatures()
+	server.AddPromptFeatures()
+
+	if cfg.Resources {
+		server.AddResourceFeatures()
+	}
+
+	go reloadTokensOnSIGHUP(cfg, server)
+
+	if cfg.Transport.Type == mcp.TransportStdio {
+		err = server.Start()
+	} else {
+		signalCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
 
-	err = server.Start()
+		log.Info().Str("listen", cfg.Transport.Listen).Msg("serving MCP over HTTP")
+		err = server.StartHTTP(signalCtx, cfg.Transport.Type, cfg.Transport.Listen)
+	}
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to start server")
 	}
 }
+
+// configureLogging applies the logging settings to the global logger.
+// Logs are always written to stderr, as stdout is used by the stdio transport.
+func configureLogging(cfg config.LoggingConfig) {
+	level, err := zerolog.ParseLevel(cfg.Level)
+	if err != nil {
+		log.Fatal().Err(err).Str("level", cfg.Level).Msg("invalid log level")
+	}
+	zerolog.SetGlobalLevel(level)
+
+	if cfg.Format == config.LogFormatText {
+		log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr, NoColor: true})
+	}
+
+	// Messages of the libraries logging through the standard logger go through the same logger
+	stdlog.SetFlags(0)
+	stdlog.SetOutput(log.Logger)
+}
+
+// shutdownTracing flushes the spans that have not been exported yet
+func shutdownTracing(provider *sdktrace.TracerProvider) {
+	ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+	defer cancel()
+
+	if err := provider.Shutdown(ctx); err != nil {
+		log.Error().Err(err).Msg("failed to flush traces")
+	}
+}
+
+// reloadTokensOnSIGHUP re-reads the token files of the Portainer instances each time the process
+// receives SIGHUP, so that the API tokens can be rotated without restarting the server
+func reloadTokensOnSIGHUP(cfg *config.Config, server *mcp.PortainerMCPServer) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	for range signals {
+		if cfg.TokenFile != "" {
+			reloadToken(server, cfg.InstanceName, cfg.TokenFile, cfg.ReadToken)
+		}
+
+		for _, instance := range cfg.Instances {
+			if instance.TokenFile != "" {
+				reloadToken(server, instance.Name, instance.TokenFile, instance.ReadToken)
+			}
+		}
+	}
+}
+
+// reloadToken replaces the token of the named Portainer instance with the one read by readToken
+func reloadToken(server *mcp.PortainerMCPServer, name, tokenFile string, readToken func() (string, error)) {
+	token, err := readToken()
+	if err != nil {
+		log.Error().Err(err).Str("instance", name).Str("token-file", tokenFile).Msg("failed to reload token, keeping the current one")
+		return
+	}
+
+	if err := server.UpdateInstanceToken(name, token); err != nil {
+		log.Error().Err(err).Str("instance", name).Msg("failed to reload token")
+		return
+	}
+
+	log.Info().Str("instance", name).Str("token-file", tokenFile).Msg("reloaded token")
+}
+
+// instanceNames returns the names of the additional Portainer instances
+func instanceNames(instances []config.InstanceConfig) []string {
+	names := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		names = append(names, instance.Name)
+	}
+
+	return names
+}
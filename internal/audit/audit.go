@@ -0,0 +1,224 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Result statuses recorded in the audit log
+const (
+	StatusSuccess = "success"
+	StatusError   = "error"
+)
+
+// RedactedValue replaces the value of sensitive arguments in the audit log
+const RedactedValue = "[REDACTED]"
+
+// sensitiveKeys are the normalized argument or header names whose values are redacted
+var sensitiveKeys = []string{
+	"token",
+	"password",
+	"passwd",
+	"secret",
+	"apikey",
+	"authorization",
+	"registryauth",
+	"credential",
+	"privatekey",
+}
+
+// secretEntryKeys are the argument names whose key/value entries all hold secrets, such as the
+// environment variables of a stack, so every value is redacted whatever its key
+var secretEntryKeys = []string{
+	"env",
+}
+
+// Entry is a single record of the audit log
+type Entry struct {
+	Timestamp time.Time `json:"timestamp"`
+	Tool      string    `json:"tool"`
+	// Resource is the URI of the MCP resource read, Tool is then the equivalent tool
+	Resource      string         `json:"resource,omitempty"`
+	SessionID     string         `json:"session_id,omitempty"`
+	Instance      string         `json:"instance,omitempty"`
+	EnvironmentID *int           `json:"environment_id,omitempty"`
+	Arguments     map[string]any `json:"arguments,omitempty"`
+	Status        string         `json:"status"`
+	Error         string         `json:"error,omitempty"`
+	DurationMs    int64          `json:"duration_ms"`
+}
+
+// Logger writes audit entries to an append-only JSONL file.
+// When the file would grow beyond the maximum size, it is renamed with a
+// timestamp suffix and a new file is started. Rotated files are never removed.
+type Logger struct {
+	mu      sync.Mutex
+	path    string
+	maxSize int64
+	file    *os.File
+	size    int64
+	now     func() time.Time
+}
+
+// NewLogger opens (or creates) the audit log file at the given path.
+// A maxSize of 0 disables rotation.
+func NewLogger(path string, maxSize int64) (*Logger, error) {
+	l := &Logger{
+		path:    path,
+		maxSize: maxSize,
+		now:     time.Now,
+	}
+
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// Log appends an entry to the audit log. Sensitive arguments are redacted.
+func (l *Logger) Log(entry Entry) error {
+	entry.Arguments = Redact(entry.Arguments)
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+	data = append(data, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.maxSize > 0 && l.size > 0 && l.size+int64(len(data)) > l.maxSize {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := l.file.Write(data)
+	l.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes the audit log file
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.file.Close()
+}
+
+func (l *Logger) open() error {
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat audit log: %w", err)
+	}
+
+	l.file = file
+	l.size = info.Size()
+
+	return nil
+}
+
+func (l *Logger) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return fmt.Errorf("failed to close audit log: %w", err)
+	}
+
+	rotatedPath := fmt.Sprintf("%s.%s", l.path, l.now().UTC().Format("20060102T150405.000000000Z"))
+	if err := os.Rename(l.path, rotatedPath); err != nil {
+		return fmt.Errorf("failed to rotate audit log: %w", err)
+	}
+
+	return l.open()
+}
+
+// Redact returns a copy of the arguments where the values of sensitive arguments are replaced
+// by RedactedValue. This covers both named arguments (e.g. "password") and key/value
+// entries (e.g. a header {key: "Authorization", value: "..."}). All the values of the entries
+// of secretEntryKeys arguments (e.g. the "env" of a stack) are redacted.
+func Redact(args map[string]any) map[string]any {
+	if args == nil {
+		return nil
+	}
+
+	redacted := make(map[string]any, len(args))
+	for k, v := range args {
+		if isSensitiveKey(k) {
+			redacted[k] = RedactedValue
+			continue
+		}
+		if slices.Contains(secretEntryKeys, k) {
+			redacted[k] = redactEntryValues(v)
+			continue
+		}
+		redacted[k] = redactValue(v)
+	}
+
+	return redacted
+}
+
+func redactValue(v any) any {
+	switch value := v.(type) {
+	case map[string]any:
+		redacted := Redact(value)
+		if key, ok := value["key"].(string); ok && isSensitiveKey(key) {
+			if _, hasValue := value["value"]; hasValue {
+				redacted["value"] = RedactedValue
+			}
+		}
+		return redacted
+	case []any:
+		redacted := make([]any, len(value))
+		for i, item := range value {
+			redacted[i] = redactValue(item)
+		}
+		return redacted
+	default:
+		return v
+	}
+}
+
+// redactEntryValues redacts the value of every key/value entry of a list
+func redactEntryValues(v any) any {
+	entries, ok := v.([]any)
+	if !ok {
+		return redactValue(v)
+	}
+
+	redacted := make([]any, len(entries))
+	for i, entry := range entries {
+		redacted[i] = redactValue(entry)
+		if m, ok := redacted[i].(map[string]any); ok {
+			if _, hasValue := m["value"]; hasValue {
+				m["value"] = RedactedValue
+			}
+		}
+	}
+	return redacted
+}
+
+func isSensitiveKey(key string) bool {
+	normalized := strings.NewReplacer("-", "", "_", "", " ", "").Replace(strings.ToLower(key))
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(normalized, sensitive) {
+			return true
+		}
+	}
+	return false
+}
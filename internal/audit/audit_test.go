@@ -0,0 +1,177 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readEntries(t *testing.T, path string) []Entry {
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry Entry
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	require.NoError(t, scanner.Err())
+
+	return entries
+}
+
+func TestLoggerAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	environmentId := 2
+
+	logger, err := NewLogger(path, 0)
+	require.NoError(t, err)
+	require.NoError(t, logger.Log(Entry{Tool: "listStacks", Status: StatusSuccess}))
+	require.NoError(t, logger.Close())
+
+	// Reopening the log must append to the existing entries
+	logger, err = NewLogger(path, 0)
+	require.NoError(t, err)
+	require.NoError(t, logger.Log(Entry{
+		Tool:          "deleteStack",
+		EnvironmentID: &environmentId,
+		Arguments:     map[string]any{"id": float64(1), "endpointId": float64(2)},
+		Status:        StatusError,
+		Error:         "stack not found",
+		DurationMs:    12,
+	}))
+	require.NoError(t, logger.Close())
+
+	entries := readEntries(t, path)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "listStacks", entries[0].Tool)
+	assert.Nil(t, entries[0].EnvironmentID)
+	assert.Equal(t, "deleteStack", entries[1].Tool)
+	assert.Equal(t, 2, *entries[1].EnvironmentID)
+	assert.Equal(t, StatusError, entries[1].Status)
+	assert.Equal(t, "stack not found", entries[1].Error)
+	assert.Equal(t, int64(12), entries[1].DurationMs)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestLoggerRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.jsonl")
+
+	logger, err := NewLogger(path, 200)
+	require.NoError(t, err)
+	defer logger.Close()
+
+	tick := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+	logger.now = func() time.Time {
+		tick = tick.Add(time.Second)
+		return tick
+	}
+
+	for range 5 {
+		require.NoError(t, logger.Log(Entry{Tool: "listEnvironments", Status: StatusSuccess}))
+	}
+
+	rotated, err := filepath.Glob(path + ".*")
+	require.NoError(t, err)
+	assert.NotEmpty(t, rotated, "the log should have been rotated")
+
+	total := len(readEntries(t, path))
+	for _, file := range rotated {
+		info, err := os.Stat(file)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, info.Size(), int64(200))
+		total += len(readEntries(t, file))
+	}
+	assert.Equal(t, 5, total, "rotation must not lose entries")
+}
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    map[string]any
+		expected map[string]any
+	}{
+		{
+			name:     "nil arguments",
+			input:    nil,
+			expected: nil,
+		},
+		{
+			name:     "plain arguments are kept",
+			input:    map[string]any{"id": float64(1), "name": "web"},
+			expected: map[string]any{"id": float64(1), "name": "web"},
+		},
+		{
+			name:     "sensitive argument names",
+			input:    map[string]any{"password": "s3cret", "apiToken": "abc", "client_secret": "xyz"},
+			expected: map[string]any{"password": RedactedValue, "apiToken": RedactedValue, "client_secret": RedactedValue},
+		},
+		{
+			name: "sensitive key/value entries",
+			input: map[string]any{
+				"headers": []any{
+					map[string]any{"key": "Authorization", "value": "Bearer abc"},
+					map[string]any{"key": "X-Registry-Auth", "value": "eyJ"},
+					map[string]any{"key": "Accept", "value": "application/json"},
+				},
+			},
+			expected: map[string]any{
+				"headers": []any{
+					map[string]any{"key": "Authorization", "value": RedactedValue},
+					map[string]any{"key": "X-Registry-Auth", "value": RedactedValue},
+					map[string]any{"key": "Accept", "value": "application/json"},
+				},
+			},
+		},
+		{
+			name: "all values of environment variables",
+			input: map[string]any{
+				"name": "db",
+				"env": []any{
+					map[string]any{"key": "POSTGRES_DB", "value": "app"},
+					map[string]any{"key": "POSTGRES_PASSWORD", "value": "s3cret"},
+				},
+			},
+			expected: map[string]any{
+				"name": "db",
+				"env": []any{
+					map[string]any{"key": "POSTGRES_DB", "value": RedactedValue},
+					map[string]any{"key": "POSTGRES_PASSWORD", "value": RedactedValue},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Redact(tt.input))
+		})
+	}
+}
+
+func TestRedactDoesNotModifyInput(t *testing.T) {
+	input := map[string]any{
+		"password": "s3cret",
+		"headers":  []any{map[string]any{"key": "Authorization", "value": "Bearer abc"}},
+		"env":      []any{map[string]any{"key": "DEBUG", "value": "true"}},
+	}
+
+	Redact(input)
+
+	assert.Equal(t, "s3cret", input["password"])
+	assert.Equal(t, "Bearer abc", input["headers"].([]any)[0].(map[string]any)["value"])
+	assert.Equal(t, "true", input["env"].([]any)[0].(map[string]any)["value"])
+}
@@ -0,0 +1,95 @@
+package compose
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File is the part of a Docker Compose file that is compared between two versions of a stack
+type File struct {
+	Services map[string]Service `yaml:"services"`
+	Volumes  map[string]any     `yaml:"volumes"`
+}
+
+// Service is a service of a compose file. Ports and volumes accept both the short
+// (string) and the long (mapping) syntax.
+type Service struct {
+	Image   string `yaml:"image"`
+	Ports   []any  `yaml:"ports"`
+	Volumes []any  `yaml:"volumes"`
+}
+
+// Parse parses the content of a compose file
+func Parse(content string) (File, error) {
+	var file File
+	if err := yaml.Unmarshal([]byte(content), &file); err != nil {
+		return File{}, fmt.Errorf("invalid compose file: %w", err)
+	}
+
+	return file, nil
+}
+
+// PortSpecs returns the ports of the service in the short syntax, e.g. "127.0.0.1:8080:80/udp"
+func (s Service) PortSpecs() []string {
+	specs := make([]string, 0, len(s.Ports))
+	for _, port := range s.Ports {
+		specs = append(specs, portSpec(port))
+	}
+
+	return specs
+}
+
+// VolumeSpecs returns the volumes of the service in the short syntax, e.g. "data:/var/lib/data:ro"
+func (s Service) VolumeSpecs() []string {
+	specs := make([]string, 0, len(s.Volumes))
+	for _, volume := range s.Volumes {
+		specs = append(specs, volumeSpec(volume))
+	}
+
+	return specs
+}
+
+// portSpec converts a port of either syntax to the short syntax. The tcp protocol is
+// omitted, as it is the default of the short syntax.
+func portSpec(port any) string {
+	long, ok := port.(map[string]any)
+	if !ok {
+		return strings.TrimSpace(fmt.Sprint(port))
+	}
+
+	var parts []string
+	if hostIP := long["host_ip"]; hostIP != nil {
+		parts = append(parts, fmt.Sprint(hostIP))
+	}
+	if published := long["published"]; published != nil {
+		parts = append(parts, fmt.Sprint(published))
+	}
+	parts = append(parts, fmt.Sprint(long["target"]))
+
+	spec := strings.Join(parts, ":")
+	if protocol := long["protocol"]; protocol != nil && protocol != "tcp" {
+		spec += "/" + fmt.Sprint(protocol)
+	}
+
+	return spec
+}
+
+// volumeSpec converts a volume of either syntax to the short syntax
+func volumeSpec(volume any) string {
+	long, ok := volume.(map[string]any)
+	if !ok {
+		return strings.TrimSpace(fmt.Sprint(volume))
+	}
+
+	spec := fmt.Sprint(long["target"])
+	if source := long["source"]; source != nil {
+		spec = fmt.Sprintf("%v:%s", source, spec)
+	}
+	if readOnly, _ := long["read_only"].(bool); readOnly {
+		spec += ":ro"
+	}
+
+	return spec
+}
@@ -0,0 +1,83 @@
+package compose
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		expected      File
+		errorContains string
+	}{
+		{
+			name: "services and named volumes",
+			content: `services:
+  web:
+    image: nginx:1.27
+    ports:
+      - "8080:80"
+      - 443
+    volumes:
+      - data:/usr/share/nginx/html:ro
+volumes:
+  data:
+`,
+			expected: File{
+				Services: map[string]Service{
+					"web": {Image: "nginx:1.27", Ports: []any{"8080:80", 443}, Volumes: []any{"data:/usr/share/nginx/html:ro"}},
+				},
+				Volumes: map[string]any{"data": nil},
+			},
+		},
+		{
+			name:     "empty file",
+			content:  "",
+			expected: File{},
+		},
+		{
+			name:          "invalid yaml",
+			content:       "services:\n  web:\n    image: [nginx\n",
+			errorContains: "invalid compose file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := Parse(tt.content)
+
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, file)
+		})
+	}
+}
+
+func TestPortSpecs(t *testing.T) {
+	service := Service{Ports: []any{
+		"8080:80",
+		443,
+		map[string]any{"target": 53, "published": "5353", "protocol": "udp"},
+		map[string]any{"target": 80, "published": 8081, "host_ip": "127.0.0.1", "protocol": "tcp"},
+		map[string]any{"target": 9000},
+	}}
+
+	assert.Equal(t, []string{"8080:80", "443", "5353:53/udp", "127.0.0.1:8081:80", "9000"}, service.PortSpecs())
+}
+
+func TestVolumeSpecs(t *testing.T) {
+	service := Service{Volumes: []any{
+		"data:/var/lib/data",
+		map[string]any{"type": "bind", "source": "./config", "target": "/etc/app", "read_only": true},
+		map[string]any{"type": "tmpfs", "target": "/tmp"},
+	}}
+
+	assert.Equal(t, []string{"data:/var/lib/data", "./config:/etc/app:ro", "/tmp"}, service.VolumeSpecs())
+}
@@ -0,0 +1,149 @@
+package compose
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// Diff is the difference between the current and the proposed compose file of a stack
+type Diff struct {
+	Changed bool `json:"changed"`
+	// Unified is the unified diff of the two files, empty when they are identical
+	Unified string `json:"unified_diff"`
+	// Summary is nil when one of the files cannot be parsed, SummaryError then explains why
+	Summary      *Summary `json:"summary,omitempty"`
+	SummaryError string   `json:"summary_error,omitempty"`
+}
+
+// Summary lists the changes of the services, their images, ports and volumes, and of the
+// named volumes of a compose file
+type Summary struct {
+	ServicesAdded   []string        `json:"services_added"`
+	ServicesRemoved []string        `json:"services_removed"`
+	ServicesChanged []ServiceChange `json:"services_changed"`
+	VolumesAdded    []string        `json:"volumes_added"`
+	VolumesRemoved  []string        `json:"volumes_removed"`
+}
+
+// ServiceChange lists the changes of a service present in both files
+type ServiceChange struct {
+	Service        string       `json:"service"`
+	Image          *ImageChange `json:"image,omitempty"`
+	PortsAdded     []string     `json:"ports_added,omitempty"`
+	PortsRemoved   []string     `json:"ports_removed,omitempty"`
+	VolumesAdded   []string     `json:"volumes_added,omitempty"`
+	VolumesRemoved []string     `json:"volumes_removed,omitempty"`
+}
+
+// ImageChange is the change of the image of a service, often only its tag
+type ImageChange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Compare returns the unified diff and the summary of the changes between the current
+// and the proposed content of a compose file
+func Compare(current, proposed string) (Diff, error) {
+	unified, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(current),
+		B:        splitLines(proposed),
+		FromFile: "current",
+		ToFile:   "proposed",
+		Context:  3,
+	})
+	if err != nil {
+		return Diff{}, err
+	}
+
+	diff := Diff{Changed: unified != "", Unified: unified}
+
+	summary, err := summarize(current, proposed)
+	if err != nil {
+		diff.SummaryError = err.Error()
+		return diff, nil
+	}
+	diff.Summary = &summary
+
+	return diff, nil
+}
+
+func summarize(current, proposed string) (Summary, error) {
+	before, err := Parse(current)
+	if err != nil {
+		return Summary{}, err
+	}
+
+	after, err := Parse(proposed)
+	if err != nil {
+		return Summary{}, err
+	}
+
+	summary := Summary{
+		ServicesAdded:   added(slices.Collect(maps.Keys(before.Services)), slices.Collect(maps.Keys(after.Services))),
+		ServicesRemoved: added(slices.Collect(maps.Keys(after.Services)), slices.Collect(maps.Keys(before.Services))),
+		ServicesChanged: []ServiceChange{},
+		VolumesAdded:    added(slices.Collect(maps.Keys(before.Volumes)), slices.Collect(maps.Keys(after.Volumes))),
+		VolumesRemoved:  added(slices.Collect(maps.Keys(after.Volumes)), slices.Collect(maps.Keys(before.Volumes))),
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(after.Services)) {
+		oldService, ok := before.Services[name]
+		if !ok {
+			continue
+		}
+
+		if change, changed := compareService(name, oldService, after.Services[name]); changed {
+			summary.ServicesChanged = append(summary.ServicesChanged, change)
+		}
+	}
+
+	return summary, nil
+}
+
+func compareService(name string, before, after Service) (ServiceChange, bool) {
+	change := ServiceChange{
+		Service:        name,
+		PortsAdded:     added(before.PortSpecs(), after.PortSpecs()),
+		PortsRemoved:   added(after.PortSpecs(), before.PortSpecs()),
+		VolumesAdded:   added(before.VolumeSpecs(), after.VolumeSpecs()),
+		VolumesRemoved: added(after.VolumeSpecs(), before.VolumeSpecs()),
+	}
+
+	if before.Image != after.Image {
+		change.Image = &ImageChange{From: before.Image, To: after.Image}
+	}
+
+	changed := change.Image != nil ||
+		len(change.PortsAdded) > 0 || len(change.PortsRemoved) > 0 ||
+		len(change.VolumesAdded) > 0 || len(change.VolumesRemoved) > 0
+
+	return change, changed
+}
+
+// splitLines splits content into lines that all end with a newline, as expected by difflib
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+
+	lines := strings.SplitAfter(strings.TrimSuffix(content, "\n"), "\n")
+	lines[len(lines)-1] += "\n"
+
+	return lines
+}
+
+// added returns the items of after that are not in before, sorted
+func added(before, after []string) []string {
+	result := []string{}
+	for _, item := range after {
+		if !slices.Contains(before, item) && !slices.Contains(result, item) {
+			result = append(result, item)
+		}
+	}
+	slices.Sort(result)
+
+	return result
+}
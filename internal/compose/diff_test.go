@@ -0,0 +1,119 @@
+package compose
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const currentFile = `services:
+  web:
+    image: nginx:1.25
+    ports:
+      - "8080:80"
+    volumes:
+      - html:/usr/share/nginx/html
+  cache:
+    image: redis:7
+volumes:
+  html:
+`
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  string
+		proposed string
+		expected Diff
+	}{
+		{
+			name:     "identical files",
+			current:  currentFile,
+			proposed: currentFile,
+			expected: Diff{
+				Summary: &Summary{
+					ServicesAdded:   []string{},
+					ServicesRemoved: []string{},
+					ServicesChanged: []ServiceChange{},
+					VolumesAdded:    []string{},
+					VolumesRemoved:  []string{},
+				},
+			},
+		},
+		{
+			name:    "image tag, ports, volumes and services changed",
+			current: currentFile,
+			proposed: `services:
+  web:
+    image: nginx:1.27
+    ports:
+      - target: 80
+        published: 8080
+      - "8443:443"
+    volumes:
+      - static:/usr/share/nginx/html
+  db:
+    image: postgres:16
+volumes:
+  static:
+`,
+			expected: Diff{
+				Changed: true,
+				Summary: &Summary{
+					ServicesAdded:   []string{"db"},
+					ServicesRemoved: []string{"cache"},
+					ServicesChanged: []ServiceChange{
+						{
+							Service:        "web",
+							Image:          &ImageChange{From: "nginx:1.25", To: "nginx:1.27"},
+							PortsAdded:     []string{"8443:443"},
+							PortsRemoved:   []string{},
+							VolumesAdded:   []string{"static:/usr/share/nginx/html"},
+							VolumesRemoved: []string{"html:/usr/share/nginx/html"},
+						},
+					},
+					VolumesAdded:   []string{"static"},
+					VolumesRemoved: []string{"html"},
+				},
+			},
+		},
+		{
+			name:     "invalid proposed file",
+			current:  currentFile,
+			proposed: "services:\n  web:\n    image: [nginx\n",
+			expected: Diff{
+				Changed:      true,
+				SummaryError: "invalid compose file: yaml: line 2: did not find expected ',' or ']'",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff, err := Compare(tt.current, tt.proposed)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expected.Changed, diff.Changed)
+			assert.Equal(t, tt.expected.Summary, diff.Summary)
+			assert.Equal(t, tt.expected.SummaryError, diff.SummaryError)
+			if !tt.expected.Changed {
+				assert.Empty(t, diff.Unified)
+			}
+		})
+	}
+}
+
+func TestCompareUnifiedDiff(t *testing.T) {
+	diff, err := Compare("services:\n  web:\n    image: nginx:1.25\n", "services:\n  web:\n    image: nginx:1.27\n")
+	require.NoError(t, err)
+
+	assert.Equal(t, `--- current
++++ proposed
+@@ -1,3 +1,3 @@
+ services:
+   web:
+-    image: nginx:1.25
++    image: nginx:1.27
+`, diff.Unified)
+}
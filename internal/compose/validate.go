@@ -0,0 +1,411 @@
+package compose
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Issue is an error or a warning found in a compose file. Line is 0 when the issue
+// cannot be located in the file.
+type Issue struct {
+	Line    int    `json:"line,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+// Validation is the result of the validation of a compose file. The file is valid when
+// it has no errors, warnings point at risky settings that are still deployed.
+type Validation struct {
+	Valid    bool    `json:"valid"`
+	Errors   []Issue `json:"errors"`
+	Warnings []Issue `json:"warnings"`
+}
+
+var (
+	// yamlErrorPattern extracts the line number of the errors of the YAML parser
+	yamlErrorPattern = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+	// portPattern is the short syntax of a port: [[host_ip:]published[-range]:]target[-range][/protocol]
+	portPattern = regexp.MustCompile(`^(?:(?:\[[0-9a-fA-F:.]+\]|[0-9.]+):)?(?:(\d+(?:-\d+)?)?:)?(\d+(?:-\d+)?)(?:/(?:tcp|udp|sctp))?$`)
+
+	// variablePattern matches the $$ escape, ${VAR...} and $VAR
+	variablePattern = regexp.MustCompile(`\$(?:\$|\{([^}]*)\}|([A-Za-z_][A-Za-z0-9_]*))`)
+
+	// variableNamePattern splits the content of ${...} into the variable name and its modifier
+	variableNamePattern = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)(:?[-?+].*)?$`)
+
+	numberPattern = regexp.MustCompile(`\d+`)
+
+	volumeModes = []string{"ro", "rw", "z", "Z", "cached", "delegated", "consistent", "nocopy"}
+	volumeTypes = []string{"volume", "bind", "tmpfs", "npipe", "cluster"}
+)
+
+// Validate checks a compose file before it is deployed: the YAML syntax, the required fields of
+// the services, the syntax of their ports, volumes and variable references. It warns about the
+// ${VAR} references that env does not define, privileged services, host networking and images
+// using the latest tag.
+func Validate(content string, env map[string]string) Validation {
+	v := &validator{env: env, result: Validation{Errors: []Issue{}, Warnings: []Issue{}}}
+	v.validate(content)
+	v.result.Valid = len(v.result.Errors) == 0
+
+	return v.result
+}
+
+// ReferencesVariables returns whether a compose file uses ${VAR} or $VAR interpolation
+func ReferencesVariables(content string) bool {
+	for _, match := range variablePattern.FindAllString(content, -1) {
+		if match != "$$" {
+			return true
+		}
+	}
+
+	return false
+}
+
+type validator struct {
+	env    map[string]string
+	result Validation
+}
+
+func (v *validator) errorf(node *yaml.Node, path, format string, args ...any) {
+	v.result.Errors = append(v.result.Errors, Issue{Line: line(node), Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) warnf(node *yaml.Node, path, format string, args ...any) {
+	v.result.Warnings = append(v.result.Warnings, Issue{Line: line(node), Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) validate(content string) {
+	var document yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &document); err != nil {
+		v.result.Errors = append(v.result.Errors, yamlIssue(err))
+		return
+	}
+
+	if len(document.Content) == 0 {
+		v.errorf(nil, "", "compose file is empty")
+		return
+	}
+
+	root := document.Content[0]
+	if root.Kind != yaml.MappingNode {
+		v.errorf(root, "", "compose file must be a mapping")
+		return
+	}
+
+	v.validateVariables(root, "")
+
+	services := mappingValue(root, "services")
+	if services == nil {
+		v.errorf(root, "", "compose file has no services")
+		return
+	}
+	if services.Kind != yaml.MappingNode || len(services.Content) == 0 {
+		v.errorf(services, "services", "services must be a mapping of at least one service")
+		return
+	}
+
+	namedVolumes := mappingKeys(mappingValue(root, "volumes"))
+	for i := 0; i < len(services.Content); i += 2 {
+		v.validateService(services.Content[i], services.Content[i+1], namedVolumes)
+	}
+}
+
+// validateService checks a service, the errors about the whole service point at its key
+func (v *validator) validateService(key, service *yaml.Node, namedVolumes []string) {
+	name := key.Value
+	path := "services." + name
+	if service.Kind != yaml.MappingNode {
+		v.errorf(key, path, "service %s must be a mapping", name)
+		return
+	}
+
+	// The image can also come from the extended service or from a merged anchor
+	image := mappingValue(service, "image")
+	if image == nil && mappingValue(service, "build") == nil && mappingValue(service, "extends") == nil && mappingValue(service, "<<") == nil {
+		v.errorf(key, path, "service %s must define an image or a build", name)
+	}
+	if image != nil {
+		v.validateImage(image, path+".image")
+	}
+
+	if ports := mappingValue(service, "ports"); ports != nil {
+		v.validatePorts(ports, path+".ports")
+	}
+
+	if volumes := mappingValue(service, "volumes"); volumes != nil {
+		v.validateVolumes(volumes, path+".volumes", namedVolumes)
+	}
+
+	if privileged := mappingValue(service, "privileged"); privileged != nil && strings.EqualFold(privileged.Value, "true") {
+		v.warnf(privileged, path+".privileged", "service %s runs privileged, with full access to the host", name)
+	}
+
+	if networkMode := mappingValue(service, "network_mode"); networkMode != nil && networkMode.Value == "host" {
+		v.warnf(networkMode, path+".network_mode", "service %s uses the host network, its ports are exposed without isolation", name)
+	}
+}
+
+func (v *validator) validateImage(image *yaml.Node, path string) {
+	if image.Kind != yaml.ScalarNode || image.Value == "" {
+		v.errorf(image, path, "image must be a non-empty string")
+		return
+	}
+
+	reference := image.Value
+	if strings.Contains(reference, "@") || strings.Contains(reference, "$") {
+		return
+	}
+
+	tag := ""
+	if i := strings.LastIndex(reference, ":"); i > strings.LastIndex(reference, "/") {
+		tag = reference[i+1:]
+	}
+
+	switch tag {
+	case "latest":
+		v.warnf(image, path, "image %s uses the latest tag, redeployments may run a different version", reference)
+	case "":
+		v.warnf(image, path, "image %s has no tag and defaults to latest, redeployments may run a different version", reference)
+	}
+}
+
+func (v *validator) validatePorts(ports *yaml.Node, path string) {
+	if ports.Kind != yaml.SequenceNode {
+		v.errorf(ports, path, "ports must be a list")
+		return
+	}
+
+	for i, port := range ports.Content {
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+
+		switch port.Kind {
+		case yaml.ScalarNode:
+			if strings.Contains(port.Value, "$") {
+				continue
+			}
+			if !portPattern.MatchString(port.Value) {
+				v.errorf(port, itemPath, "invalid port %q, expected [[host_ip:]published:]target[/protocol]", port.Value)
+				continue
+			}
+			if err := checkPortNumbers(port.Value); err != nil {
+				v.errorf(port, itemPath, "invalid port %q: %s", port.Value, err)
+			}
+		case yaml.MappingNode:
+			target := mappingValue(port, "target")
+			if target == nil {
+				v.errorf(port, itemPath, "port must define a target")
+				continue
+			}
+			if n, err := strconv.Atoi(target.Value); !strings.Contains(target.Value, "$") && (err != nil || n < 1 || n > 65535) {
+				v.errorf(target, itemPath+".target", "invalid target port %q, expected a number between 1 and 65535", target.Value)
+			}
+		default:
+			v.errorf(port, itemPath, "port must be a string or a mapping")
+		}
+	}
+}
+
+func (v *validator) validateVolumes(volumes *yaml.Node, path string, namedVolumes []string) {
+	if volumes.Kind != yaml.SequenceNode {
+		v.errorf(volumes, path, "volumes must be a list")
+		return
+	}
+
+	for i, volume := range volumes.Content {
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+
+		switch volume.Kind {
+		case yaml.ScalarNode:
+			if strings.Contains(volume.Value, "$") {
+				continue
+			}
+			v.validateShortVolume(volume, itemPath, namedVolumes)
+		case yaml.MappingNode:
+			if target := mappingValue(volume, "target"); target == nil || target.Value == "" {
+				v.errorf(volume, itemPath, "volume must define a target")
+			}
+			volumeType := mappingValue(volume, "type")
+			if volumeType != nil && !slices.Contains(volumeTypes, volumeType.Value) {
+				v.errorf(volumeType, itemPath+".type", "invalid volume type %q, expected one of %s", volumeType.Value, strings.Join(volumeTypes, ", "))
+			}
+			if volumeType != nil && volumeType.Value == "volume" {
+				if source := mappingValue(volume, "source"); source != nil && !slices.Contains(namedVolumes, source.Value) {
+					v.errorf(source, itemPath+".source", "volume %s is not defined in the top-level volumes", source.Value)
+				}
+			}
+		default:
+			v.errorf(volume, itemPath, "volume must be a string or a mapping")
+		}
+	}
+}
+
+// validateShortVolume checks a volume of the short syntax: [source:]target[:mode]
+func (v *validator) validateShortVolume(volume *yaml.Node, path string, namedVolumes []string) {
+	parts := strings.Split(volume.Value, ":")
+	if len(parts) > 3 || slices.Contains(parts, "") {
+		v.errorf(volume, path, "invalid volume %q, expected [source:]target[:mode]", volume.Value)
+		return
+	}
+
+	target := parts[0]
+	if len(parts) > 1 {
+		target = parts[1]
+	}
+	if !strings.HasPrefix(target, "/") {
+		v.errorf(volume, path, "invalid volume %q, the target %s must be an absolute path", volume.Value, target)
+		return
+	}
+
+	if len(parts) == 3 {
+		for _, mode := range strings.Split(parts[2], ",") {
+			if !slices.Contains(volumeModes, mode) {
+				v.errorf(volume, path, "invalid volume %q, unknown mode %s", volume.Value, mode)
+			}
+		}
+	}
+
+	if len(parts) > 1 && isNamedVolume(parts[0]) && !slices.Contains(namedVolumes, parts[0]) {
+		v.errorf(volume, path, "volume %s is not defined in the top-level volumes", parts[0])
+	}
+}
+
+// validateVariables reports the invalid ${...} references of the scalar values as errors, and
+// the ${VAR} and $VAR references that env does not define and that have no default value as warnings
+func (v *validator) validateVariables(node *yaml.Node, path string) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i < len(node.Content); i += 2 {
+			v.validateVariables(node.Content[i+1], joinPath(path, node.Content[i].Value))
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			v.validateVariables(item, fmt.Sprintf("%s[%d]", path, i))
+		}
+	case yaml.ScalarNode:
+		for _, match := range variablePattern.FindAllStringSubmatch(node.Value, -1) {
+			v.validateVariable(node, path, match)
+		}
+	}
+}
+
+func (v *validator) validateVariable(node *yaml.Node, path string, match []string) {
+	reference, braced, name := match[0], match[1], match[2]
+	if reference == "$$" {
+		return
+	}
+
+	modifier := ""
+	if name == "" {
+		parts := variableNamePattern.FindStringSubmatch(braced)
+		if parts == nil {
+			v.errorf(node, path, "invalid variable reference %s", reference)
+			return
+		}
+		name, modifier = parts[1], parts[2]
+	}
+
+	if _, defined := v.env[name]; defined {
+		return
+	}
+
+	// ${VAR:-default} and ${VAR-default} fall back to their default, ${VAR:+value} to an empty value
+	if strings.HasPrefix(modifier, "-") || strings.HasPrefix(modifier, ":-") ||
+		strings.HasPrefix(modifier, "+") || strings.HasPrefix(modifier, ":+") {
+		return
+	}
+
+	v.warnf(node, path, "variable %s is not defined, set it in the environment variables of the stack", name)
+}
+
+// yamlIssue converts an error of the YAML parser to an issue, with its line number when known
+func yamlIssue(err error) Issue {
+	message := strings.TrimPrefix(err.Error(), "yaml: unmarshal errors:\n  ")
+	if match := yamlErrorPattern.FindStringSubmatch(message); match != nil {
+		line, _ := strconv.Atoi(match[1])
+		return Issue{Line: line, Message: "invalid YAML: " + match[2]}
+	}
+
+	return Issue{Message: "invalid YAML: " + strings.TrimPrefix(message, "yaml: ")}
+}
+
+// checkPortNumbers checks that the port numbers of a port, or of a port range, are between 1 and 65535
+func checkPortNumbers(port string) error {
+	for _, number := range numberPattern.FindAllString(portNumbersPart(port), -1) {
+		n, err := strconv.Atoi(number)
+		if err != nil || n < 1 || n > 65535 {
+			return fmt.Errorf("port %s is out of the 1-65535 range", number)
+		}
+	}
+
+	return nil
+}
+
+// portNumbersPart strips the host IP and the protocol of a port in the short syntax
+func portNumbersPart(port string) string {
+	port, _, _ = strings.Cut(port, "/")
+	if strings.HasPrefix(port, "[") {
+		if i := strings.Index(port, "]:"); i >= 0 {
+			return port[i+2:]
+		}
+	}
+	if parts := strings.Split(port, ":"); len(parts) == 3 {
+		return parts[1] + ":" + parts[2]
+	}
+
+	return port
+}
+
+// isNamedVolume returns whether the source of a volume is a named volume rather than a host path
+func isNamedVolume(source string) bool {
+	return !strings.HasPrefix(source, "/") && !strings.HasPrefix(source, ".") && !strings.HasPrefix(source, "~")
+}
+
+// mappingValue returns the value of a key of a mapping node, or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+// mappingKeys returns the keys of a mapping node
+func mappingKeys(node *yaml.Node) []string {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	keys := make([]string, 0, len(node.Content)/2)
+	for i := 0; i < len(node.Content); i += 2 {
+		keys = append(keys, node.Content[i].Value)
+	}
+
+	return keys
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func line(node *yaml.Node) int {
+	if node == nil {
+		return 0
+	}
+	return node.Line
+}
@@ -0,0 +1,160 @@
+package compose
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name             string
+		content          string
+		env              map[string]string
+		expectedErrors   []Issue
+		expectedWarnings []Issue
+	}{
+		{
+			name: "valid file",
+			content: `services:
+  web:
+    image: nginx:1.27
+    ports:
+      - "8080:80"
+      - 127.0.0.1:8443:443/tcp
+      - "[::1]:5353:53/udp"
+      - "9000-9001:9000-9001"
+      - target: 80
+        published: 8081
+    volumes:
+      - html:/usr/share/nginx/html:ro
+      - ./config:/etc/nginx/conf.d:ro,z
+      - /var/log/nginx
+      - type: volume
+        source: html
+        target: /srv
+    environment:
+      DOMAIN: ${DOMAIN}
+      PORT: ${PORT:-80}
+      PRICE: $$5
+  worker:
+    build: .
+volumes:
+  html:
+`,
+			env:              map[string]string{"DOMAIN": "acme.com"},
+			expectedErrors:   []Issue{},
+			expectedWarnings: []Issue{},
+		},
+		{
+			name:           "invalid yaml",
+			content:        "services:\n  web:\n    image: nginx: 1.27\n",
+			expectedErrors: []Issue{{Line: 3, Message: "invalid YAML: mapping values are not allowed in this context"}},
+		},
+		{
+			name:           "no services",
+			content:        "volumes:\n  data:\n",
+			expectedErrors: []Issue{{Line: 1, Message: "compose file has no services"}},
+		},
+		{
+			name:           "empty file",
+			content:        "",
+			expectedErrors: []Issue{{Message: "compose file is empty"}},
+		},
+		{
+			name: "missing image and invalid ports",
+			content: `services:
+  web:
+    ports:
+      - "80:http"
+      - "70000:80"
+      - target: web
+`,
+			expectedErrors: []Issue{
+				{Line: 2, Path: "services.web", Message: "service web must define an image or a build"},
+				{Line: 4, Path: "services.web.ports[0]", Message: `invalid port "80:http", expected [[host_ip:]published:]target[/protocol]`},
+				{Line: 5, Path: "services.web.ports[1]", Message: `invalid port "70000:80": port 70000 is out of the 1-65535 range`},
+				{Line: 6, Path: "services.web.ports[2].target", Message: `invalid target port "web", expected a number between 1 and 65535`},
+			},
+		},
+		{
+			name: "invalid volumes",
+			content: `services:
+  web:
+    image: nginx:1.27
+    volumes:
+      - data:/srv
+      - ./html:html
+      - ./html:/srv:rx
+      - type: disk
+        target: /srv
+`,
+			expectedErrors: []Issue{
+				{Line: 5, Path: "services.web.volumes[0]", Message: "volume data is not defined in the top-level volumes"},
+				{Line: 6, Path: "services.web.volumes[1]", Message: `invalid volume "./html:html", the target html must be an absolute path`},
+				{Line: 7, Path: "services.web.volumes[2]", Message: `invalid volume "./html:/srv:rx", unknown mode rx`},
+				{Line: 8, Path: "services.web.volumes[3].type", Message: `invalid volume type "disk", expected one of volume, bind, tmpfs, npipe, cluster`},
+			},
+		},
+		{
+			name: "undefined variables",
+			content: `services:
+  db:
+    image: postgres:${PG_VERSION}
+    environment:
+      POSTGRES_PASSWORD: $DB_PASSWORD
+      POSTGRES_USER: ${DB_USER:?the user is required}
+      POSTGRES_DB: ${DB_NAME-app}
+      BROKEN: ${1NVALID}
+`,
+			env: map[string]string{"PG_VERSION": "16"},
+			expectedErrors: []Issue{
+				{Line: 8, Path: "services.db.environment.BROKEN", Message: "invalid variable reference ${1NVALID}"},
+			},
+			expectedWarnings: []Issue{
+				{Line: 5, Path: "services.db.environment.POSTGRES_PASSWORD", Message: "variable DB_PASSWORD is not defined, set it in the environment variables of the stack"},
+				{Line: 6, Path: "services.db.environment.POSTGRES_USER", Message: "variable DB_USER is not defined, set it in the environment variables of the stack"},
+			},
+		},
+		{
+			name: "risky settings",
+			content: `services:
+  agent:
+    image: portainer/agent
+    privileged: true
+    network_mode: host
+  web:
+    image: registry.local:5000/nginx:latest
+`,
+			expectedErrors: []Issue{},
+			expectedWarnings: []Issue{
+				{Line: 3, Path: "services.agent.image", Message: "image portainer/agent has no tag and defaults to latest, redeployments may run a different version"},
+				{Line: 4, Path: "services.agent.privileged", Message: "service agent runs privileged, with full access to the host"},
+				{Line: 5, Path: "services.agent.network_mode", Message: "service agent uses the host network, its ports are exposed without isolation"},
+				{Line: 7, Path: "services.web.image", Message: "image registry.local:5000/nginx:latest uses the latest tag, redeployments may run a different version"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validation := Validate(tt.content, tt.env)
+
+			expectedWarnings := tt.expectedWarnings
+			if expectedWarnings == nil {
+				expectedWarnings = []Issue{}
+			}
+
+			assert.Equal(t, len(tt.expectedErrors) == 0, validation.Valid)
+			assert.Equal(t, tt.expectedErrors, validation.Errors)
+			assert.Equal(t, expectedWarnings, validation.Warnings)
+		})
+	}
+}
+
+func TestReferencesVariables(t *testing.T) {
+	assert.True(t, ReferencesVariables("image: postgres:${PG_VERSION}"))
+	assert.True(t, ReferencesVariables("command: echo $HOME"))
+	assert.False(t, ReferencesVariables("command: echo $$HOME costs $$5"))
+	assert.False(t, ReferencesVariables("image: nginx:1.27"))
+}
@@ -0,0 +1,463 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is the prefix of the environment variables overriding the settings
+const EnvPrefix = "PORTAINER_MCP_"
+
+// Default values of the settings
+const (
+	DefaultToolsPath     = "tools.yaml"
+	DefaultTransport     = "stdio"
+	DefaultListenAddress = ":8080"
+	DefaultAuditLogSize  = 100
+	DefaultLogLevel      = "info"
+	DefaultLogFormat     = LogFormatJSON
+	DefaultInstanceName  = "default"
+)
+
+// Log formats
+const (
+	LogFormatJSON = "json"
+	LogFormatText = "text"
+)
+
+// Config holds the settings of the server.
+//
+// Settings are resolved in the following order, each source overriding the previous one:
+//  1. Default values
+//  2. The YAML config file
+//  3. The PORTAINER_MCP_* environment variables
+//  4. The command line flags
+type Config struct {
+	Server              string          `yaml:"server"`
+	Token               string          `yaml:"token"`
+	TokenFile           string          `yaml:"token_file"`
+	Username            string          `yaml:"username"`
+	Password            string          `yaml:"password"`
+	PasswordFile        string          `yaml:"password_file"`
+	Tools               string          `yaml:"tools"`
+	ReadOnly            bool            `yaml:"read_only"`
+	DryRun              bool            `yaml:"dry_run"`
+	DisableVersionCheck bool            `yaml:"disable_version_check"`
+	ConfirmDestructive  bool            `yaml:"confirm_destructive"`
+	Resources           bool            `yaml:"resources"`
+	SessionCredentials  bool            `yaml:"session_credentials"`
+	Policy              string          `yaml:"policy"`
+	TLS                 TLSConfig       `yaml:"tls"`
+	Transport           TransportConfig `yaml:"transport"`
+	Audit               AuditConfig     `yaml:"audit"`
+	Logging             LoggingConfig   `yaml:"logging"`
+	Cache               CacheConfig     `yaml:"cache"`
+	Metrics             MetricsConfig   `yaml:"metrics"`
+	Tracing             TracingConfig   `yaml:"tracing"`
+	Limits              LimitsConfig    `yaml:"limits"`
+	// InstanceName names the Portainer instance configured above, the default one
+	InstanceName string           `yaml:"instance_name"`
+	Instances    []InstanceConfig `yaml:"instances"`
+}
+
+// InstanceConfig holds the settings of a Portainer instance served in addition to the default one.
+// The read-only mode and the version check settings of the server also apply to the instance.
+type InstanceConfig struct {
+	Name                string    `yaml:"name"`
+	Server              string    `yaml:"server"`
+	Token               string    `yaml:"token"`
+	TokenFile           string    `yaml:"token_file"`
+	Username            string    `yaml:"username"`
+	Password            string    `yaml:"password"`
+	PasswordFile        string    `yaml:"password_file"`
+	ReadOnly            bool      `yaml:"read_only"`
+	DisableVersionCheck bool      `yaml:"disable_version_check"`
+	TLS                 TLSConfig `yaml:"tls"`
+}
+
+// TLSConfig holds the TLS settings of the connection to Portainer.
+// The certificate of the server is verified against the system roots unless
+// a CA certificate or a pinned fingerprint is configured.
+type TLSConfig struct {
+	SkipVerify        bool   `yaml:"skip_verify"`
+	CACert            string `yaml:"ca_cert"`
+	ClientCert        string `yaml:"client_cert"`
+	ClientKey         string `yaml:"client_key"`
+	PinnedFingerprint string `yaml:"pinned_fingerprint"`
+}
+
+// CacheConfig holds the settings of the cache of Portainer list calls.
+// TTLs overrides TTL for the given entity types, a TTL of 0 disables caching.
+type CacheConfig struct {
+	TTL  time.Duration            `yaml:"ttl"`
+	TTLs map[string]time.Duration `yaml:"ttls"`
+}
+
+// MetricsConfig holds the settings of the Prometheus metrics endpoint, disabled when Listen is empty
+type MetricsConfig struct {
+	Listen string `yaml:"listen"`
+}
+
+// TracingConfig holds the settings of the export of the traces over OTLP/HTTP, disabled when Endpoint is empty
+type TracingConfig struct {
+	Endpoint string `yaml:"endpoint"`
+}
+
+// LimitsConfig holds the limits of the requests sent to each Portainer instance, 0 disables a limit.
+// The rates are in requests per second, the bursts default to one second of requests.
+type LimitsConfig struct {
+	MaxConcurrent    int64   `yaml:"max_concurrent"`
+	Rate             float64 `yaml:"rate"`
+	Burst            int64   `yaml:"burst"`
+	EnvironmentRate  float64 `yaml:"environment_rate"`
+	EnvironmentBurst int64   `yaml:"environment_burst"`
+}
+
+// TransportConfig holds the settings of the MCP transport
+type TransportConfig struct {
+	Type   string `yaml:"type"`
+	Listen string `yaml:"listen"`
+}
+
+// AuditConfig holds the settings of the audit log
+type AuditConfig struct {
+	Log     string `yaml:"log"`
+	MaxSize int64  `yaml:"max_size"`
+}
+
+// LoggingConfig holds the settings of the server logs
+type LoggingConfig struct {
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"`
+}
+
+// Default returns the default settings
+func Default() Config {
+	return Config{
+		Tools:        DefaultToolsPath,
+		InstanceName: DefaultInstanceName,
+		Transport: TransportConfig{
+			Type:   DefaultTransport,
+			Listen: DefaultListenAddress,
+		},
+		Audit: AuditConfig{
+			MaxSize: DefaultAuditLogSize,
+		},
+		Logging: LoggingConfig{
+			Level:  DefaultLogLevel,
+			Format: DefaultLogFormat,
+		},
+	}
+}
+
+// Load returns the default settings overridden by the given YAML config file.
+// An empty path returns the default settings.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+	if path == "" {
+		return &cfg, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return &cfg, nil
+}
+
+// settingValue is the value of a setting given by one of the sources of the settings
+type settingValue struct {
+	setting setting
+	raw     string
+	// source names the environment variable or the flag that set the value
+	source string
+}
+
+// ApplyEnv overrides the settings with the PORTAINER_MCP_* environment variables
+// returned by lookup, typically os.LookupEnv
+func (c *Config) ApplyEnv(lookup func(string) (string, bool)) error {
+	var values []settingValue
+	for _, s := range settings {
+		if raw, ok := lookup(EnvPrefix + s.env); ok {
+			values = append(values, settingValue{setting: s, raw: raw, source: EnvPrefix + s.env})
+		}
+	}
+
+	return c.apply(values)
+}
+
+// apply overrides the settings with the values of a source. The settings of the lower sources
+// that conflict with a value, such as a token file when a token is given, are cleared first,
+// so that only the conflicts within a single source are reported by Validate.
+func (c *Config) apply(values []settingValue) error {
+	for _, v := range values {
+		if v.setting.override != nil && v.raw != "" {
+			v.setting.override(c)
+		}
+	}
+
+	for _, v := range values {
+		if err := setValue(v.setting.value(c), v.raw); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", v.source, err)
+		}
+	}
+
+	return nil
+}
+
+// RegisterFlags registers a command line flag for each setting that has one
+func RegisterFlags(fs *flag.FlagSet) {
+	defaults := Default()
+
+	for _, s := range settings {
+		if s.flag == "" {
+			continue
+		}
+
+		switch value := s.value(&defaults).(type) {
+		case *string:
+			fs.String(s.flag, *value, s.usage)
+		case *bool:
+			fs.Bool(s.flag, *value, s.usage)
+		case *int64:
+			fs.Int64(s.flag, *value, s.usage)
+		case *float64:
+			fs.Float64(s.flag, *value, s.usage)
+		case *time.Duration:
+			fs.Duration(s.flag, *value, s.usage)
+		}
+	}
+}
+
+// ApplyFlags overrides the settings with the command line flags explicitly set on the command line
+func (c *Config) ApplyFlags(fs *flag.FlagSet) error {
+	flags := map[string]setting{}
+	for _, s := range settings {
+		if s.flag != "" {
+			flags[s.flag] = s
+		}
+	}
+
+	var values []settingValue
+	fs.Visit(func(f *flag.Flag) {
+		if s, ok := flags[f.Name]; ok {
+			values = append(values, settingValue{setting: s, raw: f.Value.String(), source: "-" + f.Name})
+		}
+	})
+
+	return c.apply(values)
+}
+
+// Validate checks the consistency of the settings
+func (c *Config) Validate() error {
+	if c.Server == "" {
+		return fmt.Errorf("the Portainer server URL is required (-server, %sSERVER or server)", EnvPrefix)
+	}
+
+	if err := validateAuthentication(c.Token, c.TokenFile, c.Username, c.Password, c.PasswordFile); err != nil {
+		return err
+	}
+
+	if c.Token == "" && c.TokenFile == "" && c.Username == "" && !c.SessionCredentials {
+		return fmt.Errorf("a token, a token file or a username and password are required unless session credentials are enabled")
+	}
+
+	if c.Logging.Format != LogFormatJSON && c.Logging.Format != LogFormatText {
+		return fmt.Errorf("invalid log format %q, must be json or text", c.Logging.Format)
+	}
+
+	if err := c.TLS.validate(); err != nil {
+		return err
+	}
+
+	if err := c.validateInstances(); err != nil {
+		return err
+	}
+
+	if c.Cache.TTL < 0 {
+		return fmt.Errorf("the cache TTL cannot be negative")
+	}
+
+	if c.Audit.MaxSize < 0 {
+		return fmt.Errorf("the audit log max size cannot be negative")
+	}
+
+	if c.Limits.MaxConcurrent < 0 || c.Limits.Rate < 0 || c.Limits.Burst < 0 || c.Limits.EnvironmentRate < 0 || c.Limits.EnvironmentBurst < 0 {
+		return fmt.Errorf("the request limits cannot be negative")
+	}
+
+	return nil
+}
+
+// validateInstances checks the settings of the additional Portainer instances
+func (c *Config) validateInstances() error {
+	if len(c.Instances) == 0 {
+		return nil
+	}
+
+	if c.SessionCredentials {
+		return fmt.Errorf("session credentials cannot be combined with multiple Portainer instances")
+	}
+
+	names := map[string]bool{c.InstanceName: true}
+	for i, instance := range c.Instances {
+		if instance.Name == "" {
+			return fmt.Errorf("instance %d: a name is required", i+1)
+		}
+
+		if names[instance.Name] {
+			return fmt.Errorf("duplicate Portainer instance name %q", instance.Name)
+		}
+		names[instance.Name] = true
+
+		if instance.Server == "" {
+			return fmt.Errorf("instance %s: the Portainer server URL is required", instance.Name)
+		}
+
+		if err := validateAuthentication(instance.Token, instance.TokenFile, instance.Username, instance.Password, instance.PasswordFile); err != nil {
+			return fmt.Errorf("instance %s: %w", instance.Name, err)
+		}
+
+		if instance.Token == "" && instance.TokenFile == "" && instance.Username == "" {
+			return fmt.Errorf("instance %s: a token, a token file or a username and password are required", instance.Name)
+		}
+
+		if err := instance.TLS.validate(); err != nil {
+			return fmt.Errorf("instance %s: %w", instance.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// validateAuthentication checks that the API token and the username and password,
+// each of them set inline or in a file, are not combined
+func validateAuthentication(token, tokenFile, username, password, passwordFile string) error {
+	if token != "" && tokenFile != "" {
+		return fmt.Errorf("token and token file are mutually exclusive")
+	}
+
+	if password != "" && passwordFile != "" {
+		return fmt.Errorf("password and password file are mutually exclusive")
+	}
+
+	if username == "" {
+		if password != "" || passwordFile != "" {
+			return fmt.Errorf("a password requires a username")
+		}
+		return nil
+	}
+
+	if token != "" || tokenFile != "" {
+		return fmt.Errorf("a token cannot be combined with a username and password")
+	}
+
+	if password == "" && passwordFile == "" {
+		return fmt.Errorf("a password or a password file is required with a username")
+	}
+
+	return nil
+}
+
+// validate checks the consistency of the TLS settings
+func (t TLSConfig) validate() error {
+	if t.SkipVerify && (t.CACert != "" || t.PinnedFingerprint != "") {
+		return fmt.Errorf("skipping TLS verification cannot be combined with a CA certificate or a pinned fingerprint")
+	}
+
+	if (t.ClientCert == "") != (t.ClientKey == "") {
+		return fmt.Errorf("the TLS client certificate and key must be set together")
+	}
+
+	return nil
+}
+
+// ReadToken returns the Portainer API token, reading it from the token file when one is configured
+func (c *Config) ReadToken() (string, error) {
+	return readSecret("token", c.Token, c.TokenFile)
+}
+
+// ReadPassword returns the Portainer password, reading it from the password file when one is configured
+func (c *Config) ReadPassword() (string, error) {
+	return readSecret("password", c.Password, c.PasswordFile)
+}
+
+// ReadToken returns the API token of the instance, reading it from the token file when one is configured
+func (i *InstanceConfig) ReadToken() (string, error) {
+	return readSecret("token", i.Token, i.TokenFile)
+}
+
+// ReadPassword returns the password of the instance, reading it from the password file when one is configured
+func (i *InstanceConfig) ReadPassword() (string, error) {
+	return readSecret("password", i.Password, i.PasswordFile)
+}
+
+// readSecret returns the secret, or the content of its file when one is configured
+func readSecret(kind, value, file string) (string, error) {
+	if file == "" {
+		return value, nil
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s file: %w", kind, err)
+	}
+
+	value = strings.TrimSpace(string(data))
+	if value == "" {
+		return "", fmt.Errorf("%s file %s is empty", kind, file)
+	}
+
+	return value, nil
+}
+
+func setValue(value any, raw string) error {
+	switch v := value.(type) {
+	case *string:
+		*v = raw
+	case *bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", raw)
+		}
+		*v = b
+	case *int64:
+		i, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", raw)
+		}
+		*v = i
+	case *float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", raw)
+		}
+		*v = f
+	case *time.Duration:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration", raw)
+		}
+		*v = d
+	default:
+		return fmt.Errorf("unsupported setting type %T", value)
+	}
+
+	return nil
+}
@@ -0,0 +1,510 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func envLookup(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+server: https://portainer.example.com
+token_file: /run/secrets/token
+read_only: true
+tls:
+  ca_cert: /etc/portainer-mcp/ca.pem
+transport:
+  type: streamable-http
+audit:
+  log: /var/log/audit.jsonl
+logging:
+  format: text
+cache:
+  ttl: 30s
+  ttls:
+    stacks: 5s
+metrics:
+  listen: ":9090"
+tracing:
+  endpoint: http://localhost:4318
+limits:
+  max_concurrent: 8
+  environment_rate: 2.5
+instance_name: production
+instances:
+  - name: staging
+    server: https://portainer.staging.example.com
+    token_file: /run/secrets/staging-token
+    tls:
+      skip_verify: true
+`)
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+
+	expected := Default()
+	expected.Server = "https://portainer.example.com"
+	expected.TokenFile = "/run/secrets/token"
+	expected.ReadOnly = true
+	expected.TLS.CACert = "/etc/portainer-mcp/ca.pem"
+	expected.Transport.Type = "streamable-http"
+	expected.Audit.Log = "/var/log/audit.jsonl"
+	expected.Logging.Format = LogFormatText
+	expected.Cache.TTL = 30 * time.Second
+	expected.Cache.TTLs = map[string]time.Duration{"stacks": 5 * time.Second}
+	expected.Metrics.Listen = ":9090"
+	expected.Tracing.Endpoint = "http://localhost:4318"
+	expected.Limits = LimitsConfig{MaxConcurrent: 8, EnvironmentRate: 2.5}
+	expected.InstanceName = "production"
+	expected.Instances = []InstanceConfig{
+		{
+			Name:      "staging",
+			Server:    "https://portainer.staging.example.com",
+			TokenFile: "/run/secrets/staging-token",
+			TLS:       TLSConfig{SkipVerify: true},
+		},
+	}
+	assert.Equal(t, expected, *cfg)
+}
+
+func TestLoadErrors(t *testing.T) {
+	_, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.ErrorContains(t, err, "failed to read config file")
+
+	_, err = Load(writeFile(t, "config.yaml", "server: [\n"))
+	assert.ErrorContains(t, err, "failed to parse config file")
+
+	_, err = Load(writeFile(t, "config.yaml", "sever: https://typo.example.com\n"))
+	assert.ErrorContains(t, err, "field sever not found", "unknown settings must be rejected")
+
+	cfg, err := Load(writeFile(t, "config.yaml", ""))
+	require.NoError(t, err)
+	assert.Equal(t, Default(), *cfg)
+
+	cfg, err = Load("")
+	require.NoError(t, err)
+	assert.Equal(t, Default(), *cfg)
+}
+
+func TestPrecedence(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+server: https://from-file.example.com
+tools: /etc/portainer-mcp/tools.yaml
+read_only: true
+transport:
+  listen: ":9000"
+audit:
+  max_size: 10
+`)
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+
+	err = cfg.ApplyEnv(envLookup(map[string]string{
+		"PORTAINER_MCP_SERVER":             "https://from-env.example.com",
+		"PORTAINER_MCP_TOKEN":              "env-token",
+		"PORTAINER_MCP_LISTEN":             ":9100",
+		"PORTAINER_MCP_AUDIT_LOG_MAX_SIZE": "20",
+		"PORTAINER_MCP_TLS_SKIP_VERIFY":    "true",
+	}))
+	require.NoError(t, err)
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	RegisterFlags(fs)
+	require.NoError(t, fs.Parse([]string{"-listen", ":9200", "-read-only=false", "-tls-skip-verify=false", "-log-level", "debug", "-rate-limit", "0.5"}))
+	require.NoError(t, cfg.ApplyFlags(fs))
+
+	assert.Equal(t, "https://from-env.example.com", cfg.Server, "environment overrides the file")
+	assert.Equal(t, "env-token", cfg.Token)
+	assert.Equal(t, "/etc/portainer-mcp/tools.yaml", cfg.Tools, "the file overrides the defaults")
+	assert.Equal(t, ":9200", cfg.Transport.Listen, "flags override the environment")
+	assert.False(t, cfg.ReadOnly, "flags override the file")
+	assert.Equal(t, int64(20), cfg.Audit.MaxSize)
+	assert.False(t, cfg.TLS.SkipVerify, "flags override the environment")
+	assert.Equal(t, DefaultTransport, cfg.Transport.Type, "unset flags keep the current value")
+	assert.Equal(t, "debug", cfg.Logging.Level)
+	assert.Equal(t, 0.5, cfg.Limits.Rate)
+}
+
+func TestAuthenticationPrecedence(t *testing.T) {
+	tests := []struct {
+		name          string
+		file          string
+		env           map[string]string
+		flags         []string
+		expected      func(*testing.T, *Config)
+		errorContains string
+	}{
+		{
+			name: "token from the environment overrides a token file",
+			file: "token_file: /run/secrets/token",
+			env:  map[string]string{"PORTAINER_MCP_TOKEN": "env-token"},
+			expected: func(t *testing.T, c *Config) {
+				assert.Equal(t, "env-token", c.Token)
+				assert.Empty(t, c.TokenFile)
+			},
+		},
+		{
+			name:  "token flag overrides a username and password",
+			file:  "username: admin\npassword_file: /run/secrets/password",
+			flags: []string{"-token", "flag-token"},
+			expected: func(t *testing.T, c *Config) {
+				assert.Equal(t, "flag-token", c.Token)
+				assert.Empty(t, c.Username)
+				assert.Empty(t, c.PasswordFile)
+			},
+		},
+		{
+			name:  "password file flag overrides a password",
+			file:  "username: admin\npassword: secret",
+			flags: []string{"-password-file", "/run/secrets/password"},
+			expected: func(t *testing.T, c *Config) {
+				assert.Equal(t, "admin", c.Username)
+				assert.Empty(t, c.Password)
+				assert.Equal(t, "/run/secrets/password", c.PasswordFile)
+			},
+		},
+		{
+			name:  "username flag overrides a token",
+			file:  "token: file-token\npassword_file: /run/secrets/password",
+			flags: []string{"-username", "admin"},
+			expected: func(t *testing.T, c *Config) {
+				assert.Empty(t, c.Token)
+				assert.Equal(t, "admin", c.Username)
+				assert.Equal(t, "/run/secrets/password", c.PasswordFile)
+			},
+		},
+		{
+			name:  "flag overrides the environment",
+			env:   map[string]string{"PORTAINER_MCP_TOKEN_FILE": "/run/secrets/token"},
+			flags: []string{"-token", "flag-token"},
+			expected: func(t *testing.T, c *Config) {
+				assert.Equal(t, "flag-token", c.Token)
+				assert.Empty(t, c.TokenFile)
+			},
+		},
+		{
+			name:          "conflicts within a single source are still reported",
+			env:           map[string]string{"PORTAINER_MCP_TOKEN": "env-token", "PORTAINER_MCP_TOKEN_FILE": "/run/secrets/token"},
+			errorContains: "token and token file are mutually exclusive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Load(writeFile(t, "config.yaml", "server: https://portainer.example.com\n"+tt.file))
+			require.NoError(t, err)
+			require.NoError(t, cfg.ApplyEnv(envLookup(tt.env)))
+
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			RegisterFlags(fs)
+			require.NoError(t, fs.Parse(tt.flags))
+			require.NoError(t, cfg.ApplyFlags(fs))
+
+			err = cfg.Validate()
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+				return
+			}
+
+			require.NoError(t, err)
+			tt.expected(t, cfg)
+		})
+	}
+}
+
+func TestApplyEnvErrors(t *testing.T) {
+	cfg := Default()
+	err := cfg.ApplyEnv(envLookup(map[string]string{"PORTAINER_MCP_READ_ONLY": "maybe"}))
+	assert.ErrorContains(t, err, "PORTAINER_MCP_READ_ONLY")
+
+	err = cfg.ApplyEnv(envLookup(map[string]string{"PORTAINER_MCP_AUDIT_LOG_MAX_SIZE": "big"}))
+	assert.ErrorContains(t, err, "is not an integer")
+
+	err = cfg.ApplyEnv(envLookup(map[string]string{"PORTAINER_MCP_CACHE_TTL": "30"}))
+	assert.ErrorContains(t, err, "is not a duration")
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		modify        func(*Config)
+		errorContains string
+	}{
+		{
+			name:   "valid",
+			modify: func(c *Config) {},
+		},
+		{
+			name:          "missing server",
+			modify:        func(c *Config) { c.Server = "" },
+			errorContains: "server URL is required",
+		},
+		{
+			name:          "token and token file",
+			modify:        func(c *Config) { c.TokenFile = "/run/secrets/token" },
+			errorContains: "mutually exclusive",
+		},
+		{
+			name:          "missing token",
+			modify:        func(c *Config) { c.Token = "" },
+			errorContains: "a token, a token file or a username and password are required",
+		},
+		{
+			name: "username and password",
+			modify: func(c *Config) {
+				c.Token = ""
+				c.Username = "admin"
+				c.PasswordFile = "/run/secrets/password"
+			},
+		},
+		{
+			name: "username without password",
+			modify: func(c *Config) {
+				c.Token = ""
+				c.Username = "admin"
+			},
+			errorContains: "a password or a password file is required with a username",
+		},
+		{
+			name:          "password without username",
+			modify:        func(c *Config) { c.Password = "secret" },
+			errorContains: "a password requires a username",
+		},
+		{
+			name: "username with token",
+			modify: func(c *Config) {
+				c.Username = "admin"
+				c.Password = "secret"
+			},
+			errorContains: "a token cannot be combined with a username and password",
+		},
+		{
+			name: "password and password file",
+			modify: func(c *Config) {
+				c.Token = ""
+				c.Username = "admin"
+				c.Password = "secret"
+				c.PasswordFile = "/run/secrets/password"
+			},
+			errorContains: "password and password file are mutually exclusive",
+		},
+		{
+			name: "session credentials without token",
+			modify: func(c *Config) {
+				c.Token = ""
+				c.SessionCredentials = true
+			},
+		},
+		{
+			name:          "negative rate limit",
+			modify:        func(c *Config) { c.Limits.EnvironmentRate = -1 },
+			errorContains: "the request limits cannot be negative",
+		},
+		{
+			name:          "invalid log format",
+			modify:        func(c *Config) { c.Logging.Format = "xml" },
+			errorContains: "invalid log format",
+		},
+		{
+			name: "skip verify with pinned fingerprint",
+			modify: func(c *Config) {
+				c.TLS.SkipVerify = true
+				c.TLS.PinnedFingerprint = "ab:cd"
+			},
+			errorContains: "cannot be combined",
+		},
+		{
+			name:          "client certificate without key",
+			modify:        func(c *Config) { c.TLS.ClientCert = "/etc/portainer-mcp/client.pem" },
+			errorContains: "must be set together",
+		},
+		{
+			name:          "negative cache TTL",
+			modify:        func(c *Config) { c.Cache.TTL = -time.Second },
+			errorContains: "cache TTL cannot be negative",
+		},
+		{
+			name:          "negative audit log size",
+			modify:        func(c *Config) { c.Audit.MaxSize = -1 },
+			errorContains: "cannot be negative",
+		},
+		{
+			name: "additional instance",
+			modify: func(c *Config) {
+				c.Instances = []InstanceConfig{{Name: "staging", Server: "https://portainer.staging.example.com", Token: "staging-token"}}
+			},
+		},
+		{
+			name: "instance without name",
+			modify: func(c *Config) {
+				c.Instances = []InstanceConfig{{Server: "https://portainer.staging.example.com", Token: "staging-token"}}
+			},
+			errorContains: "instance 1: a name is required",
+		},
+		{
+			name: "instance named after the default instance",
+			modify: func(c *Config) {
+				c.Instances = []InstanceConfig{{Name: DefaultInstanceName, Server: "https://portainer.staging.example.com", Token: "staging-token"}}
+			},
+			errorContains: "duplicate Portainer instance name",
+		},
+		{
+			name: "duplicate instances",
+			modify: func(c *Config) {
+				c.Instances = []InstanceConfig{{Name: "staging", Server: "https://portainer.staging.example.com", Token: "staging-token"}, {Name: "staging", Server: "https://portainer.staging.example.com", Token: "staging-token"}}
+			},
+			errorContains: "duplicate Portainer instance name",
+		},
+		{
+			name:          "instance without server",
+			modify:        func(c *Config) { c.Instances = []InstanceConfig{{Name: "staging", Token: "staging-token"}} },
+			errorContains: "instance staging: the Portainer server URL is required",
+		},
+		{
+			name: "instance without token",
+			modify: func(c *Config) {
+				c.Instances = []InstanceConfig{{Name: "staging", Server: "https://portainer.staging.example.com"}}
+			},
+			errorContains: "instance staging: a token, a token file or a username and password are required",
+		},
+		{
+			name: "instance with username and password",
+			modify: func(c *Config) {
+				c.Instances = []InstanceConfig{{Name: "staging", Server: "https://portainer.staging.example.com", Username: "admin", Password: "secret"}}
+			},
+		},
+		{
+			name: "instance with username and token",
+			modify: func(c *Config) {
+				c.Instances = []InstanceConfig{{Name: "staging", Server: "https://portainer.staging.example.com", Token: "staging-token", Username: "admin", Password: "secret"}}
+			},
+			errorContains: "instance staging: a token cannot be combined with a username and password",
+		},
+		{
+			name: "instance with invalid TLS settings",
+			modify: func(c *Config) {
+				instance := InstanceConfig{Name: "staging", Server: "https://portainer.staging.example.com", Token: "staging-token"}
+				instance.TLS.ClientKey = "/etc/portainer-mcp/staging-key.pem"
+				c.Instances = []InstanceConfig{instance}
+			},
+			errorContains: "instance staging: the TLS client certificate and key must be set together",
+		},
+		{
+			name: "instances with session credentials",
+			modify: func(c *Config) {
+				c.SessionCredentials = true
+				c.Instances = []InstanceConfig{{Name: "staging", Server: "https://portainer.staging.example.com", Token: "staging-token"}}
+			},
+			errorContains: "session credentials cannot be combined",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			cfg.Server = "https://portainer.example.com"
+			cfg.Token = "token"
+			tt.modify(&cfg)
+
+			err := cfg.Validate()
+			if tt.errorContains == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.errorContains)
+			}
+		})
+	}
+}
+
+func TestReadToken(t *testing.T) {
+	cfg := Default()
+	cfg.Token = "inline-token"
+
+	token, err := cfg.ReadToken()
+	require.NoError(t, err)
+	assert.Equal(t, "inline-token", token)
+
+	cfg = Default()
+	cfg.TokenFile = writeFile(t, "token", "file-token\n")
+	token, err = cfg.ReadToken()
+	require.NoError(t, err)
+	assert.Equal(t, "file-token", token, "surrounding whitespace is trimmed")
+
+	cfg.TokenFile = writeFile(t, "token", "  \n")
+	_, err = cfg.ReadToken()
+	assert.ErrorContains(t, err, "is empty")
+
+	cfg.TokenFile = filepath.Join(t.TempDir(), "missing")
+	_, err = cfg.ReadToken()
+	assert.ErrorContains(t, err, "failed to read token file")
+
+	instance := InstanceConfig{TokenFile: writeFile(t, "staging-token", "staging-token\n")}
+	token, err = instance.ReadToken()
+	require.NoError(t, err)
+	assert.Equal(t, "staging-token", token)
+}
+
+func TestReadPassword(t *testing.T) {
+	cfg := Default()
+	cfg.Password = "inline-password"
+
+	password, err := cfg.ReadPassword()
+	require.NoError(t, err)
+	assert.Equal(t, "inline-password", password)
+
+	cfg.PasswordFile = writeFile(t, "password", "file-password\n")
+	password, err = cfg.ReadPassword()
+	require.NoError(t, err)
+	assert.Equal(t, "file-password", password, "the password file takes precedence and is trimmed")
+
+	cfg.PasswordFile = filepath.Join(t.TempDir(), "missing")
+	_, err = cfg.ReadPassword()
+	assert.ErrorContains(t, err, "failed to read password file")
+
+	instance := InstanceConfig{PasswordFile: writeFile(t, "staging-password", "staging-password\n")}
+	password, err = instance.ReadPassword()
+	require.NoError(t, err)
+	assert.Equal(t, "staging-password", password)
+}
+
+func TestSettings(t *testing.T) {
+	envs := map[string]bool{}
+	flags := map[string]bool{}
+	cfg := Default()
+
+	for _, s := range settings {
+		assert.False(t, envs[s.env], "duplicate environment variable %s", s.env)
+		envs[s.env] = true
+
+		if s.flag != "" {
+			assert.False(t, flags[s.flag], "duplicate flag %s", s.flag)
+			flags[s.flag] = true
+		}
+
+		// "1" is a valid string, boolean and integer, so this checks that every field type is supported
+		raw := "1"
+		if _, ok := s.value(&cfg).(*time.Duration); ok {
+			raw = "1s"
+		}
+		assert.NoError(t, setValue(s.value(&cfg), raw), "setting %s", s.env)
+	}
+}
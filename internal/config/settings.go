@@ -0,0 +1,209 @@
+package config
+
+// setting maps a field of Config to its environment variable and command line flag
+type setting struct {
+	// env is the name of the environment variable, without EnvPrefix
+	env string
+	// flag is the name of the command line flag, empty when the setting has no flag
+	flag  string
+	usage string
+	// value returns a pointer to the field of the setting
+	value func(c *Config) any
+	// override clears the settings of the lower sources that conflict with this one, such as
+	// a token file from the config file when the token is set by an environment variable
+	override func(c *Config)
+}
+
+// clearToken clears the API token settings, overridden by a username and password
+func clearToken(c *Config) {
+	c.Token = ""
+	c.TokenFile = ""
+}
+
+// clearCredentials clears the username and password settings, overridden by an API token
+func clearCredentials(c *Config) {
+	c.Username = ""
+	c.Password = ""
+	c.PasswordFile = ""
+}
+
+var settings = []setting{
+	{
+		env: "SERVER", flag: "server",
+		usage: "The Portainer server URL",
+		value: func(c *Config) any { return &c.Server },
+	},
+	{
+		env: "TOKEN", flag: "token",
+		usage: "The authentication token for the Portainer server, prefer -token-file to keep it out of process listings",
+		value: func(c *Config) any { return &c.Token },
+		override: func(c *Config) {
+			c.TokenFile = ""
+			clearCredentials(c)
+		},
+	},
+	{
+		env: "TOKEN_FILE", flag: "token-file",
+		usage: "The path to a file containing the authentication token for the Portainer server, re-read on SIGHUP",
+		value: func(c *Config) any { return &c.TokenFile },
+		override: func(c *Config) {
+			c.Token = ""
+			clearCredentials(c)
+		},
+	},
+	{
+		env: "USERNAME", flag: "username",
+		usage:    "The Portainer username to log in with instead of a token, for accounts that cannot use API keys",
+		value:    func(c *Config) any { return &c.Username },
+		override: clearToken,
+	},
+	{
+		env: "PASSWORD_FILE", flag: "password-file",
+		usage: "The path to a file containing the password of the Portainer user",
+		value: func(c *Config) any { return &c.PasswordFile },
+		override: func(c *Config) {
+			c.Password = ""
+			clearToken(c)
+		},
+	},
+	{
+		env: "INSTANCE_NAME", flag: "instance-name",
+		usage: "The name of the Portainer instance, used to select it when other instances are configured",
+		value: func(c *Config) any { return &c.InstanceName },
+	},
+	{
+		env: "TOOLS", flag: "tools",
+		usage: "The path to the tools YAML file",
+		value: func(c *Config) any { return &c.Tools },
+	},
+	{
+		env: "READ_ONLY", flag: "read-only",
+		usage: "Run in read-only mode",
+		value: func(c *Config) any { return &c.ReadOnly },
+	},
+	{
+		env: "DRY_RUN", flag: "dry-run",
+		usage: "Return the Portainer calls that write tools would make instead of making them",
+		value: func(c *Config) any { return &c.DryRun },
+	},
+	{
+		env: "DISABLE_VERSION_CHECK", flag: "disable-version-check",
+		usage: "Disable Portainer server version check",
+		value: func(c *Config) any { return &c.DisableVersionCheck },
+	},
+	{
+		env: "CONFIRM_DESTRUCTIVE", flag: "confirm-destructive",
+		usage: "Require a confirmation, by elicitation or token, before running tools annotated as destructive",
+		value: func(c *Config) any { return &c.ConfirmDestructive },
+	},
+	{
+		env: "RESOURCES", flag: "resources",
+		usage: "Expose Portainer entities as MCP resources and resource templates",
+		value: func(c *Config) any { return &c.Resources },
+	},
+	{
+		env: "SESSION_CREDENTIALS", flag: "session-credentials",
+		usage: "Require each MCP session to send its own Portainer API key in the X-Portainer-API-Key header",
+		value: func(c *Config) any { return &c.SessionCredentials },
+	},
+	{
+		env: "POLICY", flag: "policy",
+		usage: "The path to a policy file restricting tool calls per environment, tag and access group",
+		value: func(c *Config) any { return &c.Policy },
+	},
+	{
+		env: "TLS_SKIP_VERIFY", flag: "tls-skip-verify",
+		usage: "Skip the verification of the Portainer server certificate (insecure, the token is sent to whichever server answers)",
+		value: func(c *Config) any { return &c.TLS.SkipVerify },
+	},
+	{
+		env: "TLS_CA_CERT", flag: "tls-ca-cert",
+		usage: "The path to a PEM bundle of CA certificates used to verify the Portainer server instead of the system roots",
+		value: func(c *Config) any { return &c.TLS.CACert },
+	},
+	{
+		env: "TLS_CLIENT_CERT", flag: "tls-client-cert",
+		usage: "The path to a PEM client certificate presented to the Portainer server (mutual TLS)",
+		value: func(c *Config) any { return &c.TLS.ClientCert },
+	},
+	{
+		env: "TLS_CLIENT_KEY", flag: "tls-client-key",
+		usage: "The path to the PEM private key of the client certificate",
+		value: func(c *Config) any { return &c.TLS.ClientKey },
+	},
+	{
+		env: "TLS_PINNED_FINGERPRINT", flag: "tls-pinned-fingerprint",
+		usage: "The SHA-256 fingerprint of the Portainer server certificate, accepted instead of verifying the certificate chain",
+		value: func(c *Config) any { return &c.TLS.PinnedFingerprint },
+	},
+	{
+		env: "CACHE_TTL", flag: "cache-ttl",
+		usage: "How long the results of Portainer list calls are cached, e.g. 30s (0 disables the cache)",
+		value: func(c *Config) any { return &c.Cache.TTL },
+	},
+	{
+		env: "TRANSPORT", flag: "transport",
+		usage: "The MCP transport to serve: stdio, sse or streamable-http",
+		value: func(c *Config) any { return &c.Transport.Type },
+	},
+	{
+		env: "LISTEN", flag: "listen",
+		usage: "The address to listen on when using the sse or streamable-http transport",
+		value: func(c *Config) any { return &c.Transport.Listen },
+	},
+	{
+		env: "AUDIT_LOG", flag: "audit-log",
+		usage: "The path to the JSONL audit log of tool invocations (disabled when empty)",
+		value: func(c *Config) any { return &c.Audit.Log },
+	},
+	{
+		env: "METRICS_LISTEN", flag: "metrics-listen",
+		usage: "The address on which Prometheus metrics are served at /metrics, e.g. :9090 (disabled when empty)",
+		value: func(c *Config) any { return &c.Metrics.Listen },
+	},
+	{
+		env: "TRACING_ENDPOINT", flag: "tracing-endpoint",
+		usage: "The URL of the OTLP/HTTP endpoint to which traces are exported, e.g. http://localhost:4318 (disabled when empty)",
+		value: func(c *Config) any { return &c.Tracing.Endpoint },
+	},
+	{
+		env: "MAX_CONCURRENT_REQUESTS", flag: "max-concurrent-requests",
+		usage: "The maximum number of requests in flight to each Portainer instance (0 disables the limit)",
+		value: func(c *Config) any { return &c.Limits.MaxConcurrent },
+	},
+	{
+		env: "RATE_LIMIT", flag: "rate-limit",
+		usage: "The maximum number of requests per second to each Portainer instance (0 disables the limit)",
+		value: func(c *Config) any { return &c.Limits.Rate },
+	},
+	{
+		env: "RATE_LIMIT_BURST", flag: "rate-limit-burst",
+		usage: "The number of requests to each Portainer instance allowed in a burst above the rate limit (defaults to one second of requests)",
+		value: func(c *Config) any { return &c.Limits.Burst },
+	},
+	{
+		env: "ENVIRONMENT_RATE_LIMIT", flag: "environment-rate-limit",
+		usage: "The maximum number of requests per second to each environment, including the Docker and Kubernetes proxy requests (0 disables the limit)",
+		value: func(c *Config) any { return &c.Limits.EnvironmentRate },
+	},
+	{
+		env: "ENVIRONMENT_RATE_LIMIT_BURST", flag: "environment-rate-limit-burst",
+		usage: "The number of requests to each environment allowed in a burst above the rate limit (defaults to one second of requests)",
+		value: func(c *Config) any { return &c.Limits.EnvironmentBurst },
+	},
+	{
+		env: "AUDIT_LOG_MAX_SIZE", flag: "audit-log-max-size",
+		usage: "The size in megabytes after which the audit log is rotated (0 disables rotation)",
+		value: func(c *Config) any { return &c.Audit.MaxSize },
+	},
+	{
+		env: "LOG_LEVEL", flag: "log-level",
+		usage: "The minimum level of the server logs: debug, info, warn or error",
+		value: func(c *Config) any { return &c.Logging.Level },
+	},
+	{
+		env: "LOG_FORMAT", flag: "log-format",
+		usage: "The format of the server logs: json or text",
+		value: func(c *Config) any { return &c.Logging.Format },
+	},
+}
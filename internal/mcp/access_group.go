@@ -4,28 +4,28 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/portainer/portainer-mcp/pkg/portainer/models"
 	"github.com/portainer/portainer-mcp/pkg/toolgen"
 )
 
 func (s *PortainerMCPServer) AddAccessGroupFeatures() {
 	s.addToolIfExists(ToolListAccessGroups, s.HandleGetAccessGroups())
 
-	if !s.readOnly {
-		s.addToolIfExists(ToolCreateAccessGroup, s.HandleCreateAccessGroup())
-		s.addToolIfExists(ToolUpdateAccessGroupName, s.HandleUpdateAccessGroupName())
-		s.addToolIfExists(ToolUpdateAccessGroupUserAccesses, s.HandleUpdateAccessGroupUserAccesses())
-		s.addToolIfExists(ToolUpdateAccessGroupTeamAccesses, s.HandleUpdateAccessGroupTeamAccesses())
-		s.addToolIfExists(ToolAddEnvironmentToAccessGroup, s.HandleAddEnvironmentToAccessGroup())
-		s.addToolIfExists(ToolRemoveEnvironmentFromAccessGroup, s.HandleRemoveEnvironmentFromAccessGroup())
-	}
+	s.addWriteToolIfExists(ToolCreateAccessGroup, s.HandleCreateAccessGroup())
+	s.addWriteToolIfExists(ToolUpdateAccessGroupName, s.HandleUpdateAccessGroupName())
+	s.addWriteToolIfExists(ToolUpdateAccessGroupUserAccesses, s.HandleUpdateAccessGroupUserAccesses())
+	s.addWriteToolIfExists(ToolUpdateAccessGroupTeamAccesses, s.HandleUpdateAccessGroupTeamAccesses())
+	s.addWriteToolIfExists(ToolAddEnvironmentToAccessGroup, s.HandleAddEnvironmentToAccessGroup())
+	s.addWriteToolIfExists(ToolRemoveEnvironmentFromAccessGroup, s.HandleRemoveEnvironmentFromAccessGroup())
 }
 
 func (s *PortainerMCPServer) HandleGetAccessGroups() server.ToolHandlerFunc {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		accessGroups, err := s.cli.GetAccessGroups()
+		accessGroups, err := s.client(ctx).GetAccessGroups(ctx)
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("failed to get access groups", err), nil
 		}
@@ -53,7 +53,15 @@ func (s *PortainerMCPServer) HandleCreateAccessGroup() server.ToolHandlerFunc {
 			return mcp.NewToolResultErrorFromErr("invalid environmentIds parameter", err), nil
 		}
 
-		groupID, err := s.cli.CreateAccessGroup(name, environmentIds)
+		if s.isDryRun(ctx) {
+			after := models.AccessGroup{Name: name, EnvironmentIds: environmentIds}
+			return dryRunResult(nil, after, dryRunCall{
+				Operation: "CreateAccessGroup",
+				Arguments: map[string]any{"name": name, "environmentIds": environmentIds},
+			})
+		}
+
+		groupID, err := s.client(ctx).CreateAccessGroup(ctx, name, environmentIds)
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("failed to create access group", err), nil
 		}
@@ -76,7 +84,21 @@ func (s *PortainerMCPServer) HandleUpdateAccessGroupName() server.ToolHandlerFun
 			return mcp.NewToolResultErrorFromErr("invalid name parameter", err), nil
 		}
 
-		err = s.cli.UpdateAccessGroupName(id, name)
+		if s.isDryRun(ctx) {
+			before, err := s.findAccessGroup(ctx, id)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("failed to resolve access group", err), nil
+			}
+
+			after := before
+			after.Name = name
+			return dryRunResult(before, after, dryRunCall{
+				Operation: "UpdateAccessGroupName",
+				Arguments: map[string]any{"id": id, "name": name},
+			})
+		}
+
+		err = s.client(ctx).UpdateAccessGroupName(ctx, id, name)
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("failed to update access group name", err), nil
 		}
@@ -104,7 +126,21 @@ func (s *PortainerMCPServer) HandleUpdateAccessGroupUserAccesses() server.ToolHa
 			return mcp.NewToolResultErrorFromErr("invalid user accesses", err), nil
 		}
 
-		err = s.cli.UpdateAccessGroupUserAccesses(id, userAccessesMap)
+		if s.isDryRun(ctx) {
+			before, err := s.findAccessGroup(ctx, id)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("failed to resolve access group", err), nil
+			}
+
+			after := before
+			after.UserAccesses = userAccessesMap
+			return dryRunResult(before, after, dryRunCall{
+				Operation: "UpdateAccessGroupUserAccesses",
+				Arguments: map[string]any{"id": id, "userAccesses": userAccessesMap},
+			})
+		}
+
+		err = s.client(ctx).UpdateAccessGroupUserAccesses(ctx, id, userAccessesMap)
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("failed to update access group user accesses", err), nil
 		}
@@ -132,7 +168,21 @@ func (s *PortainerMCPServer) HandleUpdateAccessGroupTeamAccesses() server.ToolHa
 			return mcp.NewToolResultErrorFromErr("invalid team accesses", err), nil
 		}
 
-		err = s.cli.UpdateAccessGroupTeamAccesses(id, teamAccessesMap)
+		if s.isDryRun(ctx) {
+			before, err := s.findAccessGroup(ctx, id)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("failed to resolve access group", err), nil
+			}
+
+			after := before
+			after.TeamAccesses = teamAccessesMap
+			return dryRunResult(before, after, dryRunCall{
+				Operation: "UpdateAccessGroupTeamAccesses",
+				Arguments: map[string]any{"id": id, "teamAccesses": teamAccessesMap},
+			})
+		}
+
+		err = s.client(ctx).UpdateAccessGroupTeamAccesses(ctx, id, teamAccessesMap)
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("failed to update access group team accesses", err), nil
 		}
@@ -155,7 +205,23 @@ func (s *PortainerMCPServer) HandleAddEnvironmentToAccessGroup() server.ToolHand
 			return mcp.NewToolResultErrorFromErr("invalid environmentId parameter", err), nil
 		}
 
-		err = s.cli.AddEnvironmentToAccessGroup(id, environmentId)
+		if s.isDryRun(ctx) {
+			before, err := s.findAccessGroup(ctx, id)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("failed to resolve access group", err), nil
+			}
+
+			after := before
+			if !slices.Contains(before.EnvironmentIds, environmentId) {
+				after.EnvironmentIds = append(slices.Clone(before.EnvironmentIds), environmentId)
+			}
+			return dryRunResult(before, after, dryRunCall{
+				Operation: "AddEnvironmentToAccessGroup",
+				Arguments: map[string]any{"id": id, "environmentId": environmentId},
+			})
+		}
+
+		err = s.client(ctx).AddEnvironmentToAccessGroup(ctx, id, environmentId)
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("failed to add environment to access group", err), nil
 		}
@@ -178,7 +244,23 @@ func (s *PortainerMCPServer) HandleRemoveEnvironmentFromAccessGroup() server.Too
 			return mcp.NewToolResultErrorFromErr("invalid environmentId parameter", err), nil
 		}
 
-		err = s.cli.RemoveEnvironmentFromAccessGroup(id, environmentId)
+		if s.isDryRun(ctx) {
+			before, err := s.findAccessGroup(ctx, id)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("failed to resolve access group", err), nil
+			}
+
+			after := before
+			after.EnvironmentIds = slices.DeleteFunc(slices.Clone(before.EnvironmentIds), func(envId int) bool {
+				return envId == environmentId
+			})
+			return dryRunResult(before, after, dryRunCall{
+				Operation: "RemoveEnvironmentFromAccessGroup",
+				Arguments: map[string]any{"id": id, "environmentId": environmentId},
+			})
+		}
+
+		err = s.client(ctx).RemoveEnvironmentFromAccessGroup(ctx, id, environmentId)
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("failed to remove environment from access group", err), nil
 		}
@@ -0,0 +1,96 @@
+package mcp
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/portainer/portainer-mcp/internal/audit"
+	"github.com/rs/zerolog/log"
+)
+
+// withAudit wraps a tool handler so that every invocation is recorded in the audit log,
+// including calls rejected before reaching the Portainer API (e.g. invalid parameters).
+func (s *PortainerMCPServer) withAudit(toolName string, handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	if s.audit == nil {
+		return handler
+	}
+
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		result, err := handler(ctx, request)
+
+		entry := audit.Entry{
+			Timestamp:  start.UTC(),
+			Tool:       toolName,
+			Arguments:  request.GetArguments(),
+			Status:     audit.StatusSuccess,
+			DurationMs: time.Since(start).Milliseconds(),
+		}
+
+		if err != nil {
+			entry.Status = audit.StatusError
+			entry.Error = err.Error()
+		} else if result != nil && result.IsError {
+			entry.Status = audit.StatusError
+			entry.Error = resultText(result)
+		}
+
+		s.writeAuditEntry(ctx, entry, request)
+
+		return result, err
+	}
+}
+
+// auditResourceRead records the read of a resource in the audit log, as a call of the equivalent tool
+func (s *PortainerMCPServer) auditResourceRead(ctx context.Context, uri, toolName string, toolArguments map[string]any, start time.Time, err error) {
+	if s.audit == nil {
+		return
+	}
+
+	entry := audit.Entry{
+		Timestamp:  start.UTC(),
+		Tool:       toolName,
+		Resource:   uri,
+		Status:     audit.StatusSuccess,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+
+	if err != nil {
+		entry.Status = audit.StatusError
+		entry.Error = err.Error()
+	}
+
+	s.writeAuditEntry(ctx, entry, CreateMCPRequest(toolArguments))
+}
+
+// writeAuditEntry completes an entry with the session, the Portainer instance and the environment
+// of the call and writes it
+func (s *PortainerMCPServer) writeAuditEntry(ctx context.Context, entry audit.Entry, request mcp.CallToolRequest) {
+	entry.Instance = s.requestInstance(ctx, request)
+
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		entry.SessionID = session.SessionID()
+	}
+
+	if environmentId, ok := environmentIdFromRequest(entry.Tool, request); ok {
+		entry.EnvironmentID = &environmentId
+	}
+
+	if logErr := s.audit.Log(entry); logErr != nil {
+		log.Error().Err(logErr).Str("tool", entry.Tool).Msg("failed to write audit log entry")
+	}
+}
+
+// resultText returns the concatenated text content of a tool result
+func resultText(result *mcp.CallToolResult) string {
+	var texts []string
+	for _, content := range result.Content {
+		if textContent, ok := content.(mcp.TextContent); ok {
+			texts = append(texts, textContent.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
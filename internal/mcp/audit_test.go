@@ -0,0 +1,181 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/portainer/portainer-mcp/internal/audit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithAudit(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          map[string]any
+		mockSetup     func(*MockPortainerClient)
+		handlerErr    error
+		expectedEnvId *int
+		expectedState string
+		errorContains string
+	}{
+		{
+			name: "successful call",
+			args: map[string]any{"id": float64(1), "endpointId": float64(2)},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("DeleteStack", 1, 2).Return(nil)
+			},
+			expectedEnvId: intPtr(2),
+			expectedState: audit.StatusSuccess,
+		},
+		{
+			name: "upstream error",
+			args: map[string]any{"id": float64(1), "endpointId": float64(2)},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("DeleteStack", 1, 2).Return(errors.New("stack is locked"))
+			},
+			expectedEnvId: intPtr(2),
+			expectedState: audit.StatusError,
+			errorContains: "stack is locked",
+		},
+		{
+			name:          "parameter parsing failure is still logged",
+			args:          map[string]any{"endpointId": float64(2)},
+			mockSetup:     func(m *MockPortainerClient) {},
+			expectedEnvId: intPtr(2),
+			expectedState: audit.StatusError,
+			errorContains: "invalid id parameter",
+		},
+		{
+			name:          "invalid environment id",
+			args:          map[string]any{"id": float64(1), "endpointId": "two"},
+			mockSetup:     func(m *MockPortainerClient) {},
+			expectedState: audit.StatusError,
+			errorContains: "invalid endpointId parameter",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "audit.jsonl")
+			logger, err := audit.NewLogger(path, 0)
+			require.NoError(t, err)
+
+			mockClient := &MockPortainerClient{}
+			tt.mockSetup(mockClient)
+
+			s := &PortainerMCPServer{cli: mockClient, audit: logger}
+			handler := s.withAudit(ToolDeleteStack, s.HandleDeleteStack())
+
+			_, err = handler(context.Background(), CreateMCPRequest(tt.args))
+			require.NoError(t, err)
+			require.NoError(t, logger.Close())
+
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+			require.Len(t, lines, 1)
+
+			var entry audit.Entry
+			require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+			assert.Equal(t, ToolDeleteStack, entry.Tool)
+			assert.Equal(t, tt.args, entry.Arguments)
+			assert.Equal(t, tt.expectedEnvId, entry.EnvironmentID)
+			assert.Equal(t, tt.expectedState, entry.Status)
+			assert.False(t, entry.Timestamp.IsZero())
+			if tt.errorContains != "" {
+				assert.Contains(t, entry.Error, tt.errorContains)
+			} else {
+				assert.Empty(t, entry.Error)
+			}
+
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestWithAuditRedactsArguments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	logger, err := audit.NewLogger(path, 0)
+	require.NoError(t, err)
+
+	s := &PortainerMCPServer{audit: logger}
+	handler := s.withAudit(ToolDockerProxy, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("ok"), nil
+	})
+
+	sessionServer := server.NewMCPServer("Test Server", "1.0.0")
+	_, err = handler(sessionCtx(sessionServer, "session-1", ""), CreateMCPRequest(map[string]any{
+		"environmentId": float64(3),
+		"headers": []any{
+			map[string]any{"key": "X-Registry-Auth", "value": "secret-value"},
+		},
+	}))
+	require.NoError(t, err)
+	require.NoError(t, logger.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "secret-value")
+	assert.Contains(t, string(data), audit.RedactedValue)
+	assert.Contains(t, string(data), `"session_id":"session-1"`)
+	assert.Contains(t, string(data), `"environment_id":3`)
+}
+
+func TestWithAuditRecordsInstance(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     map[string]any
+		expected string
+	}{
+		{
+			name:     "default instance",
+			args:     map[string]any{},
+			expected: "production",
+		},
+		{
+			name:     "selected instance",
+			args:     map[string]any{InstanceParameter: "staging"},
+			expected: "staging",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "audit.jsonl")
+			logger, err := audit.NewLogger(path, 0)
+			require.NoError(t, err)
+
+			s := &PortainerMCPServer{
+				audit:        logger,
+				instanceName: "production",
+				instances:    map[string]*instance{"staging": {name: "staging"}},
+			}
+			handler := s.withAudit(ToolListEnvironments, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				return mcp.NewToolResultText("ok"), nil
+			})
+
+			_, err = handler(context.Background(), CreateMCPRequest(tt.args))
+			require.NoError(t, err)
+			require.NoError(t, logger.Close())
+
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+
+			var entry audit.Entry
+			require.NoError(t, json.Unmarshal(data, &entry))
+			assert.Equal(t, tt.expected, entry.Instance)
+		})
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
@@ -0,0 +1,386 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/portainer/portainer-mcp/pkg/portainer/models"
+	"github.com/portainer/portainer-mcp/pkg/toolgen"
+)
+
+// RefreshParameter is the tool parameter used to bypass the cache
+const RefreshParameter = "refresh"
+
+// Cached entity types, used as keys of the per-entity TTLs
+const (
+	CacheEnvironments      = "environments"
+	CacheEnvironmentGroups = "environment_groups"
+	CacheAccessGroups      = "access_groups"
+	CacheTags              = "tags"
+	CacheStacks            = "stacks"
+	CacheTeams             = "teams"
+	CacheUsers             = "users"
+	CacheSettings          = "settings"
+)
+
+// cacheEntities lists the entity types that can be cached. Edge stacks are not cached,
+// their deployment status changes as the edge agents report back to Portainer.
+var cacheEntities = []string{
+	CacheEnvironments,
+	CacheEnvironmentGroups,
+	CacheAccessGroups,
+	CacheTags,
+	CacheStacks,
+	CacheTeams,
+	CacheUsers,
+	CacheSettings,
+}
+
+type refreshKey struct{}
+
+// withRefresh marks the context so that reads bypass the cache
+func withRefresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, refreshKey{}, true)
+}
+
+// isRefresh reports whether reads should bypass the cache for the current call
+func isRefresh(ctx context.Context) bool {
+	refresh, _ := ctx.Value(refreshKey{}).(bool)
+	return refresh
+}
+
+// cacheConfig holds the TTL of each cached entity type, an entity without a TTL is not cached
+type cacheConfig map[string]time.Duration
+
+// newCacheConfig applies the per-entity TTLs over the default TTL
+func newCacheConfig(defaultTTL time.Duration, ttls map[string]time.Duration) (cacheConfig, error) {
+	config := cacheConfig{}
+	for _, entity := range cacheEntities {
+		config[entity] = defaultTTL
+	}
+
+	for entity, ttl := range ttls {
+		if _, ok := config[entity]; !ok {
+			return nil, fmt.Errorf("unknown cache entity %q, must be one of %s", entity, strings.Join(cacheEntities, ", "))
+		}
+		if ttl < 0 {
+			return nil, fmt.Errorf("the TTL of %s cannot be negative", entity)
+		}
+		config[entity] = ttl
+	}
+
+	return config, nil
+}
+
+// enabled reports whether at least one entity type is cached
+func (c cacheConfig) enabled() bool {
+	for _, ttl := range c {
+		if ttl > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+type cacheEntry struct {
+	value   any
+	expires time.Time
+}
+
+// responseCache stores the results of read calls until their TTL expires or
+// a write invalidates them. Entries are keyed by entity type, optionally followed
+// by a slash and an ID.
+type responseCache struct {
+	ttls cacheConfig
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+func newResponseCache(ttls cacheConfig) *responseCache {
+	return &responseCache{
+		ttls:    ttls,
+		now:     time.Now,
+		entries: map[string]cacheEntry{},
+	}
+}
+
+func (c *responseCache) get(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || !c.now().Before(entry.expires) {
+		return nil, false
+	}
+
+	return entry.value, true
+}
+
+func (c *responseCache) set(entity, key string, value any) {
+	ttl := c.ttls[entity]
+	if ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = cacheEntry{value: value, expires: c.now().Add(ttl)}
+}
+
+// invalidate removes the entries of the given entity types
+func (c *responseCache) invalidate(entities ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.entries {
+		for _, entity := range entities {
+			if key == entity || strings.HasPrefix(key, entity+"/") {
+				delete(c.entries, key)
+				break
+			}
+		}
+	}
+}
+
+// cachingClient is a PortainerClient that caches the results of list calls.
+// A successful write invalidates the entity types it affects. Cached values are
+// shared between calls and must not be modified.
+type cachingClient struct {
+	PortainerClient
+	cache *responseCache
+	// refresh makes reads skip the cache lookup, the fresh results are still stored
+	refresh bool
+}
+
+func newCachingClient(cli PortainerClient, ttls cacheConfig) *cachingClient {
+	return &cachingClient{
+		PortainerClient: cli,
+		cache:           newResponseCache(ttls),
+	}
+}
+
+// refreshing returns a view of the client whose reads bypass the cache
+func (c *cachingClient) refreshing() *cachingClient {
+	return &cachingClient{
+		PortainerClient: c.PortainerClient,
+		cache:           c.cache,
+		refresh:         true,
+	}
+}
+
+// cachedRead returns the cached value of the key, or fetches and caches it
+func cachedRead[T any](ctx context.Context, c *cachingClient, entity, key string, fetch func(context.Context) (T, error)) (T, error) {
+	if !c.refresh {
+		if value, ok := c.cache.get(key); ok {
+			return value.(T), nil
+		}
+	}
+
+	value, err := fetch(ctx)
+	if err != nil {
+		return value, err
+	}
+
+	c.cache.set(entity, key, value)
+	return value, nil
+}
+
+// invalidateOnSuccess invalidates the given entity types when the write succeeded
+func (c *cachingClient) invalidateOnSuccess(err error, entities ...string) error {
+	if err == nil {
+		c.cache.invalidate(entities...)
+	}
+	return err
+}
+
+func (c *cachingClient) GetEnvironmentTags(ctx context.Context) ([]models.EnvironmentTag, error) {
+	return cachedRead(ctx, c, CacheTags, CacheTags, c.PortainerClient.GetEnvironmentTags)
+}
+
+func (c *cachingClient) CreateEnvironmentTag(ctx context.Context, name string) (int, error) {
+	id, err := c.PortainerClient.CreateEnvironmentTag(ctx, name)
+	return id, c.invalidateOnSuccess(err, CacheTags)
+}
+
+func (c *cachingClient) GetEnvironments(ctx context.Context) ([]models.Environment, error) {
+	return cachedRead(ctx, c, CacheEnvironments, CacheEnvironments, c.PortainerClient.GetEnvironments)
+}
+
+// UpdateEnvironmentTags also invalidates the tags and the environment groups,
+// as they list their environments and dynamic groups match environments by tag
+func (c *cachingClient) UpdateEnvironmentTags(ctx context.Context, id int, tagIds []int) error {
+	return c.invalidateOnSuccess(c.PortainerClient.UpdateEnvironmentTags(ctx, id, tagIds),
+		CacheEnvironments, CacheTags, CacheEnvironmentGroups)
+}
+
+func (c *cachingClient) UpdateEnvironmentUserAccesses(ctx context.Context, id int, userAccesses map[int]string) error {
+	return c.invalidateOnSuccess(c.PortainerClient.UpdateEnvironmentUserAccesses(ctx, id, userAccesses), CacheEnvironments)
+}
+
+func (c *cachingClient) UpdateEnvironmentTeamAccesses(ctx context.Context, id int, teamAccesses map[int]string) error {
+	return c.invalidateOnSuccess(c.PortainerClient.UpdateEnvironmentTeamAccesses(ctx, id, teamAccesses), CacheEnvironments)
+}
+
+func (c *cachingClient) GetEnvironmentGroups(ctx context.Context) ([]models.Group, error) {
+	return cachedRead(ctx, c, CacheEnvironmentGroups, CacheEnvironmentGroups, c.PortainerClient.GetEnvironmentGroups)
+}
+
+func (c *cachingClient) CreateEnvironmentGroup(ctx context.Context, name string, environmentIds []int) (int, error) {
+	id, err := c.PortainerClient.CreateEnvironmentGroup(ctx, name, environmentIds)
+	return id, c.invalidateOnSuccess(err, CacheEnvironmentGroups)
+}
+
+func (c *cachingClient) UpdateEnvironmentGroupName(ctx context.Context, id int, name string) error {
+	return c.invalidateOnSuccess(c.PortainerClient.UpdateEnvironmentGroupName(ctx, id, name), CacheEnvironmentGroups)
+}
+
+func (c *cachingClient) UpdateEnvironmentGroupEnvironments(ctx context.Context, id int, environmentIds []int) error {
+	return c.invalidateOnSuccess(c.PortainerClient.UpdateEnvironmentGroupEnvironments(ctx, id, environmentIds), CacheEnvironmentGroups)
+}
+
+func (c *cachingClient) UpdateEnvironmentGroupTags(ctx context.Context, id int, tagIds []int) error {
+	return c.invalidateOnSuccess(c.PortainerClient.UpdateEnvironmentGroupTags(ctx, id, tagIds), CacheEnvironmentGroups)
+}
+
+func (c *cachingClient) GetAccessGroups(ctx context.Context) ([]models.AccessGroup, error) {
+	return cachedRead(ctx, c, CacheAccessGroups, CacheAccessGroups, c.PortainerClient.GetAccessGroups)
+}
+
+// CreateAccessGroup also invalidates the environments, as they reference their access group
+func (c *cachingClient) CreateAccessGroup(ctx context.Context, name string, environmentIds []int) (int, error) {
+	id, err := c.PortainerClient.CreateAccessGroup(ctx, name, environmentIds)
+	return id, c.invalidateOnSuccess(err, CacheAccessGroups, CacheEnvironments)
+}
+
+func (c *cachingClient) UpdateAccessGroupName(ctx context.Context, id int, name string) error {
+	return c.invalidateOnSuccess(c.PortainerClient.UpdateAccessGroupName(ctx, id, name), CacheAccessGroups)
+}
+
+func (c *cachingClient) UpdateAccessGroupUserAccesses(ctx context.Context, id int, userAccesses map[int]string) error {
+	return c.invalidateOnSuccess(c.PortainerClient.UpdateAccessGroupUserAccesses(ctx, id, userAccesses), CacheAccessGroups)
+}
+
+func (c *cachingClient) UpdateAccessGroupTeamAccesses(ctx context.Context, id int, teamAccesses map[int]string) error {
+	return c.invalidateOnSuccess(c.PortainerClient.UpdateAccessGroupTeamAccesses(ctx, id, teamAccesses), CacheAccessGroups)
+}
+
+func (c *cachingClient) AddEnvironmentToAccessGroup(ctx context.Context, id int, environmentId int) error {
+	return c.invalidateOnSuccess(c.PortainerClient.AddEnvironmentToAccessGroup(ctx, id, environmentId),
+		CacheAccessGroups, CacheEnvironments)
+}
+
+func (c *cachingClient) RemoveEnvironmentFromAccessGroup(ctx context.Context, id int, environmentId int) error {
+	return c.invalidateOnSuccess(c.PortainerClient.RemoveEnvironmentFromAccessGroup(ctx, id, environmentId),
+		CacheAccessGroups, CacheEnvironments)
+}
+
+func (c *cachingClient) GetStacks(ctx context.Context) ([]models.Stack, error) {
+	return cachedRead(ctx, c, CacheStacks, CacheStacks, c.PortainerClient.GetStacks)
+}
+
+func (c *cachingClient) GetStackFile(ctx context.Context, id int) (string, error) {
+	return cachedRead(ctx, c, CacheStacks, fmt.Sprintf("%s/%d/file", CacheStacks, id), func(ctx context.Context) (string, error) {
+		return c.PortainerClient.GetStackFile(ctx, id)
+	})
+}
+
+func (c *cachingClient) CreateStack(ctx context.Context, name string, file string, endpointId int, env map[string]string) (int, error) {
+	id, err := c.PortainerClient.CreateStack(ctx, name, file, endpointId, env)
+	return id, c.invalidateOnSuccess(err, CacheStacks)
+}
+
+func (c *cachingClient) UpdateStack(ctx context.Context, id int, file string, endpointId int, pullImage bool, env map[string]string) error {
+	return c.invalidateOnSuccess(c.PortainerClient.UpdateStack(ctx, id, file, endpointId, pullImage, env), CacheStacks)
+}
+
+func (c *cachingClient) CreateGitStack(ctx context.Context, name string, endpointId int, repository models.StackGitRepository, env map[string]string) (int, error) {
+	id, err := c.PortainerClient.CreateGitStack(ctx, name, endpointId, repository, env)
+	return id, c.invalidateOnSuccess(err, CacheStacks)
+}
+
+func (c *cachingClient) RedeployGitStack(ctx context.Context, id int, endpointId int, pullImage bool, env map[string]string) error {
+	return c.invalidateOnSuccess(c.PortainerClient.RedeployGitStack(ctx, id, endpointId, pullImage, env), CacheStacks)
+}
+
+func (c *cachingClient) StartStack(ctx context.Context, id int, endpointId int) error {
+	return c.invalidateOnSuccess(c.PortainerClient.StartStack(ctx, id, endpointId), CacheStacks)
+}
+
+func (c *cachingClient) StopStack(ctx context.Context, id int, endpointId int) error {
+	return c.invalidateOnSuccess(c.PortainerClient.StopStack(ctx, id, endpointId), CacheStacks)
+}
+
+func (c *cachingClient) DeleteStack(ctx context.Context, id int, endpointId int) error {
+	return c.invalidateOnSuccess(c.PortainerClient.DeleteStack(ctx, id, endpointId), CacheStacks)
+}
+
+func (c *cachingClient) GetTeams(ctx context.Context) ([]models.Team, error) {
+	return cachedRead(ctx, c, CacheTeams, CacheTeams, c.PortainerClient.GetTeams)
+}
+
+func (c *cachingClient) CreateTeam(ctx context.Context, name string) (int, error) {
+	id, err := c.PortainerClient.CreateTeam(ctx, name)
+	return id, c.invalidateOnSuccess(err, CacheTeams)
+}
+
+func (c *cachingClient) UpdateTeamName(ctx context.Context, id int, name string) error {
+	return c.invalidateOnSuccess(c.PortainerClient.UpdateTeamName(ctx, id, name), CacheTeams)
+}
+
+func (c *cachingClient) UpdateTeamMembers(ctx context.Context, id int, userIds []int) error {
+	return c.invalidateOnSuccess(c.PortainerClient.UpdateTeamMembers(ctx, id, userIds), CacheTeams)
+}
+
+func (c *cachingClient) GetUsers(ctx context.Context) ([]models.User, error) {
+	return cachedRead(ctx, c, CacheUsers, CacheUsers, c.PortainerClient.GetUsers)
+}
+
+func (c *cachingClient) UpdateUserRole(ctx context.Context, id int, role string) error {
+	return c.invalidateOnSuccess(c.PortainerClient.UpdateUserRole(ctx, id, role), CacheUsers)
+}
+
+func (c *cachingClient) GetSettings(ctx context.Context) (models.PortainerSettings, error) {
+	return cachedRead(ctx, c, CacheSettings, CacheSettings, c.PortainerClient.GetSettings)
+}
+
+// withRefreshParameter returns a copy of a read tool with the refresh parameter
+func withRefreshParameter(tool mcp.Tool) mcp.Tool {
+	properties := maps.Clone(tool.InputSchema.Properties)
+	if properties == nil {
+		properties = map[string]any{}
+	}
+
+	properties[RefreshParameter] = map[string]any{
+		"type":        "boolean",
+		"description": "Bypass the cache and fetch the latest data from Portainer. Only needed right after a change made outside of this server.",
+	}
+	tool.InputSchema.Properties = properties
+
+	return tool
+}
+
+// withCacheRefresh wraps a tool handler so that the reads of the call bypass the cache
+// when the refresh parameter is set. The fresh results replace the cached ones.
+func (s *PortainerMCPServer) withCacheRefresh(handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		refresh, err := toolgen.NewParameterParser(request).GetBoolean(RefreshParameter, false)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid refresh parameter", err), nil
+		}
+
+		if refresh {
+			ctx = withRefresh(ctx)
+		}
+
+		return handler(ctx, request)
+	}
+}
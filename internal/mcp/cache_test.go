@@ -0,0 +1,257 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/portainer/portainer-mcp/pkg/portainer/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCacheConfig(t *testing.T) {
+	config, err := newCacheConfig(time.Minute, map[string]time.Duration{CacheStacks: 0, CacheTeams: time.Second})
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, config[CacheEnvironments])
+	assert.Equal(t, time.Second, config[CacheTeams])
+	assert.Equal(t, time.Duration(0), config[CacheStacks])
+	assert.True(t, config.enabled())
+
+	config, err = newCacheConfig(0, nil)
+	require.NoError(t, err)
+	assert.False(t, config.enabled())
+
+	_, err = newCacheConfig(time.Minute, map[string]time.Duration{"stack": time.Second})
+	assert.ErrorContains(t, err, "unknown cache entity")
+
+	_, err = newCacheConfig(time.Minute, map[string]time.Duration{CacheUsers: -time.Second})
+	assert.ErrorContains(t, err, "cannot be negative")
+}
+
+func TestCachingClientTTL(t *testing.T) {
+	mockClient := &MockPortainerClient{}
+	mockClient.On("GetTeams").Return([]models.Team{{ID: 1, Name: "devs"}}, nil).Twice()
+	mockClient.On("GetUsers").Return([]models.User{{ID: 1, Username: "admin"}}, nil).Twice()
+
+	now := time.Now()
+	cli := newCachingClient(mockClient, cacheConfig{CacheTeams: time.Minute, CacheUsers: 0})
+	cli.cache.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		teams, err := cli.GetTeams(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "devs", teams[0].Name)
+	}
+
+	now = now.Add(time.Minute)
+	_, err := cli.GetTeams(context.Background())
+	require.NoError(t, err, "an expired entry is fetched again")
+
+	_, err = cli.GetUsers(context.Background())
+	require.NoError(t, err)
+	_, err = cli.GetUsers(context.Background())
+	require.NoError(t, err, "entities with a TTL of 0 are not cached")
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestCachingClientErrorsAreNotCached(t *testing.T) {
+	mockClient := &MockPortainerClient{}
+	mockClient.On("GetStacks").Return(nil, errors.New("api error")).Once()
+	mockClient.On("GetStacks").Return([]models.Stack{{ID: 1}}, nil).Once()
+
+	cli := newCachingClient(mockClient, cacheConfig{CacheStacks: time.Minute})
+
+	_, err := cli.GetStacks(context.Background())
+	assert.Error(t, err)
+
+	stacks, err := cli.GetStacks(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, stacks, 1)
+
+	_, err = cli.GetStacks(context.Background())
+	require.NoError(t, err)
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestCachingClientInvalidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		write       func(*cachingClient) error
+		mockSetup   func(*MockPortainerClient)
+		invalidated []string
+	}{
+		{
+			name: "update environment tags",
+			write: func(c *cachingClient) error {
+				return c.UpdateEnvironmentTags(context.Background(), 1, []int{2})
+			},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("UpdateEnvironmentTags", 1, []int{2}).Return(nil)
+			},
+			invalidated: []string{CacheEnvironments, CacheTags, CacheEnvironmentGroups},
+		},
+		{
+			name: "create team",
+			write: func(c *cachingClient) error {
+				_, err := c.CreateTeam(context.Background(), "ops")
+				return err
+			},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("CreateTeam", "ops").Return(3, nil)
+			},
+			invalidated: []string{CacheTeams},
+		},
+		{
+			name: "add environment to access group",
+			write: func(c *cachingClient) error {
+				return c.AddEnvironmentToAccessGroup(context.Background(), 1, 2)
+			},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("AddEnvironmentToAccessGroup", 1, 2).Return(nil)
+			},
+			invalidated: []string{CacheAccessGroups, CacheEnvironments},
+		},
+		{
+			name: "update stack",
+			write: func(c *cachingClient) error {
+				return c.UpdateStack(context.Background(), 1, "services: {}", 2, true, nil)
+			},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("UpdateStack", 1, "services: {}", 2, true, map[string]string(nil)).Return(nil)
+			},
+			invalidated: []string{CacheStacks, "stacks/1/file"},
+		},
+		{
+			name: "failed write keeps the cache",
+			write: func(c *cachingClient) error {
+				return c.UpdateUserRole(context.Background(), 1, "admin")
+			},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("UpdateUserRole", 1, "admin").Return(errors.New("api error"))
+			},
+		},
+	}
+
+	keys := append([]string{"stacks/1/file"}, cacheEntities...)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockPortainerClient{}
+			tt.mockSetup(mockClient)
+
+			cli := newCachingClient(mockClient, cacheConfig{})
+			for _, key := range keys {
+				cli.cache.entries[key] = cacheEntry{value: key, expires: time.Now().Add(time.Hour)}
+			}
+
+			_ = tt.write(cli)
+
+			for _, key := range keys {
+				_, cached := cli.cache.get(key)
+				assert.Equal(t, !slices.Contains(tt.invalidated, key), cached, "entry %s", key)
+			}
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCacheRefreshParameter(t *testing.T) {
+	mockClient := &MockPortainerClient{}
+	mockClient.On("GetEnvironments").Return([]models.Environment{{ID: 1, Name: "old"}}, nil).Once()
+	mockClient.On("GetEnvironments").Return([]models.Environment{{ID: 1, Name: "new"}}, nil).Once()
+
+	cache := cacheConfig{CacheEnvironments: time.Hour}
+	s := &PortainerMCPServer{
+		srv:   server.NewMCPServer("Test Server", "1.0.0"),
+		cli:   newCachingClient(mockClient, cache),
+		cache: cache,
+	}
+	handler := s.withCacheRefresh(s.HandleGetEnvironments())
+
+	call := func(arguments map[string]any) string {
+		request := CreateMCPRequest(arguments)
+		result, err := handler(context.Background(), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	assert.Contains(t, call(nil), "old")
+	assert.Contains(t, call(nil), "old", "the second call is served from the cache")
+	assert.Contains(t, call(map[string]any{RefreshParameter: true}), "new")
+	assert.Contains(t, call(nil), "new", "the refreshed result replaces the cached one")
+
+	request := CreateMCPRequest(map[string]any{RefreshParameter: "yes"})
+	result, err := handler(context.Background(), request)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestRefreshParameterRegistration(t *testing.T) {
+	tests := []struct {
+		name     string
+		cache    cacheConfig
+		toolName string
+		write    bool
+		expected bool
+	}{
+		{name: "read tool with the cache", cache: cacheConfig{CacheEnvironments: time.Hour}, toolName: ToolListEnvironments, expected: true},
+		{name: "write tool with the cache", cache: cacheConfig{CacheEnvironments: time.Hour}, toolName: ToolCreateEnvironmentTag, write: true},
+		{name: "read tool without the cache", toolName: ToolListEnvironments},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := mcp.NewTool(tt.toolName, mcp.WithString("filter"))
+			s := &PortainerMCPServer{
+				srv:   server.NewMCPServer("Test Server", "1.0.0"),
+				tools: map[string]mcp.Tool{tt.toolName: tool},
+				cache: tt.cache,
+			}
+
+			s.registerTool(tt.toolName, tt.write, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				return mcp.NewToolResultText("ok"), nil
+			})
+
+			registered := s.srv.GetTool(tt.toolName)
+			require.NotNil(t, registered)
+			assert.Contains(t, registered.Tool.InputSchema.Properties, "filter")
+			if tt.expected {
+				assert.Equal(t, map[string]any{
+					"type":        "boolean",
+					"description": "Bypass the cache and fetch the latest data from Portainer. Only needed right after a change made outside of this server.",
+				}, registered.Tool.InputSchema.Properties[RefreshParameter])
+			} else {
+				assert.NotContains(t, registered.Tool.InputSchema.Properties, RefreshParameter)
+			}
+			assert.NotContains(t, tool.InputSchema.Properties, RefreshParameter, "the original tool must not be modified")
+		})
+	}
+}
+
+func TestNewPortainerMCPServerWithCache(t *testing.T) {
+	s, err := NewPortainerMCPServer("https://portainer.example.com", "token", "testdata/valid_tools.yaml",
+		WithClient(&MockPortainerClient{}),
+		WithDisableVersionCheck(true),
+		WithCache(time.Minute, map[string]time.Duration{CacheStacks: 0}),
+	)
+	require.NoError(t, err)
+	assert.IsType(t, &cachingClient{}, s.client(context.Background()))
+	assert.IsType(t, &cachingClient{}, s.factory("other-token"))
+
+	_, err = NewPortainerMCPServer("https://portainer.example.com", "token", "testdata/valid_tools.yaml",
+		WithClient(&MockPortainerClient{}),
+		WithDisableVersionCheck(true),
+		WithCache(time.Minute, map[string]time.Duration{"unknown": time.Second}),
+	)
+	assert.ErrorContains(t, err, "invalid cache configuration")
+}
@@ -0,0 +1,127 @@
+package mcp
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// methodNotificationCancelled is the notification sent by MCP clients to cancel one of their requests
+const methodNotificationCancelled = "notifications/cancelled"
+
+// cancellations aborts the in-flight tool calls that the client cancels with notifications/cancelled.
+//
+// mcp-go does not give the JSON-RPC ID of a request to the tool handlers. The ID is recorded by a
+// hook that runs right before the handler with the same context, and claimed by the handler.
+type cancellations struct {
+	mu sync.Mutex
+	// pending holds the IDs recorded by the hook, until the handler claims them
+	pending map[context.Context]mcp.RequestId
+	// inflight holds the cancel functions of the running calls, by session and request ID
+	inflight map[string]context.CancelFunc
+}
+
+func newCancellations() *cancellations {
+	return &cancellations{
+		pending:  make(map[context.Context]mcp.RequestId),
+		inflight: make(map[string]context.CancelFunc),
+	}
+}
+
+// install records the IDs of the tool calls with the hooks of the MCP server
+func (c *cancellations) install(hooks *server.Hooks) {
+	hooks.AddBeforeCallTool(func(ctx context.Context, id any, _ *mcp.CallToolRequest) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		c.pending[ctx] = mcp.NewRequestId(id)
+	})
+
+	// A call to an unknown tool never reaches a handler to claim its ID
+	hooks.AddOnError(func(ctx context.Context, _ any, method mcp.MCPMethod, _ any, _ error) {
+		if method != mcp.MethodToolsCall {
+			return
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		delete(c.pending, ctx)
+	})
+}
+
+// begin claims the ID recorded for the call and returns a context that is cancelled when the
+// client cancels the call. The returned function must be called once the call is over.
+func (c *cancellations) begin(ctx context.Context) (context.Context, func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id, ok := c.pending[ctx]
+	if !ok {
+		return ctx, func() {}
+	}
+	delete(c.pending, ctx)
+
+	key := callKey(ctx, id)
+	ctx, cancel := context.WithCancel(ctx)
+	c.inflight[key] = cancel
+
+	return ctx, func() {
+		c.mu.Lock()
+		delete(c.inflight, key)
+		c.mu.Unlock()
+
+		cancel()
+	}
+}
+
+// cancel aborts the call with the given request ID of the calling session.
+// It reports whether the call was running.
+func (c *cancellations) cancel(ctx context.Context, id mcp.RequestId) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cancel, ok := c.inflight[callKey(ctx, id)]
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// handleNotification handles notifications/cancelled. Notifications for requests that
+// already completed, or that are not tool calls, are ignored as allowed by the protocol.
+func (c *cancellations) handleNotification(ctx context.Context, notification mcp.JSONRPCNotification) {
+	id, ok := notification.Params.AdditionalFields["requestId"]
+	if !ok {
+		return
+	}
+
+	c.cancel(ctx, mcp.NewRequestId(id))
+}
+
+// callKey identifies a request of a session. Numeric IDs are normalised by RequestId.String,
+// so that the ID of the call and the ID of the notification match whatever their JSON type.
+func callKey(ctx context.Context, id mcp.RequestId) string {
+	sessionID := ""
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		sessionID = session.SessionID()
+	}
+	return sessionID + "/" + id.String()
+}
+
+// withCancellation wraps a tool handler so that the client can cancel the call.
+// It must be the outermost wrapper, as the call is matched to its ID by its context.
+func (s *PortainerMCPServer) withCancellation(handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	if s.cancellations == nil {
+		return handler
+	}
+
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ctx, end := s.cancellations.begin(ctx)
+		defer end()
+
+		return handler(ctx, request)
+	}
+}
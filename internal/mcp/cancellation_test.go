@@ -0,0 +1,124 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type messageKey struct{}
+
+// newCancellableServer builds an MCP server with a listStacks tool that waits until its call is aborted
+func newCancellableServer(t *testing.T) (*server.MCPServer, chan struct{}) {
+	s := &PortainerMCPServer{
+		cancellations: newCancellations(),
+		timeouts:      map[string]time.Duration{ToolListStacks: time.Minute},
+	}
+
+	hooks := &server.Hooks{}
+	s.cancellations.install(hooks)
+	srv := server.NewMCPServer("test", "1.0", server.WithToolCapabilities(true), server.WithHooks(hooks))
+	srv.AddNotificationHandler(methodNotificationCancelled, s.cancellations.handleNotification)
+
+	started := make(chan struct{})
+	handler := s.withTimeout(ToolListStacks, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		close(started)
+		<-ctx.Done()
+		return mcp.NewToolResultErrorFromErr("failed to get stacks", ctx.Err()), nil
+	})
+	srv.AddTool(mcp.NewTool(ToolListStacks), s.withCancellation(handler))
+
+	return srv, started
+}
+
+// sendMessage sends a JSON-RPC message with its own context, as the networked transports do
+func sendMessage(t *testing.T, srv *server.MCPServer, message map[string]any) mcp.JSONRPCMessage {
+	data, err := json.Marshal(message)
+	require.NoError(t, err)
+
+	ctx := context.WithValue(context.Background(), messageKey{}, message)
+	return srv.HandleMessage(ctx, data)
+}
+
+func TestCancelledNotificationAbortsToolCall(t *testing.T) {
+	tests := []struct {
+		name      string
+		callID    any
+		cancelID  any
+		cancelled bool
+	}{
+		{name: "numeric request ID", callID: 7, cancelID: 7, cancelled: true},
+		{name: "string request ID", callID: "call-7", cancelID: "call-7", cancelled: true},
+		{name: "other request ID", callID: 7, cancelID: 8, cancelled: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, started := newCancellableServer(t)
+
+			responses := make(chan mcp.JSONRPCMessage, 1)
+			go func() {
+				responses <- sendMessage(t, srv, map[string]any{
+					"jsonrpc": "2.0",
+					"id":      tt.callID,
+					"method":  "tools/call",
+					"params":  map[string]any{"name": ToolListStacks},
+				})
+			}()
+
+			<-started
+			assert.Nil(t, sendMessage(t, srv, map[string]any{
+				"jsonrpc": "2.0",
+				"method":  methodNotificationCancelled,
+				"params":  map[string]any{"requestId": tt.cancelID, "reason": "user aborted"},
+			}))
+
+			select {
+			case response := <-responses:
+				require.True(t, tt.cancelled, "the call must not complete when another request is cancelled")
+				result, ok := response.(mcp.JSONRPCResponse).Result.(mcp.CallToolResult)
+				require.True(t, ok)
+				assert.True(t, result.IsError)
+				assert.Equal(t, "listStacks was cancelled", resultText(&result))
+			case <-time.After(100 * time.Millisecond):
+				require.False(t, tt.cancelled, "the cancelled call did not complete")
+
+				sendMessage(t, srv, map[string]any{
+					"jsonrpc": "2.0",
+					"method":  methodNotificationCancelled,
+					"params":  map[string]any{"requestId": tt.callID},
+				})
+				<-responses
+			}
+		})
+	}
+}
+
+func TestCancellationsCleanup(t *testing.T) {
+	c := newCancellations()
+	hooks := &server.Hooks{}
+	c.install(hooks)
+	srv := server.NewMCPServer("test", "1.0", server.WithToolCapabilities(true), server.WithHooks(hooks))
+
+	sendMessage(t, srv, map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params":  map[string]any{"name": "unknownTool"},
+	})
+	assert.Empty(t, c.pending, "the ID of a call to an unknown tool must be forgotten")
+
+	ctx := context.Background()
+	c.pending[ctx] = mcp.NewRequestId(2)
+	_, end := c.begin(ctx)
+	assert.Len(t, c.inflight, 1)
+	end()
+	assert.Empty(t, c.inflight)
+	assert.False(t, c.cancel(ctx, mcp.NewRequestId(2)), "completed calls cannot be cancelled")
+}
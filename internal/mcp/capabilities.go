@@ -0,0 +1,250 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/portainer/portainer-mcp/pkg/portainer/models"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/mod/semver"
+)
+
+const (
+	// EditionCE is the edition reported by Portainer Community Edition servers
+	EditionCE = "CE"
+	// EditionEE is the edition reported by Portainer Business Edition servers
+	EditionEE = "EE"
+	// EditionUnknown is used when the edition of the Portainer server could not be detected
+	EditionUnknown = "unknown"
+)
+
+// capability is a feature of the Portainer instance that some tools depend on
+type capability string
+
+const (
+	capabilityEdgeCompute capability = "edge compute"
+	capabilityKubernetes  capability = "Kubernetes environments"
+	capabilityDocker      capability = "Docker environments"
+)
+
+// toolRequirements lists the tools that can only be served by instances with a given capability.
+// Tools that are not listed only require a supported Portainer version.
+var toolRequirements = map[string]capability{
+	ToolListEnvironmentGroups:              capabilityEdgeCompute,
+	ToolCreateEnvironmentGroup:             capabilityEdgeCompute,
+	ToolUpdateEnvironmentGroupName:         capabilityEdgeCompute,
+	ToolUpdateEnvironmentGroupEnvironments: capabilityEdgeCompute,
+	ToolUpdateEnvironmentGroupTags:         capabilityEdgeCompute,
+	ToolListEdgeStacks:                     capabilityEdgeCompute,
+	ToolGetEdgeStackFile:                   capabilityEdgeCompute,
+	ToolCreateEdgeStack:                    capabilityEdgeCompute,
+	ToolUpdateEdgeStack:                    capabilityEdgeCompute,
+	ToolDeleteEdgeStack:                    capabilityEdgeCompute,
+	ToolKubernetesProxy:                    capabilityKubernetes,
+	ToolKubernetesProxyStripped:            capabilityKubernetes,
+	ToolDockerProxy:                        capabilityDocker,
+}
+
+// Capabilities describes the features detected on the Portainer instance at startup
+type Capabilities struct {
+	Version     string
+	Edition     string
+	EdgeCompute bool
+	Kubernetes  bool
+	Docker      bool
+}
+
+// has reports whether the instance provides the given capability
+func (c *Capabilities) has(cap capability) bool {
+	switch cap {
+	case capabilityEdgeCompute:
+		return c.EdgeCompute
+	case capabilityKubernetes:
+		return c.Kubernetes
+	case capabilityDocker:
+		return c.Docker
+	default:
+		return true
+	}
+}
+
+// missingCapability returns the capability required by the tool that the instance does not provide.
+// A nil Capabilities means that detection was skipped, in which case every tool is served.
+func (c *Capabilities) missingCapability(toolName string) (capability, bool) {
+	if c == nil {
+		return "", false
+	}
+
+	required, ok := toolRequirements[toolName]
+	if !ok || c.has(required) {
+		return "", false
+	}
+
+	return required, true
+}
+
+// isSupportedPortainerVersion reports whether the version is within the supported range,
+// from MinimumPortainerVersion included to MaximumPortainerVersion excluded
+func isSupportedPortainerVersion(version string) bool {
+	v := "v" + strings.TrimPrefix(version, "v")
+	if !semver.IsValid(v) {
+		return false
+	}
+
+	return semver.Compare(v, "v"+MinimumPortainerVersion) >= 0 &&
+		semver.Compare(v, "v"+MaximumPortainerVersion) < 0
+}
+
+// checkPortainerVersion returns the version of the Portainer server, or an error
+// if it is outside of the supported range
+func checkPortainerVersion(ctx context.Context, cli PortainerClient) (string, error) {
+	version, err := cli.GetVersion(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get Portainer server version: %w", err)
+	}
+
+	if !isSupportedPortainerVersion(version) {
+		return "", unsupportedVersionError(version)
+	}
+
+	return version, nil
+}
+
+func unsupportedVersionError(version string) error {
+	return fmt.Errorf("unsupported Portainer server version: %s, supported versions are >= %s and < %s", version, MinimumPortainerVersion, MaximumPortainerVersion)
+}
+
+// deferredVersionCheck checks the version of a Portainer instance with the client of the first
+// MCP session, when the server has no credentials of its own to check it at startup.
+// A failure to get the version is not kept, as it may only come from the API key of a session.
+type deferredVersionCheck struct {
+	name string
+
+	mu   sync.Mutex
+	done bool
+	err  error
+}
+
+func newDeferredVersionCheck(name string) *deferredVersionCheck {
+	return &deferredVersionCheck{name: name}
+}
+
+// check returns an error if the version of the instance is outside of the supported range
+func (c *deferredVersionCheck) check(ctx context.Context, cli PortainerClient) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.done {
+		return c.err
+	}
+
+	version, err := cli.GetVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get Portainer server version: %w", err)
+	}
+
+	c.done = true
+	if !isSupportedPortainerVersion(version) {
+		c.err = unsupportedVersionError(version)
+		return c.err
+	}
+
+	log.Info().
+		Str("instance", c.name).
+		Str("version", version).
+		Msg("connected to Portainer instance")
+
+	return nil
+}
+
+// checkInstance checks that the version of a Portainer instance is supported and detects its
+// capabilities, within StartupCheckTimeout. It returns no capabilities when the version check
+// is disabled, so that all the tools are registered.
+func checkInstance(name string, cli PortainerClient, disableVersionCheck bool) (*Capabilities, error) {
+	if disableVersionCheck {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), StartupCheckTimeout)
+	defer cancel()
+
+	version, err := checkPortainerVersion(ctx, cli)
+	if err != nil {
+		return nil, err
+	}
+
+	capabilities := detectCapabilities(ctx, cli, version)
+	log.Info().
+		Str("instance", name).
+		Str("version", capabilities.Version).
+		Str("edition", capabilities.Edition).
+		Bool("edge-compute", capabilities.EdgeCompute).
+		Bool("kubernetes", capabilities.Kubernetes).
+		Bool("docker", capabilities.Docker).
+		Msg("connected to Portainer instance")
+
+	return capabilities, nil
+}
+
+// detectCapabilities probes the Portainer instance for the features that tools depend on.
+// A probe that fails is logged and the capability is assumed to be present, so that
+// a transient error or a missing permission does not hide tools.
+func detectCapabilities(ctx context.Context, cli PortainerClient, version string) *Capabilities {
+	caps := &Capabilities{
+		Version:     version,
+		Edition:     EditionUnknown,
+		EdgeCompute: true,
+		Kubernetes:  true,
+		Docker:      true,
+	}
+
+	edition, err := cli.GetEdition(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to detect the Portainer edition")
+	} else {
+		caps.Edition = edition
+	}
+
+	settings, err := cli.GetSettings(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to detect whether edge compute is enabled, assuming it is")
+	} else {
+		caps.EdgeCompute = settings.Edge.Enabled
+	}
+
+	environments, err := cli.GetEnvironments(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to detect Kubernetes and Docker environments, assuming there are some")
+	} else {
+		caps.Kubernetes = hasKubernetesEnvironment(environments)
+		caps.Docker = hasDockerEnvironment(environments)
+	}
+
+	return caps
+}
+
+// hasKubernetesEnvironment reports whether any of the environments is a Kubernetes environment
+func hasKubernetesEnvironment(environments []models.Environment) bool {
+	for _, environment := range environments {
+		switch environment.Type {
+		case models.EnvironmentTypeKubernetesLocal, models.EnvironmentTypeKubernetesAgent, models.EnvironmentTypeKubernetesEdgeAgent:
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasDockerEnvironment reports whether any of the environments is a Docker environment
+func hasDockerEnvironment(environments []models.Environment) bool {
+	for _, environment := range environments {
+		switch environment.Type {
+		case models.EnvironmentTypeDockerLocal, models.EnvironmentTypeDockerAgent, models.EnvironmentTypeDockerEdgeAgent:
+			return true
+		}
+	}
+
+	return false
+}
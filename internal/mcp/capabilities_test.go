@@ -0,0 +1,185 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/portainer/portainer-mcp/pkg/portainer/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsSupportedPortainerVersion(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected bool
+	}{
+		{version: SupportedPortainerVersion, expected: true},
+		{version: MinimumPortainerVersion, expected: true},
+		{version: "2.31.9", expected: true},
+		{version: "v2.31.2", expected: true},
+		{version: "2.30.1", expected: false},
+		{version: MaximumPortainerVersion, expected: false},
+		{version: "3.0.0", expected: false},
+		{version: "not-a-version", expected: false},
+		{version: "", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			assert.Equal(t, tt.expected, isSupportedPortainerVersion(tt.version))
+		})
+	}
+}
+
+func TestDetectCapabilities(t *testing.T) {
+	tests := []struct {
+		name      string
+		mockSetup func(*MockPortainerClient)
+		expected  *Capabilities
+	}{
+		{
+			name: "community edition without edge compute nor kubernetes",
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetEdition").Return(EditionCE, nil)
+				m.On("GetSettings").Return(models.PortainerSettings{}, nil)
+				m.On("GetEnvironments").Return([]models.Environment{
+					{ID: 1, Type: models.EnvironmentTypeDockerLocal},
+				}, nil)
+			},
+			expected: &Capabilities{Version: SupportedPortainerVersion, Edition: EditionCE, Docker: true},
+		},
+		{
+			name: "kubernetes only",
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetEdition").Return(EditionCE, nil)
+				m.On("GetSettings").Return(models.PortainerSettings{}, nil)
+				m.On("GetEnvironments").Return([]models.Environment{
+					{ID: 1, Type: models.EnvironmentTypeKubernetesLocal},
+				}, nil)
+			},
+			expected: &Capabilities{Version: SupportedPortainerVersion, Edition: EditionCE, Kubernetes: true},
+		},
+		{
+			name: "enterprise edition with edge compute and kubernetes",
+			mockSetup: func(m *MockPortainerClient) {
+				settings := models.PortainerSettings{}
+				settings.Edge.Enabled = true
+
+				m.On("GetEdition").Return(EditionEE, nil)
+				m.On("GetSettings").Return(settings, nil)
+				m.On("GetEnvironments").Return([]models.Environment{
+					{ID: 1, Type: models.EnvironmentTypeDockerLocal},
+					{ID: 2, Type: models.EnvironmentTypeKubernetesAgent},
+				}, nil)
+			},
+			expected: &Capabilities{Version: SupportedPortainerVersion, Edition: EditionEE, EdgeCompute: true, Kubernetes: true, Docker: true},
+		},
+		{
+			name: "failed probes assume the capability is present",
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetEdition").Return("", errors.New("api error"))
+				m.On("GetSettings").Return(models.PortainerSettings{}, errors.New("api error"))
+				m.On("GetEnvironments").Return(nil, errors.New("api error"))
+			},
+			expected: &Capabilities{Version: SupportedPortainerVersion, Edition: EditionUnknown, EdgeCompute: true, Kubernetes: true, Docker: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockPortainerClient{}
+			tt.mockSetup(mockClient)
+
+			assert.Equal(t, tt.expected, detectCapabilities(context.Background(), mockClient, SupportedPortainerVersion))
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAddToolIfExistsWithCapabilities(t *testing.T) {
+	tests := []struct {
+		name         string
+		capabilities *Capabilities
+		toolName     string
+		registered   bool
+	}{
+		{
+			name:       "capabilities not detected",
+			toolName:   ToolKubernetesProxyStripped,
+			registered: true,
+		},
+		{
+			name:         "missing kubernetes",
+			capabilities: &Capabilities{EdgeCompute: true},
+			toolName:     ToolKubernetesProxyStripped,
+			registered:   false,
+		},
+		{
+			name:         "missing edge compute",
+			capabilities: &Capabilities{Kubernetes: true},
+			toolName:     ToolListEnvironmentGroups,
+			registered:   false,
+		},
+		{
+			name:         "edge compute enabled",
+			capabilities: &Capabilities{EdgeCompute: true},
+			toolName:     ToolListEnvironmentGroups,
+			registered:   true,
+		},
+		{
+			name:         "missing docker",
+			capabilities: &Capabilities{Kubernetes: true},
+			toolName:     ToolDockerProxy,
+			registered:   false,
+		},
+		{
+			name:         "tool without requirement",
+			capabilities: &Capabilities{},
+			toolName:     ToolListEnvironments,
+			registered:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &PortainerMCPServer{
+				srv:          server.NewMCPServer("Test Server", "1.0.0", server.WithToolCapabilities(true)),
+				tools:        map[string]mcp.Tool{tt.toolName: {Name: tt.toolName}},
+				capabilities: tt.capabilities,
+			}
+
+			s.addToolIfExists(tt.toolName, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				return mcp.NewToolResultText("ok"), nil
+			})
+
+			assert.Equal(t, tt.registered, slices.Contains(listToolNames(t, s.srv), tt.toolName))
+		})
+	}
+}
+
+// listToolNames sends a tools/list message through the MCP server and returns the names of the registered tools
+func listToolNames(t *testing.T, srv *server.MCPServer) []string {
+	message, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/list",
+	})
+	require.NoError(t, err)
+
+	response, ok := srv.HandleMessage(context.Background(), message).(mcp.JSONRPCResponse)
+	require.True(t, ok)
+	result, ok := response.Result.(mcp.ListToolsResult)
+	require.True(t, ok)
+
+	names := make([]string, 0, len(result.Tools))
+	for _, tool := range result.Tools {
+		names = append(names, tool.Name)
+	}
+	return names
+}
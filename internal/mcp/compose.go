@@ -0,0 +1,105 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/portainer/portainer-mcp/internal/compose"
+	"github.com/portainer/portainer-mcp/pkg/portainer/models"
+	"github.com/portainer/portainer-mcp/pkg/toolgen"
+)
+
+// HandleValidateCompose validates a compose file locally, without calling Portainer, with the
+// same checks as the ones run before a stack is created or updated
+func (s *PortainerMCPServer) HandleValidateCompose() server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		parser := toolgen.NewParameterParser(request)
+
+		file, err := parser.GetString("file", true)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid file parameter", err), nil
+		}
+
+		env, err := parseStackEnv(request)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid env parameter", err), nil
+		}
+
+		data, err := json.Marshal(compose.Validate(file, env))
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to marshal validation", err), nil
+		}
+
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
+
+// validateStackFile validates the compose file of a stack before it is sent to Portainer. It returns
+// an error result listing the errors of an invalid file, or the warnings of a valid one.
+func validateStackFile(file string, env map[string]string) ([]compose.Issue, *mcp.CallToolResult) {
+	validation := compose.Validate(file, env)
+	if !validation.Valid {
+		return nil, mcp.NewToolResultError("invalid compose file:\n" + formatIssues(validation.Errors))
+	}
+
+	return validation.Warnings, nil
+}
+
+// stackFileEnv returns the environment variables the compose file of an updated stack is
+// interpolated with: the given ones, or the current ones of the stack when env is nil
+func (s *PortainerMCPServer) stackFileEnv(ctx context.Context, id int, file string, env map[string]string) (map[string]string, error) {
+	if env != nil || !compose.ReferencesVariables(file) {
+		return env, nil
+	}
+
+	stack, err := s.findStack(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return stackEnvMap(stack.Env), nil
+}
+
+func stackEnvMap(vars []models.StackEnvVar) map[string]string {
+	env := make(map[string]string, len(vars))
+	for _, v := range vars {
+		env[v.Name] = v.Value
+	}
+
+	return env
+}
+
+// withWarnings appends the warnings of the compose file to the message of a successful write
+func withWarnings(message string, warnings []compose.Issue) string {
+	if len(warnings) == 0 {
+		return message
+	}
+
+	return fmt.Sprintf("%s. Warnings:\n%s", message, formatIssues(warnings))
+}
+
+// formatIssues lists issues one per line, with their location in the compose file
+func formatIssues(issues []compose.Issue) string {
+	lines := make([]string, len(issues))
+	for i, issue := range issues {
+		var location []string
+		if issue.Line > 0 {
+			location = append(location, fmt.Sprintf("line %d", issue.Line))
+		}
+		if issue.Path != "" {
+			location = append(location, issue.Path)
+		}
+
+		if len(location) == 0 {
+			lines[i] = "- " + issue.Message
+		} else {
+			lines[i] = fmt.Sprintf("- %s: %s", strings.Join(location, ", "), issue.Message)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
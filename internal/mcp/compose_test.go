@@ -0,0 +1,161 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/portainer/portainer-mcp/internal/compose"
+	"github.com/portainer/portainer-mcp/pkg/portainer/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleValidateCompose(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          map[string]any
+		expected      compose.Validation
+		errorContains string
+	}{
+		{
+			name: "valid file with warnings",
+			args: map[string]any{
+				"file": "services:\n  web:\n    image: nginx:${TAG}\n    privileged: true\n",
+				"env":  []any{map[string]any{"key": "TAG", "value": "1.27"}},
+			},
+			expected: compose.Validation{
+				Valid:  true,
+				Errors: []compose.Issue{},
+				Warnings: []compose.Issue{
+					{Line: 4, Path: "services.web.privileged", Message: "service web runs privileged, with full access to the host"},
+				},
+			},
+		},
+		{
+			name: "undefined variable",
+			args: map[string]any{"file": "services:\n  web:\n    image: nginx:${TAG}\n"},
+			expected: compose.Validation{
+				Valid:  true,
+				Errors: []compose.Issue{},
+				Warnings: []compose.Issue{
+					{Line: 3, Path: "services.web.image", Message: "variable TAG is not defined, set it in the environment variables of the stack"},
+				},
+			},
+		},
+		{
+			name:          "missing file parameter",
+			args:          map[string]any{},
+			errorContains: "file is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &PortainerMCPServer{}
+
+			result, err := server.HandleValidateCompose()(context.Background(), CreateMCPRequest(tt.args))
+			require.NoError(t, err)
+
+			textContent, ok := result.Content[0].(mcp.TextContent)
+			require.True(t, ok)
+
+			if tt.errorContains != "" {
+				assert.True(t, result.IsError)
+				assert.Contains(t, textContent.Text, tt.errorContains)
+			} else {
+				assert.False(t, result.IsError)
+				var validation compose.Validation
+				require.NoError(t, json.Unmarshal([]byte(textContent.Text), &validation))
+				assert.Equal(t, tt.expected, validation)
+			}
+		})
+	}
+}
+
+func TestStackFileValidation(t *testing.T) {
+	tests := []struct {
+		name          string
+		handler       func(*PortainerMCPServer) server.ToolHandlerFunc
+		args          map[string]any
+		mockSetup     func(*MockPortainerClient)
+		expected      string
+		errorContains string
+	}{
+		{
+			name:          "invalid file is not sent to Portainer",
+			handler:       (*PortainerMCPServer).HandleCreateStack,
+			args:          map[string]any{"name": "web", "endpointId": float64(2), "file": "services:\n  web:\n    ports:\n      - 80:http\n"},
+			mockSetup:     func(m *MockPortainerClient) {},
+			errorContains: "invalid compose file:\n- line 2, services.web: service web must define an image or a build\n- line 4, services.web.ports[0]: invalid port",
+		},
+		{
+			name:    "warnings reported after creation",
+			handler: (*PortainerMCPServer).HandleCreateStack,
+			args:    map[string]any{"name": "web", "endpointId": float64(2), "file": "services:\n  web:\n    image: nginx:latest\n"},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("CreateStack", "web", "services:\n  web:\n    image: nginx:latest\n", 2, map[string]string(nil)).Return(1, nil)
+			},
+			expected: "Stack created successfully with ID: 1. Warnings:\n- line 3, services.web.image: image nginx:latest uses the latest tag, redeployments may run a different version",
+		},
+		{
+			name:    "update interpolated with the current variables of the stack",
+			handler: (*PortainerMCPServer).HandleUpdateStack,
+			args:    map[string]any{"id": float64(1), "endpointId": float64(2), "file": "services:\n  web:\n    image: nginx:${TAG}\n"},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetStacks").Return([]models.Stack{{ID: 1, EndpointID: 2, Env: []models.StackEnvVar{{Name: "TAG", Value: "1.27"}}}}, nil)
+				m.On("UpdateStack", 1, "services:\n  web:\n    image: nginx:${TAG}\n", 2, true, map[string]string(nil)).Return(nil)
+			},
+			expected: "Stack updated successfully",
+		},
+		{
+			name:    "update with a variable the stack does not define",
+			handler: (*PortainerMCPServer).HandleUpdateStack,
+			args:    map[string]any{"id": float64(1), "endpointId": float64(2), "file": "services:\n  web:\n    image: nginx:${TAG}\n"},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetStacks").Return([]models.Stack{{ID: 1, EndpointID: 2}}, nil)
+				m.On("UpdateStack", 1, "services:\n  web:\n    image: nginx:${TAG}\n", 2, true, map[string]string(nil)).Return(nil)
+			},
+			expected: "Stack updated successfully. Warnings:\n- line 3, services.web.image: variable TAG is not defined, set it in the environment variables of the stack",
+		},
+		{
+			name:    "update with new variables",
+			handler: (*PortainerMCPServer).HandleUpdateStack,
+			args: map[string]any{
+				"id": float64(1), "endpointId": float64(2), "file": "services:\n  web:\n    image: nginx:${TAG}\n",
+				"env": []any{map[string]any{"key": "TAG", "value": "1.27"}},
+			},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("UpdateStack", 1, "services:\n  web:\n    image: nginx:${TAG}\n", 2, true, map[string]string{"TAG": "1.27"}).Return(nil)
+			},
+			expected: "Stack updated successfully",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockPortainerClient{}
+			tt.mockSetup(mockClient)
+
+			server := &PortainerMCPServer{cli: mockClient}
+
+			result, err := tt.handler(server)(context.Background(), CreateMCPRequest(tt.args))
+			require.NoError(t, err)
+
+			textContent, ok := result.Content[0].(mcp.TextContent)
+			require.True(t, ok)
+
+			if tt.errorContains != "" {
+				assert.True(t, result.IsError)
+				assert.Contains(t, textContent.Text, tt.errorContains)
+			} else {
+				assert.False(t, result.IsError)
+				assert.Equal(t, tt.expected, textContent.Text)
+			}
+
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
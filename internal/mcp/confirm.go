@@ -0,0 +1,326 @@
+package mcp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// ConfirmationTokenParameter is the tool parameter used to confirm a destructive call
+	ConfirmationTokenParameter = "confirmationToken"
+	// DefaultConfirmationTTL is how long a confirmation token remains valid
+	DefaultConfirmationTTL = 2 * time.Minute
+)
+
+var (
+	errConfirmationInvalid  = errors.New("invalid or expired confirmation token, call the tool again without confirmationToken to get a new one")
+	errConfirmationMismatch = errors.New("confirmation token was issued for a different call, the tool name and arguments must be identical to the first call")
+)
+
+// confirmParameter is the field of the elicitation form confirming a destructive call
+const confirmParameter = "confirm"
+
+// confirmationResponse is returned by the first call to a destructive tool
+type confirmationResponse struct {
+	ConfirmationRequired bool      `json:"confirmation_required"`
+	ConfirmationToken    string    `json:"confirmation_token"`
+	ExpiresAt            time.Time `json:"expires_at"`
+	Message              string    `json:"message"`
+	Impact               any       `json:"impact"`
+}
+
+// isDestructiveTool reports whether a tool is annotated with destructiveHint
+func isDestructiveTool(tool mcp.Tool) bool {
+	return tool.Annotations.DestructiveHint != nil && *tool.Annotations.DestructiveHint
+}
+
+// requiresConfirmation reports whether calls to a tool must be confirmed
+func (s *PortainerMCPServer) requiresConfirmation(tool mcp.Tool) bool {
+	return s.confirmations != nil && !s.dryRun && isDestructiveTool(tool)
+}
+
+// withConfirmationParameter returns a copy of a destructive tool whose input schema
+// accepts the confirmation token
+func withConfirmationParameter(tool mcp.Tool) mcp.Tool {
+	properties := maps.Clone(tool.InputSchema.Properties)
+	if properties == nil {
+		properties = map[string]any{}
+	}
+
+	properties[ConfirmationTokenParameter] = map[string]any{
+		"type":        "string",
+		"description": "The confirmation token returned by a previous call with the same arguments. Destructive calls without a valid token only return a summary of their impact.",
+	}
+	tool.InputSchema.Properties = properties
+
+	return tool
+}
+
+// withConfirmation wraps a destructive tool handler so that the action is only performed
+// once confirmed. When the client of the session supports elicitation, the user is asked to
+// confirm the call with a summary of its impact, computed as a dry run. Otherwise, calls
+// without a token return the summary and a short-lived single-use token bound to the tool,
+// its arguments and the MCP session.
+func (s *PortainerMCPServer) withConfirmation(tool mcp.Tool, handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	if !s.requiresConfirmation(tool) {
+		return handler
+	}
+
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := maps.Clone(request.GetArguments())
+		token, _ := args[ConfirmationTokenParameter].(string)
+		delete(args, ConfirmationTokenParameter)
+
+		// Read-only requests of the proxy tools are not destructive
+		if method, ok := args["method"].(string); ok && isReadOnlyHTTPMethod(method) {
+			return handler(ctx, request)
+		}
+
+		key, err := confirmationKey(ctx, tool.Name, args)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to compute confirmation key", err), nil
+		}
+
+		if token != "" {
+			if err := s.confirmations.consume(token, key); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			request.Params.Arguments = args
+			return handler(ctx, request)
+		}
+
+		impact, err := handler(withDryRun(ctx), request)
+		if err != nil || impact == nil || impact.IsError {
+			return impact, err
+		}
+
+		var plan any
+		if err := json.Unmarshal([]byte(resultText(impact)), &plan); err != nil {
+			plan = resultText(impact)
+		}
+
+		if s.confirmations.elicits(ctx) {
+			confirmed, err := s.elicitConfirmation(ctx, tool.Name, plan)
+			switch {
+			case err == nil && confirmed:
+				request.Params.Arguments = args
+				return handler(ctx, request)
+			case err == nil:
+				return mcp.NewToolResultError(fmt.Sprintf("%s was not confirmed by the user and has not been performed", tool.Name)), nil
+			case errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled):
+				return mcp.NewToolResultError(fmt.Sprintf("%s was not confirmed by the user within %s and has not been performed", tool.Name, s.confirmations.ttl)), nil
+			}
+
+			log.Debug().Err(err).Str("tool", tool.Name).Msg("failed to request confirmation with elicitation, falling back to a confirmation token")
+		}
+
+		token, expiresAt, err := s.confirmations.issue(key)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to issue confirmation token", err), nil
+		}
+
+		data, err := json.Marshal(confirmationResponse{
+			ConfirmationRequired: true,
+			ConfirmationToken:    token,
+			ExpiresAt:            expiresAt,
+			Message: fmt.Sprintf("%s is destructive and has not been performed. Review the impact, then call %s again with the same arguments and %s set to the token before it expires.",
+				tool.Name, tool.Name, ConfirmationTokenParameter),
+			Impact: plan,
+		})
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to marshal confirmation", err), nil
+		}
+
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
+
+// elicitConfirmation asks the user of the session to confirm a destructive call with an
+// elicitation request, waiting for the answer as long as a confirmation token would remain valid.
+// It returns false when the user declines, cancels or does not confirm.
+func (s *PortainerMCPServer) elicitConfirmation(ctx context.Context, toolName string, plan any) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.confirmations.ttl)
+	defer cancel()
+
+	impact, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return false, err
+	}
+
+	result, err := s.srv.RequestElicitation(ctx, mcp.ElicitationRequest{
+		Params: mcp.ElicitationParams{
+			Message: fmt.Sprintf("%s is destructive. Review its impact and confirm to perform it:\n%s", toolName, impact),
+			RequestedSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					confirmParameter: map[string]any{
+						"type":        "boolean",
+						"title":       "Confirm",
+						"description": fmt.Sprintf("Perform %s", toolName),
+					},
+				},
+				"required": []string{confirmParameter},
+			},
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if result.Action != mcp.ElicitationResponseActionAccept {
+		return false, nil
+	}
+
+	content, _ := result.Content.(map[string]any)
+	confirmed, _ := content[confirmParameter].(bool)
+
+	return confirmed, nil
+}
+
+// confirmationKey identifies a call so that a token can only confirm the exact call it was issued for
+func confirmationKey(ctx context.Context, toolName string, args map[string]any) (string, error) {
+	data, err := json.Marshal(args)
+	if err != nil {
+		return "", err
+	}
+
+	sessionID := ""
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		sessionID = session.SessionID()
+	}
+
+	return fmt.Sprintf("%s\x00%s\x00%s", sessionID, toolName, data), nil
+}
+
+// pendingConfirmation is a confirmation token waiting to be used
+type pendingConfirmation struct {
+	key       string
+	expiresAt time.Time
+}
+
+// confirmationStore keeps the issued confirmation tokens until they are used or expire,
+// and the sessions whose client can confirm calls with elicitation
+type confirmationStore struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	pending map[string]pendingConfirmation
+	now     func() time.Time
+	// elicitation holds the sessions whose client advertised elicitation
+	elicitation map[string]bool
+	// connected holds the registered sessions, which can receive elicitation requests
+	connected map[string]bool
+}
+
+func newConfirmationStore(ttl time.Duration) *confirmationStore {
+	return &confirmationStore{
+		ttl:         ttl,
+		pending:     map[string]pendingConfirmation{},
+		now:         time.Now,
+		elicitation: map[string]bool{},
+		connected:   map[string]bool{},
+	}
+}
+
+// install records the sessions able to answer elicitation requests with the hooks of the MCP server.
+// A session needs a client advertising elicitation and a registered connection: streamable HTTP
+// sessions only receive requests from the server while their client listens with GET.
+func (c *confirmationStore) install(hooks *server.Hooks) {
+	hooks.AddAfterInitialize(func(ctx context.Context, _ any, request *mcp.InitializeRequest, _ *mcp.InitializeResult) {
+		session := server.ClientSessionFromContext(ctx)
+		if session == nil || request.Params.Capabilities.Elicitation == nil {
+			return
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		c.elicitation[session.SessionID()] = true
+	})
+
+	hooks.AddOnRegisterSession(func(_ context.Context, session server.ClientSession) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		c.connected[session.SessionID()] = true
+	})
+
+	hooks.AddOnUnregisterSession(func(_ context.Context, session server.ClientSession) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		delete(c.elicitation, session.SessionID())
+		delete(c.connected, session.SessionID())
+	})
+}
+
+// elicits reports whether the client of the session of the call can confirm it with elicitation
+func (c *confirmationStore) elicits(ctx context.Context) bool {
+	session := server.ClientSessionFromContext(ctx)
+	if session == nil {
+		return false
+	}
+
+	if _, ok := session.(server.SessionWithElicitation); !ok {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.elicitation[session.SessionID()] && c.connected[session.SessionID()]
+}
+
+// issue creates a new token for the call identified by key
+func (c *confirmationStore) issue(key string) (string, time.Time, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", time.Time{}, err
+	}
+	token := hex.EncodeToString(buf)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	for t, p := range c.pending {
+		if now.After(p.expiresAt) {
+			delete(c.pending, t)
+		}
+	}
+
+	expiresAt := now.Add(c.ttl)
+	c.pending[token] = pendingConfirmation{key: key, expiresAt: expiresAt}
+
+	return token, expiresAt, nil
+}
+
+// consume validates a token for the call identified by key. A valid token can only be used once.
+func (c *confirmationStore) consume(token, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pending, ok := c.pending[token]
+	if !ok || c.now().After(pending.expiresAt) {
+		delete(c.pending, token)
+		return errConfirmationInvalid
+	}
+
+	if pending.key != key {
+		return errConfirmationMismatch
+	}
+
+	delete(c.pending, token)
+	return nil
+}
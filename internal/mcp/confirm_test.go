@@ -0,0 +1,385 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/portainer/portainer-mcp/pkg/portainer/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func destructiveTool(name string) mcp.Tool {
+	return mcp.NewTool(name,
+		mcp.WithNumber("id"),
+		mcp.WithDestructiveHintAnnotation(true),
+	)
+}
+
+func TestConfirmationStore(t *testing.T) {
+	now := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+	store := newConfirmationStore(time.Minute)
+	store.now = func() time.Time { return now }
+
+	token, expiresAt, err := store.issue("call-a")
+	require.NoError(t, err)
+	assert.Len(t, token, 32)
+	assert.Equal(t, now.Add(time.Minute), expiresAt)
+
+	assert.ErrorIs(t, store.consume(token, "call-b"), errConfirmationMismatch)
+	assert.NoError(t, store.consume(token, "call-a"))
+	assert.ErrorIs(t, store.consume(token, "call-a"), errConfirmationInvalid, "tokens are single-use")
+
+	token, _, err = store.issue("call-a")
+	require.NoError(t, err)
+	now = now.Add(2 * time.Minute)
+	assert.ErrorIs(t, store.consume(token, "call-a"), errConfirmationInvalid, "tokens expire")
+
+	assert.ErrorIs(t, store.consume("unknown", "call-a"), errConfirmationInvalid)
+}
+
+func TestWithConfirmationParameter(t *testing.T) {
+	tool := destructiveTool(ToolDeleteStack)
+
+	confirmed := withConfirmationParameter(tool)
+
+	assert.Contains(t, confirmed.InputSchema.Properties, ConfirmationTokenParameter)
+	assert.NotContains(t, tool.InputSchema.Properties, ConfirmationTokenParameter, "the original tool must not be modified")
+	assert.Contains(t, confirmed.InputSchema.Properties, "id")
+}
+
+func TestRequiresConfirmation(t *testing.T) {
+	s := &PortainerMCPServer{}
+	assert.False(t, s.requiresConfirmation(destructiveTool(ToolDeleteStack)), "confirmation disabled")
+
+	s.confirmations = newConfirmationStore(time.Minute)
+	assert.True(t, s.requiresConfirmation(destructiveTool(ToolDeleteStack)))
+	assert.False(t, s.requiresConfirmation(mcp.NewTool(ToolListStacks, mcp.WithDestructiveHintAnnotation(false))))
+
+	s.dryRun = true
+	assert.False(t, s.requiresConfirmation(destructiveTool(ToolDeleteStack)), "dry-run mode never performs the action")
+}
+
+func TestWithConfirmation(t *testing.T) {
+	mockClient := &MockPortainerClient{}
+	mockClient.On("GetStacks").Return([]models.Stack{{ID: 1, Name: "web", EndpointID: 2}}, nil)
+	mockClient.On("GetStackFile", 1).Return("services: {}", nil)
+
+	s := &PortainerMCPServer{cli: mockClient, confirmations: newConfirmationStore(time.Minute)}
+	handler := s.withConfirmation(destructiveTool(ToolDeleteStack), s.HandleDeleteStack())
+	mcpServer := server.NewMCPServer("Test Server", "1.0.0")
+	ctx := sessionCtx(mcpServer, "session-1", "")
+	args := map[string]any{"id": float64(1), "endpointId": float64(2)}
+
+	call := func(ctx context.Context, args map[string]any) (string, bool) {
+		result, err := handler(ctx, CreateMCPRequest(args))
+		require.NoError(t, err)
+		textContent, ok := result.Content[0].(mcp.TextContent)
+		require.True(t, ok)
+		return textContent.Text, result.IsError
+	}
+
+	// The first call only returns the impact and a token
+	text, isError := call(ctx, args)
+	require.False(t, isError, text)
+
+	var response confirmationResponse
+	require.NoError(t, json.Unmarshal([]byte(text), &response))
+	assert.True(t, response.ConfirmationRequired)
+	require.NotEmpty(t, response.ConfirmationToken)
+	assert.Contains(t, response.Message, ConfirmationTokenParameter)
+	assert.Equal(t, "services: {}", response.Impact.(map[string]any)["before"].(map[string]any)["file"])
+	mockClient.AssertNotCalled(t, "DeleteStack", mock.Anything, mock.Anything)
+
+	withToken := func(token string, overrides map[string]any) map[string]any {
+		confirmedArgs := map[string]any{ConfirmationTokenParameter: token}
+		for k, v := range args {
+			confirmedArgs[k] = v
+		}
+		for k, v := range overrides {
+			confirmedArgs[k] = v
+		}
+		return confirmedArgs
+	}
+
+	// The token is bound to the arguments and to the session
+	text, isError = call(ctx, withToken(response.ConfirmationToken, map[string]any{"id": float64(3)}))
+	assert.True(t, isError)
+	assert.Contains(t, text, "different call")
+
+	text, isError = call(sessionCtx(mcpServer, "session-2", ""), withToken(response.ConfirmationToken, nil))
+	assert.True(t, isError)
+	assert.Contains(t, text, "different call")
+
+	// The second call with the token performs the action
+	mockClient.On("DeleteStack", 1, 2).Return(nil).Once()
+	text, isError = call(ctx, withToken(response.ConfirmationToken, nil))
+	assert.False(t, isError)
+	assert.Equal(t, "Stack deleted successfully", text)
+
+	// The token cannot be reused
+	text, isError = call(ctx, withToken(response.ConfirmationToken, nil))
+	assert.True(t, isError)
+	assert.Contains(t, text, "invalid or expired")
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestWithConfirmationInvalidCall(t *testing.T) {
+	mockClient := &MockPortainerClient{}
+	mockClient.On("GetStacks").Return([]models.Stack{}, nil)
+
+	s := &PortainerMCPServer{cli: mockClient, confirmations: newConfirmationStore(time.Minute)}
+	handler := s.withConfirmation(destructiveTool(ToolDeleteStack), s.HandleDeleteStack())
+
+	result, err := handler(context.Background(), CreateMCPRequest(map[string]any{"id": float64(1), "endpointId": float64(2)}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError, "no token is issued for a call that would fail")
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "stack with ID 1 not found")
+	assert.Empty(t, s.confirmations.pending)
+}
+
+func TestWithConfirmationReadOnlyProxyRequest(t *testing.T) {
+	mockClient := &MockPortainerClient{}
+	mockClient.On("ProxyDockerRequest", mock.Anything).Return(createMockHttpResponse(200, `[]`), nil)
+
+	s := &PortainerMCPServer{cli: mockClient, confirmations: newConfirmationStore(time.Minute)}
+	handler := s.withConfirmation(destructiveTool(ToolDockerProxy), s.HandleDockerProxy())
+
+	result, err := handler(context.Background(), CreateMCPRequest(map[string]any{
+		"environmentId": float64(2),
+		"method":        "GET",
+		"dockerAPIPath": "/containers/json",
+	}))
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Equal(t, "[]", result.Content[0].(mcp.TextContent).Text)
+
+	mockClient.AssertExpectations(t)
+}
+
+// elicitingSession is a session whose client answers elicitation requests with a fixed result
+type elicitingSession struct {
+	fakeSession
+	result   *mcp.ElicitationResult
+	err      error
+	delay    time.Duration
+	requests []mcp.ElicitationRequest
+}
+
+func (e *elicitingSession) RequestElicitation(ctx context.Context, request mcp.ElicitationRequest) (*mcp.ElicitationResult, error) {
+	e.requests = append(e.requests, request)
+
+	select {
+	case <-time.After(e.delay):
+		return e.result, e.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestConfirmationStoreElicitation(t *testing.T) {
+	store := newConfirmationStore(time.Minute)
+	hooks := &server.Hooks{}
+	store.install(hooks)
+	mcpServer := server.NewMCPServer("Test Server", "1.0.0")
+
+	session := &elicitingSession{fakeSession: fakeSession{id: "session-1"}}
+	ctx := mcpServer.WithContext(context.Background(), session)
+
+	initialize := func(elicitation bool) {
+		request := &mcp.InitializeRequest{}
+		if elicitation {
+			request.Params.Capabilities.Elicitation = &struct{}{}
+		}
+		for _, hook := range hooks.OnAfterInitialize {
+			hook(ctx, 1, request, &mcp.InitializeResult{})
+		}
+	}
+
+	initialize(false)
+	for _, hook := range hooks.OnRegisterSession {
+		hook(ctx, session)
+	}
+	assert.False(t, store.elicits(ctx), "the client did not advertise elicitation")
+
+	initialize(true)
+	assert.True(t, store.elicits(ctx))
+
+	assert.False(t, store.elicits(sessionCtx(mcpServer, "session-1", "")), "the session cannot send elicitation requests")
+
+	for _, hook := range hooks.OnUnregisterSession {
+		hook(ctx, session)
+	}
+	assert.False(t, store.elicits(ctx), "the session is no longer connected")
+	assert.Empty(t, store.elicitation)
+}
+
+func TestWithConfirmationElicitation(t *testing.T) {
+	tests := []struct {
+		name          string
+		result        *mcp.ElicitationResult
+		err           error
+		expectDeleted bool
+		expectError   string
+		expectToken   bool
+	}{
+		{
+			name: "accepted and confirmed",
+			result: &mcp.ElicitationResult{ElicitationResponse: mcp.ElicitationResponse{
+				Action:  mcp.ElicitationResponseActionAccept,
+				Content: map[string]any{"confirm": true},
+			}},
+			expectDeleted: true,
+		},
+		{
+			name: "accepted without confirming",
+			result: &mcp.ElicitationResult{ElicitationResponse: mcp.ElicitationResponse{
+				Action:  mcp.ElicitationResponseActionAccept,
+				Content: map[string]any{"confirm": false},
+			}},
+			expectError: "deleteStack was not confirmed by the user",
+		},
+		{
+			name: "declined",
+			result: &mcp.ElicitationResult{ElicitationResponse: mcp.ElicitationResponse{
+				Action: mcp.ElicitationResponseActionDecline,
+			}},
+			expectError: "deleteStack was not confirmed by the user",
+		},
+		{
+			name: "cancelled",
+			result: &mcp.ElicitationResult{ElicitationResponse: mcp.ElicitationResponse{
+				Action: mcp.ElicitationResponseActionCancel,
+			}},
+			expectError: "deleteStack was not confirmed by the user",
+		},
+		{
+			name:        "elicitation request failure falls back to a token",
+			err:         errors.New("elicitation request queue is full"),
+			expectToken: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockPortainerClient{}
+			mockClient.On("GetStacks").Return([]models.Stack{{ID: 1, Name: "web", EndpointID: 2}}, nil)
+			mockClient.On("GetStackFile", 1).Return("services: {}", nil)
+			if tt.expectDeleted {
+				mockClient.On("DeleteStack", 1, 2).Return(nil).Once()
+			}
+
+			s := &PortainerMCPServer{
+				cli:           mockClient,
+				confirmations: newConfirmationStore(time.Minute),
+				srv:           server.NewMCPServer("Test Server", "1.0.0", server.WithElicitation()),
+			}
+			session := &elicitingSession{fakeSession: fakeSession{id: "session-1"}, result: tt.result, err: tt.err}
+			s.confirmations.elicitation[session.id] = true
+			s.confirmations.connected[session.id] = true
+
+			handler := s.withConfirmation(destructiveTool(ToolDeleteStack), s.HandleDeleteStack())
+			result, err := handler(s.srv.WithContext(context.Background(), session), CreateMCPRequest(map[string]any{
+				"id":         float64(1),
+				"endpointId": float64(2),
+			}))
+			require.NoError(t, err)
+			text := result.Content[0].(mcp.TextContent).Text
+
+			require.Len(t, session.requests, 1)
+			params := session.requests[0].Params
+			assert.Contains(t, params.Message, "deleteStack is destructive")
+			assert.Contains(t, params.Message, "services: {}", "the message includes the impact")
+			assert.Equal(t, []string{"confirm"}, params.RequestedSchema.(map[string]any)["required"])
+
+			switch {
+			case tt.expectDeleted:
+				assert.False(t, result.IsError, text)
+				assert.Equal(t, "Stack deleted successfully", text)
+			case tt.expectToken:
+				assert.False(t, result.IsError, text)
+				var response confirmationResponse
+				require.NoError(t, json.Unmarshal([]byte(text), &response))
+				assert.NotEmpty(t, response.ConfirmationToken)
+			default:
+				assert.True(t, result.IsError)
+				assert.Contains(t, text, tt.expectError)
+			}
+
+			mockClient.AssertExpectations(t)
+			if !tt.expectDeleted {
+				mockClient.AssertNotCalled(t, "DeleteStack", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestWithConfirmationElicitationTiming(t *testing.T) {
+	confirmed := &mcp.ElicitationResult{ElicitationResponse: mcp.ElicitationResponse{
+		Action:  mcp.ElicitationResponseActionAccept,
+		Content: map[string]any{"confirm": true},
+	}}
+
+	tests := []struct {
+		name          string
+		delay         time.Duration
+		expectDeleted bool
+		expectError   string
+	}{
+		{
+			name:          "answer after the timeout of the tool",
+			delay:         50 * time.Millisecond,
+			expectDeleted: true,
+		},
+		{
+			name:        "no answer before the confirmation expires",
+			delay:       time.Second,
+			expectError: "deleteStack was not confirmed by the user within 200ms",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &PortainerMCPServer{
+				confirmations: newConfirmationStore(200 * time.Millisecond),
+				srv:           server.NewMCPServer("Test Server", "1.0.0", server.WithElicitation()),
+				timeouts:      map[string]time.Duration{ToolDeleteStack: 20 * time.Millisecond},
+			}
+			session := &elicitingSession{fakeSession: fakeSession{id: "session-1"}, result: confirmed, delay: tt.delay}
+			s.confirmations.elicitation[session.id] = true
+			s.confirmations.connected[session.id] = true
+
+			// The handler stands in for a tool whose requests to Portainer fail once the timeout has elapsed
+			deleted := false
+			tool := s.withTimeout(ToolDeleteStack, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				if err := ctx.Err(); err != nil {
+					return mcp.NewToolResultErrorFromErr("failed to delete stack", err), nil
+				}
+				if s.isDryRun(ctx) {
+					return mcp.NewToolResultText(`{"dry_run": true}`), nil
+				}
+				deleted = true
+				return mcp.NewToolResultText("Stack deleted successfully"), nil
+			})
+
+			handler := s.withConfirmation(destructiveTool(ToolDeleteStack), tool)
+			result, err := handler(s.srv.WithContext(context.Background(), session), CreateMCPRequest(map[string]any{"id": float64(1)}))
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expectDeleted, deleted)
+			if tt.expectError != "" {
+				assert.True(t, result.IsError)
+				assert.Contains(t, resultText(result), tt.expectError)
+			} else {
+				assert.False(t, result.IsError, resultText(result))
+			}
+		})
+	}
+}
@@ -13,9 +13,7 @@ import (
 )
 
 func (s *PortainerMCPServer) AddDockerProxyFeatures() {
-	if !s.readOnly {
-		s.addToolIfExists(ToolDockerProxy, s.HandleDockerProxy())
-	}
+	s.addWriteToolIfExists(ToolDockerProxy, s.HandleDockerProxy())
 }
 
 func (s *PortainerMCPServer) HandleDockerProxy() server.ToolHandlerFunc {
@@ -66,6 +64,24 @@ func (s *PortainerMCPServer) HandleDockerProxy() server.ToolHandlerFunc {
 			return mcp.NewToolResultErrorFromErr("invalid body parameter", err), nil
 		}
 
+		if s.isDryRun(ctx) && !isReadOnlyHTTPMethod(method) {
+			if _, err := s.findEnvironment(ctx, environmentId); err != nil {
+				return mcp.NewToolResultErrorFromErr("failed to resolve environment", err), nil
+			}
+
+			return dryRunResult(nil, nil, dryRunCall{
+				Operation: "ProxyDockerRequest",
+				Arguments: map[string]any{
+					"environmentId": environmentId,
+					"method":        method,
+					"path":          dockerAPIPath,
+					"queryParams":   queryParamsMap,
+					"headers":       headersMap,
+					"body":          body,
+				},
+			})
+		}
+
 		opts := models.DockerProxyRequestOptions{
 			EnvironmentID: environmentId,
 			Path:          dockerAPIPath,
@@ -78,10 +94,11 @@ func (s *PortainerMCPServer) HandleDockerProxy() server.ToolHandlerFunc {
 			opts.Body = strings.NewReader(body)
 		}
 
-		response, err := s.cli.ProxyDockerRequest(opts)
+		response, err := s.client(ctx).ProxyDockerRequest(ctx, opts)
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("failed to send Docker API request", err), nil
 		}
+		defer response.Body.Close()
 
 		responseBody, err := io.ReadAll(response.Body)
 		if err != nil {
@@ -0,0 +1,156 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/portainer/portainer-mcp/internal/compose"
+	"github.com/portainer/portainer-mcp/pkg/portainer/models"
+)
+
+// dryRunKey is the context key marking a single tool call as a dry run
+type dryRunKey struct{}
+
+// withDryRun returns a context in which write tools only return their dry-run plan
+func withDryRun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, true)
+}
+
+// isDryRun reports whether write tools must return their dry-run plan instead of
+// applying the change, either because the server runs in dry-run mode or because
+// the call is a dry run (e.g. to summarise the impact of a destructive call)
+func (s *PortainerMCPServer) isDryRun(ctx context.Context) bool {
+	dryRun, _ := ctx.Value(dryRunKey{}).(bool)
+	return s.dryRun || dryRun
+}
+
+// dryRunCall is a Portainer client call that a write tool would make
+type dryRunCall struct {
+	Operation string         `json:"operation"`
+	Arguments map[string]any `json:"arguments"`
+}
+
+// dryRunPlan is returned by write tools in dry-run mode instead of applying the change.
+// Before is nil when the call creates a new entity, After is nil when it deletes one.
+type dryRunPlan struct {
+	DryRun bool         `json:"dry_run"`
+	Calls  []dryRunCall `json:"calls"`
+	Before any          `json:"before"`
+	After  any          `json:"after"`
+}
+
+// stackState is the state of a stack in a dry-run plan, including its compose file
+type stackState struct {
+	models.Stack
+	File string `json:"file,omitempty"`
+	// Warnings are the warnings of the validation of the compose file
+	Warnings []compose.Issue `json:"warnings,omitempty"`
+}
+
+// edgeStackState is the state of an edge stack in a dry-run plan, including its compose file
+type edgeStackState struct {
+	models.EdgeStack
+	File string `json:"file,omitempty"`
+	// Note warns about the deployments missing from the plan
+	Note string `json:"note,omitempty"`
+}
+
+// dryRunResult returns the tool result describing the calls a write tool would make
+// and the state of the entity before and after these calls
+func dryRunResult(before, after any, calls ...dryRunCall) (*mcp.CallToolResult, error) {
+	data, err := json.Marshal(dryRunPlan{
+		DryRun: true,
+		Calls:  calls,
+		Before: before,
+		After:  after,
+	})
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to marshal dry-run plan", err), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// findByID returns the item with the given ID
+func findByID[T any](items []T, id int, getID func(T) int, kind string) (T, error) {
+	idx := slices.IndexFunc(items, func(item T) bool {
+		return getID(item) == id
+	})
+	if idx == -1 {
+		var zero T
+		return zero, fmt.Errorf("%s with ID %d not found", kind, id)
+	}
+
+	return items[idx], nil
+}
+
+func (s *PortainerMCPServer) findEnvironment(ctx context.Context, id int) (models.Environment, error) {
+	environments, err := s.client(ctx).GetEnvironments(ctx)
+	if err != nil {
+		return models.Environment{}, fmt.Errorf("failed to get environments: %w", err)
+	}
+
+	return findByID(environments, id, func(e models.Environment) int { return e.ID }, "environment")
+}
+
+func (s *PortainerMCPServer) findEnvironmentGroup(ctx context.Context, id int) (models.Group, error) {
+	groups, err := s.client(ctx).GetEnvironmentGroups(ctx)
+	if err != nil {
+		return models.Group{}, fmt.Errorf("failed to get environment groups: %w", err)
+	}
+
+	return findByID(groups, id, func(g models.Group) int { return g.ID }, "environment group")
+}
+
+func (s *PortainerMCPServer) findAccessGroup(ctx context.Context, id int) (models.AccessGroup, error) {
+	groups, err := s.client(ctx).GetAccessGroups(ctx)
+	if err != nil {
+		return models.AccessGroup{}, fmt.Errorf("failed to get access groups: %w", err)
+	}
+
+	return findByID(groups, id, func(g models.AccessGroup) int { return g.ID }, "access group")
+}
+
+func (s *PortainerMCPServer) findStack(ctx context.Context, id int) (models.Stack, error) {
+	stacks, err := s.client(ctx).GetStacks(ctx)
+	if err != nil {
+		return models.Stack{}, fmt.Errorf("failed to get stacks: %w", err)
+	}
+
+	return findByID(stacks, id, func(st models.Stack) int { return st.ID }, "stack")
+}
+
+func (s *PortainerMCPServer) findTeam(ctx context.Context, id int) (models.Team, error) {
+	teams, err := s.client(ctx).GetTeams(ctx)
+	if err != nil {
+		return models.Team{}, fmt.Errorf("failed to get teams: %w", err)
+	}
+
+	return findByID(teams, id, func(t models.Team) int { return t.ID }, "team")
+}
+
+func (s *PortainerMCPServer) findUser(ctx context.Context, id int) (models.User, error) {
+	users, err := s.client(ctx).GetUsers(ctx)
+	if err != nil {
+		return models.User{}, fmt.Errorf("failed to get users: %w", err)
+	}
+
+	return findByID(users, id, func(u models.User) int { return u.ID }, "user")
+}
+
+// findStackOnEnvironment returns the stack with the given ID and checks that it is deployed on the given environment
+func (s *PortainerMCPServer) findStackOnEnvironment(ctx context.Context, id, endpointId int) (models.Stack, error) {
+	stack, err := s.findStack(ctx, id)
+	if err != nil {
+		return models.Stack{}, err
+	}
+
+	if stack.EndpointID != endpointId {
+		return models.Stack{}, fmt.Errorf("stack %d is deployed on environment %d, not %d", id, stack.EndpointID, endpointId)
+	}
+
+	return stack, nil
+}
@@ -0,0 +1,390 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/portainer/portainer-mcp/pkg/portainer/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// The mock client panics on unexpected calls, so these tests also ensure that
+// no write method is called in dry-run mode
+func TestDryRun(t *testing.T) {
+	stacks := []models.Stack{{ID: 1, Name: "web", Status: models.StackStatusActive, EndpointID: 2}}
+
+	tests := []struct {
+		name          string
+		handler       func(*PortainerMCPServer) server.ToolHandlerFunc
+		args          map[string]any
+		mockSetup     func(*MockPortainerClient)
+		expectedPlan  string
+		errorContains string
+	}{
+		{
+			name:    "update environment user accesses",
+			handler: (*PortainerMCPServer).HandleUpdateEnvironmentUserAccesses,
+			args: map[string]any{
+				"id":           float64(2),
+				"userAccesses": []any{map[string]any{"id": float64(3), "access": AccessLevelStandardUser}},
+			},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetEnvironments").Return([]models.Environment{
+					{ID: 2, Name: "prod", UserAccesses: map[int]string{1: AccessLevelEnvironmentAdmin}},
+				}, nil)
+			},
+			expectedPlan: `{
+				"dry_run": true,
+				"calls": [{"operation": "UpdateEnvironmentUserAccesses", "arguments": {"id": 2, "userAccesses": {"3": "standard_user"}}}],
+				"before": {"id": 2, "name": "prod", "status": "", "type": "", "tag_ids": null, "user_accesses": {"1": "environment_administrator"}, "team_accesses": null},
+				"after": {"id": 2, "name": "prod", "status": "", "type": "", "tag_ids": null, "user_accesses": {"3": "standard_user"}, "team_accesses": null}
+			}`,
+		},
+		{
+			name:    "update stack",
+			handler: (*PortainerMCPServer).HandleUpdateStack,
+			args:    map[string]any{"id": float64(1), "endpointId": float64(2), "file": "services:\n  web:\n    image: nginx:1.27\n"},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetStacks").Return(stacks, nil)
+				m.On("GetStackFile", 1).Return("old-file", nil)
+			},
+			expectedPlan: `{
+				"dry_run": true,
+				"calls": [{"operation": "UpdateStack", "arguments": {"id": 1, "endpointId": 2, "file": "services:\n  web:\n    image: nginx:1.27\n", "pullImage": true}}],
+				"before": {"id": 1, "name": "web", "status": "active", "created_at": "", "endpoint_id": 2, "file": "old-file"},
+				"after": {"id": 1, "name": "web", "status": "active", "created_at": "", "endpoint_id": 2, "file": "services:\n  web:\n    image: nginx:1.27\n"}
+			}`,
+		},
+		{
+			name:    "update stack with compose warnings",
+			handler: (*PortainerMCPServer).HandleUpdateStack,
+			args:    map[string]any{"id": float64(1), "endpointId": float64(2), "file": "services:\n  web:\n    image: nginx:latest\n"},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetStacks").Return(stacks, nil)
+				m.On("GetStackFile", 1).Return("old-file", nil)
+			},
+			expectedPlan: `{
+				"dry_run": true,
+				"calls": [{"operation": "UpdateStack", "arguments": {"id": 1, "endpointId": 2, "file": "services:\n  web:\n    image: nginx:latest\n", "pullImage": true}}],
+				"before": {"id": 1, "name": "web", "status": "active", "created_at": "", "endpoint_id": 2, "file": "old-file"},
+				"after": {"id": 1, "name": "web", "status": "active", "created_at": "", "endpoint_id": 2, "file": "services:\n  web:\n    image: nginx:latest\n",
+					"warnings": [{"line": 3, "path": "services.web.image", "message": "image nginx:latest uses the latest tag, redeployments may run a different version"}]}
+			}`,
+		},
+		{
+			name:    "update stack environment variables",
+			handler: (*PortainerMCPServer).HandleUpdateStack,
+			args: map[string]any{
+				"id": float64(1), "endpointId": float64(2), "file": "services:\n  web:\n    image: nginx:1.27\n",
+				"env": []any{map[string]any{"key": "DB_PASSWORD", "value": "n3w"}, map[string]any{"key": "DEBUG", "value": ""}},
+			},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetStacks").Return([]models.Stack{{
+					ID: 1, Name: "web", Status: models.StackStatusActive, EndpointID: 2,
+					Env: []models.StackEnvVar{{Name: "DB_PASSWORD", Value: "old"}},
+				}}, nil)
+				m.On("GetStackFile", 1).Return("old-file", nil)
+			},
+			expectedPlan: `{
+				"dry_run": true,
+				"calls": [{"operation": "UpdateStack", "arguments": {"id": 1, "endpointId": 2, "file": "services:\n  web:\n    image: nginx:1.27\n", "pullImage": true,
+					"env": [{"name": "DB_PASSWORD", "value": "********"}, {"name": "DEBUG", "value": ""}]}}],
+				"before": {"id": 1, "name": "web", "status": "active", "created_at": "", "endpoint_id": 2, "file": "old-file",
+					"env": [{"name": "DB_PASSWORD", "value": "********"}]},
+				"after": {"id": 1, "name": "web", "status": "active", "created_at": "", "endpoint_id": 2, "file": "services:\n  web:\n    image: nginx:1.27\n",
+					"env": [{"name": "DB_PASSWORD", "value": "********"}, {"name": "DEBUG", "value": ""}]}
+			}`,
+		},
+		{
+			name:    "update stack on another environment",
+			handler: (*PortainerMCPServer).HandleUpdateStack,
+			args:    map[string]any{"id": float64(1), "endpointId": float64(3), "file": "services:\n  web:\n    image: nginx:1.27\n"},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetStacks").Return(stacks, nil)
+			},
+			errorContains: "stack 1 is deployed on environment 2, not 3",
+		},
+		{
+			name:    "stop stack",
+			handler: (*PortainerMCPServer).HandleStopStack,
+			args:    map[string]any{"id": float64(1), "endpointId": float64(2)},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetStacks").Return(stacks, nil)
+			},
+			expectedPlan: `{
+				"dry_run": true,
+				"calls": [{"operation": "StopStack", "arguments": {"id": 1, "endpointId": 2}}],
+				"before": {"id": 1, "name": "web", "status": "active", "created_at": "", "endpoint_id": 2},
+				"after": {"id": 1, "name": "web", "status": "inactive", "created_at": "", "endpoint_id": 2}
+			}`,
+		},
+		{
+			name:    "delete stack",
+			handler: (*PortainerMCPServer).HandleDeleteStack,
+			args:    map[string]any{"id": float64(1), "endpointId": float64(2)},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetStacks").Return(stacks, nil)
+				m.On("GetStackFile", 1).Return("old-file", nil)
+			},
+			expectedPlan: `{
+				"dry_run": true,
+				"calls": [{"operation": "DeleteStack", "arguments": {"id": 1, "endpointId": 2}}],
+				"before": {"id": 1, "name": "web", "status": "active", "created_at": "", "endpoint_id": 2, "file": "old-file"},
+				"after": null
+			}`,
+		},
+		{
+			name:    "create git stack masks the password",
+			handler: (*PortainerMCPServer).HandleCreateGitStack,
+			args: map[string]any{
+				"name": "web", "endpointId": float64(2), "repositoryUrl": "https://github.com/acme/stacks.git",
+				"username": "deploy", "password": "t0ken",
+			},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetEnvironments").Return([]models.Environment{{ID: 2}}, nil)
+			},
+			expectedPlan: `{
+				"dry_run": true,
+				"calls": [{"operation": "CreateGitStack", "arguments": {"name": "web", "endpointId": 2,
+					"repositoryUrl": "https://github.com/acme/stacks.git", "referenceName": "", "composeFilePath": "docker-compose.yml",
+					"username": "deploy", "password": "********", "env": []}}],
+				"before": null,
+				"after": {"id": 0, "name": "web", "status": "active", "created_at": "", "endpoint_id": 2, "git_config": {
+					"url": "https://github.com/acme/stacks.git", "compose_file_path": "docker-compose.yml", "authenticated": true, "username": "deploy"}}
+			}`,
+		},
+		{
+			name:    "redeploy git stack",
+			handler: (*PortainerMCPServer).HandleRedeployGitStack,
+			args:    map[string]any{"id": float64(1), "endpointId": float64(2)},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetStacks").Return([]models.Stack{{
+					ID: 1, Name: "web", Status: models.StackStatusActive, EndpointID: 2,
+					GitConfig: &models.StackGitConfig{URL: "https://github.com/acme/stacks.git", ComposeFilePath: "docker-compose.yml", DeployedCommit: "bc4c183"},
+				}}, nil)
+			},
+			expectedPlan: `{
+				"dry_run": true,
+				"calls": [{"operation": "RedeployGitStack", "arguments": {"id": 1, "endpointId": 2, "pullImage": true}}],
+				"before": {"id": 1, "name": "web", "status": "active", "created_at": "", "endpoint_id": 2, "git_config": {
+					"url": "https://github.com/acme/stacks.git", "compose_file_path": "docker-compose.yml", "authenticated": false, "deployed_commit": "bc4c183"}},
+				"after": {"id": 1, "name": "web", "status": "active", "created_at": "", "endpoint_id": 2, "git_config": {
+					"url": "https://github.com/acme/stacks.git", "compose_file_path": "docker-compose.yml", "authenticated": false, "deployed_commit": "bc4c183"}}
+			}`,
+		},
+		{
+			name:    "redeploy stack not deployed from git",
+			handler: (*PortainerMCPServer).HandleRedeployGitStack,
+			args:    map[string]any{"id": float64(1), "endpointId": float64(2)},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetStacks").Return(stacks, nil)
+			},
+			errorContains: "stack 1 is not deployed from a git repository",
+		},
+		{
+			name:    "create stack with compose warnings",
+			handler: (*PortainerMCPServer).HandleCreateStack,
+			args:    map[string]any{"name": "web", "file": "services:\n  web:\n    image: nginx:latest\n", "endpointId": float64(2)},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetEnvironments").Return([]models.Environment{{ID: 2}}, nil)
+			},
+			expectedPlan: `{
+				"dry_run": true,
+				"calls": [{"operation": "CreateStack", "arguments": {"name": "web", "file": "services:\n  web:\n    image: nginx:latest\n", "endpointId": 2, "env": []}}],
+				"before": null,
+				"after": {"id": 0, "name": "web", "status": "active", "created_at": "", "endpoint_id": 2, "file": "services:\n  web:\n    image: nginx:latest\n",
+					"warnings": [{"line": 3, "path": "services.web.image", "message": "image nginx:latest uses the latest tag, redeployments may run a different version"}]}
+			}`,
+		},
+		{
+			name:    "create stack on unknown environment",
+			handler: (*PortainerMCPServer).HandleCreateStack,
+			args:    map[string]any{"name": "web", "file": "services:\n  web:\n    image: nginx:1.27\n", "endpointId": float64(9)},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetEnvironments").Return([]models.Environment{{ID: 2}}, nil)
+			},
+			errorContains: "environment with ID 9 not found",
+		},
+		{
+			name:    "create edge stack",
+			handler: (*PortainerMCPServer).HandleCreateEdgeStack,
+			args:    map[string]any{"name": "agent", "file": "file", "environmentGroupIds": []any{float64(1), float64(2)}},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetEnvironmentGroups").Return([]models.Group{
+					{ID: 1, EnvironmentIds: []int{3, 4}},
+					{ID: 2, EnvironmentIds: []int{4, 5}},
+				}, nil)
+			},
+			expectedPlan: `{
+				"dry_run": true,
+				"calls": [{"operation": "CreateEdgeStack", "arguments": {"name": "agent", "file": "file", "environmentGroupIds": [1, 2]}}],
+				"before": null,
+				"after": {"id": 0, "name": "agent", "created_at": "", "group_ids": [1, 2], "file": "file", "deployments": [
+					{"environment_id": 3, "status": "pending"},
+					{"environment_id": 4, "status": "pending"},
+					{"environment_id": 5, "status": "pending"}
+				]}
+			}`,
+		},
+		{
+			name:    "create edge stack on a dynamic environment group",
+			handler: (*PortainerMCPServer).HandleCreateEdgeStack,
+			args:    map[string]any{"name": "agent", "file": "file", "environmentGroupIds": []any{float64(1), float64(2)}},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetEnvironmentGroups").Return([]models.Group{
+					{ID: 1, EnvironmentIds: []int{3}},
+					{ID: 2, TagIds: []int{7}},
+				}, nil)
+			},
+			expectedPlan: `{
+				"dry_run": true,
+				"calls": [{"operation": "CreateEdgeStack", "arguments": {"name": "agent", "file": "file", "environmentGroupIds": [1, 2]}}],
+				"before": null,
+				"after": {"id": 0, "name": "agent", "created_at": "", "group_ids": [1, 2], "file": "file", "deployments": [
+					{"environment_id": 3, "status": "pending"}
+				], "note": "the environment groups [2] are dynamic, their environments are matched by tags when the edge stack is deployed and their deployments are not listed"}
+			}`,
+		},
+		{
+			name:    "create edge stack on unknown environment group",
+			handler: (*PortainerMCPServer).HandleCreateEdgeStack,
+			args:    map[string]any{"name": "agent", "file": "file", "environmentGroupIds": []any{float64(9)}},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetEnvironmentGroups").Return([]models.Group{{ID: 1}}, nil)
+			},
+			errorContains: "environment group with ID 9 not found",
+		},
+		{
+			name:    "delete edge stack",
+			handler: (*PortainerMCPServer).HandleDeleteEdgeStack,
+			args:    map[string]any{"id": float64(1)},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetEdgeStack", 1).Return(models.EdgeStack{
+					ID:                  1,
+					Name:                "agent",
+					EnvironmentGroupIds: []int{1},
+					Deployments:         []models.EdgeStackDeployment{{EnvironmentID: 3, Status: models.EdgeStackStatusRunning}},
+				}, nil)
+				m.On("GetEdgeStackFile", 1).Return("old-file", nil)
+			},
+			expectedPlan: `{
+				"dry_run": true,
+				"calls": [{"operation": "DeleteEdgeStack", "arguments": {"id": 1}}],
+				"before": {"id": 1, "name": "agent", "created_at": "", "group_ids": [1], "file": "old-file", "deployments": [
+					{"environment_id": 3, "status": "running"}
+				]},
+				"after": null
+			}`,
+		},
+		{
+			name:      "create team",
+			handler:   (*PortainerMCPServer).HandleCreateTeam,
+			args:      map[string]any{"name": "devs"},
+			mockSetup: func(m *MockPortainerClient) {},
+			expectedPlan: `{
+				"dry_run": true,
+				"calls": [{"operation": "CreateTeam", "arguments": {"name": "devs"}}],
+				"before": null,
+				"after": {"id": 0, "name": "devs", "members": null}
+			}`,
+		},
+		{
+			name:    "add environment to access group",
+			handler: (*PortainerMCPServer).HandleAddEnvironmentToAccessGroup,
+			args:    map[string]any{"id": float64(1), "environmentId": float64(3)},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetAccessGroups").Return([]models.AccessGroup{{ID: 1, Name: "ops", EnvironmentIds: []int{2}}}, nil)
+			},
+			expectedPlan: `{
+				"dry_run": true,
+				"calls": [{"operation": "AddEnvironmentToAccessGroup", "arguments": {"id": 1, "environmentId": 3}}],
+				"before": {"id": 1, "name": "ops", "environment_ids": [2], "user_accesses": null, "team_accesses": null},
+				"after": {"id": 1, "name": "ops", "environment_ids": [2, 3], "user_accesses": null, "team_accesses": null}
+			}`,
+		},
+		{
+			name:    "docker proxy write",
+			handler: (*PortainerMCPServer).HandleDockerProxy,
+			args:    map[string]any{"environmentId": float64(2), "method": "DELETE", "dockerAPIPath": "/containers/abc"},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetEnvironments").Return([]models.Environment{{ID: 2}}, nil)
+			},
+			expectedPlan: `{
+				"dry_run": true,
+				"calls": [{"operation": "ProxyDockerRequest", "arguments": {"environmentId": 2, "method": "DELETE", "path": "/containers/abc", "queryParams": {}, "headers": {}, "body": ""}}],
+				"before": null,
+				"after": null
+			}`,
+		},
+		{
+			name:    "invalid parameters are still rejected",
+			handler: (*PortainerMCPServer).HandleUpdateUserRole,
+			args:    map[string]any{"id": float64(1), "role": "superuser"},
+			mockSetup: func(m *MockPortainerClient) {
+			},
+			errorContains: "invalid role",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockPortainerClient{}
+			tt.mockSetup(mockClient)
+
+			s := &PortainerMCPServer{cli: mockClient, dryRun: true}
+			result, err := tt.handler(s)(context.Background(), CreateMCPRequest(tt.args))
+			require.NoError(t, err)
+
+			textContent, ok := result.Content[0].(mcp.TextContent)
+			require.True(t, ok)
+
+			if tt.errorContains != "" {
+				assert.True(t, result.IsError)
+				assert.Contains(t, textContent.Text, tt.errorContains)
+			} else {
+				assert.False(t, result.IsError, textContent.Text)
+				assert.JSONEq(t, tt.expectedPlan, textContent.Text)
+			}
+
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDryRunDockerProxyRead(t *testing.T) {
+	mockClient := &MockPortainerClient{}
+	mockClient.On("ProxyDockerRequest", mock.Anything).Return(createMockHttpResponse(200, `[]`), nil)
+
+	s := &PortainerMCPServer{cli: mockClient, dryRun: true}
+	result, err := s.HandleDockerProxy()(context.Background(), CreateMCPRequest(map[string]any{
+		"environmentId": float64(2),
+		"method":        "GET",
+		"dockerAPIPath": "/containers/json",
+	}))
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Equal(t, "[]", result.Content[0].(mcp.TextContent).Text)
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestFindByID(t *testing.T) {
+	users := []models.User{{ID: 1, Username: "alice"}, {ID: 2, Username: "bob"}}
+	getID := func(u models.User) int { return u.ID }
+
+	user, err := findByID(users, 2, getID, "user")
+	require.NoError(t, err)
+	assert.Equal(t, "bob", user.Username)
+
+	_, err = findByID(users, 3, getID, "user")
+	assert.EqualError(t, err, "user with ID 3 not found")
+
+	data, err := json.Marshal(stackState{Stack: models.Stack{ID: 1}, File: "f"})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"file":"f"`)
+}
@@ -0,0 +1,289 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/portainer/portainer-mcp/pkg/portainer/models"
+	"github.com/portainer/portainer-mcp/pkg/toolgen"
+)
+
+func (s *PortainerMCPServer) AddEdgeStackFeatures() {
+	s.addToolIfExists(ToolListEdgeStacks, s.HandleGetEdgeStacks())
+	s.addToolIfExists(ToolGetEdgeStackFile, s.HandleGetEdgeStackFile())
+
+	s.addWriteToolIfExists(ToolCreateEdgeStack, s.HandleCreateEdgeStack())
+	s.addWriteToolIfExists(ToolUpdateEdgeStack, s.HandleUpdateEdgeStack())
+	s.addWriteToolIfExists(ToolDeleteEdgeStack, s.HandleDeleteEdgeStack())
+}
+
+func (s *PortainerMCPServer) HandleGetEdgeStacks() server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		stacks, err := s.client(ctx).GetEdgeStacks(ctx)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to get edge stacks", err), nil
+		}
+
+		data, err := json.Marshal(stacks)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to marshal edge stacks", err), nil
+		}
+
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
+
+// HandleGetEdgeStackFile returns the compose file of an edge stack together with
+// its deployment status, so that a failing deployment can be related to the file
+func (s *PortainerMCPServer) HandleGetEdgeStackFile() server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		parser := toolgen.NewParameterParser(request)
+
+		id, err := parser.GetInt("id", true)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid id parameter", err), nil
+		}
+
+		state, err := s.getEdgeStackState(ctx, id)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to get edge stack", err), nil
+		}
+
+		data, err := json.Marshal(state)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to marshal edge stack", err), nil
+		}
+
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
+
+func (s *PortainerMCPServer) HandleCreateEdgeStack() server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		parser := toolgen.NewParameterParser(request)
+
+		name, err := parser.GetString("name", true)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid name parameter", err), nil
+		}
+
+		file, err := parser.GetString("file", true)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid file parameter", err), nil
+		}
+
+		environmentGroupIds, err := parser.GetArrayOfIntegers("environmentGroupIds", true)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid environmentGroupIds parameter", err), nil
+		}
+
+		if len(environmentGroupIds) == 0 {
+			return mcp.NewToolResultError("at least one environment group is required"), nil
+		}
+
+		if s.isDryRun(ctx) {
+			deployments, dynamicGroupIds, err := s.pendingDeployments(ctx, environmentGroupIds)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("failed to resolve environment groups", err), nil
+			}
+
+			after := edgeStackState{
+				EdgeStack: models.EdgeStack{Name: name, EnvironmentGroupIds: environmentGroupIds, Deployments: deployments},
+				File:      file,
+				Note:      dynamicGroupsNote(dynamicGroupIds),
+			}
+			return dryRunResult(nil, after, dryRunCall{
+				Operation: "CreateEdgeStack",
+				Arguments: map[string]any{"name": name, "file": file, "environmentGroupIds": environmentGroupIds},
+			})
+		}
+
+		id, err := s.client(ctx).CreateEdgeStack(ctx, name, file, environmentGroupIds)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to create edge stack", err), nil
+		}
+
+		return s.edgeStackDeploymentResult(ctx, id, fmt.Sprintf("Edge stack created successfully with ID: %d", id)), nil
+	}
+}
+
+func (s *PortainerMCPServer) HandleUpdateEdgeStack() server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		parser := toolgen.NewParameterParser(request)
+
+		id, err := parser.GetInt("id", true)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid id parameter", err), nil
+		}
+
+		file, err := parser.GetString("file", true)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid file parameter", err), nil
+		}
+
+		environmentGroupIds, err := parser.GetArrayOfIntegers("environmentGroupIds", false)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid environmentGroupIds parameter", err), nil
+		}
+
+		var stack models.EdgeStack
+		if len(environmentGroupIds) == 0 || s.isDryRun(ctx) {
+			stack, err = s.client(ctx).GetEdgeStack(ctx, id)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("failed to get edge stack", err), nil
+			}
+		}
+
+		// Portainer requires the groups on each update, the current ones are kept when none are given
+		if len(environmentGroupIds) == 0 {
+			environmentGroupIds = stack.EnvironmentGroupIds
+		}
+
+		if s.isDryRun(ctx) {
+			currentFile, err := s.client(ctx).GetEdgeStackFile(ctx, id)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("failed to get edge stack file", err), nil
+			}
+
+			deployments, dynamicGroupIds, err := s.pendingDeployments(ctx, environmentGroupIds)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("failed to resolve environment groups", err), nil
+			}
+
+			before := edgeStackState{EdgeStack: stack, File: currentFile}
+			after := edgeStackState{EdgeStack: stack, File: file, Note: dynamicGroupsNote(dynamicGroupIds)}
+			after.EnvironmentGroupIds = environmentGroupIds
+			after.Deployments = deployments
+			return dryRunResult(before, after, dryRunCall{
+				Operation: "UpdateEdgeStack",
+				Arguments: map[string]any{"id": id, "file": file, "environmentGroupIds": environmentGroupIds},
+			})
+		}
+
+		err = s.client(ctx).UpdateEdgeStack(ctx, id, file, environmentGroupIds)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to update edge stack", err), nil
+		}
+
+		return s.edgeStackDeploymentResult(ctx, id, "Edge stack updated successfully"), nil
+	}
+}
+
+func (s *PortainerMCPServer) HandleDeleteEdgeStack() server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		parser := toolgen.NewParameterParser(request)
+
+		id, err := parser.GetInt("id", true)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid id parameter", err), nil
+		}
+
+		if s.isDryRun(ctx) {
+			before, err := s.getEdgeStackState(ctx, id)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("failed to resolve edge stack", err), nil
+			}
+
+			return dryRunResult(before, nil, dryRunCall{
+				Operation: "DeleteEdgeStack",
+				Arguments: map[string]any{"id": id},
+			})
+		}
+
+		// The deployments are read first, the stack cannot be inspected once deleted
+		stack, err := s.client(ctx).GetEdgeStack(ctx, id)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to get edge stack", err), nil
+		}
+
+		err = s.client(ctx).DeleteEdgeStack(ctx, id)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to delete edge stack", err), nil
+		}
+
+		data, err := json.Marshal(stack.Deployments)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to marshal deployments", err), nil
+		}
+
+		return mcp.NewToolResultText(fmt.Sprintf("Edge stack deleted successfully, the edge agents remove it from these environments: %s", data)), nil
+	}
+}
+
+// getEdgeStackState returns an edge stack with its compose file
+func (s *PortainerMCPServer) getEdgeStackState(ctx context.Context, id int) (edgeStackState, error) {
+	stack, err := s.client(ctx).GetEdgeStack(ctx, id)
+	if err != nil {
+		return edgeStackState{}, err
+	}
+
+	file, err := s.client(ctx).GetEdgeStackFile(ctx, id)
+	if err != nil {
+		return edgeStackState{}, err
+	}
+
+	return edgeStackState{EdgeStack: stack, File: file}, nil
+}
+
+// pendingDeployments returns the deployments that Portainer schedules on the environments
+// of the given environment groups, after checking that the groups exist. It also returns the
+// dynamic groups listing no environment: their environments are matched by tags when the edge
+// stack is deployed, and their deployments cannot be predicted.
+func (s *PortainerMCPServer) pendingDeployments(ctx context.Context, environmentGroupIds []int) ([]models.EdgeStackDeployment, []int, error) {
+	groups, err := s.client(ctx).GetEnvironmentGroups(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get environment groups: %w", err)
+	}
+
+	var environmentIds, dynamicGroupIds []int
+	for _, id := range environmentGroupIds {
+		group, err := findByID(groups, id, func(g models.Group) int { return g.ID }, "environment group")
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(group.EnvironmentIds) == 0 && len(group.TagIds) > 0 {
+			dynamicGroupIds = append(dynamicGroupIds, group.ID)
+		}
+		environmentIds = append(environmentIds, group.EnvironmentIds...)
+	}
+
+	slices.Sort(environmentIds)
+	environmentIds = slices.Compact(environmentIds)
+
+	deployments := make([]models.EdgeStackDeployment, len(environmentIds))
+	for i, environmentId := range environmentIds {
+		deployments[i] = models.EdgeStackDeployment{EnvironmentID: environmentId, Status: models.EdgeStackStatusPending}
+	}
+
+	return deployments, dynamicGroupIds, nil
+}
+
+// dynamicGroupsNote tells that the deployments on the environments of dynamic groups are not listed
+func dynamicGroupsNote(dynamicGroupIds []int) string {
+	if len(dynamicGroupIds) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("the environment groups %v are dynamic, their environments are matched by tags when the edge stack is deployed and their deployments are not listed", dynamicGroupIds)
+}
+
+// edgeStackDeploymentResult reports a successful write followed by the deployment status of the
+// edge stack on each environment. The write is still reported when the status cannot be read.
+func (s *PortainerMCPServer) edgeStackDeploymentResult(ctx context.Context, id int, message string) *mcp.CallToolResult {
+	stack, err := s.client(ctx).GetEdgeStack(ctx, id)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf("%s, but its deployment status could not be read: %s", message, err))
+	}
+
+	data, err := json.Marshal(stack)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf("%s, but its deployment status could not be marshalled: %s", message, err))
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("%s. Deployment status: %s", message, data))
+}
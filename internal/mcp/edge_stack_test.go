@@ -0,0 +1,345 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/portainer/portainer-mcp/pkg/portainer/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testEdgeStack is deployed to the environments of group 1, running on 3 and failing on 4
+var testEdgeStack = models.EdgeStack{
+	ID:                  1,
+	Name:                "agent",
+	CreatedAt:           "2025-01-01T00:00:00Z",
+	EnvironmentGroupIds: []int{1},
+	Deployments: []models.EdgeStackDeployment{
+		{EnvironmentID: 3, Status: models.EdgeStackStatusRunning},
+		{EnvironmentID: 4, Status: models.EdgeStackStatusError, Error: "image not found"},
+	},
+}
+
+func TestHandleGetEdgeStacks(t *testing.T) {
+	tests := []struct {
+		name        string
+		mockStacks  []models.EdgeStack
+		mockError   error
+		expectError bool
+	}{
+		{
+			name:       "successful edge stacks retrieval",
+			mockStacks: []models.EdgeStack{testEdgeStack},
+		},
+		{
+			name:        "api error",
+			mockError:   fmt.Errorf("api error"),
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockPortainerClient{}
+			mockClient.On("GetEdgeStacks").Return(tt.mockStacks, tt.mockError)
+
+			server := &PortainerMCPServer{cli: mockClient}
+
+			result, err := server.HandleGetEdgeStacks()(context.Background(), mcp.CallToolRequest{})
+			require.NoError(t, err)
+
+			textContent, ok := result.Content[0].(mcp.TextContent)
+			require.True(t, ok)
+
+			if tt.expectError {
+				assert.True(t, result.IsError)
+				assert.Contains(t, textContent.Text, tt.mockError.Error())
+			} else {
+				var stacks []models.EdgeStack
+				require.NoError(t, json.Unmarshal([]byte(textContent.Text), &stacks))
+				assert.Equal(t, tt.mockStacks, stacks)
+			}
+
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandleGetEdgeStackFile(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          map[string]any
+		mockSetup     func(*MockPortainerClient)
+		expected      edgeStackState
+		errorContains string
+	}{
+		{
+			name: "file with deployment status",
+			args: map[string]any{"id": float64(1)},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetEdgeStack", 1).Return(testEdgeStack, nil)
+				m.On("GetEdgeStackFile", 1).Return("services: {}", nil)
+			},
+			expected: edgeStackState{EdgeStack: testEdgeStack, File: "services: {}"},
+		},
+		{
+			name: "unknown edge stack",
+			args: map[string]any{"id": float64(1)},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetEdgeStack", 1).Return(models.EdgeStack{}, fmt.Errorf("edge stack not found"))
+			},
+			errorContains: "edge stack not found",
+		},
+		{
+			name:          "missing id parameter",
+			args:          map[string]any{},
+			mockSetup:     func(m *MockPortainerClient) {},
+			errorContains: "id is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockPortainerClient{}
+			tt.mockSetup(mockClient)
+
+			server := &PortainerMCPServer{cli: mockClient}
+
+			result, err := server.HandleGetEdgeStackFile()(context.Background(), CreateMCPRequest(tt.args))
+			require.NoError(t, err)
+
+			textContent, ok := result.Content[0].(mcp.TextContent)
+			require.True(t, ok)
+
+			if tt.errorContains != "" {
+				assert.True(t, result.IsError)
+				assert.Contains(t, textContent.Text, tt.errorContains)
+			} else {
+				var state edgeStackState
+				require.NoError(t, json.Unmarshal([]byte(textContent.Text), &state))
+				assert.Equal(t, tt.expected, state)
+			}
+
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandleCreateEdgeStack(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          map[string]any
+		mockSetup     func(*MockPortainerClient)
+		expected      []string
+		errorContains string
+	}{
+		{
+			name: "successful creation reports the deployment status",
+			args: map[string]any{"name": "agent", "file": "services: {}", "environmentGroupIds": []any{float64(1)}},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("CreateEdgeStack", "agent", "services: {}", []int{1}).Return(1, nil)
+				m.On("GetEdgeStack", 1).Return(testEdgeStack, nil)
+			},
+			expected: []string{
+				"Edge stack created successfully with ID: 1. Deployment status: ",
+				`{"environment_id":4,"status":"error","error":"image not found"}`,
+			},
+		},
+		{
+			name: "creation reported when the status cannot be read",
+			args: map[string]any{"name": "agent", "file": "services: {}", "environmentGroupIds": []any{float64(1)}},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("CreateEdgeStack", "agent", "services: {}", []int{1}).Return(1, nil)
+				m.On("GetEdgeStack", 1).Return(models.EdgeStack{}, fmt.Errorf("timeout"))
+			},
+			expected: []string{"Edge stack created successfully with ID: 1, but its deployment status could not be read: timeout"},
+		},
+		{
+			name: "api error",
+			args: map[string]any{"name": "agent", "file": "services: {}", "environmentGroupIds": []any{float64(1)}},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("CreateEdgeStack", "agent", "services: {}", []int{1}).Return(0, fmt.Errorf("name already in use"))
+			},
+			errorContains: "name already in use",
+		},
+		{
+			name:          "empty environment groups",
+			args:          map[string]any{"name": "agent", "file": "services: {}", "environmentGroupIds": []any{}},
+			mockSetup:     func(m *MockPortainerClient) {},
+			errorContains: "at least one environment group is required",
+		},
+		{
+			name:          "missing file parameter",
+			args:          map[string]any{"name": "agent", "environmentGroupIds": []any{float64(1)}},
+			mockSetup:     func(m *MockPortainerClient) {},
+			errorContains: "file is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockPortainerClient{}
+			tt.mockSetup(mockClient)
+
+			server := &PortainerMCPServer{cli: mockClient}
+
+			result, err := server.HandleCreateEdgeStack()(context.Background(), CreateMCPRequest(tt.args))
+			require.NoError(t, err)
+
+			textContent, ok := result.Content[0].(mcp.TextContent)
+			require.True(t, ok)
+
+			if tt.errorContains != "" {
+				assert.True(t, result.IsError)
+				assert.Contains(t, textContent.Text, tt.errorContains)
+			} else {
+				assert.False(t, result.IsError)
+				for _, expected := range tt.expected {
+					assert.Contains(t, textContent.Text, expected)
+				}
+			}
+
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandleUpdateEdgeStack(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          map[string]any
+		mockSetup     func(*MockPortainerClient)
+		expected      string
+		errorContains string
+	}{
+		{
+			name: "update with new environment groups",
+			args: map[string]any{"id": float64(1), "file": "new-file", "environmentGroupIds": []any{float64(1), float64(2)}},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("UpdateEdgeStack", 1, "new-file", []int{1, 2}).Return(nil)
+				m.On("GetEdgeStack", 1).Return(testEdgeStack, nil)
+			},
+			expected: "Edge stack updated successfully. Deployment status: ",
+		},
+		{
+			name: "current environment groups kept when omitted",
+			args: map[string]any{"id": float64(1), "file": "new-file"},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetEdgeStack", 1).Return(testEdgeStack, nil)
+				m.On("UpdateEdgeStack", 1, "new-file", []int{1}).Return(nil)
+			},
+			expected: "Edge stack updated successfully. Deployment status: ",
+		},
+		{
+			name: "unknown edge stack",
+			args: map[string]any{"id": float64(1), "file": "new-file"},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetEdgeStack", 1).Return(models.EdgeStack{}, fmt.Errorf("edge stack not found"))
+			},
+			errorContains: "edge stack not found",
+		},
+		{
+			name: "api error",
+			args: map[string]any{"id": float64(1), "file": "new-file", "environmentGroupIds": []any{float64(1)}},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("UpdateEdgeStack", 1, "new-file", []int{1}).Return(fmt.Errorf("api error"))
+			},
+			errorContains: "api error",
+		},
+		{
+			name:          "missing id parameter",
+			args:          map[string]any{"file": "new-file"},
+			mockSetup:     func(m *MockPortainerClient) {},
+			errorContains: "id is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockPortainerClient{}
+			tt.mockSetup(mockClient)
+
+			server := &PortainerMCPServer{cli: mockClient}
+
+			result, err := server.HandleUpdateEdgeStack()(context.Background(), CreateMCPRequest(tt.args))
+			require.NoError(t, err)
+
+			textContent, ok := result.Content[0].(mcp.TextContent)
+			require.True(t, ok)
+
+			if tt.errorContains != "" {
+				assert.True(t, result.IsError)
+				assert.Contains(t, textContent.Text, tt.errorContains)
+			} else {
+				assert.False(t, result.IsError)
+				assert.Contains(t, textContent.Text, tt.expected)
+			}
+
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandleDeleteEdgeStack(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          map[string]any
+		mockSetup     func(*MockPortainerClient)
+		expected      string
+		errorContains string
+	}{
+		{
+			name: "deletion reports the environments the stack is removed from",
+			args: map[string]any{"id": float64(1)},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetEdgeStack", 1).Return(testEdgeStack, nil)
+				m.On("DeleteEdgeStack", 1).Return(nil)
+			},
+			expected: `Edge stack deleted successfully, the edge agents remove it from these environments: [{"environment_id":3,"status":"running"},{"environment_id":4,"status":"error","error":"image not found"}]`,
+		},
+		{
+			name: "api error",
+			args: map[string]any{"id": float64(1)},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetEdgeStack", 1).Return(testEdgeStack, nil)
+				m.On("DeleteEdgeStack", 1).Return(fmt.Errorf("api error"))
+			},
+			errorContains: "api error",
+		},
+		{
+			name:          "missing id parameter",
+			args:          map[string]any{},
+			mockSetup:     func(m *MockPortainerClient) {},
+			errorContains: "id is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockPortainerClient{}
+			tt.mockSetup(mockClient)
+
+			server := &PortainerMCPServer{cli: mockClient}
+
+			result, err := server.HandleDeleteEdgeStack()(context.Background(), CreateMCPRequest(tt.args))
+			require.NoError(t, err)
+
+			textContent, ok := result.Content[0].(mcp.TextContent)
+			require.True(t, ok)
+
+			if tt.errorContains != "" {
+				assert.True(t, result.IsError)
+				assert.Contains(t, textContent.Text, tt.errorContains)
+			} else {
+				assert.False(t, result.IsError)
+				assert.Equal(t, tt.expected, textContent.Text)
+			}
+
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
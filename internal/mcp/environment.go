@@ -12,16 +12,14 @@ import (
 func (s *PortainerMCPServer) AddEnvironmentFeatures() {
 	s.addToolIfExists(ToolListEnvironments, s.HandleGetEnvironments())
 
-	if !s.readOnly {
-		s.addToolIfExists(ToolUpdateEnvironmentTags, s.HandleUpdateEnvironmentTags())
-		s.addToolIfExists(ToolUpdateEnvironmentUserAccesses, s.HandleUpdateEnvironmentUserAccesses())
-		s.addToolIfExists(ToolUpdateEnvironmentTeamAccesses, s.HandleUpdateEnvironmentTeamAccesses())
-	}
+	s.addWriteToolIfExists(ToolUpdateEnvironmentTags, s.HandleUpdateEnvironmentTags())
+	s.addWriteToolIfExists(ToolUpdateEnvironmentUserAccesses, s.HandleUpdateEnvironmentUserAccesses())
+	s.addWriteToolIfExists(ToolUpdateEnvironmentTeamAccesses, s.HandleUpdateEnvironmentTeamAccesses())
 }
 
 func (s *PortainerMCPServer) HandleGetEnvironments() server.ToolHandlerFunc {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		environments, err := s.cli.GetEnvironments()
+		environments, err := s.client(ctx).GetEnvironments(ctx)
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("failed to get environments", err), nil
 		}
@@ -49,7 +47,21 @@ func (s *PortainerMCPServer) HandleUpdateEnvironmentTags() server.ToolHandlerFun
 			return mcp.NewToolResultErrorFromErr("invalid tagIds parameter", err), nil
 		}
 
-		err = s.cli.UpdateEnvironmentTags(id, tagIds)
+		if s.isDryRun(ctx) {
+			before, err := s.findEnvironment(ctx, id)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("failed to resolve environment", err), nil
+			}
+
+			after := before
+			after.TagIds = tagIds
+			return dryRunResult(before, after, dryRunCall{
+				Operation: "UpdateEnvironmentTags",
+				Arguments: map[string]any{"id": id, "tagIds": tagIds},
+			})
+		}
+
+		err = s.client(ctx).UpdateEnvironmentTags(ctx, id, tagIds)
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("failed to update environment tags", err), nil
 		}
@@ -77,7 +89,21 @@ func (s *PortainerMCPServer) HandleUpdateEnvironmentUserAccesses() server.ToolHa
 			return mcp.NewToolResultErrorFromErr("invalid user accesses", err), nil
 		}
 
-		err = s.cli.UpdateEnvironmentUserAccesses(id, userAccessesMap)
+		if s.isDryRun(ctx) {
+			before, err := s.findEnvironment(ctx, id)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("failed to resolve environment", err), nil
+			}
+
+			after := before
+			after.UserAccesses = userAccessesMap
+			return dryRunResult(before, after, dryRunCall{
+				Operation: "UpdateEnvironmentUserAccesses",
+				Arguments: map[string]any{"id": id, "userAccesses": userAccessesMap},
+			})
+		}
+
+		err = s.client(ctx).UpdateEnvironmentUserAccesses(ctx, id, userAccessesMap)
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("failed to update environment user accesses", err), nil
 		}
@@ -105,7 +131,21 @@ func (s *PortainerMCPServer) HandleUpdateEnvironmentTeamAccesses() server.ToolHa
 			return mcp.NewToolResultErrorFromErr("invalid team accesses", err), nil
 		}
 
-		err = s.cli.UpdateEnvironmentTeamAccesses(id, teamAccessesMap)
+		if s.isDryRun(ctx) {
+			before, err := s.findEnvironment(ctx, id)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("failed to resolve environment", err), nil
+			}
+
+			after := before
+			after.TeamAccesses = teamAccessesMap
+			return dryRunResult(before, after, dryRunCall{
+				Operation: "UpdateEnvironmentTeamAccesses",
+				Arguments: map[string]any{"id": id, "teamAccesses": teamAccessesMap},
+			})
+		}
+
+		err = s.client(ctx).UpdateEnvironmentTeamAccesses(ctx, id, teamAccessesMap)
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("failed to update environment team accesses", err), nil
 		}
@@ -0,0 +1,219 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/portainer/portainer-mcp/pkg/portainer/models"
+	"github.com/portainer/portainer-mcp/pkg/toolgen"
+)
+
+// defaultComposeFilePath is the path of the compose file in the git repository when none is given
+const defaultComposeFilePath = "docker-compose.yml"
+
+// HandleGetStackGitConfig returns the git repository a stack is deployed from, with the
+// commit of its last deployment. The stack is always read from Portainer, never from the cache.
+func (s *PortainerMCPServer) HandleGetStackGitConfig() server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		parser := toolgen.NewParameterParser(request)
+
+		id, err := parser.GetInt("id", true)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid id parameter", err), nil
+		}
+
+		stack, err := s.client(ctx).GetStack(ctx, id)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to get stack", err), nil
+		}
+
+		if stack.GitConfig == nil {
+			return mcp.NewToolResultError(fmt.Sprintf("stack %d is not deployed from a git repository", id)), nil
+		}
+
+		data, err := json.Marshal(stack.GitConfig)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to marshal git configuration", err), nil
+		}
+
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
+
+func (s *PortainerMCPServer) HandleCreateGitStack() server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		parser := toolgen.NewParameterParser(request)
+
+		name, err := parser.GetString("name", true)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid name parameter", err), nil
+		}
+
+		endpointId, err := parser.GetInt("endpointId", true)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid endpointId parameter", err), nil
+		}
+
+		repositoryUrl, err := parser.GetString("repositoryUrl", true)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid repositoryUrl parameter", err), nil
+		}
+
+		referenceName, err := parser.GetString("referenceName", false)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid referenceName parameter", err), nil
+		}
+
+		composeFilePath, err := parser.GetString("composeFilePath", false)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid composeFilePath parameter", err), nil
+		}
+		if composeFilePath == "" {
+			composeFilePath = defaultComposeFilePath
+		}
+
+		username, err := parser.GetString("username", false)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid username parameter", err), nil
+		}
+
+		password, err := parser.GetString("password", false)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid password parameter", err), nil
+		}
+
+		env, err := parseStackEnv(request)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid env parameter", err), nil
+		}
+
+		repository := models.StackGitRepository{
+			URL:             repositoryUrl,
+			ReferenceName:   referenceName,
+			ComposeFilePath: composeFilePath,
+			Username:        username,
+			Password:        password,
+		}
+
+		if s.isDryRun(ctx) {
+			if _, err := s.findEnvironment(ctx, endpointId); err != nil {
+				return mcp.NewToolResultErrorFromErr("failed to resolve environment", err), nil
+			}
+
+			after := models.Stack{
+				Name:       name,
+				Status:     models.StackStatusActive,
+				EndpointID: endpointId,
+				Env:        stackEnvVars(env),
+				GitConfig: &models.StackGitConfig{
+					URL:             repositoryUrl,
+					ReferenceName:   referenceName,
+					ComposeFilePath: composeFilePath,
+					Authenticated:   username != "" || password != "",
+					Username:        username,
+				},
+			}
+
+			arguments := map[string]any{
+				"name":            name,
+				"endpointId":      endpointId,
+				"repositoryUrl":   repositoryUrl,
+				"referenceName":   referenceName,
+				"composeFilePath": composeFilePath,
+				"username":        username,
+				"env":             stackEnvVars(env),
+			}
+			if password != "" {
+				arguments["password"] = models.MaskedEnvValue
+			}
+
+			return dryRunResult(nil, after, dryRunCall{
+				Operation: "CreateGitStack",
+				Arguments: arguments,
+			})
+		}
+
+		id, err := s.client(ctx).CreateGitStack(ctx, name, endpointId, repository, env)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("error creating git stack", err), nil
+		}
+
+		return s.gitStackDeploymentResult(ctx, id, fmt.Sprintf("Git stack created successfully with ID: %d", id)), nil
+	}
+}
+
+func (s *PortainerMCPServer) HandleRedeployGitStack() server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		parser := toolgen.NewParameterParser(request)
+
+		id, err := parser.GetInt("id", true)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid id parameter", err), nil
+		}
+
+		endpointId, err := parser.GetInt("endpointId", true)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid endpointId parameter", err), nil
+		}
+
+		// pullImage defaults to true if not specified
+		pullImage := true
+		pullImageParam, pullErr := parser.GetString("pullImage", false)
+		if pullErr == nil && pullImageParam == "false" {
+			pullImage = false
+		}
+
+		// env is nil when the parameter is not set, the current variables are then kept
+		env, err := parseStackEnv(request)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid env parameter", err), nil
+		}
+
+		if s.isDryRun(ctx) {
+			before, err := s.findStackOnEnvironment(ctx, id, endpointId)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("failed to resolve stack", err), nil
+			}
+
+			if before.GitConfig == nil {
+				return mcp.NewToolResultError(fmt.Sprintf("stack %d is not deployed from a git repository", id)), nil
+			}
+
+			after := before
+			arguments := map[string]any{"id": id, "endpointId": endpointId, "pullImage": pullImage}
+			if env != nil {
+				after.Env = stackEnvVars(env)
+				arguments["env"] = stackEnvVars(env)
+			}
+			return dryRunResult(before, after, dryRunCall{
+				Operation: "RedeployGitStack",
+				Arguments: arguments,
+			})
+		}
+
+		err = s.client(ctx).RedeployGitStack(ctx, id, endpointId, pullImage, env)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to redeploy git stack", err), nil
+		}
+
+		return s.gitStackDeploymentResult(ctx, id, "Git stack redeployed successfully"), nil
+	}
+}
+
+// gitStackDeploymentResult reports a successful deployment of a git stack followed by the
+// deployed commit. The deployment is still reported when the commit cannot be read.
+func (s *PortainerMCPServer) gitStackDeploymentResult(ctx context.Context, id int, message string) *mcp.CallToolResult {
+	stack, err := s.client(ctx).GetStack(ctx, id)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf("%s, but its deployed commit could not be read: %s", message, err))
+	}
+
+	if stack.GitConfig == nil || stack.GitConfig.DeployedCommit == "" {
+		return mcp.NewToolResultText(message)
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("%s. Deployed commit: %s", message, stack.GitConfig.DeployedCommit))
+}
@@ -0,0 +1,256 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/portainer/portainer-mcp/pkg/portainer/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testGitStack is deployed on environment 2 from the main branch of a private repository
+var testGitStack = models.Stack{
+	ID:         1,
+	Name:       "web",
+	Status:     models.StackStatusActive,
+	EndpointID: 2,
+	GitConfig: &models.StackGitConfig{
+		URL:             "https://github.com/acme/stacks.git",
+		ReferenceName:   "refs/heads/main",
+		ComposeFilePath: "web/docker-compose.yml",
+		Authenticated:   true,
+		Username:        "deploy",
+		DeployedCommit:  "bc4c183d756879ea4d173315338110b31004b8e0",
+	},
+}
+
+func TestHandleGetStackGitConfig(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          map[string]any
+		mockSetup     func(*MockPortainerClient)
+		expected      *models.StackGitConfig
+		errorContains string
+	}{
+		{
+			name: "git stack",
+			args: map[string]any{"id": float64(1)},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetStack", 1).Return(testGitStack, nil)
+			},
+			expected: testGitStack.GitConfig,
+		},
+		{
+			name: "stack not deployed from git",
+			args: map[string]any{"id": float64(1)},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetStack", 1).Return(models.Stack{ID: 1, Name: "web"}, nil)
+			},
+			errorContains: "stack 1 is not deployed from a git repository",
+		},
+		{
+			name: "api error",
+			args: map[string]any{"id": float64(1)},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetStack", 1).Return(models.Stack{}, fmt.Errorf("stack not found"))
+			},
+			errorContains: "stack not found",
+		},
+		{
+			name:          "missing id parameter",
+			args:          map[string]any{},
+			mockSetup:     func(m *MockPortainerClient) {},
+			errorContains: "id is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockPortainerClient{}
+			tt.mockSetup(mockClient)
+
+			server := &PortainerMCPServer{cli: mockClient}
+
+			result, err := server.HandleGetStackGitConfig()(context.Background(), CreateMCPRequest(tt.args))
+			require.NoError(t, err)
+
+			textContent, ok := result.Content[0].(mcp.TextContent)
+			require.True(t, ok)
+
+			if tt.errorContains != "" {
+				assert.True(t, result.IsError)
+				assert.Contains(t, textContent.Text, tt.errorContains)
+			} else {
+				var gitConfig models.StackGitConfig
+				require.NoError(t, json.Unmarshal([]byte(textContent.Text), &gitConfig))
+				assert.Equal(t, *tt.expected, gitConfig)
+			}
+
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandleCreateGitStack(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          map[string]any
+		mockSetup     func(*MockPortainerClient)
+		expected      string
+		errorContains string
+	}{
+		{
+			name: "private repository with environment variables",
+			args: map[string]any{
+				"name":            "web",
+				"endpointId":      float64(2),
+				"repositoryUrl":   "https://github.com/acme/stacks.git",
+				"referenceName":   "refs/heads/main",
+				"composeFilePath": "web/docker-compose.yml",
+				"username":        "deploy",
+				"password":        "t0ken",
+				"env":             []any{map[string]any{"key": "DOMAIN", "value": "acme.com"}},
+			},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("CreateGitStack", "web", 2, models.StackGitRepository{
+					URL:             "https://github.com/acme/stacks.git",
+					ReferenceName:   "refs/heads/main",
+					ComposeFilePath: "web/docker-compose.yml",
+					Username:        "deploy",
+					Password:        "t0ken",
+				}, map[string]string{"DOMAIN": "acme.com"}).Return(1, nil)
+				m.On("GetStack", 1).Return(testGitStack, nil)
+			},
+			expected: "Git stack created successfully with ID: 1. Deployed commit: bc4c183d756879ea4d173315338110b31004b8e0",
+		},
+		{
+			name: "public repository with the default compose file",
+			args: map[string]any{"name": "web", "endpointId": float64(2), "repositoryUrl": "https://github.com/acme/stacks.git"},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("CreateGitStack", "web", 2, models.StackGitRepository{
+					URL:             "https://github.com/acme/stacks.git",
+					ComposeFilePath: "docker-compose.yml",
+				}, map[string]string(nil)).Return(1, nil)
+				m.On("GetStack", 1).Return(models.Stack{}, fmt.Errorf("timeout"))
+			},
+			expected: "Git stack created successfully with ID: 1, but its deployed commit could not be read: timeout",
+		},
+		{
+			name: "api error",
+			args: map[string]any{"name": "web", "endpointId": float64(2), "repositoryUrl": "https://github.com/acme/stacks.git"},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("CreateGitStack", "web", 2, models.StackGitRepository{
+					URL:             "https://github.com/acme/stacks.git",
+					ComposeFilePath: "docker-compose.yml",
+				}, map[string]string(nil)).Return(0, fmt.Errorf("authentication required"))
+			},
+			errorContains: "authentication required",
+		},
+		{
+			name:          "missing repositoryUrl parameter",
+			args:          map[string]any{"name": "web", "endpointId": float64(2)},
+			mockSetup:     func(m *MockPortainerClient) {},
+			errorContains: "repositoryUrl is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockPortainerClient{}
+			tt.mockSetup(mockClient)
+
+			server := &PortainerMCPServer{cli: mockClient}
+
+			result, err := server.HandleCreateGitStack()(context.Background(), CreateMCPRequest(tt.args))
+			require.NoError(t, err)
+
+			textContent, ok := result.Content[0].(mcp.TextContent)
+			require.True(t, ok)
+
+			if tt.errorContains != "" {
+				assert.True(t, result.IsError)
+				assert.Contains(t, textContent.Text, tt.errorContains)
+			} else {
+				assert.False(t, result.IsError)
+				assert.Equal(t, tt.expected, textContent.Text)
+			}
+
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandleRedeployGitStack(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          map[string]any
+		mockSetup     func(*MockPortainerClient)
+		expected      string
+		errorContains string
+	}{
+		{
+			name: "redeploy keeping the environment variables",
+			args: map[string]any{"id": float64(1), "endpointId": float64(2)},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("RedeployGitStack", 1, 2, true, map[string]string(nil)).Return(nil)
+				m.On("GetStack", 1).Return(testGitStack, nil)
+			},
+			expected: "Git stack redeployed successfully. Deployed commit: bc4c183d756879ea4d173315338110b31004b8e0",
+		},
+		{
+			name: "redeploy without pulling images and new environment variables",
+			args: map[string]any{
+				"id": float64(1), "endpointId": float64(2), "pullImage": "false",
+				"env": []any{map[string]any{"key": "DOMAIN", "value": "acme.com"}},
+			},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("RedeployGitStack", 1, 2, false, map[string]string{"DOMAIN": "acme.com"}).Return(nil)
+				m.On("GetStack", 1).Return(testGitStack, nil)
+			},
+			expected: "Git stack redeployed successfully. Deployed commit: bc4c183d756879ea4d173315338110b31004b8e0",
+		},
+		{
+			name: "api error",
+			args: map[string]any{"id": float64(1), "endpointId": float64(2)},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("RedeployGitStack", 1, 2, true, map[string]string(nil)).Return(fmt.Errorf("stack 1 is not deployed from a git repository"))
+			},
+			errorContains: "stack 1 is not deployed from a git repository",
+		},
+		{
+			name:          "missing endpointId parameter",
+			args:          map[string]any{"id": float64(1)},
+			mockSetup:     func(m *MockPortainerClient) {},
+			errorContains: "endpointId is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockPortainerClient{}
+			tt.mockSetup(mockClient)
+
+			server := &PortainerMCPServer{cli: mockClient}
+
+			result, err := server.HandleRedeployGitStack()(context.Background(), CreateMCPRequest(tt.args))
+			require.NoError(t, err)
+
+			textContent, ok := result.Content[0].(mcp.TextContent)
+			require.True(t, ok)
+
+			if tt.errorContains != "" {
+				assert.True(t, result.IsError)
+				assert.Contains(t, textContent.Text, tt.errorContains)
+			} else {
+				assert.False(t, result.IsError)
+				assert.Equal(t, tt.expected, textContent.Text)
+			}
+
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
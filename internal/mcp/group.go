@@ -7,23 +7,22 @@ import (
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/portainer/portainer-mcp/pkg/portainer/models"
 	"github.com/portainer/portainer-mcp/pkg/toolgen"
 )
 
 func (s *PortainerMCPServer) AddEnvironmentGroupFeatures() {
 	s.addToolIfExists(ToolListEnvironmentGroups, s.HandleGetEnvironmentGroups())
 
-	if !s.readOnly {
-		s.addToolIfExists(ToolCreateEnvironmentGroup, s.HandleCreateEnvironmentGroup())
-		s.addToolIfExists(ToolUpdateEnvironmentGroupName, s.HandleUpdateEnvironmentGroupName())
-		s.addToolIfExists(ToolUpdateEnvironmentGroupEnvironments, s.HandleUpdateEnvironmentGroupEnvironments())
-		s.addToolIfExists(ToolUpdateEnvironmentGroupTags, s.HandleUpdateEnvironmentGroupTags())
-	}
+	s.addWriteToolIfExists(ToolCreateEnvironmentGroup, s.HandleCreateEnvironmentGroup())
+	s.addWriteToolIfExists(ToolUpdateEnvironmentGroupName, s.HandleUpdateEnvironmentGroupName())
+	s.addWriteToolIfExists(ToolUpdateEnvironmentGroupEnvironments, s.HandleUpdateEnvironmentGroupEnvironments())
+	s.addWriteToolIfExists(ToolUpdateEnvironmentGroupTags, s.HandleUpdateEnvironmentGroupTags())
 }
 
 func (s *PortainerMCPServer) HandleGetEnvironmentGroups() server.ToolHandlerFunc {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		edgeGroups, err := s.cli.GetEnvironmentGroups()
+		edgeGroups, err := s.client(ctx).GetEnvironmentGroups(ctx)
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("failed to get environment groups", err), nil
 		}
@@ -51,7 +50,15 @@ func (s *PortainerMCPServer) HandleCreateEnvironmentGroup() server.ToolHandlerFu
 			return mcp.NewToolResultErrorFromErr("invalid environmentIds parameter", err), nil
 		}
 
-		id, err := s.cli.CreateEnvironmentGroup(name, environmentIds)
+		if s.isDryRun(ctx) {
+			after := models.Group{Name: name, EnvironmentIds: environmentIds}
+			return dryRunResult(nil, after, dryRunCall{
+				Operation: "CreateEnvironmentGroup",
+				Arguments: map[string]any{"name": name, "environmentIds": environmentIds},
+			})
+		}
+
+		id, err := s.client(ctx).CreateEnvironmentGroup(ctx, name, environmentIds)
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("failed to create environment group", err), nil
 		}
@@ -74,7 +81,21 @@ func (s *PortainerMCPServer) HandleUpdateEnvironmentGroupName() server.ToolHandl
 			return mcp.NewToolResultErrorFromErr("invalid name parameter", err), nil
 		}
 
-		err = s.cli.UpdateEnvironmentGroupName(id, name)
+		if s.isDryRun(ctx) {
+			before, err := s.findEnvironmentGroup(ctx, id)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("failed to resolve environment group", err), nil
+			}
+
+			after := before
+			after.Name = name
+			return dryRunResult(before, after, dryRunCall{
+				Operation: "UpdateEnvironmentGroupName",
+				Arguments: map[string]any{"id": id, "name": name},
+			})
+		}
+
+		err = s.client(ctx).UpdateEnvironmentGroupName(ctx, id, name)
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("failed to update environment group name", err), nil
 		}
@@ -97,7 +118,21 @@ func (s *PortainerMCPServer) HandleUpdateEnvironmentGroupEnvironments() server.T
 			return mcp.NewToolResultErrorFromErr("invalid environmentIds parameter", err), nil
 		}
 
-		err = s.cli.UpdateEnvironmentGroupEnvironments(id, environmentIds)
+		if s.isDryRun(ctx) {
+			before, err := s.findEnvironmentGroup(ctx, id)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("failed to resolve environment group", err), nil
+			}
+
+			after := before
+			after.EnvironmentIds = environmentIds
+			return dryRunResult(before, after, dryRunCall{
+				Operation: "UpdateEnvironmentGroupEnvironments",
+				Arguments: map[string]any{"id": id, "environmentIds": environmentIds},
+			})
+		}
+
+		err = s.client(ctx).UpdateEnvironmentGroupEnvironments(ctx, id, environmentIds)
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("failed to update environment group environments", err), nil
 		}
@@ -120,7 +155,21 @@ func (s *PortainerMCPServer) HandleUpdateEnvironmentGroupTags() server.ToolHandl
 			return mcp.NewToolResultErrorFromErr("invalid tagIds parameter", err), nil
 		}
 
-		err = s.cli.UpdateEnvironmentGroupTags(id, tagIds)
+		if s.isDryRun(ctx) {
+			before, err := s.findEnvironmentGroup(ctx, id)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("failed to resolve environment group", err), nil
+			}
+
+			after := before
+			after.TagIds = tagIds
+			return dryRunResult(before, after, dryRunCall{
+				Operation: "UpdateEnvironmentGroupTags",
+				Arguments: map[string]any{"id": id, "tagIds": tagIds},
+			})
+		}
+
+		err = s.client(ctx).UpdateEnvironmentGroupTags(ctx, id, tagIds)
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("failed to update environment group tags", err), nil
 		}
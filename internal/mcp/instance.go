@@ -0,0 +1,295 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/portainer/portainer-mcp/pkg/portainer/client"
+	"github.com/portainer/portainer-mcp/pkg/toolgen"
+)
+
+const (
+	// DefaultInstanceName is the name of the Portainer instance given to NewPortainerMCPServer,
+	// unless another name is set with WithInstanceName
+	DefaultInstanceName = "default"
+	// InstanceParameter is the optional tool parameter selecting the Portainer instance,
+	// it is only added to the tools when the server has more than one instance
+	InstanceParameter = "instance"
+)
+
+// instanceNamePattern restricts instance names to identifiers that are easy to type in a tool call
+var instanceNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// Instance describes a Portainer instance served in addition to the one given to NewPortainerMCPServer
+type Instance struct {
+	Name      string
+	ServerURL string
+	Token     string
+	// Username and Password authenticate to the instance instead of the token, see WithCredentials
+	Username string
+	Password string
+	// ReadOnly rejects the calls of write tools targeting the instance
+	ReadOnly bool
+	// DisableVersionCheck skips the version check and the capability detection of the instance
+	DisableVersionCheck bool
+	TLS                 TLSOptions
+	// Client is used instead of a client built from the URL and the token.
+	// This is primarily used for testing to inject mock clients.
+	Client PortainerClient
+}
+
+// WithInstanceName sets the name of the Portainer instance given to NewPortainerMCPServer.
+// The instance is the default one: tools use it when the call does not select an instance.
+func WithInstanceName(name string) ServerOption {
+	return func(opts *serverOptions) {
+		opts.instanceName = name
+	}
+}
+
+// WithInstance serves an additional Portainer instance. Every tool then accepts an instance
+// parameter selecting the Portainer instance of the call, and labels its result with the name
+// of the instance. The version check and the read-only mode apply to each instance.
+func WithInstance(instance Instance) ServerOption {
+	return func(opts *serverOptions) {
+		opts.instances = append(opts.instances, instance)
+	}
+}
+
+// instance is an additional Portainer instance served by the server.
+// The default instance uses the client, capabilities and read-only mode of the server itself.
+type instance struct {
+	name         string
+	readOnly     bool
+	capabilities *Capabilities
+	factory      ClientFactory
+
+	mu  sync.RWMutex
+	cli PortainerClient
+}
+
+type instanceKey struct{}
+
+// client returns the Portainer client of the instance
+func (i *instance) client() PortainerClient {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	return i.cli
+}
+
+// newInstances connects to the additional Portainer instances.
+// The clients of the instances are built with the given options in addition to their TLS options,
+// cached with the given cache configuration, and each instance gets its own limiter enforcing the limits.
+func newInstances(defaultName string, defs []Instance, cache cacheConfig, extraOptions []client.ClientOption, limits client.Limits) (map[string]*instance, error) {
+	instances := make(map[string]*instance, len(defs))
+
+	for _, def := range defs {
+		if !instanceNamePattern.MatchString(def.Name) {
+			return nil, fmt.Errorf("invalid Portainer instance name %q", def.Name)
+		}
+
+		if _, exists := instances[def.Name]; exists || def.Name == defaultName {
+			return nil, fmt.Errorf("duplicate Portainer instance name %q", def.Name)
+		}
+
+		clientOptions, err := def.TLS.clientOptions()
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure TLS of instance %s: %w", def.Name, err)
+		}
+		clientOptions = append(clientOptions, extraOptions...)
+		clientOptions = append(clientOptions, limiterOptions(limits)...)
+
+		serverURL := def.ServerURL
+		factory := func(token string) PortainerClient {
+			return client.NewPortainerClient(serverURL, token, clientOptions...)
+		}
+
+		cli := def.Client
+		if cli == nil {
+			if def.Username != "" {
+				cli = client.NewPortainerClient(serverURL, "", slices.Concat(clientOptions, []client.ClientOption{client.WithCredentials(def.Username, def.Password)})...)
+			} else {
+				cli = factory(def.Token)
+			}
+		}
+
+		capabilities, err := checkInstance(def.Name, cli, def.DisableVersionCheck)
+		if err != nil {
+			return nil, fmt.Errorf("instance %s: %w", def.Name, err)
+		}
+
+		if cache != nil {
+			baseFactory := factory
+			factory = func(token string) PortainerClient {
+				return newCachingClient(baseFactory(token), cache)
+			}
+			cli = newCachingClient(cli, cache)
+		}
+
+		instances[def.Name] = &instance{
+			name:         def.Name,
+			readOnly:     def.ReadOnly,
+			capabilities: capabilities,
+			factory:      factory,
+			cli:          cli,
+		}
+	}
+
+	return instances, nil
+}
+
+// instanceNames returns the names of the Portainer instances, the default one first
+func (s *PortainerMCPServer) instanceNames() []string {
+	return append([]string{s.instanceName}, slices.Sorted(maps.Keys(s.instances))...)
+}
+
+// allReadOnly reports whether every Portainer instance is read-only, in which case
+// the write tools are not registered
+func (s *PortainerMCPServer) allReadOnly() bool {
+	if !s.readOnly {
+		return false
+	}
+
+	for _, inst := range s.instances {
+		if !inst.readOnly {
+			return false
+		}
+	}
+
+	return true
+}
+
+// missingCapability returns the capability required by the tool that no Portainer instance provides.
+// Tools are registered as long as one instance provides their capabilities.
+func (s *PortainerMCPServer) missingCapability(toolName string) (capability, bool) {
+	missing, ok := s.capabilities.missingCapability(toolName)
+	if !ok {
+		return "", false
+	}
+
+	for _, inst := range s.instances {
+		if _, instanceMissing := inst.capabilities.missingCapability(toolName); !instanceMissing {
+			return "", false
+		}
+	}
+
+	return missing, true
+}
+
+// UpdateInstanceToken replaces the client of the named Portainer instance with a client
+// authenticated with the given token, see UpdateToken.
+func (s *PortainerMCPServer) UpdateInstanceToken(name, token string) error {
+	if name == s.instanceName {
+		s.UpdateToken(token)
+		return nil
+	}
+
+	inst, ok := s.instances[name]
+	if !ok {
+		return fmt.Errorf("unknown Portainer instance %q", name)
+	}
+
+	cli := inst.factory(token)
+
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+
+	inst.cli = cli
+	return nil
+}
+
+// withInstanceParameter returns a copy of the tool with the instance parameter
+func (s *PortainerMCPServer) withInstanceParameter(tool mcp.Tool) mcp.Tool {
+	properties := maps.Clone(tool.InputSchema.Properties)
+	if properties == nil {
+		properties = map[string]any{}
+	}
+
+	names := s.instanceNames()
+	properties[InstanceParameter] = map[string]any{
+		"type":        "string",
+		"enum":        names,
+		"description": fmt.Sprintf("The Portainer instance to use. Defaults to %s.", s.instanceName),
+	}
+	tool.InputSchema.Properties = properties
+
+	return tool
+}
+
+// withInstance wraps a tool handler so that it uses the Portainer instance selected by the
+// instance parameter. Calls of write tools are rejected on read-only instances, and calls of
+// tools requiring a capability are rejected on the instances that do not provide it.
+// Successful results are labelled with the name of the instance. It is a no-op with a single instance.
+func (s *PortainerMCPServer) withInstance(toolName string, write bool, handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	if len(s.instances) == 0 {
+		return handler
+	}
+
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, err := toolgen.NewParameterParser(request).GetString(InstanceParameter, false)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid instance parameter", err), nil
+		}
+
+		if name == "" {
+			name = s.instanceName
+		}
+
+		readOnly, capabilities := s.readOnly, s.capabilities
+		if name != s.instanceName {
+			inst, ok := s.instances[name]
+			if !ok {
+				return mcp.NewToolResultError(fmt.Sprintf("unknown Portainer instance %q, must be one of: %s", name, strings.Join(s.instanceNames(), ", "))), nil
+			}
+
+			readOnly, capabilities = inst.readOnly, inst.capabilities
+			ctx = context.WithValue(ctx, instanceKey{}, inst)
+		}
+
+		if write && readOnly {
+			return mcp.NewToolResultError(fmt.Sprintf("%s is not allowed, the Portainer instance is read-only", toolName)), nil
+		}
+
+		if missing, ok := capabilities.missingCapability(toolName); ok {
+			return mcp.NewToolResultError(fmt.Sprintf("%s requires %s, which the Portainer instance does not provide", toolName, missing)), nil
+		}
+
+		result, err := handler(ctx, request)
+		return labelResult(result, name), err
+	}
+}
+
+// requestInstance returns the name of the Portainer instance of a call: the instance of the
+// context, or else the instance selected by the instance parameter or the default instance
+func (s *PortainerMCPServer) requestInstance(ctx context.Context, request mcp.CallToolRequest) string {
+	if inst, ok := ctx.Value(instanceKey{}).(*instance); ok {
+		return inst.name
+	}
+
+	if len(s.instances) > 0 {
+		if name, ok := request.GetArguments()[InstanceParameter].(string); ok && name != "" {
+			return name
+		}
+	}
+
+	return s.instanceName
+}
+
+// labelResult appends the name of the Portainer instance to the content of the result,
+// as a separate text content so that the content produced by the tool is left untouched.
+// Error results are not labelled, their text ends up in the audit log, the logs and the spans.
+func labelResult(result *mcp.CallToolResult, name string) *mcp.CallToolResult {
+	if result == nil || result.IsError {
+		return result
+	}
+
+	result.Content = append(result.Content, mcp.NewTextContent("Portainer instance: "+name))
+	return result
+}
@@ -0,0 +1,380 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/portainer/portainer-mcp/pkg/portainer/client"
+	"github.com/portainer/portainer-mcp/pkg/portainer/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewInstances(t *testing.T) {
+	tests := []struct {
+		name          string
+		defs          []Instance
+		mockSetup     func(*MockPortainerClient)
+		errorContains string
+	}{
+		{
+			name: "valid instances",
+			defs: []Instance{
+				{Name: "staging", DisableVersionCheck: true},
+				{Name: "qa.eu-1", ReadOnly: true, DisableVersionCheck: true},
+			},
+		},
+		{
+			name:          "invalid name",
+			defs:          []Instance{{Name: "stag ing", DisableVersionCheck: true}},
+			errorContains: `invalid Portainer instance name "stag ing"`,
+		},
+		{
+			name:          "name of the default instance",
+			defs:          []Instance{{Name: DefaultInstanceName, DisableVersionCheck: true}},
+			errorContains: `duplicate Portainer instance name "default"`,
+		},
+		{
+			name: "duplicate name",
+			defs: []Instance{
+				{Name: "staging", DisableVersionCheck: true},
+				{Name: "staging", DisableVersionCheck: true},
+			},
+			errorContains: `duplicate Portainer instance name "staging"`,
+		},
+		{
+			name: "invalid TLS settings",
+			defs: []Instance{
+				{Name: "staging", DisableVersionCheck: true, TLS: TLSOptions{CACertFile: filepath.Join(t.TempDir(), "missing.pem")}},
+			},
+			errorContains: "failed to configure TLS of instance staging",
+		},
+		{
+			name: "version check failure",
+			defs: []Instance{{Name: "staging"}},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetVersion").Return("", errors.New("connection refused"))
+			},
+			errorContains: "instance staging:",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockPortainerClient{}
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockClient)
+			}
+
+			for i := range tt.defs {
+				tt.defs[i].Client = mockClient
+			}
+
+			instances, err := newInstances(DefaultInstanceName, tt.defs, nil, nil, client.Limits{})
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, instances, len(tt.defs))
+			for _, def := range tt.defs {
+				assert.Equal(t, def.ReadOnly, instances[def.Name].readOnly)
+				assert.Same(t, mockClient, instances[def.Name].client())
+			}
+		})
+	}
+}
+
+func TestWithInstance(t *testing.T) {
+	tests := []struct {
+		name           string
+		toolName       string
+		write          bool
+		instance       any
+		failingClient  string
+		expectError    bool
+		expectedLabel  string
+		expectedClient string
+		expectedResult string
+	}{
+		{
+			name:           "default instance",
+			toolName:       ToolListEnvironments,
+			expectedLabel:  "Portainer instance: production",
+			expectedClient: "production",
+		},
+		{
+			name:           "default instance selected by name",
+			toolName:       ToolListEnvironments,
+			instance:       "production",
+			expectedLabel:  "Portainer instance: production",
+			expectedClient: "production",
+		},
+		{
+			name:           "additional instance",
+			toolName:       ToolListEnvironments,
+			instance:       "staging",
+			expectedLabel:  "Portainer instance: staging",
+			expectedClient: "staging",
+		},
+		{
+			name:           "unknown instance",
+			toolName:       ToolListEnvironments,
+			instance:       "qa",
+			expectError:    true,
+			expectedResult: `unknown Portainer instance "qa", must be one of: production, audit, staging`,
+		},
+		{
+			name:           "invalid instance parameter",
+			toolName:       ToolListEnvironments,
+			instance:       42,
+			expectError:    true,
+			expectedResult: "invalid instance parameter",
+		},
+		{
+			name:           "write tool on a writable instance",
+			toolName:       ToolCreateEnvironmentTag,
+			write:          true,
+			instance:       "staging",
+			expectedLabel:  "Portainer instance: staging",
+			expectedClient: "staging",
+		},
+		{
+			name:           "write tool on a read-only instance",
+			toolName:       ToolCreateEnvironmentTag,
+			write:          true,
+			instance:       "audit",
+			expectError:    true,
+			expectedResult: "createEnvironmentTag is not allowed, the Portainer instance is read-only",
+		},
+		{
+			name:           "read tool on a read-only instance",
+			toolName:       ToolListEnvironments,
+			instance:       "audit",
+			expectedLabel:  "Portainer instance: audit",
+			expectedClient: "audit",
+		},
+		{
+			name:           "tool requiring a missing capability",
+			toolName:       ToolKubernetesProxyStripped,
+			instance:       "staging",
+			expectError:    true,
+			expectedResult: "getKubernetesResourceStripped requires Kubernetes environments, which the Portainer instance does not provide",
+		},
+		{
+			name:           "error result of the tool",
+			toolName:       ToolListEnvironments,
+			instance:       "staging",
+			failingClient:  "staging",
+			expectError:    true,
+			expectedClient: "staging",
+			expectedResult: "failed to get environments: connection refused",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clients := map[string]*MockPortainerClient{}
+			for _, name := range []string{"production", "staging", "audit"} {
+				clients[name] = &MockPortainerClient{}
+				if name == tt.failingClient {
+					clients[name].On("GetEnvironments").Return([]models.Environment(nil), errors.New("connection refused"))
+				} else {
+					clients[name].On("GetEnvironments").Return([]models.Environment{{Name: name}}, nil)
+				}
+			}
+
+			s := &PortainerMCPServer{
+				cli:          clients["production"],
+				instanceName: "production",
+				capabilities: &Capabilities{Kubernetes: true},
+				instances: map[string]*instance{
+					"staging": {name: "staging", capabilities: &Capabilities{}, cli: clients["staging"]},
+					"audit":   {name: "audit", readOnly: true, cli: clients["audit"]},
+				},
+			}
+
+			// The handler stands in for any tool, it reports the instance whose client it was given
+			handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				environments, err := s.client(ctx).GetEnvironments(ctx)
+				if err != nil {
+					return mcp.NewToolResultErrorFromErr("failed to get environments", err), nil
+				}
+				return mcp.NewToolResultText(environments[0].Name), nil
+			}
+
+			args := map[string]any{}
+			if tt.instance != nil {
+				args[InstanceParameter] = tt.instance
+			}
+
+			result, err := s.withInstance(tt.toolName, tt.write, handler)(context.Background(), CreateMCPRequest(args))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectError, result.IsError)
+
+			contents := make([]string, 0, len(result.Content))
+			for _, content := range result.Content {
+				text, ok := content.(mcp.TextContent)
+				require.True(t, ok)
+				contents = append(contents, text.Text)
+			}
+
+			if tt.expectedLabel != "" {
+				require.NotEmpty(t, contents)
+				assert.Equal(t, tt.expectedLabel, contents[len(contents)-1], "the label is the last content")
+				contents = contents[:len(contents)-1]
+			}
+
+			if tt.expectError {
+				require.Len(t, contents, 1, "error results are not labelled")
+				assert.Contains(t, contents[0], tt.expectedResult)
+			} else {
+				assert.Equal(t, []string{tt.expectedClient}, contents)
+			}
+
+			for name, client := range clients {
+				if name == tt.expectedClient {
+					client.AssertCalled(t, "GetEnvironments")
+				} else {
+					client.AssertNotCalled(t, "GetEnvironments")
+				}
+			}
+		})
+	}
+}
+
+func TestWithInstanceSingleInstance(t *testing.T) {
+	s := &PortainerMCPServer{instanceName: DefaultInstanceName}
+
+	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("ok"), nil
+	}
+
+	result, err := s.withInstance(ToolListEnvironments, false, handler)(context.Background(), CreateMCPRequest(nil))
+	require.NoError(t, err)
+	assert.Equal(t, []mcp.Content{mcp.NewTextContent("ok")}, result.Content, "results are not labelled with a single instance")
+}
+
+func TestLabelResult(t *testing.T) {
+	image := mcp.NewImageContent("aW1hZ2U=", "image/png")
+	result := labelResult(&mcp.CallToolResult{Content: []mcp.Content{image}}, "staging")
+
+	assert.Equal(t, []mcp.Content{image, mcp.NewTextContent("Portainer instance: staging")}, result.Content,
+		"the content of the tool is left untouched")
+	assert.Nil(t, labelResult(nil, "staging"))
+}
+
+func TestAllReadOnly(t *testing.T) {
+	tests := []struct {
+		name      string
+		readOnly  bool
+		instances map[string]*instance
+		expected  bool
+	}{
+		{
+			name:     "single writable instance",
+			expected: false,
+		},
+		{
+			name:     "single read-only instance",
+			readOnly: true,
+			expected: true,
+		},
+		{
+			name:      "read-only default instance with a writable instance",
+			readOnly:  true,
+			instances: map[string]*instance{"staging": {}},
+			expected:  false,
+		},
+		{
+			name:      "writable default instance with a read-only instance",
+			instances: map[string]*instance{"audit": {readOnly: true}},
+			expected:  false,
+		},
+		{
+			name:      "every instance read-only",
+			readOnly:  true,
+			instances: map[string]*instance{"audit": {readOnly: true}},
+			expected:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &PortainerMCPServer{readOnly: tt.readOnly, instances: tt.instances}
+			assert.Equal(t, tt.expected, s.allReadOnly())
+		})
+	}
+}
+
+func TestMissingCapabilityAcrossInstances(t *testing.T) {
+	s := &PortainerMCPServer{
+		capabilities: &Capabilities{},
+		instances: map[string]*instance{
+			"staging": {capabilities: &Capabilities{}},
+		},
+	}
+
+	missing, ok := s.missingCapability(ToolKubernetesProxyStripped)
+	assert.True(t, ok, "no instance provides Kubernetes environments")
+	assert.Equal(t, capabilityKubernetes, missing)
+
+	s.instances["kubernetes"] = &instance{capabilities: &Capabilities{Kubernetes: true}}
+	_, ok = s.missingCapability(ToolKubernetesProxyStripped)
+	assert.False(t, ok, "the tool is registered when one instance provides the capability")
+}
+
+func TestWithInstanceParameter(t *testing.T) {
+	s := &PortainerMCPServer{
+		instanceName: "production",
+		instances: map[string]*instance{
+			"staging": {},
+			"audit":   {},
+		},
+	}
+
+	tool := mcp.NewTool(ToolListEnvironments, mcp.WithString("filter"))
+	withParameter := s.withInstanceParameter(tool)
+
+	assert.Equal(t, map[string]any{
+		"type":        "string",
+		"enum":        []string{"production", "audit", "staging"},
+		"description": "The Portainer instance to use. Defaults to production.",
+	}, withParameter.InputSchema.Properties[InstanceParameter])
+	assert.Contains(t, withParameter.InputSchema.Properties, "filter")
+	assert.NotContains(t, tool.InputSchema.Properties, InstanceParameter, "the original tool must not be modified")
+}
+
+func TestUpdateInstanceToken(t *testing.T) {
+	var tokens []string
+	staging := &instance{
+		name: "staging",
+		factory: func(token string) PortainerClient {
+			tokens = append(tokens, token)
+			return &MockPortainerClient{}
+		},
+		cli: &MockPortainerClient{},
+	}
+	defaultClient := &MockPortainerClient{}
+
+	s := &PortainerMCPServer{
+		cli:          defaultClient,
+		instanceName: DefaultInstanceName,
+		instances:    map[string]*instance{"staging": staging},
+		factory:      func(token string) PortainerClient { return &MockPortainerClient{} },
+	}
+
+	previous := staging.client()
+	require.NoError(t, s.UpdateInstanceToken("staging", "new-token"))
+	assert.Equal(t, []string{"new-token"}, tokens)
+	assert.NotSame(t, previous, staging.client())
+
+	require.NoError(t, s.UpdateInstanceToken(DefaultInstanceName, "new-default-token"))
+	assert.NotSame(t, defaultClient, s.cli, "the default instance uses the client of the server")
+
+	assert.ErrorContains(t, s.UpdateInstanceToken("qa", "token"), `unknown Portainer instance "qa"`)
+}
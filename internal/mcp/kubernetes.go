@@ -16,9 +16,7 @@ import (
 func (s *PortainerMCPServer) AddKubernetesProxyFeatures() {
 	s.addToolIfExists(ToolKubernetesProxyStripped, s.HandleKubernetesProxyStripped())
 
-	if !s.readOnly {
-		s.addToolIfExists(ToolKubernetesProxy, s.HandleKubernetesProxy())
-	}
+	s.addWriteToolIfExists(ToolKubernetesProxy, s.HandleKubernetesProxy())
 }
 
 func (s *PortainerMCPServer) HandleKubernetesProxyStripped() server.ToolHandlerFunc {
@@ -64,7 +62,7 @@ func (s *PortainerMCPServer) HandleKubernetesProxyStripped() server.ToolHandlerF
 			Headers:       headersMap,
 		}
 
-		response, err := s.cli.ProxyKubernetesRequest(opts)
+		response, err := s.client(ctx).ProxyKubernetesRequest(ctx, opts)
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("failed to send Kubernetes API request", err), nil
 		}
@@ -126,6 +124,24 @@ func (s *PortainerMCPServer) HandleKubernetesProxy() server.ToolHandlerFunc {
 			return mcp.NewToolResultErrorFromErr("invalid body parameter", err), nil
 		}
 
+		if s.isDryRun(ctx) && !isReadOnlyHTTPMethod(method) {
+			if _, err := s.findEnvironment(ctx, environmentId); err != nil {
+				return mcp.NewToolResultErrorFromErr("failed to resolve environment", err), nil
+			}
+
+			return dryRunResult(nil, nil, dryRunCall{
+				Operation: "ProxyKubernetesRequest",
+				Arguments: map[string]any{
+					"environmentId": environmentId,
+					"method":        method,
+					"path":          kubernetesAPIPath,
+					"queryParams":   queryParamsMap,
+					"headers":       headersMap,
+					"body":          body,
+				},
+			})
+		}
+
 		opts := models.KubernetesProxyRequestOptions{
 			EnvironmentID: environmentId,
 			Path:          kubernetesAPIPath,
@@ -138,10 +154,11 @@ func (s *PortainerMCPServer) HandleKubernetesProxy() server.ToolHandlerFunc {
 			opts.Body = strings.NewReader(body)
 		}
 
-		response, err := s.cli.ProxyKubernetesRequest(opts)
+		response, err := s.client(ctx).ProxyKubernetesRequest(ctx, opts)
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("failed to send Kubernetes API request", err), nil
 		}
+		defer response.Body.Close()
 
 		responseBody, err := io.ReadAll(response.Body)
 		if err != nil {
@@ -0,0 +1,234 @@
+package mcp
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// DefaultClientLogLevel is the minimum level of the log messages sent to the clients
+	// that did not set one with logging/setLevel
+	DefaultClientLogLevel = mcp.LoggingLevelWarning
+	// LoggerName identifies the server in the log messages sent to the clients
+	LoggerName = "portainer-mcp"
+)
+
+const (
+	// methodNotificationInitialized is the notification sent by MCP clients once initialized
+	methodNotificationInitialized = "notifications/initialized"
+	// methodNotificationMessage is the notification carrying a log message to the client
+	methodNotificationMessage = "notifications/message"
+)
+
+// clientLogLevels orders the MCP log levels by increasing severity
+var clientLogLevels = []mcp.LoggingLevel{
+	mcp.LoggingLevelDebug,
+	mcp.LoggingLevelInfo,
+	mcp.LoggingLevelNotice,
+	mcp.LoggingLevelWarning,
+	mcp.LoggingLevelError,
+	mcp.LoggingLevelCritical,
+	mcp.LoggingLevelAlert,
+	mcp.LoggingLevelEmergency,
+}
+
+// clientLogLevel returns the MCP log level matching a zerolog level
+func clientLogLevel(level zerolog.Level) mcp.LoggingLevel {
+	switch level {
+	case zerolog.TraceLevel, zerolog.DebugLevel:
+		return mcp.LoggingLevelDebug
+	case zerolog.InfoLevel:
+		return mcp.LoggingLevelInfo
+	case zerolog.WarnLevel:
+		return mcp.LoggingLevelWarning
+	case zerolog.ErrorLevel:
+		return mcp.LoggingLevelError
+	case zerolog.FatalLevel:
+		return mcp.LoggingLevelCritical
+	default:
+		return mcp.LoggingLevelEmergency
+	}
+}
+
+// logEvent is an event worth reporting to the clients, in addition to the server logs
+type logEvent struct {
+	level   zerolog.Level
+	message string
+	fields  map[string]any
+}
+
+// params returns the parameters of the notifications/message notification of the event
+func (e logEvent) params() map[string]any {
+	data := maps.Clone(e.fields)
+	if data == nil {
+		data = map[string]any{}
+	}
+	data["message"] = e.message
+
+	return map[string]any{
+		"level":  clientLogLevel(e.level),
+		"logger": LoggerName,
+		"data":   data,
+	}
+}
+
+// clientLogging sends the important events of the server to the clients as notifications/message,
+// honouring the minimum level set by each client with logging/setLevel.
+//
+// Not every transport keeps the level set by the client, so the levels are tracked by session.
+type clientLogging struct {
+	mu sync.Mutex
+	// levels holds the minimum level set by each session
+	levels map[string]mcp.LoggingLevel
+	// startup holds the events that occurred before any client connected, they are sent to
+	// each client once its session is initialized
+	startup []logEvent
+}
+
+func newClientLogging() *clientLogging {
+	return &clientLogging{
+		levels: make(map[string]mcp.LoggingLevel),
+	}
+}
+
+// install records the levels set by the clients with the hooks of the MCP server
+func (l *clientLogging) install(hooks *server.Hooks) {
+	hooks.AddAfterSetLevel(func(ctx context.Context, _ any, request *mcp.SetLevelRequest, _ *mcp.EmptyResult) {
+		session := server.ClientSessionFromContext(ctx)
+		if session == nil {
+			return
+		}
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		l.levels[session.SessionID()] = request.Params.Level
+	})
+
+	hooks.AddOnUnregisterSession(func(_ context.Context, session server.ClientSession) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		delete(l.levels, session.SessionID())
+	})
+}
+
+// accepts reports whether the session accepts messages of the given level
+func (l *clientLogging) accepts(sessionID string, level zerolog.Level) bool {
+	l.mu.Lock()
+	minimum, ok := l.levels[sessionID]
+	l.mu.Unlock()
+
+	if !ok {
+		minimum = DefaultClientLogLevel
+	}
+
+	return slices.Index(clientLogLevels, clientLogLevel(level)) >= slices.Index(clientLogLevels, minimum)
+}
+
+// addStartupEvent keeps an event to send to the clients once their sessions are initialized
+func (l *clientLogging) addStartupEvent(event logEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.startup = append(l.startup, event)
+}
+
+// startupEvents returns the events that occurred before any client connected
+func (l *clientLogging) startupEvents() []logEvent {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return slices.Clone(l.startup)
+}
+
+// logStartupEvent logs an event that occurs before clients connect, such as a tool that is not
+// registered. The event is sent to each client once its session is initialized.
+func (s *PortainerMCPServer) logStartupEvent(level zerolog.Level, message string, fields map[string]any) {
+	log.WithLevel(level).Fields(fields).Msg(message)
+
+	if s.logging != nil {
+		s.logging.addStartupEvent(logEvent{level: level, message: message, fields: fields})
+	}
+}
+
+// handleInitialized handles notifications/initialized by sending the startup events to the client
+func (s *PortainerMCPServer) handleInitialized(ctx context.Context, _ mcp.JSONRPCNotification) {
+	session := server.ClientSessionFromContext(ctx)
+	if session == nil {
+		return
+	}
+
+	for _, event := range s.logging.startupEvents() {
+		s.notifyClient(session.SessionID(), event)
+	}
+}
+
+// logCallKey holds the callLog of the tool call of a context
+type logCallKey struct{}
+
+// callLog records whether an event of a tool call was already reported to the client
+type callLog struct {
+	reported bool
+}
+
+// logCallEvent logs an event of a tool call, such as a denied call, and sends it to the client of
+// the call when the client accepts its level
+func (s *PortainerMCPServer) logCallEvent(ctx context.Context, level zerolog.Level, message string, fields map[string]any) {
+	log.WithLevel(level).Fields(fields).Msg(message)
+
+	if call, ok := ctx.Value(logCallKey{}).(*callLog); ok {
+		call.reported = true
+	}
+
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		s.notifyClient(session.SessionID(), logEvent{level: level, message: message, fields: fields})
+	}
+}
+
+// notifyClient sends an event to the client of a session when the client accepts its level
+func (s *PortainerMCPServer) notifyClient(sessionID string, event logEvent) {
+	if s.logging == nil || s.srv == nil || !s.logging.accepts(sessionID, event.level) {
+		return
+	}
+
+	if err := s.srv.SendNotificationToSpecificClient(sessionID, methodNotificationMessage, event.params()); err != nil {
+		log.Debug().Err(err).Str("session", sessionID).Msg("failed to send log message to the client")
+	}
+}
+
+// withLogging wraps a tool handler so that failed calls are logged and reported to the client,
+// unless a more specific event of the call, such as a policy denial, was already reported.
+// Failures returned as tool errors, including upstream Portainer errors, are logged as warnings
+// and handler errors as errors.
+func (s *PortainerMCPServer) withLogging(toolName string, handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		call := &callLog{}
+		result, err := handler(context.WithValue(ctx, logCallKey{}, call), request)
+
+		if call.reported {
+			return result, err
+		}
+
+		if err != nil {
+			s.logCallEvent(ctx, zerolog.ErrorLevel, "tool call failed", map[string]any{
+				"tool":  toolName,
+				"error": err.Error(),
+			})
+		} else if result != nil && result.IsError {
+			s.logCallEvent(ctx, zerolog.WarnLevel, "tool call failed", map[string]any{
+				"tool":  toolName,
+				"error": resultText(result),
+			})
+		}
+
+		return result, err
+	}
+}
@@ -0,0 +1,234 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/portainer/portainer-mcp/internal/policy"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loggingSession is a ClientSession supporting logging/setLevel that records its notifications
+type loggingSession struct {
+	id            string
+	notifications chan mcp.JSONRPCNotification
+	level         mcp.LoggingLevel
+}
+
+func newLoggingSession(id string) *loggingSession {
+	return &loggingSession{id: id, notifications: make(chan mcp.JSONRPCNotification, 10)}
+}
+
+func (l *loggingSession) Initialize()                                         {}
+func (l *loggingSession) Initialized() bool                                   { return true }
+func (l *loggingSession) NotificationChannel() chan<- mcp.JSONRPCNotification { return l.notifications }
+func (l *loggingSession) SessionID() string                                   { return l.id }
+func (l *loggingSession) SetLogLevel(level mcp.LoggingLevel)                  { l.level = level }
+func (l *loggingSession) GetLogLevel() mcp.LoggingLevel                       { return l.level }
+
+// received returns the log messages sent to the session
+func (l *loggingSession) received() []map[string]any {
+	var messages []map[string]any
+	for {
+		select {
+		case notification := <-l.notifications:
+			if notification.Method == methodNotificationMessage {
+				messages = append(messages, notification.Params.AdditionalFields)
+			}
+		default:
+			return messages
+		}
+	}
+}
+
+// newLoggingServer builds a server with client logging and a registered session
+func newLoggingServer(t *testing.T) (*PortainerMCPServer, *loggingSession, context.Context) {
+	s := &PortainerMCPServer{logging: newClientLogging()}
+
+	hooks := &server.Hooks{}
+	s.logging.install(hooks)
+	s.srv = server.NewMCPServer("test", "1.0", server.WithLogging(), server.WithHooks(hooks))
+	s.srv.AddNotificationHandler(methodNotificationInitialized, s.handleInitialized)
+
+	session := newLoggingSession("session-1")
+	require.NoError(t, s.srv.RegisterSession(context.Background(), session))
+
+	return s, session, s.srv.WithContext(context.Background(), session)
+}
+
+// handleMessage sends a JSON-RPC message to the server in the context of the session
+func handleMessage(t *testing.T, s *PortainerMCPServer, ctx context.Context, message map[string]any) mcp.JSONRPCMessage {
+	data, err := json.Marshal(message)
+	require.NoError(t, err)
+
+	return s.srv.HandleMessage(ctx, data)
+}
+
+func TestClientLogLevel(t *testing.T) {
+	tests := []struct {
+		level    zerolog.Level
+		expected mcp.LoggingLevel
+	}{
+		{level: zerolog.DebugLevel, expected: mcp.LoggingLevelDebug},
+		{level: zerolog.InfoLevel, expected: mcp.LoggingLevelInfo},
+		{level: zerolog.WarnLevel, expected: mcp.LoggingLevelWarning},
+		{level: zerolog.ErrorLevel, expected: mcp.LoggingLevelError},
+		{level: zerolog.FatalLevel, expected: mcp.LoggingLevelCritical},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.level.String(), func(t *testing.T) {
+			assert.Equal(t, tt.expected, clientLogLevel(tt.level))
+		})
+	}
+}
+
+func TestClientLoggingHonoursSetLevel(t *testing.T) {
+	tests := []struct {
+		name     string
+		setLevel mcp.LoggingLevel
+		level    zerolog.Level
+		expected bool
+	}{
+		{name: "warning by default", level: zerolog.WarnLevel, expected: true},
+		{name: "info filtered by default", level: zerolog.InfoLevel, expected: false},
+		{name: "info after lowering the level", setLevel: mcp.LoggingLevelInfo, level: zerolog.InfoLevel, expected: true},
+		{name: "warning filtered after raising the level", setLevel: mcp.LoggingLevelError, level: zerolog.WarnLevel, expected: false},
+		{name: "error after raising the level", setLevel: mcp.LoggingLevelError, level: zerolog.ErrorLevel, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, session, ctx := newLoggingServer(t)
+
+			if tt.setLevel != "" {
+				response := handleMessage(t, s, ctx, map[string]any{
+					"jsonrpc": "2.0",
+					"id":      1,
+					"method":  "logging/setLevel",
+					"params":  map[string]any{"level": tt.setLevel},
+				})
+				require.IsType(t, mcp.JSONRPCResponse{}, response)
+			}
+
+			s.logCallEvent(ctx, tt.level, "something happened", map[string]any{"tool": ToolListStacks})
+
+			messages := session.received()
+			if !tt.expected {
+				assert.Empty(t, messages)
+				return
+			}
+
+			require.Len(t, messages, 1)
+			assert.Equal(t, map[string]any{
+				"level":  clientLogLevel(tt.level),
+				"logger": LoggerName,
+				"data":   map[string]any{"message": "something happened", "tool": ToolListStacks},
+			}, messages[0])
+		})
+	}
+}
+
+func TestStartupEventsSentOnceInitialized(t *testing.T) {
+	s, session, ctx := newLoggingServer(t)
+
+	s.registerTool(ToolListStacks, false, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("ok"), nil
+	})
+	assert.Empty(t, session.received(), "nothing is sent before the session is initialized")
+
+	assert.Nil(t, handleMessage(t, s, ctx, map[string]any{
+		"jsonrpc": "2.0",
+		"method":  methodNotificationInitialized,
+	}))
+
+	messages := session.received()
+	require.Len(t, messages, 1)
+	assert.Equal(t, mcp.LoggingLevelWarning, messages[0]["level"])
+	assert.Equal(t, map[string]any{
+		"message": "tool not registered, it is not defined in the tools file",
+		"tool":    ToolListStacks,
+	}, messages[0]["data"])
+}
+
+func TestWithLogging(t *testing.T) {
+	tests := []struct {
+		name            string
+		handler         server.ToolHandlerFunc
+		policy          string
+		expectedLevel   mcp.LoggingLevel
+		expectedMessage string
+		expectedError   string
+	}{
+		{
+			name: "successful call",
+			handler: func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				return mcp.NewToolResultText("ok"), nil
+			},
+		},
+		{
+			name: "upstream error",
+			handler: func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				return mcp.NewToolResultErrorFromErr("failed to get stacks", errors.New("502 Bad Gateway")), nil
+			},
+			expectedLevel:   mcp.LoggingLevelWarning,
+			expectedMessage: "tool call failed",
+			expectedError:   "failed to get stacks: 502 Bad Gateway",
+		},
+		{
+			name: "handler error",
+			handler: func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				return nil, errors.New("unexpected failure")
+			},
+			expectedLevel:   mcp.LoggingLevelError,
+			expectedMessage: "tool call failed",
+			expectedError:   "unexpected failure",
+		},
+		{
+			name: "policy denial reported once",
+			handler: func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				return mcp.NewToolResultText("ok"), nil
+			},
+			policy:          "default: deny\n",
+			expectedLevel:   mcp.LoggingLevelWarning,
+			expectedMessage: "tool call denied by policy",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, session, ctx := newLoggingServer(t)
+
+			if tt.policy != "" {
+				p, err := policy.Parse([]byte(tt.policy))
+				require.NoError(t, err)
+				s.policy = p
+			}
+
+			_, _ = s.withLogging(ToolListStacks, s.withPolicy(ToolListStacks, tt.handler))(ctx, CreateMCPRequest(nil))
+
+			messages := session.received()
+			if tt.expectedMessage == "" {
+				assert.Empty(t, messages)
+				return
+			}
+
+			require.Len(t, messages, 1)
+			assert.Equal(t, tt.expectedLevel, messages[0]["level"])
+
+			data, ok := messages[0]["data"].(map[string]any)
+			require.True(t, ok)
+			assert.Equal(t, tt.expectedMessage, data["message"])
+			assert.Equal(t, ToolListStacks, data["tool"])
+			if tt.expectedError != "" {
+				assert.Equal(t, tt.expectedError, data["error"])
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package mcp
+
+import (
+	"context"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/portainer/portainer-mcp/internal/metrics"
+)
+
+// withMetrics wraps a tool handler so that the outcome and the duration of every call are recorded,
+// including calls rejected before reaching the Portainer API (e.g. invalid parameters).
+func (s *PortainerMCPServer) withMetrics(toolName string, handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	if s.metrics == nil {
+		return handler
+	}
+
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		result, err := handler(ctx, request)
+
+		outcome := metrics.OutcomeSuccess
+		if err != nil || (result != nil && result.IsError) {
+			outcome = metrics.OutcomeError
+		}
+		s.metrics.ObserveToolCall(toolName, outcome, time.Since(start))
+
+		return result, err
+	}
+}
@@ -0,0 +1,71 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/portainer/portainer-mcp/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithMetrics(t *testing.T) {
+	tests := []struct {
+		name            string
+		handler         server.ToolHandlerFunc
+		expectedOutcome string
+	}{
+		{
+			name: "successful call",
+			handler: func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				return mcp.NewToolResultText("ok"), nil
+			},
+			expectedOutcome: metrics.OutcomeSuccess,
+		},
+		{
+			name: "error result",
+			handler: func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				return mcp.NewToolResultError("failed to get stacks"), nil
+			},
+			expectedOutcome: metrics.OutcomeError,
+		},
+		{
+			name: "handler error",
+			handler: func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				return nil, errors.New("unexpected failure")
+			},
+			expectedOutcome: metrics.OutcomeError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := metrics.New()
+			s := &PortainerMCPServer{metrics: m}
+
+			_, _ = s.withMetrics(ToolListStacks, tt.handler)(context.Background(), CreateMCPRequest(nil))
+
+			recorder := httptest.NewRecorder()
+			m.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, metrics.Path, nil))
+			require.Equal(t, http.StatusOK, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), `portainer_mcp_tool_calls_total{outcome="`+tt.expectedOutcome+`",tool="listStacks"} 1`)
+			assert.Contains(t, recorder.Body.String(), `portainer_mcp_tool_call_duration_seconds_count{tool="listStacks"} 1`)
+		})
+	}
+}
+
+func TestWithMetricsDisabled(t *testing.T) {
+	s := &PortainerMCPServer{}
+
+	result, err := s.withMetrics(ToolListStacks, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("ok"), nil
+	})(context.Background(), CreateMCPRequest(nil))
+
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+}
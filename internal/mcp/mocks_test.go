@@ -1,6 +1,7 @@
 package mcp
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/portainer/portainer-mcp/pkg/portainer/models"
@@ -46,7 +47,7 @@ type MockPortainerClient struct {
 
 // Tag methods
 
-func (m *MockPortainerClient) GetEnvironmentTags() ([]models.EnvironmentTag, error) {
+func (m *MockPortainerClient) GetEnvironmentTags(ctx context.Context) ([]models.EnvironmentTag, error) {
 	args := m.Called()
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -54,14 +55,14 @@ func (m *MockPortainerClient) GetEnvironmentTags() ([]models.EnvironmentTag, err
 	return args.Get(0).([]models.EnvironmentTag), args.Error(1)
 }
 
-func (m *MockPortainerClient) CreateEnvironmentTag(name string) (int, error) {
+func (m *MockPortainerClient) CreateEnvironmentTag(ctx context.Context, name string) (int, error) {
 	args := m.Called(name)
 	return args.Int(0), args.Error(1)
 }
 
 // Environment methods
 
-func (m *MockPortainerClient) GetEnvironments() ([]models.Environment, error) {
+func (m *MockPortainerClient) GetEnvironments(ctx context.Context) ([]models.Environment, error) {
 	args := m.Called()
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -69,24 +70,24 @@ func (m *MockPortainerClient) GetEnvironments() ([]models.Environment, error) {
 	return args.Get(0).([]models.Environment), args.Error(1)
 }
 
-func (m *MockPortainerClient) UpdateEnvironmentTags(id int, tagIds []int) error {
+func (m *MockPortainerClient) UpdateEnvironmentTags(ctx context.Context, id int, tagIds []int) error {
 	args := m.Called(id, tagIds)
 	return args.Error(0)
 }
 
-func (m *MockPortainerClient) UpdateEnvironmentUserAccesses(id int, userAccesses map[int]string) error {
+func (m *MockPortainerClient) UpdateEnvironmentUserAccesses(ctx context.Context, id int, userAccesses map[int]string) error {
 	args := m.Called(id, userAccesses)
 	return args.Error(0)
 }
 
-func (m *MockPortainerClient) UpdateEnvironmentTeamAccesses(id int, teamAccesses map[int]string) error {
+func (m *MockPortainerClient) UpdateEnvironmentTeamAccesses(ctx context.Context, id int, teamAccesses map[int]string) error {
 	args := m.Called(id, teamAccesses)
 	return args.Error(0)
 }
 
 // Environment Group methods
 
-func (m *MockPortainerClient) GetEnvironmentGroups() ([]models.Group, error) {
+func (m *MockPortainerClient) GetEnvironmentGroups(ctx context.Context) ([]models.Group, error) {
 	args := m.Called()
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -94,29 +95,29 @@ func (m *MockPortainerClient) GetEnvironmentGroups() ([]models.Group, error) {
 	return args.Get(0).([]models.Group), args.Error(1)
 }
 
-func (m *MockPortainerClient) CreateEnvironmentGroup(name string, environmentIds []int) (int, error) {
+func (m *MockPortainerClient) CreateEnvironmentGroup(ctx context.Context, name string, environmentIds []int) (int, error) {
 	args := m.Called(name, environmentIds)
 	return args.Int(0), args.Error(1)
 }
 
-func (m *MockPortainerClient) UpdateEnvironmentGroupName(id int, name string) error {
+func (m *MockPortainerClient) UpdateEnvironmentGroupName(ctx context.Context, id int, name string) error {
 	args := m.Called(id, name)
 	return args.Error(0)
 }
 
-func (m *MockPortainerClient) UpdateEnvironmentGroupEnvironments(id int, environmentIds []int) error {
+func (m *MockPortainerClient) UpdateEnvironmentGroupEnvironments(ctx context.Context, id int, environmentIds []int) error {
 	args := m.Called(id, environmentIds)
 	return args.Error(0)
 }
 
-func (m *MockPortainerClient) UpdateEnvironmentGroupTags(id int, tagIds []int) error {
+func (m *MockPortainerClient) UpdateEnvironmentGroupTags(ctx context.Context, id int, tagIds []int) error {
 	args := m.Called(id, tagIds)
 	return args.Error(0)
 }
 
 // Access Group methods
 
-func (m *MockPortainerClient) GetAccessGroups() ([]models.AccessGroup, error) {
+func (m *MockPortainerClient) GetAccessGroups(ctx context.Context) ([]models.AccessGroup, error) {
 	args := m.Called()
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -124,39 +125,39 @@ func (m *MockPortainerClient) GetAccessGroups() ([]models.AccessGroup, error) {
 	return args.Get(0).([]models.AccessGroup), args.Error(1)
 }
 
-func (m *MockPortainerClient) CreateAccessGroup(name string, environmentIds []int) (int, error) {
+func (m *MockPortainerClient) CreateAccessGroup(ctx context.Context, name string, environmentIds []int) (int, error) {
 	args := m.Called(name, environmentIds)
 	return args.Int(0), args.Error(1)
 }
 
-func (m *MockPortainerClient) UpdateAccessGroupName(id int, name string) error {
+func (m *MockPortainerClient) UpdateAccessGroupName(ctx context.Context, id int, name string) error {
 	args := m.Called(id, name)
 	return args.Error(0)
 }
 
-func (m *MockPortainerClient) UpdateAccessGroupUserAccesses(id int, userAccesses map[int]string) error {
+func (m *MockPortainerClient) UpdateAccessGroupUserAccesses(ctx context.Context, id int, userAccesses map[int]string) error {
 	args := m.Called(id, userAccesses)
 	return args.Error(0)
 }
 
-func (m *MockPortainerClient) UpdateAccessGroupTeamAccesses(id int, teamAccesses map[int]string) error {
+func (m *MockPortainerClient) UpdateAccessGroupTeamAccesses(ctx context.Context, id int, teamAccesses map[int]string) error {
 	args := m.Called(id, teamAccesses)
 	return args.Error(0)
 }
 
-func (m *MockPortainerClient) AddEnvironmentToAccessGroup(id int, environmentId int) error {
+func (m *MockPortainerClient) AddEnvironmentToAccessGroup(ctx context.Context, id int, environmentId int) error {
 	args := m.Called(id, environmentId)
 	return args.Error(0)
 }
 
-func (m *MockPortainerClient) RemoveEnvironmentFromAccessGroup(id int, environmentId int) error {
+func (m *MockPortainerClient) RemoveEnvironmentFromAccessGroup(ctx context.Context, id int, environmentId int) error {
 	args := m.Called(id, environmentId)
 	return args.Error(0)
 }
 
 // Stack methods
 
-func (m *MockPortainerClient) GetStacks() ([]models.Stack, error) {
+func (m *MockPortainerClient) GetStacks(ctx context.Context) ([]models.Stack, error) {
 	args := m.Called()
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -164,44 +165,94 @@ func (m *MockPortainerClient) GetStacks() ([]models.Stack, error) {
 	return args.Get(0).([]models.Stack), args.Error(1)
 }
 
-func (m *MockPortainerClient) GetStackFile(id int) (string, error) {
+func (m *MockPortainerClient) GetStackFile(ctx context.Context, id int) (string, error) {
 	args := m.Called(id)
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockPortainerClient) CreateStack(name string, file string, endpointId int) (int, error) {
-	args := m.Called(name, file, endpointId)
+func (m *MockPortainerClient) CreateStack(ctx context.Context, name string, file string, endpointId int, env map[string]string) (int, error) {
+	args := m.Called(name, file, endpointId, env)
 	return args.Int(0), args.Error(1)
 }
 
-func (m *MockPortainerClient) UpdateStack(id int, file string, endpointId int, pullImage bool) error {
-	args := m.Called(id, file, endpointId, pullImage)
+func (m *MockPortainerClient) UpdateStack(ctx context.Context, id int, file string, endpointId int, pullImage bool, env map[string]string) error {
+	args := m.Called(id, file, endpointId, pullImage, env)
 	return args.Error(0)
 }
 
-func (m *MockPortainerClient) StartStack(id int, endpointId int) error {
+func (m *MockPortainerClient) StartStack(ctx context.Context, id int, endpointId int) error {
 	args := m.Called(id, endpointId)
 	return args.Error(0)
 }
 
-func (m *MockPortainerClient) StopStack(id int, endpointId int) error {
+func (m *MockPortainerClient) StopStack(ctx context.Context, id int, endpointId int) error {
 	args := m.Called(id, endpointId)
 	return args.Error(0)
 }
 
-func (m *MockPortainerClient) DeleteStack(id int, endpointId int) error {
+func (m *MockPortainerClient) DeleteStack(ctx context.Context, id int, endpointId int) error {
 	args := m.Called(id, endpointId)
 	return args.Error(0)
 }
 
+func (m *MockPortainerClient) GetStack(ctx context.Context, id int) (models.Stack, error) {
+	args := m.Called(id)
+	return args.Get(0).(models.Stack), args.Error(1)
+}
+
+func (m *MockPortainerClient) CreateGitStack(ctx context.Context, name string, endpointId int, repository models.StackGitRepository, env map[string]string) (int, error) {
+	args := m.Called(name, endpointId, repository, env)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockPortainerClient) RedeployGitStack(ctx context.Context, id int, endpointId int, pullImage bool, env map[string]string) error {
+	args := m.Called(id, endpointId, pullImage, env)
+	return args.Error(0)
+}
+
+// Edge Stack methods
+
+func (m *MockPortainerClient) GetEdgeStacks(ctx context.Context) ([]models.EdgeStack, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.EdgeStack), args.Error(1)
+}
+
+func (m *MockPortainerClient) GetEdgeStack(ctx context.Context, id int) (models.EdgeStack, error) {
+	args := m.Called(id)
+	return args.Get(0).(models.EdgeStack), args.Error(1)
+}
+
+func (m *MockPortainerClient) GetEdgeStackFile(ctx context.Context, id int) (string, error) {
+	args := m.Called(id)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPortainerClient) CreateEdgeStack(ctx context.Context, name string, file string, environmentGroupIds []int) (int, error) {
+	args := m.Called(name, file, environmentGroupIds)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockPortainerClient) UpdateEdgeStack(ctx context.Context, id int, file string, environmentGroupIds []int) error {
+	args := m.Called(id, file, environmentGroupIds)
+	return args.Error(0)
+}
+
+func (m *MockPortainerClient) DeleteEdgeStack(ctx context.Context, id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
 // Team methods
 
-func (m *MockPortainerClient) CreateTeam(name string) (int, error) {
+func (m *MockPortainerClient) CreateTeam(ctx context.Context, name string) (int, error) {
 	args := m.Called(name)
 	return args.Int(0), args.Error(1)
 }
 
-func (m *MockPortainerClient) GetTeams() ([]models.Team, error) {
+func (m *MockPortainerClient) GetTeams(ctx context.Context) ([]models.Team, error) {
 	args := m.Called()
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -209,19 +260,19 @@ func (m *MockPortainerClient) GetTeams() ([]models.Team, error) {
 	return args.Get(0).([]models.Team), args.Error(1)
 }
 
-func (m *MockPortainerClient) UpdateTeamName(id int, name string) error {
+func (m *MockPortainerClient) UpdateTeamName(ctx context.Context, id int, name string) error {
 	args := m.Called(id, name)
 	return args.Error(0)
 }
 
-func (m *MockPortainerClient) UpdateTeamMembers(id int, userIds []int) error {
+func (m *MockPortainerClient) UpdateTeamMembers(ctx context.Context, id int, userIds []int) error {
 	args := m.Called(id, userIds)
 	return args.Error(0)
 }
 
 // User methods
 
-func (m *MockPortainerClient) GetUsers() ([]models.User, error) {
+func (m *MockPortainerClient) GetUsers(ctx context.Context) ([]models.User, error) {
 	args := m.Called()
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -229,14 +280,14 @@ func (m *MockPortainerClient) GetUsers() ([]models.User, error) {
 	return args.Get(0).([]models.User), args.Error(1)
 }
 
-func (m *MockPortainerClient) UpdateUserRole(id int, role string) error {
+func (m *MockPortainerClient) UpdateUserRole(ctx context.Context, id int, role string) error {
 	args := m.Called(id, role)
 	return args.Error(0)
 }
 
 // Settings methods
 
-func (m *MockPortainerClient) GetSettings() (models.PortainerSettings, error) {
+func (m *MockPortainerClient) GetSettings(ctx context.Context) (models.PortainerSettings, error) {
 	args := m.Called()
 	if args.Get(0) == nil {
 		return models.PortainerSettings{}, args.Error(1)
@@ -244,7 +295,7 @@ func (m *MockPortainerClient) GetSettings() (models.PortainerSettings, error) {
 	return args.Get(0).(models.PortainerSettings), args.Error(1)
 }
 
-func (m *MockPortainerClient) GetVersion() (string, error) {
+func (m *MockPortainerClient) GetVersion(ctx context.Context) (string, error) {
 	args := m.Called()
 	if args.Get(0) == nil {
 		return "", args.Error(1)
@@ -252,8 +303,13 @@ func (m *MockPortainerClient) GetVersion() (string, error) {
 	return args.Get(0).(string), args.Error(1)
 }
 
+func (m *MockPortainerClient) GetEdition(ctx context.Context) (string, error) {
+	args := m.Called()
+	return args.String(0), args.Error(1)
+}
+
 // Docker Proxy methods
-func (m *MockPortainerClient) ProxyDockerRequest(opts models.DockerProxyRequestOptions) (*http.Response, error) {
+func (m *MockPortainerClient) ProxyDockerRequest(ctx context.Context, opts models.DockerProxyRequestOptions) (*http.Response, error) {
 	args := m.Called(opts)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -262,7 +318,7 @@ func (m *MockPortainerClient) ProxyDockerRequest(opts models.DockerProxyRequestO
 }
 
 // Kubernetes Proxy methods
-func (m *MockPortainerClient) ProxyKubernetesRequest(opts models.KubernetesProxyRequestOptions) (*http.Response, error) {
+func (m *MockPortainerClient) ProxyKubernetesRequest(ctx context.Context, opts models.KubernetesProxyRequestOptions) (*http.Response, error) {
 	args := m.Called(opts)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
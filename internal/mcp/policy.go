@@ -0,0 +1,282 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/portainer/portainer-mcp/internal/policy"
+	"github.com/portainer/portainer-mcp/pkg/portainer/models"
+	"github.com/rs/zerolog"
+)
+
+// withPolicy wraps a tool handler so that the call is evaluated against the policy
+// before reaching the handler. Denied calls return a tool error naming the rule that blocked them.
+func (s *PortainerMCPServer) withPolicy(toolName string, handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	if s.policy == nil {
+		return handler
+	}
+
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if err := s.checkPolicy(ctx, toolName, request); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		return handler(ctx, request)
+	}
+}
+
+// checkPolicy evaluates a call of the tool against the policy, on each environment it targets.
+// It returns an error naming the rule that denied the call.
+func (s *PortainerMCPServer) checkPolicy(ctx context.Context, toolName string, request mcp.CallToolRequest) error {
+	calls, err := s.policyCalls(ctx, toolName, request)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate policy: %w", err)
+	}
+
+	decision := s.policy.EvaluateAll(calls)
+	if !decision.Allowed {
+		s.logCallEvent(ctx, zerolog.WarnLevel, "tool call denied by policy", map[string]any{
+			"tool":   toolName,
+			"reason": decision.Reason(),
+		})
+		return fmt.Errorf("call to tool %s denied by %s", toolName, decision.Reason())
+	}
+
+	return nil
+}
+
+// policyCalls builds the descriptions of a tool call used to evaluate the policy, one for each
+// targeted environment. The environments are only resolved when the policy references them,
+// and their tags and access groups when the policy references tags or access groups.
+// Lookups bypass the cache, so that a newly tagged environment is protected right away.
+func (s *PortainerMCPServer) policyCalls(ctx context.Context, toolName string, request mcp.CallToolRequest) ([]policy.Call, error) {
+	call := policy.Call{Tool: toolName, Instance: s.requestInstance(ctx, request)}
+
+	if method, ok := request.GetArguments()["method"].(string); ok {
+		call.Method = method
+	}
+
+	if !s.policy.RequiresEnvironments() {
+		return []policy.Call{call}, nil
+	}
+
+	cli := s.uncachedClient(ctx)
+
+	environmentIds, ok := targetEnvironments(ctx, cli, toolName, request)
+	if !ok {
+		call.EnvironmentUnknown = true
+		return []policy.Call{call}, nil
+	}
+
+	if len(environmentIds) == 0 {
+		return []policy.Call{call}, nil
+	}
+
+	var tags, groups map[int][]string
+	if s.policy.RequiresEnvironmentTags() {
+		environmentTags, err := cli.GetEnvironmentTags(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get environment tags: %w", err)
+		}
+
+		tags = map[int][]string{}
+		for _, tag := range environmentTags {
+			for _, environmentId := range tag.EnvironmentIds {
+				tags[environmentId] = append(tags[environmentId], tag.Name)
+			}
+		}
+	}
+
+	if s.policy.RequiresAccessGroups() {
+		accessGroups, err := cli.GetAccessGroups(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get access groups: %w", err)
+		}
+
+		groups = map[int][]string{}
+		for _, group := range accessGroups {
+			for _, environmentId := range group.EnvironmentIds {
+				groups[environmentId] = append(groups[environmentId], group.Name)
+			}
+		}
+	}
+
+	calls := make([]policy.Call, 0, len(environmentIds))
+	for _, environmentId := range environmentIds {
+		environmentCall := call
+		environmentCall.EnvironmentID = &environmentId
+		environmentCall.EnvironmentTags = tags[environmentId]
+		environmentCall.AccessGroups = groups[environmentId]
+		calls = append(calls, environmentCall)
+	}
+
+	return calls, nil
+}
+
+// uncachedClient returns the Portainer client of the call, with reads bypassing the cache
+func (s *PortainerMCPServer) uncachedClient(ctx context.Context) PortainerClient {
+	cli := s.client(ctx)
+	if cached, ok := cli.(*cachingClient); ok {
+		return cached.refreshing()
+	}
+
+	return cli
+}
+
+// targetEnvironments returns the IDs of the environments targeted by a tool call: the environments
+// given as parameters, and those of the stack, edge stack, access group or environment group the
+// call acts on. It returns false when the environments cannot be resolved, because a parameter is
+// missing or the entity cannot be found.
+func targetEnvironments(ctx context.Context, cli PortainerClient, toolName string, request mcp.CallToolRequest) ([]int, bool) {
+	args := request.GetArguments()
+
+	var environmentIds []int
+	for _, name := range []string{"environmentId", "endpointId"} {
+		if value, ok := args[name].(float64); ok {
+			environmentIds = append(environmentIds, int(value))
+		}
+	}
+
+	if values, ok := intSliceArgument(args, "environmentIds"); ok {
+		environmentIds = append(environmentIds, values...)
+	}
+
+	var resolved []int
+	ok := true
+
+	switch toolName {
+	case ToolUpdateEnvironmentTags, ToolUpdateEnvironmentUserAccesses, ToolUpdateEnvironmentTeamAccesses:
+		var id int
+		if id, ok = intArgument(args, "id"); ok {
+			resolved = []int{id}
+		}
+	case ToolGetStackFile, ToolDiffStack, ToolGetStackGitConfig, ToolUpdateStack, ToolRedeployGitStack,
+		ToolStartStack, ToolStopStack, ToolDeleteStack:
+		resolved, ok = stackEnvironments(ctx, cli, args)
+	case ToolUpdateAccessGroupName, ToolUpdateAccessGroupUserAccesses, ToolUpdateAccessGroupTeamAccesses:
+		resolved, ok = accessGroupEnvironments(ctx, cli, args)
+	case ToolUpdateEnvironmentGroupName, ToolUpdateEnvironmentGroupEnvironments, ToolUpdateEnvironmentGroupTags:
+		var id int
+		if id, ok = intArgument(args, "id"); ok {
+			resolved, ok = environmentGroupEnvironments(ctx, cli, []int{id})
+		}
+	case ToolCreateEdgeStack, ToolUpdateEdgeStack, ToolGetEdgeStackFile, ToolDeleteEdgeStack:
+		resolved, ok = edgeStackEnvironments(ctx, cli, toolName, args)
+	}
+
+	if !ok {
+		return nil, false
+	}
+
+	environmentIds = append(environmentIds, resolved...)
+	slices.Sort(environmentIds)
+
+	return slices.Compact(environmentIds), true
+}
+
+// stackEnvironments returns the environment of the stack of the call
+func stackEnvironments(ctx context.Context, cli PortainerClient, args map[string]any) ([]int, bool) {
+	id, ok := intArgument(args, "id")
+	if !ok {
+		return nil, false
+	}
+
+	stack, err := cli.GetStack(ctx, id)
+	if err != nil {
+		return nil, false
+	}
+
+	return []int{stack.EndpointID}, true
+}
+
+// accessGroupEnvironments returns the environments of the access group of the call
+func accessGroupEnvironments(ctx context.Context, cli PortainerClient, args map[string]any) ([]int, bool) {
+	id, ok := intArgument(args, "id")
+	if !ok {
+		return nil, false
+	}
+
+	groups, err := cli.GetAccessGroups(ctx)
+	if err != nil {
+		return nil, false
+	}
+
+	for _, group := range groups {
+		if group.ID == id {
+			return group.EnvironmentIds, true
+		}
+	}
+
+	return nil, false
+}
+
+// environmentGroupEnvironments returns the environments of the environment groups
+func environmentGroupEnvironments(ctx context.Context, cli PortainerClient, groupIds []int) ([]int, bool) {
+	if len(groupIds) == 0 {
+		return nil, true
+	}
+
+	groups, err := cli.GetEnvironmentGroups(ctx)
+	if err != nil {
+		return nil, false
+	}
+
+	var environmentIds []int
+	for _, id := range groupIds {
+		index := slices.IndexFunc(groups, func(group models.Group) bool { return group.ID == id })
+		if index < 0 {
+			return nil, false
+		}
+		environmentIds = append(environmentIds, groups[index].EnvironmentIds...)
+	}
+
+	return environmentIds, true
+}
+
+// edgeStackEnvironments returns the environments an edge stack is deployed to, before and after the call
+func edgeStackEnvironments(ctx context.Context, cli PortainerClient, toolName string, args map[string]any) ([]int, bool) {
+	groupIds, _ := intSliceArgument(args, "environmentGroupIds")
+
+	if toolName != ToolCreateEdgeStack {
+		id, ok := intArgument(args, "id")
+		if !ok {
+			return nil, false
+		}
+
+		edgeStack, err := cli.GetEdgeStack(ctx, id)
+		if err != nil {
+			return nil, false
+		}
+		groupIds = append(groupIds, edgeStack.EnvironmentGroupIds...)
+	}
+
+	return environmentGroupEnvironments(ctx, cli, groupIds)
+}
+
+// intArgument returns an integer argument of a tool call, which JSON decodes as a float64
+func intArgument(args map[string]any, name string) (int, bool) {
+	value, ok := args[name].(float64)
+	return int(value), ok
+}
+
+// intSliceArgument returns an array of integers argument of a tool call
+func intSliceArgument(args map[string]any, name string) ([]int, bool) {
+	values, ok := args[name].([]any)
+	if !ok {
+		return nil, false
+	}
+
+	ints := make([]int, 0, len(values))
+	for _, value := range values {
+		number, ok := value.(float64)
+		if !ok {
+			return nil, false
+		}
+		ints = append(ints, int(number))
+	}
+
+	return ints, true
+}
@@ -0,0 +1,284 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/portainer/portainer-mcp/internal/policy"
+	"github.com/portainer/portainer-mcp/pkg/portainer/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPolicy = `
+rules:
+  - name: no-prod-writes
+    effect: deny
+    tools: [deleteStack, dockerProxy]
+    methods: [POST, PUT, DELETE]
+    environmentTags: [prod]
+  - name: no-finance
+    effect: deny
+    accessGroups: [finance]
+`
+
+func TestWithPolicy(t *testing.T) {
+	tests := []struct {
+		name          string
+		toolName      string
+		args          map[string]any
+		mockSetup     func(*MockPortainerClient)
+		expectCalled  bool
+		errorContains string
+	}{
+		{
+			name:     "denied by environment tag",
+			toolName: ToolDeleteStack,
+			args:     map[string]any{"id": float64(1), "endpointId": float64(2)},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetStack", 1).Return(models.Stack{ID: 1, EndpointID: 2}, nil)
+				m.On("GetEnvironmentTags").Return([]models.EnvironmentTag{{ID: 1, Name: "prod", EnvironmentIds: []int{2}}}, nil)
+				m.On("GetAccessGroups").Return([]models.AccessGroup{}, nil)
+			},
+			errorContains: `denied by policy rule "no-prod-writes"`,
+		},
+		{
+			name:     "denied on the environment of the stack, not the given one",
+			toolName: ToolDeleteStack,
+			args:     map[string]any{"id": float64(1), "endpointId": float64(3)},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetStack", 1).Return(models.Stack{ID: 1, EndpointID: 2}, nil)
+				m.On("GetEnvironmentTags").Return([]models.EnvironmentTag{{ID: 1, Name: "prod", EnvironmentIds: []int{2}}}, nil)
+				m.On("GetAccessGroups").Return([]models.AccessGroup{}, nil)
+			},
+			errorContains: `denied by policy rule "no-prod-writes"`,
+		},
+		{
+			name:     "stack tool with a stack ID only",
+			toolName: ToolGetStackFile,
+			args:     map[string]any{"id": float64(1)},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetStack", 1).Return(models.Stack{ID: 1, EndpointID: 3}, nil)
+				m.On("GetEnvironmentTags").Return([]models.EnvironmentTag{}, nil)
+				m.On("GetAccessGroups").Return([]models.AccessGroup{{ID: 1, Name: "finance", EnvironmentIds: []int{3}}}, nil)
+			},
+			errorContains: `call to tool getStackFile denied by policy rule "no-finance"`,
+		},
+		{
+			name:     "unknown stack denied by environment rules",
+			toolName: ToolGetStackFile,
+			args:     map[string]any{"id": float64(9)},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetStack", 9).Return(models.Stack{}, errors.New("stack not found"))
+			},
+			errorContains: `denied by policy rule "no-finance"`,
+		},
+		{
+			name:     "edge stack denied on any environment of its groups",
+			toolName: ToolCreateEdgeStack,
+			args:     map[string]any{"name": "web", "environmentGroupIds": []any{float64(1), float64(2)}},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetEnvironmentGroups").Return([]models.Group{
+					{ID: 1, EnvironmentIds: []int{4}},
+					{ID: 2, EnvironmentIds: []int{5, 6}},
+				}, nil)
+				m.On("GetEnvironmentTags").Return([]models.EnvironmentTag{}, nil)
+				m.On("GetAccessGroups").Return([]models.AccessGroup{{ID: 1, Name: "finance", EnvironmentIds: []int{6}}}, nil)
+			},
+			errorContains: `denied by policy rule "no-finance"`,
+		},
+		{
+			name:     "edge stack deleted from the environments of its groups",
+			toolName: ToolDeleteEdgeStack,
+			args:     map[string]any{"id": float64(7)},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetEdgeStack", 7).Return(models.EdgeStack{ID: 7, EnvironmentGroupIds: []int{1}}, nil)
+				m.On("GetEnvironmentGroups").Return([]models.Group{{ID: 1, EnvironmentIds: []int{4}}}, nil)
+				m.On("GetEnvironmentTags").Return([]models.EnvironmentTag{}, nil)
+				m.On("GetAccessGroups").Return([]models.AccessGroup{}, nil)
+			},
+			expectCalled: true,
+		},
+		{
+			name:     "access group tool denied on the environments of the group",
+			toolName: ToolUpdateAccessGroupName,
+			args:     map[string]any{"id": float64(1), "name": "accounting"},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetEnvironmentTags").Return([]models.EnvironmentTag{}, nil)
+				m.On("GetAccessGroups").Return([]models.AccessGroup{{ID: 1, Name: "finance", EnvironmentIds: []int{3}}}, nil)
+			},
+			errorContains: `denied by policy rule "no-finance"`,
+		},
+		{
+			name:     "access group created with environments",
+			toolName: ToolCreateAccessGroup,
+			args:     map[string]any{"name": "accounting", "environmentIds": []any{float64(3)}},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetEnvironmentTags").Return([]models.EnvironmentTag{}, nil)
+				m.On("GetAccessGroups").Return([]models.AccessGroup{{ID: 1, Name: "finance", EnvironmentIds: []int{3}}}, nil)
+			},
+			errorContains: `denied by policy rule "no-finance"`,
+		},
+		{
+			name:     "proxy read allowed on prod",
+			toolName: ToolDockerProxy,
+			args:     map[string]any{"environmentId": float64(2), "method": "GET"},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetEnvironmentTags").Return([]models.EnvironmentTag{{ID: 1, Name: "prod", EnvironmentIds: []int{2}}}, nil)
+				m.On("GetAccessGroups").Return([]models.AccessGroup{}, nil)
+			},
+			expectCalled: true,
+		},
+		{
+			name:     "denied by access group",
+			toolName: ToolDockerProxy,
+			args:     map[string]any{"environmentId": float64(3), "method": "GET"},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetEnvironmentTags").Return([]models.EnvironmentTag{}, nil)
+				m.On("GetAccessGroups").Return([]models.AccessGroup{{ID: 1, Name: "finance", EnvironmentIds: []int{3}}}, nil)
+			},
+			errorContains: `denied by policy rule "no-finance"`,
+		},
+		{
+			name:         "call without environment",
+			toolName:     ToolListStacks,
+			args:         map[string]any{},
+			mockSetup:    func(m *MockPortainerClient) {},
+			expectCalled: true,
+		},
+		{
+			name:     "lookup failure denies the call",
+			toolName: ToolDeleteStack,
+			args:     map[string]any{"id": float64(1), "endpointId": float64(2)},
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetStack", 1).Return(models.Stack{ID: 1, EndpointID: 2}, nil)
+				m.On("GetEnvironmentTags").Return(nil, errors.New("api error"))
+			},
+			errorContains: "failed to evaluate policy",
+		},
+	}
+
+	p, err := policy.Parse([]byte(testPolicy))
+	require.NoError(t, err)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockPortainerClient{}
+			tt.mockSetup(mockClient)
+
+			called := false
+			s := &PortainerMCPServer{cli: mockClient, policy: p}
+			handler := s.withPolicy(tt.toolName, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				called = true
+				return mcp.NewToolResultText("ok"), nil
+			})
+
+			result, err := handler(context.Background(), CreateMCPRequest(tt.args))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectCalled, called)
+
+			if tt.errorContains != "" {
+				assert.True(t, result.IsError)
+				textContent, ok := result.Content[0].(mcp.TextContent)
+				require.True(t, ok)
+				assert.Contains(t, textContent.Text, tt.errorContains)
+			} else {
+				assert.False(t, result.IsError)
+			}
+
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestWithPolicyInstance(t *testing.T) {
+	p, err := policy.Parse([]byte(`
+rules:
+  - name: read-only-staging
+    effect: deny
+    tools: ["create*", "update*", "delete*"]
+    instances: [staging]
+`))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		args          map[string]any
+		expectCalled  bool
+		errorContains string
+	}{
+		{
+			name:         "default instance",
+			args:         map[string]any{},
+			expectCalled: true,
+		},
+		{
+			name:          "instance denied by the policy",
+			args:          map[string]any{InstanceParameter: "staging"},
+			errorContains: `call to tool deleteStack denied by policy rule "read-only-staging"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			s := &PortainerMCPServer{
+				cli:          &MockPortainerClient{},
+				policy:       p,
+				instanceName: "production",
+				capabilities: &Capabilities{},
+				instances: map[string]*instance{
+					"staging": {name: "staging", capabilities: &Capabilities{}, cli: &MockPortainerClient{}},
+				},
+			}
+			handler := s.withInstance(ToolDeleteStack, true, s.withPolicy(ToolDeleteStack, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				called = true
+				return mcp.NewToolResultText("ok"), nil
+			}))
+
+			result, err := handler(context.Background(), CreateMCPRequest(tt.args))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectCalled, called)
+
+			if tt.errorContains != "" {
+				assert.True(t, result.IsError)
+				assert.Contains(t, resultText(result), tt.errorContains)
+			} else {
+				assert.False(t, result.IsError)
+			}
+		})
+	}
+}
+
+func TestWithPolicyBypassesCache(t *testing.T) {
+	p, err := policy.Parse([]byte(testPolicy))
+	require.NoError(t, err)
+
+	mockClient := &MockPortainerClient{}
+	mockClient.On("GetEnvironmentTags").Return([]models.EnvironmentTag{}, nil).Once()
+	mockClient.On("GetEnvironmentTags").Return([]models.EnvironmentTag{{ID: 1, Name: "prod", EnvironmentIds: []int{2}}}, nil).Once()
+	mockClient.On("GetAccessGroups").Return([]models.AccessGroup{}, nil)
+
+	cache, err := newCacheConfig(time.Hour, nil)
+	require.NoError(t, err)
+
+	s := &PortainerMCPServer{cli: newCachingClient(mockClient, cache), policy: p}
+	handler := s.withPolicy(ToolDockerProxy, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("ok"), nil
+	})
+	request := CreateMCPRequest(map[string]any{"environmentId": float64(2), "method": "POST"})
+
+	result, err := handler(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+
+	// The environment is tagged prod after the first call, the policy must see the new tag
+	result, err = handler(context.Background(), request)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+
+	mockClient.AssertExpectations(t)
+}
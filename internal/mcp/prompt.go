@@ -0,0 +1,28 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/portainer/portainer-mcp/pkg/toolgen"
+)
+
+// AddPromptFeatures registers the prompt templates defined in the tools.yaml file
+func (s *PortainerMCPServer) AddPromptFeatures() {
+	for _, prompt := range s.prompts {
+		s.srv.AddPrompt(prompt.Prompt, s.HandleGetPrompt(prompt))
+	}
+}
+
+func (s *PortainerMCPServer) HandleGetPrompt(prompt toolgen.Prompt) server.PromptHandlerFunc {
+	return func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		messages, err := prompt.Render(request.Params.Arguments)
+		if err != nil {
+			return nil, fmt.Errorf("invalid arguments for prompt %s: %w", prompt.Prompt.Name, err)
+		}
+
+		return mcp.NewGetPromptResult(prompt.Prompt.Description, messages), nil
+	}
+}
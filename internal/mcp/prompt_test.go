@@ -0,0 +1,51 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/portainer/portainer-mcp/pkg/toolgen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddPromptFeatures(t *testing.T) {
+	prompts, err := toolgen.LoadPromptsFromYAML("../tooldef/tools.yaml", MinimumToolsVersion)
+	require.NoError(t, err)
+	require.NotEmpty(t, prompts, "the embedded tools.yaml should define prompts")
+
+	s := &PortainerMCPServer{
+		srv:     server.NewMCPServer("Test Server", "1.0.0"),
+		prompts: prompts,
+	}
+	s.AddPromptFeatures()
+
+	for name := range prompts {
+		handler := s.HandleGetPrompt(prompts[name])
+		_, err := handler(context.Background(), mcp.GetPromptRequest{})
+		assert.Error(t, err, "prompt %s should require arguments", name)
+	}
+}
+
+func TestHandleGetPrompt(t *testing.T) {
+	prompts, err := toolgen.LoadPromptsFromYAML("../tooldef/tools.yaml", MinimumToolsVersion)
+	require.NoError(t, err)
+
+	prompt, ok := prompts["troubleshootStack"]
+	require.True(t, ok)
+
+	s := &PortainerMCPServer{}
+	handler := s.HandleGetPrompt(prompt)
+
+	request := mcp.GetPromptRequest{}
+	request.Params.Arguments = map[string]string{"stackId": "42"}
+
+	result, err := handler(context.Background(), request)
+	require.NoError(t, err)
+	assert.Equal(t, prompt.Prompt.Description, result.Description)
+	require.Len(t, result.Messages, 1)
+	assert.Equal(t, mcp.RoleUser, result.Messages[0].Role)
+	assert.Contains(t, result.Messages[0].Content.(mcp.TextContent).Text, "stack with ID 42")
+}
@@ -0,0 +1,361 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/portainer/portainer-mcp/pkg/portainer/models"
+	"github.com/rs/zerolog/log"
+)
+
+// Resource URIs and URI templates
+const (
+	ResourceEnvironments             = "portainer://environments"
+	ResourceStacks                   = "portainer://stacks"
+	ResourceTeams                    = "portainer://teams"
+	ResourceUsers                    = "portainer://users"
+	ResourceTemplateStackFile        = "portainer://stacks/{id}/file"
+	ResourceTemplateDockerContainers = "portainer://environments/{id}/docker/containers"
+	resourceScheme                   = "portainer://"
+	resourceMIMETypeJSON             = "application/json"
+	resourceMIMETypeYAML             = "application/yaml"
+)
+
+// AddResourceFeatures registers Portainer entities as MCP resources and resource templates.
+// Resources are optional and complement the tools, see design 202610-1. The resources of the
+// additional Portainer instances are registered under portainer://instances/{name}/.
+func (s *PortainerMCPServer) AddResourceFeatures() {
+	s.addInstanceResources(nil)
+	for _, name := range slices.Sorted(maps.Keys(s.instances)) {
+		s.addInstanceResources(s.instances[name])
+	}
+}
+
+// addInstanceResources registers the resources of a Portainer instance, nil for the default one
+func (s *PortainerMCPServer) addInstanceResources(inst *instance) {
+	s.addResource(inst,
+		mcp.NewResource(instanceResourceURI(inst, ResourceEnvironments), instanceResourceName(inst, "Environments"),
+			mcp.WithResourceDescription("All environments available in Portainer"),
+			mcp.WithMIMEType(resourceMIMETypeJSON),
+		),
+		ToolListEnvironments,
+		s.HandleEnvironmentsResource(),
+	)
+	s.addResource(inst,
+		mcp.NewResource(instanceResourceURI(inst, ResourceStacks), instanceResourceName(inst, "Stacks"),
+			mcp.WithResourceDescription("All stacks available in Portainer"),
+			mcp.WithMIMEType(resourceMIMETypeJSON),
+		),
+		ToolListStacks,
+		s.HandleStacksResource(),
+	)
+	s.addResource(inst,
+		mcp.NewResource(instanceResourceURI(inst, ResourceTeams), instanceResourceName(inst, "Teams"),
+			mcp.WithResourceDescription("All teams available in Portainer"),
+			mcp.WithMIMEType(resourceMIMETypeJSON),
+		),
+		ToolListTeams,
+		s.HandleTeamsResource(),
+	)
+	s.addResource(inst,
+		mcp.NewResource(instanceResourceURI(inst, ResourceUsers), instanceResourceName(inst, "Users"),
+			mcp.WithResourceDescription("All users available in Portainer"),
+			mcp.WithMIMEType(resourceMIMETypeJSON),
+		),
+		ToolListUsers,
+		s.HandleUsersResource(),
+	)
+	s.addResourceTemplate(inst,
+		mcp.NewResourceTemplate(instanceResourceURI(inst, ResourceTemplateStackFile), instanceResourceName(inst, "Stack file"),
+			mcp.WithTemplateDescription("The compose file of a stack"),
+			mcp.WithTemplateMIMEType(resourceMIMETypeYAML),
+		),
+		ToolGetStackFile,
+		func(id int) map[string]any { return map[string]any{"id": float64(id)} },
+		s.HandleStackFileResource(),
+	)
+	s.addResourceTemplate(inst,
+		mcp.NewResourceTemplate(instanceResourceURI(inst, ResourceTemplateDockerContainers), instanceResourceName(inst, "Docker containers"),
+			mcp.WithTemplateDescription("All containers of a Docker environment, including stopped ones"),
+			mcp.WithTemplateMIMEType(resourceMIMETypeJSON),
+		),
+		ToolDockerProxy,
+		func(id int) map[string]any {
+			return map[string]any{"environmentId": float64(id), "method": http.MethodGet}
+		},
+		s.HandleDockerContainersResource(),
+	)
+}
+
+// instanceResourceURI returns the URI of a resource of a Portainer instance, nil for the default one
+func instanceResourceURI(inst *instance, uri string) string {
+	if inst == nil {
+		return uri
+	}
+
+	return strings.Replace(uri, resourceScheme, resourceScheme+"instances/"+inst.name+"/", 1)
+}
+
+// instanceResourceName returns the name of a resource of a Portainer instance, nil for the default one
+func instanceResourceName(inst *instance, name string) string {
+	if inst == nil {
+		return name
+	}
+
+	return fmt.Sprintf("%s (%s)", name, inst.name)
+}
+
+func (s *PortainerMCPServer) HandleEnvironmentsResource() server.ResourceHandlerFunc {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		environments, err := s.client(ctx).GetEnvironments(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get environments: %w", err)
+		}
+
+		return jsonResourceContents(request.Params.URI, environments)
+	}
+}
+
+func (s *PortainerMCPServer) HandleStacksResource() server.ResourceHandlerFunc {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		stacks, err := s.client(ctx).GetStacks(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get stacks: %w", err)
+		}
+
+		return jsonResourceContents(request.Params.URI, stacks)
+	}
+}
+
+func (s *PortainerMCPServer) HandleTeamsResource() server.ResourceHandlerFunc {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		teams, err := s.client(ctx).GetTeams(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get teams: %w", err)
+		}
+
+		return jsonResourceContents(request.Params.URI, teams)
+	}
+}
+
+func (s *PortainerMCPServer) HandleUsersResource() server.ResourceHandlerFunc {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		users, err := s.client(ctx).GetUsers(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get users: %w", err)
+		}
+
+		return jsonResourceContents(request.Params.URI, users)
+	}
+}
+
+func (s *PortainerMCPServer) HandleStackFileResource() server.ResourceTemplateHandlerFunc {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		id, err := getResourceIntArgument(request, "id")
+		if err != nil {
+			return nil, err
+		}
+
+		stackFile, err := s.client(ctx).GetStackFile(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get stack file: %w", err)
+		}
+
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{
+				URI:      request.Params.URI,
+				MIMEType: resourceMIMETypeYAML,
+				Text:     stackFile,
+			},
+		}, nil
+	}
+}
+
+func (s *PortainerMCPServer) HandleDockerContainersResource() server.ResourceTemplateHandlerFunc {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		environmentId, err := getResourceIntArgument(request, "id")
+		if err != nil {
+			return nil, err
+		}
+
+		response, err := s.client(ctx).ProxyDockerRequest(ctx, models.DockerProxyRequestOptions{
+			EnvironmentID: environmentId,
+			Path:          "/containers/json",
+			Method:        http.MethodGet,
+			QueryParams:   map[string]string{"all": "true"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to send Docker API request: %w", err)
+		}
+		defer response.Body.Close()
+
+		responseBody, err := io.ReadAll(response.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Docker API response: %w", err)
+		}
+
+		if response.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("docker API returned status %d: %s", response.StatusCode, string(responseBody))
+		}
+
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{
+				URI:      request.Params.URI,
+				MIMEType: resourceMIMETypeJSON,
+				Text:     string(responseBody),
+			},
+		}, nil
+	}
+}
+
+// addResource adds a resource of a Portainer instance to the server, nil for the default one,
+// using the Portainer client of the calling session. Reads are evaluated against the policy and
+// recorded in the audit log like a call of the equivalent tool, and are aborted after
+// DefaultToolTimeout, like the calls of the tools without a timeout.
+// The resource is only added when the equivalent tool is available, see resourceAvailable.
+func (s *PortainerMCPServer) addResource(inst *instance, resource mcp.Resource, toolName string, handler server.ResourceHandlerFunc) {
+	if !s.resourceAvailable(inst, resource.URI, toolName) {
+		return
+	}
+
+	s.srv.AddResource(resource, func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		return s.readResource(ctx, inst, request, toolName, nil, handler)
+	})
+}
+
+// addResourceTemplate adds a resource template to the server, see addResource. toolArguments returns
+// the arguments of the call of the equivalent tool from the ID matched by the template.
+func (s *PortainerMCPServer) addResourceTemplate(inst *instance, template mcp.ResourceTemplate, toolName string, toolArguments func(id int) map[string]any, handler server.ResourceTemplateHandlerFunc) {
+	if !s.resourceAvailable(inst, template.URITemplate.Raw(), toolName) {
+		return
+	}
+
+	s.srv.AddResourceTemplate(template, func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		id, err := getResourceIntArgument(request, "id")
+		if err != nil {
+			return nil, err
+		}
+
+		return s.readResource(ctx, inst, request, toolName, toolArguments(id), server.ResourceHandlerFunc(handler))
+	})
+}
+
+// resourceAvailable reports whether a resource of a Portainer instance, nil for the default one,
+// can be served. Like the tools, the equivalent tool must be defined in the tools file and the
+// instance must provide the capabilities it requires.
+func (s *PortainerMCPServer) resourceAvailable(inst *instance, uri, toolName string) bool {
+	if _, exists := s.tools[toolName]; !exists {
+		log.Debug().Str("resource", uri).Str("tool", toolName).Msg("resource not registered, its tool is not defined in the tools file")
+		return false
+	}
+
+	capabilities := s.capabilities
+	if inst != nil {
+		capabilities = inst.capabilities
+	}
+
+	if missing, ok := capabilities.missingCapability(toolName); ok {
+		log.Debug().Str("resource", uri).Str("capability", string(missing)).Msg("resource not registered, the Portainer instance does not provide a capability it requires")
+		return false
+	}
+
+	return true
+}
+
+// readResource reads a resource of a Portainer instance and records the read in the audit log
+func (s *PortainerMCPServer) readResource(ctx context.Context, inst *instance, request mcp.ReadResourceRequest, toolName string, toolArguments map[string]any, handler server.ResourceHandlerFunc) ([]mcp.ResourceContents, error) {
+	if inst != nil {
+		ctx = context.WithValue(ctx, instanceKey{}, inst)
+	}
+
+	start := time.Now()
+	contents, err := s.readInstanceResource(ctx, request, toolName, toolArguments, handler)
+	s.auditResourceRead(ctx, request.Params.URI, toolName, toolArguments, start, err)
+
+	return contents, err
+}
+
+// readInstanceResource reads a resource with the client of the Portainer instance, or of the
+// calling session, once the read is allowed by the policy for the equivalent tool call
+func (s *PortainerMCPServer) readInstanceResource(ctx context.Context, request mcp.ReadResourceRequest, toolName string, toolArguments map[string]any, handler server.ResourceHandlerFunc) ([]mcp.ResourceContents, error) {
+	ctx, cancel := context.WithTimeout(ctx, DefaultToolTimeout)
+	defer cancel()
+
+	ctx, err := s.resourceContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.policy != nil {
+		if err := s.checkPolicy(ctx, toolName, CreateMCPRequest(toolArguments)); err != nil {
+			return nil, fmt.Errorf("read of resource %s denied: %w", request.Params.URI, err)
+		}
+	}
+
+	return handler(ctx, request)
+}
+
+// resourceContext returns the context to use when reading a resource.
+// It carries the Portainer client of the calling session when per-session credentials are enabled.
+func (s *PortainerMCPServer) resourceContext(ctx context.Context) (context.Context, error) {
+	if s.sessions == nil {
+		return ctx, nil
+	}
+
+	ctx, err := s.sessionContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to authenticate session: %w", err)
+	}
+
+	return ctx, nil
+}
+
+// jsonResourceContents marshals a value as the JSON content of a resource
+func jsonResourceContents(uri string, v any) ([]mcp.ResourceContents, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal resource: %w", err)
+	}
+
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      uri,
+			MIMEType: resourceMIMETypeJSON,
+			Text:     string(data),
+		},
+	}, nil
+}
+
+// getResourceIntArgument extracts an integer variable matched by a resource URI template
+func getResourceIntArgument(request mcp.ReadResourceRequest, name string) (int, error) {
+	var raw string
+	switch value := request.Params.Arguments[name].(type) {
+	case string:
+		raw = value
+	case []string:
+		if len(value) == 1 {
+			raw = value[0]
+		}
+	}
+
+	if raw == "" {
+		return 0, fmt.Errorf("%s is required", name)
+	}
+
+	id, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer: %s", name, raw)
+	}
+
+	return id, nil
+}
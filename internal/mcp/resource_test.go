@@ -0,0 +1,404 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/portainer/portainer-mcp/internal/audit"
+	"github.com/portainer/portainer-mcp/internal/policy"
+	"github.com/portainer/portainer-mcp/pkg/portainer/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// resourceTools returns the tools equivalent to the resources, without which the resources are not registered
+func resourceTools() map[string]mcp.Tool {
+	tools := map[string]mcp.Tool{}
+	for _, name := range []string{ToolListEnvironments, ToolListStacks, ToolListTeams, ToolListUsers, ToolGetStackFile, ToolDockerProxy} {
+		tools[name] = mcp.NewTool(name)
+	}
+	return tools
+}
+
+func newResourceTestServer(mockClient *MockPortainerClient) *PortainerMCPServer {
+	s := &PortainerMCPServer{
+		srv:   server.NewMCPServer("Test Server", "1.0.0"),
+		cli:   mockClient,
+		tools: resourceTools(),
+	}
+	s.AddResourceFeatures()
+	return s
+}
+
+// readResource sends a resources/read message through the MCP server so that
+// URI template matching is exercised
+func readResource(t *testing.T, s *PortainerMCPServer, uri string) (*mcp.ReadResourceResult, *mcp.JSONRPCError) {
+	message, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "resources/read",
+		"params":  map[string]any{"uri": uri},
+	})
+	require.NoError(t, err)
+
+	switch response := s.srv.HandleMessage(context.Background(), message).(type) {
+	case mcp.JSONRPCResponse:
+		result, ok := response.Result.(mcp.ReadResourceResult)
+		require.True(t, ok)
+		return &result, nil
+	case mcp.JSONRPCError:
+		return nil, &response
+	default:
+		t.Fatalf("unexpected response type %T", response)
+		return nil, nil
+	}
+}
+
+func TestResources(t *testing.T) {
+	tests := []struct {
+		name          string
+		uri           string
+		mockSetup     func(*MockPortainerClient)
+		expectedMIME  string
+		expectedText  string
+		errorContains string
+	}{
+		{
+			name: "environments",
+			uri:  ResourceEnvironments,
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetEnvironments").Return([]models.Environment{{ID: 1, Name: "local"}}, nil)
+			},
+			expectedMIME: resourceMIMETypeJSON,
+			expectedText: `"name":"local"`,
+		},
+		{
+			name: "stacks",
+			uri:  ResourceStacks,
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetStacks").Return([]models.Stack{{ID: 3, Name: "web"}}, nil)
+			},
+			expectedMIME: resourceMIMETypeJSON,
+			expectedText: `"name":"web"`,
+		},
+		{
+			name: "teams",
+			uri:  ResourceTeams,
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetTeams").Return([]models.Team{{ID: 1, Name: "devs"}}, nil)
+			},
+			expectedMIME: resourceMIMETypeJSON,
+			expectedText: `"name":"devs"`,
+		},
+		{
+			name: "users",
+			uri:  ResourceUsers,
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetUsers").Return([]models.User{{ID: 1, Username: "admin"}}, nil)
+			},
+			expectedMIME: resourceMIMETypeJSON,
+			expectedText: `"username":"admin"`,
+		},
+		{
+			name: "stack file",
+			uri:  "portainer://stacks/3/file",
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetStackFile", 3).Return("services:\n  web:\n    image: nginx", nil)
+			},
+			expectedMIME: resourceMIMETypeYAML,
+			expectedText: "image: nginx",
+		},
+		{
+			name: "docker containers",
+			uri:  "portainer://environments/2/docker/containers",
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("ProxyDockerRequest", mock.MatchedBy(func(opts models.DockerProxyRequestOptions) bool {
+					return opts.EnvironmentID == 2 &&
+						opts.Method == "GET" &&
+						opts.Path == "/containers/json" &&
+						opts.QueryParams["all"] == "true"
+				})).Return(createMockHttpResponse(200, `[{"Id":"abc"}]`), nil)
+			},
+			expectedMIME: resourceMIMETypeJSON,
+			expectedText: `[{"Id":"abc"}]`,
+		},
+		{
+			name: "docker containers with error status",
+			uri:  "portainer://environments/2/docker/containers",
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("ProxyDockerRequest", mock.Anything).Return(createMockHttpResponse(404, "not a docker environment"), nil)
+			},
+			errorContains: "status 404",
+		},
+		{
+			name: "stack file with invalid id",
+			uri:  "portainer://stacks/abc/file",
+			mockSetup: func(m *MockPortainerClient) {
+			},
+			errorContains: "id must be an integer",
+		},
+		{
+			name: "api error",
+			uri:  ResourceEnvironments,
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetEnvironments").Return(nil, fmt.Errorf("api error"))
+			},
+			errorContains: "api error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockPortainerClient{}
+			tt.mockSetup(mockClient)
+
+			result, rpcErr := readResource(t, newResourceTestServer(mockClient), tt.uri)
+
+			if tt.errorContains != "" {
+				require.NotNil(t, rpcErr)
+				assert.Contains(t, rpcErr.Error.Message, tt.errorContains)
+			} else {
+				require.Nil(t, rpcErr)
+				require.Len(t, result.Contents, 1)
+				contents, ok := result.Contents[0].(mcp.TextResourceContents)
+				require.True(t, ok)
+				assert.Equal(t, tt.uri, contents.URI)
+				assert.Equal(t, tt.expectedMIME, contents.MIMEType)
+				assert.Contains(t, contents.Text, tt.expectedText)
+			}
+
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestResourcesWithSessionCredentials(t *testing.T) {
+	sessionClient := &MockPortainerClient{}
+	sessionClient.On("GetTeams").Return([]models.Team{{ID: 1, Name: "session-team"}}, nil)
+
+	s := &PortainerMCPServer{
+		cli: &MockPortainerClient{},
+		sessions: newSessionClients(func(token string) PortainerClient {
+			return sessionClient
+		}),
+	}
+
+	handler := s.HandleTeamsResource()
+
+	_, err := s.resourceContext(context.Background())
+	assert.Error(t, err)
+
+	ctx, err := s.resourceContext(sessionCtx(server.NewMCPServer("Test Server", "1.0.0"), "session-1", "user-token"))
+	require.NoError(t, err)
+
+	contents, err := handler(ctx, mcp.ReadResourceRequest{Params: mcp.ReadResourceParams{URI: ResourceTeams}})
+	require.NoError(t, err)
+	require.Len(t, contents, 1)
+	assert.Contains(t, contents[0].(mcp.TextResourceContents).Text, "session-team")
+
+	sessionClient.AssertExpectations(t)
+}
+
+func TestResourcesWithPolicy(t *testing.T) {
+	p, err := policy.Parse([]byte(`
+rules:
+  - name: no-prod-docker
+    description: production is managed by CI
+    effect: deny
+    tools: [dockerProxy, getStackFile]
+    environmentTags: [prod]
+  - name: no-users
+    effect: deny
+    tools: [listUsers]
+`))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		uri           string
+		mockSetup     func(*MockPortainerClient)
+		errorContains string
+	}{
+		{
+			name: "containers of a prod environment",
+			uri:  "portainer://environments/2/docker/containers",
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetEnvironmentTags").Return([]models.EnvironmentTag{{ID: 1, Name: "prod", EnvironmentIds: []int{2}}}, nil)
+			},
+			errorContains: `read of resource portainer://environments/2/docker/containers denied: call to tool dockerProxy denied by policy rule "no-prod-docker" (production is managed by CI)`,
+		},
+		{
+			name: "file of a stack on a prod environment",
+			uri:  "portainer://stacks/1/file",
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetStack", 1).Return(models.Stack{ID: 1, EndpointID: 2}, nil)
+				m.On("GetEnvironmentTags").Return([]models.EnvironmentTag{{ID: 1, Name: "prod", EnvironmentIds: []int{2}}}, nil)
+			},
+			errorContains: `call to tool getStackFile denied by policy rule "no-prod-docker"`,
+		},
+		{
+			name:          "users",
+			uri:           ResourceUsers,
+			mockSetup:     func(m *MockPortainerClient) {},
+			errorContains: `call to tool listUsers denied by policy rule "no-users"`,
+		},
+		{
+			name: "file of a stack on another environment",
+			uri:  "portainer://stacks/1/file",
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetStack", 1).Return(models.Stack{ID: 1, EndpointID: 3}, nil)
+				m.On("GetEnvironmentTags").Return([]models.EnvironmentTag{{ID: 1, Name: "prod", EnvironmentIds: []int{2}}}, nil)
+				m.On("GetStackFile", 1).Return("services: {}", nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockPortainerClient{}
+			tt.mockSetup(mockClient)
+
+			s := newResourceTestServer(mockClient)
+			s.policy = p
+
+			result, rpcErr := readResource(t, s, tt.uri)
+
+			if tt.errorContains != "" {
+				require.NotNil(t, rpcErr)
+				assert.Contains(t, rpcErr.Error.Message, tt.errorContains)
+			} else {
+				require.Nil(t, rpcErr)
+				require.Len(t, result.Contents, 1)
+			}
+
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestResourcesAudit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	logger, err := audit.NewLogger(path, 0)
+	require.NoError(t, err)
+
+	mockClient := &MockPortainerClient{}
+	mockClient.On("ProxyDockerRequest", mock.Anything).Return(nil, errors.New("environment unreachable"))
+	mockClient.On("GetTeams").Return([]models.Team{{ID: 1, Name: "devs"}}, nil)
+
+	s := newResourceTestServer(mockClient)
+	s.audit = logger
+
+	_, rpcErr := readResource(t, s, "portainer://environments/3/docker/containers")
+	require.NotNil(t, rpcErr)
+	_, rpcErr = readResource(t, s, ResourceTeams)
+	require.Nil(t, rpcErr)
+	require.NoError(t, logger.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+
+	var entry audit.Entry
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+	assert.Equal(t, ToolDockerProxy, entry.Tool)
+	assert.Equal(t, "portainer://environments/3/docker/containers", entry.Resource)
+	assert.Equal(t, intPtr(3), entry.EnvironmentID)
+	assert.Equal(t, audit.StatusError, entry.Status)
+	assert.Contains(t, entry.Error, "environment unreachable")
+
+	entry = audit.Entry{}
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &entry))
+	assert.Equal(t, ToolListTeams, entry.Tool)
+	assert.Equal(t, ResourceTeams, entry.Resource)
+	assert.Equal(t, audit.StatusSuccess, entry.Status)
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestInstanceResources(t *testing.T) {
+	defaultClient := &MockPortainerClient{}
+	defaultClient.On("GetTeams").Return([]models.Team{{ID: 1, Name: "default-team"}}, nil)
+	stagingClient := &MockPortainerClient{}
+	stagingClient.On("GetTeams").Return([]models.Team{{ID: 1, Name: "staging-team"}}, nil)
+	stagingClient.On("GetStackFile", 4).Return("services: {}", nil)
+
+	s := &PortainerMCPServer{
+		srv:       server.NewMCPServer("Test Server", "1.0.0"),
+		cli:       defaultClient,
+		tools:     resourceTools(),
+		instances: map[string]*instance{"staging": {name: "staging", cli: stagingClient}},
+	}
+	s.AddResourceFeatures()
+
+	result, rpcErr := readResource(t, s, ResourceTeams)
+	require.Nil(t, rpcErr)
+	assert.Contains(t, result.Contents[0].(mcp.TextResourceContents).Text, "default-team")
+
+	result, rpcErr = readResource(t, s, "portainer://instances/staging/teams")
+	require.Nil(t, rpcErr)
+	assert.Contains(t, result.Contents[0].(mcp.TextResourceContents).Text, "staging-team")
+
+	result, rpcErr = readResource(t, s, "portainer://instances/staging/stacks/4/file")
+	require.Nil(t, rpcErr)
+	assert.Equal(t, "services: {}", result.Contents[0].(mcp.TextResourceContents).Text)
+
+	defaultClient.AssertExpectations(t)
+	stagingClient.AssertExpectations(t)
+}
+
+func TestResourcesAvailability(t *testing.T) {
+	listResources := func(s *PortainerMCPServer) []string {
+		var uris []string
+		for _, method := range []string{"resources/list", "resources/templates/list"} {
+			message, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": method})
+			require.NoError(t, err)
+
+			response, ok := s.srv.HandleMessage(context.Background(), message).(mcp.JSONRPCResponse)
+			require.True(t, ok)
+
+			switch result := response.Result.(type) {
+			case mcp.ListResourcesResult:
+				for _, resource := range result.Resources {
+					uris = append(uris, resource.URI)
+				}
+			case mcp.ListResourceTemplatesResult:
+				for _, template := range result.ResourceTemplates {
+					uris = append(uris, template.URITemplate.Raw())
+				}
+			}
+		}
+		return uris
+	}
+
+	tools := resourceTools()
+	delete(tools, ToolListUsers)
+
+	s := &PortainerMCPServer{
+		srv:          server.NewMCPServer("Test Server", "1.0.0", server.WithResourceCapabilities(false, false)),
+		cli:          &MockPortainerClient{},
+		tools:        tools,
+		capabilities: &Capabilities{Docker: true},
+		instances: map[string]*instance{
+			"kubernetes": {name: "kubernetes", capabilities: &Capabilities{Kubernetes: true}},
+		},
+	}
+	s.AddResourceFeatures()
+
+	uris := listResources(s)
+	assert.NotContains(t, uris, ResourceUsers, "listUsers is not in the tools file")
+	assert.NotContains(t, uris, "portainer://instances/kubernetes/users")
+	assert.Contains(t, uris, ResourceTeams)
+	assert.Contains(t, uris, ResourceTemplateDockerContainers)
+	assert.NotContains(t, uris, "portainer://instances/kubernetes/environments/{id}/docker/containers", "the instance has no Docker environment")
+	assert.Contains(t, uris, "portainer://instances/kubernetes/stacks/{id}/file")
+}
@@ -21,6 +21,16 @@ const (
 	ToolStartStack                         = "startStack"
 	ToolStopStack                          = "stopStack"
 	ToolDeleteStack                        = "deleteStack"
+	ToolCreateGitStack                     = "createGitStack"
+	ToolRedeployGitStack                   = "redeployGitStack"
+	ToolGetStackGitConfig                  = "getStackGitConfig"
+	ToolDiffStack                          = "diffStack"
+	ToolValidateCompose                    = "validateCompose"
+	ToolListEdgeStacks                     = "listEdgeStacks"
+	ToolGetEdgeStackFile                   = "getEdgeStackFile"
+	ToolCreateEdgeStack                    = "createEdgeStack"
+	ToolUpdateEdgeStack                    = "updateEdgeStack"
+	ToolDeleteEdgeStack                    = "deleteEdgeStack"
 	ToolCreateEnvironmentTag               = "createEnvironmentTag"
 	ToolListEnvironmentTags                = "listEnvironmentTags"
 	ToolCreateTeam                         = "createTeam"
@@ -1,82 +1,108 @@
 package mcp
 
 import (
+	"context"
 	"fmt"
-	"log"
 	"net/http"
+	"slices"
+	"sync"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/portainer/portainer-mcp/internal/audit"
+	"github.com/portainer/portainer-mcp/internal/metrics"
+	"github.com/portainer/portainer-mcp/internal/policy"
 	"github.com/portainer/portainer-mcp/pkg/portainer/client"
 	"github.com/portainer/portainer-mcp/pkg/portainer/models"
 	"github.com/portainer/portainer-mcp/pkg/toolgen"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
 	// MinimumToolsVersion is the minimum supported version of the tools.yaml file
 	MinimumToolsVersion = "1.0"
-	// SupportedPortainerVersion is the version of Portainer that is supported by this tool
+	// SupportedPortainerVersion is the version of Portainer that this tool is built and tested against
 	SupportedPortainerVersion = "2.31.2"
+	// MinimumPortainerVersion is the lowest Portainer version accepted by the version check
+	MinimumPortainerVersion = "2.31.0"
+	// MaximumPortainerVersion is the first Portainer version no longer accepted by the version check
+	MaximumPortainerVersion = "2.32.0"
+	// StartupCheckTimeout bounds the version check and the capability detection at startup
+	StartupCheckTimeout = 30 * time.Second
 )
 
 // PortainerClient defines the interface for the wrapper client used by the MCP server
 type PortainerClient interface {
 	// Tag methods
-	GetEnvironmentTags() ([]models.EnvironmentTag, error)
-	CreateEnvironmentTag(name string) (int, error)
+	GetEnvironmentTags(ctx context.Context) ([]models.EnvironmentTag, error)
+	CreateEnvironmentTag(ctx context.Context, name string) (int, error)
 
 	// Environment methods
-	GetEnvironments() ([]models.Environment, error)
-	UpdateEnvironmentTags(id int, tagIds []int) error
-	UpdateEnvironmentUserAccesses(id int, userAccesses map[int]string) error
-	UpdateEnvironmentTeamAccesses(id int, teamAccesses map[int]string) error
+	GetEnvironments(ctx context.Context) ([]models.Environment, error)
+	UpdateEnvironmentTags(ctx context.Context, id int, tagIds []int) error
+	UpdateEnvironmentUserAccesses(ctx context.Context, id int, userAccesses map[int]string) error
+	UpdateEnvironmentTeamAccesses(ctx context.Context, id int, teamAccesses map[int]string) error
 
 	// Environment Group methods
-	GetEnvironmentGroups() ([]models.Group, error)
-	CreateEnvironmentGroup(name string, environmentIds []int) (int, error)
-	UpdateEnvironmentGroupName(id int, name string) error
-	UpdateEnvironmentGroupEnvironments(id int, environmentIds []int) error
-	UpdateEnvironmentGroupTags(id int, tagIds []int) error
+	GetEnvironmentGroups(ctx context.Context) ([]models.Group, error)
+	CreateEnvironmentGroup(ctx context.Context, name string, environmentIds []int) (int, error)
+	UpdateEnvironmentGroupName(ctx context.Context, id int, name string) error
+	UpdateEnvironmentGroupEnvironments(ctx context.Context, id int, environmentIds []int) error
+	UpdateEnvironmentGroupTags(ctx context.Context, id int, tagIds []int) error
 
 	// Access Group methods
-	GetAccessGroups() ([]models.AccessGroup, error)
-	CreateAccessGroup(name string, environmentIds []int) (int, error)
-	UpdateAccessGroupName(id int, name string) error
-	UpdateAccessGroupUserAccesses(id int, userAccesses map[int]string) error
-	UpdateAccessGroupTeamAccesses(id int, teamAccesses map[int]string) error
-	AddEnvironmentToAccessGroup(id int, environmentId int) error
-	RemoveEnvironmentFromAccessGroup(id int, environmentId int) error
+	GetAccessGroups(ctx context.Context) ([]models.AccessGroup, error)
+	CreateAccessGroup(ctx context.Context, name string, environmentIds []int) (int, error)
+	UpdateAccessGroupName(ctx context.Context, id int, name string) error
+	UpdateAccessGroupUserAccesses(ctx context.Context, id int, userAccesses map[int]string) error
+	UpdateAccessGroupTeamAccesses(ctx context.Context, id int, teamAccesses map[int]string) error
+	AddEnvironmentToAccessGroup(ctx context.Context, id int, environmentId int) error
+	RemoveEnvironmentFromAccessGroup(ctx context.Context, id int, environmentId int) error
 
 	// Stack methods
-	GetStacks() ([]models.Stack, error)
-	GetStackFile(id int) (string, error)
-	CreateStack(name string, file string, endpointId int) (int, error)
-	UpdateStack(id int, file string, endpointId int, pullImage bool) error
-	StartStack(id int, endpointId int) error
-	StopStack(id int, endpointId int) error
-	DeleteStack(id int, endpointId int) error
+	GetStacks(ctx context.Context) ([]models.Stack, error)
+	GetStackFile(ctx context.Context, id int) (string, error)
+	CreateStack(ctx context.Context, name string, file string, endpointId int, env map[string]string) (int, error)
+	UpdateStack(ctx context.Context, id int, file string, endpointId int, pullImage bool, env map[string]string) error
+	StartStack(ctx context.Context, id int, endpointId int) error
+	StopStack(ctx context.Context, id int, endpointId int) error
+	DeleteStack(ctx context.Context, id int, endpointId int) error
+	GetStack(ctx context.Context, id int) (models.Stack, error)
+	CreateGitStack(ctx context.Context, name string, endpointId int, repository models.StackGitRepository, env map[string]string) (int, error)
+	RedeployGitStack(ctx context.Context, id int, endpointId int, pullImage bool, env map[string]string) error
+
+	// Edge Stack methods
+	GetEdgeStacks(ctx context.Context) ([]models.EdgeStack, error)
+	GetEdgeStack(ctx context.Context, id int) (models.EdgeStack, error)
+	GetEdgeStackFile(ctx context.Context, id int) (string, error)
+	CreateEdgeStack(ctx context.Context, name string, file string, environmentGroupIds []int) (int, error)
+	UpdateEdgeStack(ctx context.Context, id int, file string, environmentGroupIds []int) error
+	DeleteEdgeStack(ctx context.Context, id int) error
 
 	// Team methods
-	CreateTeam(name string) (int, error)
-	GetTeams() ([]models.Team, error)
-	UpdateTeamName(id int, name string) error
-	UpdateTeamMembers(id int, userIds []int) error
+	CreateTeam(ctx context.Context, name string) (int, error)
+	GetTeams(ctx context.Context) ([]models.Team, error)
+	UpdateTeamName(ctx context.Context, id int, name string) error
+	UpdateTeamMembers(ctx context.Context, id int, userIds []int) error
 
 	// User methods
-	GetUsers() ([]models.User, error)
-	UpdateUserRole(id int, role string) error
+	GetUsers(ctx context.Context) ([]models.User, error)
+	UpdateUserRole(ctx context.Context, id int, role string) error
 
 	// Settings methods
-	GetSettings() (models.PortainerSettings, error)
+	GetSettings(ctx context.Context) (models.PortainerSettings, error)
 
 	// Version methods
-	GetVersion() (string, error)
+	GetVersion(ctx context.Context) (string, error)
+	GetEdition(ctx context.Context) (string, error)
 
 	// Docker Proxy methods
-	ProxyDockerRequest(opts models.DockerProxyRequestOptions) (*http.Response, error)
+	ProxyDockerRequest(ctx context.Context, opts models.DockerProxyRequestOptions) (*http.Response, error)
 
 	// Kubernetes Proxy methods
-	ProxyKubernetesRequest(opts models.KubernetesProxyRequestOptions) (*http.Response, error)
+	ProxyKubernetesRequest(ctx context.Context, opts models.KubernetesProxyRequestOptions) (*http.Response, error)
 }
 
 // PortainerMCPServer is the main server that handles MCP protocol communication
@@ -84,8 +110,27 @@ type PortainerClient interface {
 type PortainerMCPServer struct {
 	srv      *server.MCPServer
 	cli      PortainerClient
+	cliMu    sync.RWMutex
+	factory  ClientFactory
 	tools    map[string]mcp.Tool
+	prompts  map[string]toolgen.Prompt
 	readOnly bool
+	dryRun   bool
+	calls    callTracker
+	sessions *sessionClients
+	audit    *audit.Logger
+	metrics  *metrics.Metrics
+	tracer   trace.Tracer
+	policy   *policy.Policy
+	logging  *clientLogging
+
+	confirmations *confirmationStore
+	instanceName  string
+	instances     map[string]*instance
+	capabilities  *Capabilities
+	cache         cacheConfig
+	timeouts      map[string]time.Duration
+	cancellations *cancellations
 }
 
 // ServerOption is a function that configures the server
@@ -94,8 +139,24 @@ type ServerOption func(*serverOptions)
 // serverOptions contains all configurable options for the server
 type serverOptions struct {
 	client              PortainerClient
+	clientFactory       ClientFactory
+	tls                 TLSOptions
+	username            string
+	password            string
 	readOnly            bool
+	dryRun              bool
 	disableVersionCheck bool
+	sessionCredentials  bool
+	auditLogger         *audit.Logger
+	metrics             *metrics.Metrics
+	tracerProvider      trace.TracerProvider
+	policy              *policy.Policy
+	confirmDestructive  bool
+	cacheTTL            time.Duration
+	cacheTTLs           map[string]time.Duration
+	limits              client.Limits
+	instanceName        string
+	instances           []Instance
 }
 
 // WithClient sets a custom client for the server.
@@ -106,6 +167,51 @@ func WithClient(client PortainerClient) ServerOption {
 	}
 }
 
+// WithCredentials authenticates to the Portainer server with a username and a password instead of
+// the API token, for the accounts that cannot use API keys. The JWT issued by Portainer is kept in
+// memory and renewed when it expires. Per-session clients still use the token of their session.
+func WithCredentials(username, password string) ServerOption {
+	return func(opts *serverOptions) {
+		opts.username = username
+		opts.password = password
+	}
+}
+
+// WithSkipTLSVerify disables the verification of the TLS certificate of the Portainer server.
+// The certificate is verified by default, skipping the verification sends the API token
+// to whichever server answers on the URL.
+func WithSkipTLSVerify(skip bool) ServerOption {
+	return func(opts *serverOptions) {
+		opts.tls.SkipVerify = skip
+	}
+}
+
+// WithCACertificate verifies the certificate of the Portainer server against the CA
+// certificates of the given PEM bundle instead of the system roots.
+func WithCACertificate(path string) ServerOption {
+	return func(opts *serverOptions) {
+		opts.tls.CACertFile = path
+	}
+}
+
+// WithClientCertificate authenticates to the Portainer server, or to a reverse proxy
+// in front of it, with the given PEM certificate and private key (mutual TLS).
+func WithClientCertificate(certFile, keyFile string) ServerOption {
+	return func(opts *serverOptions) {
+		opts.tls.ClientCertFile = certFile
+		opts.tls.ClientKeyFile = keyFile
+	}
+}
+
+// WithPinnedCertificate only accepts a Portainer server presenting a certificate with the given
+// SHA-256 fingerprint, in hexadecimal. This replaces the verification of the certificate chain,
+// so that a self-signed certificate can be trusted.
+func WithPinnedCertificate(fingerprint string) ServerOption {
+	return func(opts *serverOptions) {
+		opts.tls.PinnedFingerprint = fingerprint
+	}
+}
+
 // WithReadOnly sets the server to read-only mode.
 // This will prevent the server from registering write tools.
 func WithReadOnly(readOnly bool) ServerOption {
@@ -114,6 +220,16 @@ func WithReadOnly(readOnly bool) ServerOption {
 	}
 }
 
+// WithDryRun sets the server to dry-run mode.
+// Write tools then validate their input and resolve the current state, but return
+// the Portainer calls they would make together with the before/after state instead
+// of making them.
+func WithDryRun(dryRun bool) ServerOption {
+	return func(opts *serverOptions) {
+		opts.dryRun = dryRun
+	}
+}
+
 // WithDisableVersionCheck disables the Portainer server version check.
 // This allows connecting to unsupported Portainer versions.
 func WithDisableVersionCheck(disable bool) ServerOption {
@@ -122,6 +238,89 @@ func WithDisableVersionCheck(disable bool) ServerOption {
 	}
 }
 
+// WithSessionCredentials enables per-session Portainer credentials.
+// Each MCP session must then send its own Portainer API key in the SessionTokenHeader
+// HTTP header and gets its own Portainer client, so that Portainer's RBAC applies
+// to each user. This requires a networked transport.
+func WithSessionCredentials(enabled bool) ServerOption {
+	return func(opts *serverOptions) {
+		opts.sessionCredentials = enabled
+	}
+}
+
+// WithClientFactory sets a custom factory used to build the per-session clients.
+// This is primarily used for testing to inject mock clients.
+func WithClientFactory(factory ClientFactory) ServerOption {
+	return func(opts *serverOptions) {
+		opts.clientFactory = factory
+	}
+}
+
+// WithAuditLogger enables the audit log.
+// Every tool invocation is then recorded in the given logger.
+func WithAuditLogger(logger *audit.Logger) ServerOption {
+	return func(opts *serverOptions) {
+		opts.auditLogger = logger
+	}
+}
+
+// WithMetrics enables the metrics.
+// Every tool call, and every request sent to Portainer, is then recorded in the given metrics.
+func WithMetrics(m *metrics.Metrics) ServerOption {
+	return func(opts *serverOptions) {
+		opts.metrics = m
+	}
+}
+
+// WithTracerProvider enables tracing.
+// Every tool call is then recorded as a root span of the given provider, with a child span
+// for each call to the Portainer API and for each proxied request.
+func WithTracerProvider(provider trace.TracerProvider) ServerOption {
+	return func(opts *serverOptions) {
+		opts.tracerProvider = provider
+	}
+}
+
+// WithPolicy restricts tool calls with the given policy.
+// Unlike WithReadOnly, the policy is evaluated for each call and can target
+// specific environments, environment tags and access groups.
+func WithPolicy(p *policy.Policy) ServerOption {
+	return func(opts *serverOptions) {
+		opts.policy = p
+	}
+}
+
+// WithConfirmDestructive requires a confirmation for the tools annotated with destructiveHint.
+// Clients supporting elicitation ask the user to confirm the impact of the call. For the others,
+// the first call only returns a summary of the impact and a short-lived confirmation token,
+// the action is performed by a second call with the same arguments and the token.
+func WithConfirmDestructive(enabled bool) ServerOption {
+	return func(opts *serverOptions) {
+		opts.confirmDestructive = enabled
+	}
+}
+
+// WithCache caches the results of the Portainer list calls for the given TTL.
+// The TTL of each entity type (CacheEnvironments, CacheStacks...) can be overridden with ttls,
+// a TTL of 0 disables the cache for the entity type. Writes invalidate the entries they affect,
+// and read tools accept a refresh parameter to bypass the cache.
+func WithCache(defaultTTL time.Duration, ttls map[string]time.Duration) ServerOption {
+	return func(opts *serverOptions) {
+		opts.cacheTTL = defaultTTL
+		opts.cacheTTLs = ttls
+	}
+}
+
+// WithLimits bounds the requests sent to each Portainer instance: the number of requests in flight,
+// and the rate of requests to the instance and to each of its environments. Requests exceeding
+// a limit are not sent and the call fails with a "throttled, retry after" error. The clients of
+// the MCP sessions share the limits of their instance.
+func WithLimits(limits client.Limits) ServerOption {
+	return func(opts *serverOptions) {
+		opts.limits = limits
+	}
+}
+
 // NewPortainerMCPServer creates a new Portainer MCP server.
 //
 // This server provides an implementation of the MCP protocol for Portainer,
@@ -129,8 +328,8 @@ func WithDisableVersionCheck(disable bool) ServerOption {
 //
 // Parameters:
 //   - serverURL: The base URL of the Portainer server (e.g., "https://portainer.example.com")
-//   - token: The API token for authenticating with the Portainer server
-//   - toolsPath: Path to the tools.yaml file that defines the available MCP tools
+//   - token: The API token for authenticating with the Portainer server (optional with WithSessionCredentials)
+//   - toolsPath: Path to the tools.yaml file that defines the available MCP tools and prompts
 //   - options: Optional functional options for customizing server behavior (e.g., WithClient)
 //
 // Returns:
@@ -153,48 +352,292 @@ func NewPortainerMCPServer(serverURL, token, toolsPath string, options ...Server
 		return nil, fmt.Errorf("failed to load tools: %w", err)
 	}
 
+	prompts, err := toolgen.LoadPromptsFromYAML(toolsPath, MinimumToolsVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load prompts: %w", err)
+	}
+
+	timeouts, err := toolgen.LoadToolTimeoutsFromYAML(toolsPath, MinimumToolsVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tool timeouts: %w", err)
+	}
+
+	instanceName := opts.instanceName
+	if instanceName == "" {
+		instanceName = DefaultInstanceName
+	}
+
+	if !instanceNamePattern.MatchString(instanceName) {
+		return nil, fmt.Errorf("invalid Portainer instance name %q", instanceName)
+	}
+
+	if opts.sessionCredentials && len(opts.instances) > 0 {
+		return nil, fmt.Errorf("per-session credentials cannot be combined with multiple Portainer instances")
+	}
+
+	// sharedOptions apply to the clients of every instance
+	var sharedOptions []client.ClientOption
+	if opts.metrics != nil {
+		sharedOptions = append(sharedOptions, client.WithObserver(opts.metrics))
+	}
+	if opts.tracerProvider != nil {
+		sharedOptions = append(sharedOptions, client.WithTracerProvider(opts.tracerProvider))
+	}
+
+	clientOptions, err := opts.tls.clientOptions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure TLS: %w", err)
+	}
+	clientOptions = append(clientOptions, sharedOptions...)
+	clientOptions = append(clientOptions, limiterOptions(opts.limits)...)
+
+	clientFactory := opts.clientFactory
+	if clientFactory == nil {
+		clientFactory = func(token string) PortainerClient {
+			return client.NewPortainerClient(serverURL, token, clientOptions...)
+		}
+	}
+
 	var portainerClient PortainerClient
-	if opts.client != nil {
+	switch {
+	case opts.client != nil:
 		portainerClient = opts.client
-	} else {
-		portainerClient = client.NewPortainerClient(serverURL, token, client.WithSkipTLSVerify(true))
+	case opts.username != "":
+		portainerClient = client.NewPortainerClient(serverURL, "", slices.Concat(clientOptions, []client.ClientOption{client.WithCredentials(opts.username, opts.password)})...)
+	default:
+		portainerClient = clientFactory(token)
 	}
 
-	if !opts.disableVersionCheck {
-		version, err := portainerClient.GetVersion()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get Portainer server version: %w", err)
-		}
+	// Without a token of its own, the server cannot call Portainer before a session sends its API key:
+	// every tool is registered and the version is checked with the client of the first session
+	deferVersionCheck := opts.sessionCredentials && token == "" && opts.username == "" && !opts.disableVersionCheck
+
+	capabilities, err := checkInstance(instanceName, portainerClient, opts.disableVersionCheck || deferVersionCheck)
+	if err != nil {
+		return nil, err
+	}
 
-		if version != SupportedPortainerVersion {
-			return nil, fmt.Errorf("unsupported Portainer server version: %s, only version %s is supported", version, SupportedPortainerVersion)
+	cache, err := newCacheConfig(opts.cacheTTL, opts.cacheTTLs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cache configuration: %w", err)
+	}
+
+	if cache.enabled() {
+		baseFactory := clientFactory
+		clientFactory = func(token string) PortainerClient {
+			return newCachingClient(baseFactory(token), cache)
 		}
+		portainerClient = newCachingClient(portainerClient, cache)
+	} else {
+		cache = nil
+	}
+
+	instances, err := newInstances(instanceName, opts.instances, cache, sharedOptions, opts.limits)
+	if err != nil {
+		return nil, err
 	}
 
-	return &PortainerMCPServer{
-		srv: server.NewMCPServer(
-			"Portainer MCP Server",
-			"0.5.1",
-			server.WithToolCapabilities(true),
-			server.WithLogging(),
-		),
+	s := &PortainerMCPServer{
 		cli:      portainerClient,
+		factory:  clientFactory,
 		tools:    tools,
+		prompts:  prompts,
 		readOnly: opts.readOnly,
-	}, nil
+		dryRun:   opts.dryRun,
+		audit:    opts.auditLogger,
+		metrics:  opts.metrics,
+		policy:   opts.policy,
+		logging:  newClientLogging(),
+
+		instanceName:  instanceName,
+		instances:     instances,
+		capabilities:  capabilities,
+		cache:         cache,
+		timeouts:      timeouts,
+		cancellations: newCancellations(),
+	}
+
+	if opts.tracerProvider != nil {
+		s.tracer = opts.tracerProvider.Tracer(TracerName)
+	}
+
+	if opts.confirmDestructive {
+		s.confirmations = newConfirmationStore(DefaultConfirmationTTL)
+	}
+
+	hooks := &server.Hooks{}
+	s.cancellations.install(hooks)
+	s.logging.install(hooks)
+	if s.confirmations != nil {
+		s.confirmations.install(hooks)
+	}
+	if opts.sessionCredentials {
+		s.sessions = newSessionClients(clientFactory)
+		if deferVersionCheck {
+			s.sessions.versionCheck = newDeferredVersionCheck(instanceName)
+		}
+		hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+			s.sessions.remove(session.SessionID())
+		})
+	}
+
+	serverOpts := []server.ServerOption{
+		server.WithToolCapabilities(true),
+		server.WithLogging(),
+		server.WithHooks(hooks),
+	}
+	if s.confirmations != nil {
+		serverOpts = append(serverOpts, server.WithElicitation())
+	}
+
+	s.srv = server.NewMCPServer("Portainer MCP Server", "0.5.1", serverOpts...)
+	s.srv.AddNotificationHandler(methodNotificationCancelled, s.cancellations.handleNotification)
+	s.srv.AddNotificationHandler(methodNotificationInitialized, s.handleInitialized)
+
+	return s, nil
 }
 
 // Start begins listening for MCP protocol messages on standard input/output.
 // This is a blocking call that will run until the connection is closed.
+// Use StartHTTP to serve the networked transports instead.
 func (s *PortainerMCPServer) Start() error {
 	return server.ServeStdio(s.srv)
 }
 
+// UpdateToken replaces the shared Portainer client with a client authenticated with the given token.
+// It is used to rotate the API token without restarting the server. In-flight calls complete
+// with the previous client, sessions using their own credentials are not affected.
+func (s *PortainerMCPServer) UpdateToken(token string) {
+	cli := s.factory(token)
+
+	s.cliMu.Lock()
+	defer s.cliMu.Unlock()
+
+	s.cli = cli
+}
+
 // addToolIfExists adds a tool to the server if it exists in the tools map
+// and a Portainer instance provides the capabilities it requires
 func (s *PortainerMCPServer) addToolIfExists(toolName string, handler server.ToolHandlerFunc) {
+	s.registerTool(toolName, false, handler)
+}
+
+// addWriteToolIfExists adds a tool that modifies Portainer, see addToolIfExists.
+// It is not registered when every instance is read-only, and its calls are rejected
+// on the read-only instances.
+func (s *PortainerMCPServer) addWriteToolIfExists(toolName string, handler server.ToolHandlerFunc) {
+	if s.allReadOnly() {
+		return
+	}
+
+	s.registerTool(toolName, true, handler)
+}
+
+func (s *PortainerMCPServer) registerTool(toolName string, write bool, handler server.ToolHandlerFunc) {
 	if tool, exists := s.tools[toolName]; exists {
-		s.srv.AddTool(tool, handler)
+		if missing, ok := s.missingCapability(toolName); ok {
+			s.logStartupEvent(zerolog.WarnLevel, "tool not registered, the Portainer instance does not provide a capability it requires", map[string]any{
+				"tool":       toolName,
+				"capability": string(missing),
+			})
+			return
+		}
+
+		if len(s.instances) > 0 {
+			tool = s.withInstanceParameter(tool)
+		}
+
+		if s.cache != nil && !write {
+			tool = withRefreshParameter(tool)
+			handler = s.withCacheRefresh(handler)
+		}
+
+		// The confirmation wraps the timeout, so that the time the user takes to confirm a call
+		// is not taken from the time allowed to its requests to Portainer
+		handler = s.withTimeout(toolName, s.withSessionClient(s.withPolicy(toolName, handler)))
+		if s.requiresConfirmation(tool) {
+			tool = withConfirmationParameter(tool)
+			handler = s.withConfirmation(tool, handler)
+		}
+
+		handler = s.withInstance(toolName, write, handler)
+		s.srv.AddTool(tool, s.withCancellation(s.withTracing(toolName, s.withMetrics(toolName, s.withAudit(toolName, s.withLogging(toolName, s.trackCall(handler)))))))
 	} else {
-		log.Printf("Tool %s not found, will not be registered for MCP usage", toolName)
+		s.logStartupEvent(zerolog.WarnLevel, "tool not registered, it is not defined in the tools file", map[string]any{
+			"tool": toolName,
+		})
+	}
+}
+
+// limiterOptions returns the client options enforcing the limits with a new limiter,
+// to be shared by the clients of a single Portainer instance
+func limiterOptions(limits client.Limits) []client.ClientOption {
+	if limits == (client.Limits{}) {
+		return nil
+	}
+
+	return []client.ClientOption{client.WithLimiter(client.NewLimiter(limits))}
+}
+
+// trackCall wraps a tool handler so that the call is tracked as in-flight while it runs.
+// Calls received while the server is shutting down are rejected.
+func (s *PortainerMCPServer) trackCall(handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if !s.calls.begin() {
+			return mcp.NewToolResultError("server is shutting down, please retry later"), nil
+		}
+		defer s.calls.end()
+
+		return handler(ctx, request)
+	}
+}
+
+// TLSOptions configures the TLS connection to a Portainer instance.
+// The certificate of the server is verified against the system roots by default.
+type TLSOptions struct {
+	SkipVerify        bool
+	CACertFile        string
+	ClientCertFile    string
+	ClientKeyFile     string
+	PinnedFingerprint string
+}
+
+// clientOptions loads the TLS settings of the connection to Portainer into client options.
+// The files are read once, the options are then shared by all the clients built by the factory.
+func (opts TLSOptions) clientOptions() ([]client.ClientOption, error) {
+	if opts.SkipVerify && (opts.CACertFile != "" || opts.PinnedFingerprint != "") {
+		return nil, fmt.Errorf("skipping TLS verification cannot be combined with a CA certificate or a pinned certificate")
+	}
+
+	if (opts.ClientCertFile == "") != (opts.ClientKeyFile == "") {
+		return nil, fmt.Errorf("both the client certificate and its private key are required")
+	}
+
+	clientOptions := []client.ClientOption{client.WithSkipTLSVerify(opts.SkipVerify)}
+
+	if opts.CACertFile != "" {
+		pool, err := client.LoadCACertificates(opts.CACertFile)
+		if err != nil {
+			return nil, err
+		}
+		clientOptions = append(clientOptions, client.WithCACertificates(pool))
+	}
+
+	if opts.ClientCertFile != "" {
+		cert, err := client.LoadClientCertificate(opts.ClientCertFile, opts.ClientKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		clientOptions = append(clientOptions, client.WithClientCertificate(cert))
 	}
+
+	if opts.PinnedFingerprint != "" {
+		fingerprint, err := client.ParseFingerprint(opts.PinnedFingerprint)
+		if err != nil {
+			return nil, err
+		}
+		clientOptions = append(clientOptions, client.WithPinnedCertificate(fingerprint))
+	}
+
+	return clientOptions, nil
 }
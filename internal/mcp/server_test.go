@@ -3,10 +3,12 @@ package mcp
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/portainer/portainer-mcp/pkg/portainer/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -32,6 +34,22 @@ func TestNewPortainerMCPServer(t *testing.T) {
 			toolsPath: validToolsPath,
 			mockSetup: func(m *MockPortainerClient) {
 				m.On("GetVersion").Return(SupportedPortainerVersion, nil)
+				m.On("GetEdition").Return(EditionEE, nil)
+				m.On("GetSettings").Return(models.PortainerSettings{}, nil)
+				m.On("GetEnvironments").Return([]models.Environment{}, nil)
+			},
+			expectError: false,
+		},
+		{
+			name:      "successful initialization with version in supported range",
+			serverURL: "https://portainer.example.com",
+			token:     "valid-token",
+			toolsPath: validToolsPath,
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetVersion").Return(MinimumPortainerVersion, nil)
+				m.On("GetEdition").Return("", errors.New("forbidden"))
+				m.On("GetSettings").Return(models.PortainerSettings{}, errors.New("forbidden"))
+				m.On("GetEnvironments").Return(nil, errors.New("forbidden"))
 			},
 			expectError: false,
 		},
@@ -75,6 +93,17 @@ func TestNewPortainerMCPServer(t *testing.T) {
 			expectError:   true,
 			errorContains: "unsupported Portainer server version",
 		},
+		{
+			name:      "Portainer version above supported range",
+			serverURL: "https://portainer.example.com",
+			token:     "valid-token",
+			toolsPath: validToolsPath,
+			mockSetup: func(m *MockPortainerClient) {
+				m.On("GetVersion").Return(MaximumPortainerVersion, nil)
+			},
+			expectError:   true,
+			errorContains: "unsupported Portainer server version",
+		},
 		{
 			name:      "unsupported version with disabled version check",
 			serverURL: "https://portainer.example.com",
@@ -194,3 +223,84 @@ func TestAddToolIfExists(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateToken(t *testing.T) {
+	clients := map[string]*MockPortainerClient{
+		"old-token": {},
+		"new-token": {},
+	}
+
+	s, err := NewPortainerMCPServer("https://portainer.example.com", "old-token", "testdata/valid_tools.yaml",
+		WithDisableVersionCheck(true),
+		WithClientFactory(func(token string) PortainerClient {
+			return clients[token]
+		}),
+	)
+	require.NoError(t, err)
+	assert.Same(t, clients["old-token"], s.client(context.Background()))
+
+	s.UpdateToken("new-token")
+	assert.Same(t, clients["new-token"], s.client(context.Background()))
+}
+
+func TestTLSClientOptions(t *testing.T) {
+	tests := []struct {
+		name          string
+		options       []ServerOption
+		expectedCount int
+		errorContains string
+	}{
+		{
+			name:          "verification by default",
+			expectedCount: 1,
+		},
+		{
+			name:          "pinned certificate",
+			options:       []ServerOption{WithPinnedCertificate(strings.Repeat("ab", 32))},
+			expectedCount: 2,
+		},
+		{
+			name:          "invalid pinned certificate",
+			options:       []ServerOption{WithPinnedCertificate("ab")},
+			errorContains: "invalid certificate fingerprint",
+		},
+		{
+			name:          "missing CA certificate",
+			options:       []ServerOption{WithCACertificate("testdata/missing-ca.pem")},
+			errorContains: "failed to read CA certificates",
+		},
+		{
+			name:          "skip verification with pinned certificate",
+			options:       []ServerOption{WithSkipTLSVerify(true), WithPinnedCertificate(strings.Repeat("ab", 32))},
+			errorContains: "cannot be combined",
+		},
+		{
+			name:          "client certificate without key",
+			options:       []ServerOption{WithClientCertificate("client.pem", "")},
+			errorContains: "private key are required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &serverOptions{}
+			for _, option := range tt.options {
+				option(opts)
+			}
+
+			clientOptions, err := opts.tls.clientOptions()
+
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+			} else {
+				require.NoError(t, err)
+				assert.Len(t, clientOptions, tt.expectedCount)
+			}
+		})
+	}
+
+	_, err := NewPortainerMCPServer("https://portainer.example.com", "token", "testdata/valid_tools.yaml",
+		WithPinnedCertificate("ab"),
+	)
+	assert.ErrorContains(t, err, "failed to configure TLS")
+}
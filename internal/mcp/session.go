@@ -0,0 +1,167 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// SessionTokenHeader is the HTTP header used by MCP clients to send their own Portainer API key
+// when the server runs with per-session credentials
+const SessionTokenHeader = "X-Portainer-API-Key"
+
+// ClientFactory builds a Portainer client authenticated with the given API token
+type ClientFactory func(token string) PortainerClient
+
+type sessionTokenKey struct{}
+
+type clientKey struct{}
+
+// withSessionToken stores the Portainer API key sent by the MCP client in the request context.
+// It is used as the context function of the networked transports.
+func withSessionToken(ctx context.Context, r *http.Request) context.Context {
+	if token := r.Header.Get(SessionTokenHeader); token != "" {
+		return context.WithValue(ctx, sessionTokenKey{}, token)
+	}
+	return ctx
+}
+
+// sessionTokenFromContext returns the Portainer API key stored by withSessionToken
+func sessionTokenFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(sessionTokenKey{}).(string)
+	return token
+}
+
+// client returns the Portainer client to use for the current request.
+// This is the client of the MCP session when per-session credentials are enabled,
+// the client of the Portainer instance selected by the call, or the shared client of the server.
+// Reads bypass the cache when the call asked for a refresh.
+func (s *PortainerMCPServer) client(ctx context.Context) PortainerClient {
+	cli, ok := ctx.Value(clientKey{}).(PortainerClient)
+	if !ok {
+		if inst, isInstance := ctx.Value(instanceKey{}).(*instance); isInstance {
+			cli = inst.client()
+		} else {
+			s.cliMu.RLock()
+			cli = s.cli
+			s.cliMu.RUnlock()
+		}
+	}
+
+	if cached, ok := cli.(*cachingClient); ok && isRefresh(ctx) {
+		return cached.refreshing()
+	}
+
+	return cli
+}
+
+// withSessionClient wraps a tool handler so that it uses the Portainer client of the
+// calling MCP session. It is a no-op when per-session credentials are disabled.
+func (s *PortainerMCPServer) withSessionClient(handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	if s.sessions == nil {
+		return handler
+	}
+
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ctx, err := s.sessionContext(ctx)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to authenticate session", err), nil
+		}
+
+		return handler(ctx, request)
+	}
+}
+
+// sessionContext returns a context carrying the Portainer client of the calling MCP session
+func (s *PortainerMCPServer) sessionContext(ctx context.Context) (context.Context, error) {
+	session := server.ClientSessionFromContext(ctx)
+	if session == nil {
+		return nil, fmt.Errorf("no MCP session associated with the request")
+	}
+
+	token := sessionTokenFromContext(ctx)
+	if token == "" {
+		return nil, fmt.Errorf("missing %s header", SessionTokenHeader)
+	}
+
+	cli := s.sessions.get(session.SessionID(), token)
+	if s.sessions.versionCheck != nil {
+		if err := s.sessions.versionCheck.check(ctx, cli); err != nil {
+			return nil, err
+		}
+	}
+
+	return context.WithValue(ctx, clientKey{}, cli), nil
+}
+
+// sessionClient is the Portainer client of a single MCP session
+type sessionClient struct {
+	token string
+	cli   PortainerClient
+}
+
+// sessionClients holds one Portainer client per MCP session.
+// Clients are never shared between sessions.
+type sessionClients struct {
+	mu      sync.Mutex
+	clients map[string]sessionClient
+	factory ClientFactory
+
+	// versionCheck is set when the version of Portainer could not be checked at startup
+	versionCheck *deferredVersionCheck
+}
+
+func newSessionClients(factory ClientFactory) *sessionClients {
+	return &sessionClients{
+		clients: map[string]sessionClient{},
+		factory: factory,
+	}
+}
+
+// get returns the client of the session, creating it if needed.
+// The client is rebuilt if the session sends a different token.
+func (c *sessionClients) get(sessionID, token string) PortainerClient {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if existing, ok := c.clients[sessionID]; ok && existing.token == token {
+		return existing.cli
+	}
+
+	cli := c.factory(token)
+	c.clients[sessionID] = sessionClient{token: token, cli: cli}
+
+	return cli
+}
+
+// remove discards the client of the session
+func (c *sessionClients) remove(sessionID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.clients, sessionID)
+}
+
+// count returns the number of sessions with a client
+func (c *sessionClients) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.clients)
+}
+
+// sessionIdManager discards the Portainer client of a streamable HTTP session
+// when the session is terminated by the MCP client
+type sessionIdManager struct {
+	server.InsecureStatefulSessionIdManager
+	clients *sessionClients
+}
+
+func (m *sessionIdManager) Terminate(sessionID string) (bool, error) {
+	m.clients.remove(sessionID)
+	return m.InsecureStatefulSessionIdManager.Terminate(sessionID)
+}
@@ -0,0 +1,277 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/portainer/portainer-mcp/pkg/portainer/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSession is a minimal ClientSession used to attach a session ID to a context
+type fakeSession struct {
+	id string
+}
+
+func (f *fakeSession) Initialize()                                         {}
+func (f *fakeSession) Initialized() bool                                   { return true }
+func (f *fakeSession) NotificationChannel() chan<- mcp.JSONRPCNotification { return nil }
+func (f *fakeSession) SessionID() string                                   { return f.id }
+
+func sessionCtx(srv *server.MCPServer, sessionID, token string) context.Context {
+	ctx := srv.WithContext(context.Background(), &fakeSession{id: sessionID})
+	if token != "" {
+		ctx = context.WithValue(ctx, sessionTokenKey{}, token)
+	}
+	return ctx
+}
+
+func TestWithSessionToken(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	assert.Empty(t, sessionTokenFromContext(withSessionToken(context.Background(), r)))
+
+	r.Header.Set(SessionTokenHeader, "user-token")
+	assert.Equal(t, "user-token", sessionTokenFromContext(withSessionToken(context.Background(), r)))
+}
+
+func TestSessionClients(t *testing.T) {
+	built := map[string]int{}
+	clients := newSessionClients(func(token string) PortainerClient {
+		built[token]++
+		return &MockPortainerClient{}
+	})
+
+	first := clients.get("session-1", "token-a")
+	assert.Same(t, first, clients.get("session-1", "token-a"), "client should be reused within a session")
+
+	other := clients.get("session-2", "token-a")
+	assert.NotSame(t, first, other, "clients must not be shared between sessions")
+
+	rotated := clients.get("session-1", "token-b")
+	assert.NotSame(t, first, rotated, "client should be rebuilt when the session token changes")
+
+	assert.Equal(t, 2, clients.count())
+	assert.Equal(t, map[string]int{"token-a": 2, "token-b": 1}, built)
+
+	clients.remove("session-1")
+	assert.Equal(t, 1, clients.count())
+
+	manager := &sessionIdManager{clients: clients}
+	_, err := manager.Terminate("session-2")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, clients.count())
+}
+
+func TestWithSessionClient(t *testing.T) {
+	sharedClient := &MockPortainerClient{}
+	handler := func(s *PortainerMCPServer) server.ToolHandlerFunc {
+		return s.withSessionClient(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			if s.client(ctx) == sharedClient {
+				return mcp.NewToolResultText("shared"), nil
+			}
+			return mcp.NewToolResultText("session"), nil
+		})
+	}
+	mcpServer := server.NewMCPServer("Test Server", "1.0.0")
+
+	tests := []struct {
+		name          string
+		sessions      bool
+		ctx           context.Context
+		expectError   bool
+		errorContains string
+		expected      string
+	}{
+		{
+			name:     "session credentials disabled",
+			sessions: false,
+			ctx:      context.Background(),
+			expected: "shared",
+		},
+		{
+			name:          "no session",
+			sessions:      true,
+			ctx:           context.Background(),
+			expectError:   true,
+			errorContains: "no MCP session",
+		},
+		{
+			name:          "missing token",
+			sessions:      true,
+			ctx:           sessionCtx(mcpServer, "session-1", ""),
+			expectError:   true,
+			errorContains: SessionTokenHeader,
+		},
+		{
+			name:     "session client",
+			sessions: true,
+			ctx:      sessionCtx(mcpServer, "session-1", "user-token"),
+			expected: "session",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &PortainerMCPServer{cli: sharedClient}
+			if tt.sessions {
+				s.sessions = newSessionClients(func(token string) PortainerClient {
+					return &MockPortainerClient{}
+				})
+			}
+
+			result, err := handler(s)(tt.ctx, mcp.CallToolRequest{})
+			require.NoError(t, err)
+
+			textContent, ok := result.Content[0].(mcp.TextContent)
+			require.True(t, ok)
+			if tt.expectError {
+				assert.True(t, result.IsError)
+				assert.Contains(t, textContent.Text, tt.errorContains)
+			} else {
+				assert.False(t, result.IsError)
+				assert.Equal(t, tt.expected, textContent.Text)
+			}
+		})
+	}
+}
+
+func TestSessionCredentialsOverStreamableHTTP(t *testing.T) {
+	clients := map[string]*MockPortainerClient{
+		"alice-token": {},
+		"bob-token":   {},
+	}
+	clients["alice-token"].On("GetUsers").Return([]models.User{{ID: 1, Username: "alice"}}, nil)
+	clients["bob-token"].On("GetUsers").Return([]models.User{{ID: 2, Username: "bob"}}, nil)
+
+	s, err := NewPortainerMCPServer("https://portainer.example.com", "", "../tooldef/tools.yaml",
+		WithClient(&MockPortainerClient{}),
+		WithDisableVersionCheck(true),
+		WithSessionCredentials(true),
+		WithClientFactory(func(token string) PortainerClient {
+			return clients[token]
+		}),
+	)
+	require.NoError(t, err)
+	s.AddUserFeatures()
+
+	handler, err := s.newHTTPHandler(TransportStreamableHTTP)
+	require.NoError(t, err)
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	post := func(token, sessionID string, body map[string]any) (*http.Response, string) {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+
+		req, err := http.NewRequest(http.MethodPost, srv.URL+StreamableHTTPPath, bytes.NewReader(data))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set(SessionTokenHeader, token)
+		}
+		if sessionID != "" {
+			req.Header.Set("Mcp-Session-Id", sessionID)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		respBody, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(respBody)
+	}
+
+	listUsers := func(token string) string {
+		resp, _ := post(token, "", map[string]any{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"method":  "initialize",
+			"params": map[string]any{
+				"protocolVersion": mcp.LATEST_PROTOCOL_VERSION,
+				"clientInfo":      map[string]any{"name": "test", "version": "1.0.0"},
+			},
+		})
+		sessionID := resp.Header.Get("Mcp-Session-Id")
+		require.NotEmpty(t, sessionID)
+
+		_, body := post(token, sessionID, map[string]any{
+			"jsonrpc": "2.0",
+			"id":      2,
+			"method":  "tools/call",
+			"params":  map[string]any{"name": ToolListUsers},
+		})
+		return body
+	}
+
+	assert.Contains(t, listUsers("alice-token"), "alice")
+	assert.Contains(t, listUsers("bob-token"), "bob")
+	assert.Contains(t, listUsers(""), SessionTokenHeader)
+
+	assert.Equal(t, 2, s.sessions.count())
+	clients["alice-token"].AssertExpectations(t)
+	clients["bob-token"].AssertExpectations(t)
+}
+
+func TestSessionCredentialsDeferVersionCheck(t *testing.T) {
+	clients := map[string]*MockPortainerClient{
+		"invalid-token": {},
+		"alice-token":   {},
+		"bob-token":     {},
+	}
+	clients["invalid-token"].On("GetVersion").Return("", assert.AnError)
+	clients["alice-token"].On("GetVersion").Return(SupportedPortainerVersion, nil)
+
+	// The shared client has no token and must not be called at startup
+	s, err := NewPortainerMCPServer("https://portainer.example.com", "", "../tooldef/tools.yaml",
+		WithClient(&MockPortainerClient{}),
+		WithSessionCredentials(true),
+		WithClientFactory(func(token string) PortainerClient {
+			return clients[token]
+		}),
+	)
+	require.NoError(t, err)
+	assert.Nil(t, s.capabilities, "every tool should be registered")
+
+	_, err = s.sessionContext(sessionCtx(s.srv, "session-1", "invalid-token"))
+	assert.ErrorContains(t, err, "failed to get Portainer server version")
+
+	_, err = s.sessionContext(sessionCtx(s.srv, "session-2", "alice-token"))
+	assert.NoError(t, err)
+
+	_, err = s.sessionContext(sessionCtx(s.srv, "session-3", "bob-token"))
+	assert.NoError(t, err, "the version should only be checked once")
+
+	clients["invalid-token"].AssertExpectations(t)
+	clients["alice-token"].AssertExpectations(t)
+	clients["bob-token"].AssertNotCalled(t, "GetVersion")
+}
+
+func TestSessionCredentialsUnsupportedVersion(t *testing.T) {
+	cli := &MockPortainerClient{}
+	cli.On("GetVersion").Return("2.19.0", nil).Once()
+
+	s, err := NewPortainerMCPServer("https://portainer.example.com", "", "../tooldef/tools.yaml",
+		WithClient(&MockPortainerClient{}),
+		WithSessionCredentials(true),
+		WithClientFactory(func(token string) PortainerClient {
+			return cli
+		}),
+	)
+	require.NoError(t, err)
+
+	for _, sessionID := range []string{"session-1", "session-2"} {
+		_, err = s.sessionContext(sessionCtx(s.srv, sessionID, "user-token"))
+		assert.ErrorContains(t, err, "unsupported Portainer server version: 2.19.0")
+	}
+
+	cli.AssertExpectations(t)
+}
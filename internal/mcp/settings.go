@@ -14,7 +14,7 @@ func (s *PortainerMCPServer) AddSettingsFeatures() {
 
 func (s *PortainerMCPServer) HandleGetSettings() server.ToolHandlerFunc {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		settings, err := s.cli.GetSettings()
+		settings, err := s.client(ctx).GetSettings(ctx)
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("failed to get settings", err), nil
 		}
@@ -4,28 +4,35 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"maps"
+	"slices"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/portainer/portainer-mcp/internal/compose"
+	"github.com/portainer/portainer-mcp/pkg/portainer/models"
 	"github.com/portainer/portainer-mcp/pkg/toolgen"
 )
 
 func (s *PortainerMCPServer) AddStackFeatures() {
 	s.addToolIfExists(ToolListStacks, s.HandleGetStacks())
 	s.addToolIfExists(ToolGetStackFile, s.HandleGetStackFile())
-
-	if !s.readOnly {
-		s.addToolIfExists(ToolCreateStack, s.HandleCreateStack())
-		s.addToolIfExists(ToolUpdateStack, s.HandleUpdateStack())
-		s.addToolIfExists(ToolStartStack, s.HandleStartStack())
-		s.addToolIfExists(ToolStopStack, s.HandleStopStack())
-		s.addToolIfExists(ToolDeleteStack, s.HandleDeleteStack())
-	}
+	s.addToolIfExists(ToolGetStackGitConfig, s.HandleGetStackGitConfig())
+	s.addToolIfExists(ToolDiffStack, s.HandleDiffStack())
+	s.addToolIfExists(ToolValidateCompose, s.HandleValidateCompose())
+
+	s.addWriteToolIfExists(ToolCreateStack, s.HandleCreateStack())
+	s.addWriteToolIfExists(ToolUpdateStack, s.HandleUpdateStack())
+	s.addWriteToolIfExists(ToolCreateGitStack, s.HandleCreateGitStack())
+	s.addWriteToolIfExists(ToolRedeployGitStack, s.HandleRedeployGitStack())
+	s.addWriteToolIfExists(ToolStartStack, s.HandleStartStack())
+	s.addWriteToolIfExists(ToolStopStack, s.HandleStopStack())
+	s.addWriteToolIfExists(ToolDeleteStack, s.HandleDeleteStack())
 }
 
 func (s *PortainerMCPServer) HandleGetStacks() server.ToolHandlerFunc {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		stacks, err := s.cli.GetStacks()
+		stacks, err := s.client(ctx).GetStacks(ctx)
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("failed to get stacks", err), nil
 		}
@@ -48,7 +55,7 @@ func (s *PortainerMCPServer) HandleGetStackFile() server.ToolHandlerFunc {
 			return mcp.NewToolResultErrorFromErr("invalid id parameter", err), nil
 		}
 
-		stackFile, err := s.cli.GetStackFile(id)
+		stackFile, err := s.client(ctx).GetStackFile(ctx, id)
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("failed to get stack file", err), nil
 		}
@@ -57,6 +64,41 @@ func (s *PortainerMCPServer) HandleGetStackFile() server.ToolHandlerFunc {
 	}
 }
 
+// HandleDiffStack compares the current compose file of a stack with a proposed one, without
+// changing the stack. It returns a unified diff and a summary of the changed services.
+func (s *PortainerMCPServer) HandleDiffStack() server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		parser := toolgen.NewParameterParser(request)
+
+		id, err := parser.GetInt("id", true)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid id parameter", err), nil
+		}
+
+		file, err := parser.GetString("file", true)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid file parameter", err), nil
+		}
+
+		currentFile, err := s.client(ctx).GetStackFile(ctx, id)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to get stack file", err), nil
+		}
+
+		diff, err := compose.Compare(currentFile, file)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to compare stack files", err), nil
+		}
+
+		data, err := json.Marshal(diff)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to marshal stack diff", err), nil
+		}
+
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
+
 func (s *PortainerMCPServer) HandleCreateStack() server.ToolHandlerFunc {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		parser := toolgen.NewParameterParser(request)
@@ -76,12 +118,38 @@ func (s *PortainerMCPServer) HandleCreateStack() server.ToolHandlerFunc {
 			return mcp.NewToolResultErrorFromErr("invalid endpointId parameter", err), nil
 		}
 
-		id, err := s.cli.CreateStack(name, file, endpointId)
+		env, err := parseStackEnv(request)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid env parameter", err), nil
+		}
+
+		warnings, invalid := validateStackFile(file, env)
+		if invalid != nil {
+			return invalid, nil
+		}
+
+		if s.isDryRun(ctx) {
+			if _, err := s.findEnvironment(ctx, endpointId); err != nil {
+				return mcp.NewToolResultErrorFromErr("failed to resolve environment", err), nil
+			}
+
+			after := stackState{
+				Stack:    models.Stack{Name: name, Status: models.StackStatusActive, EndpointID: endpointId, Env: stackEnvVars(env)},
+				File:     file,
+				Warnings: warnings,
+			}
+			return dryRunResult(nil, after, dryRunCall{
+				Operation: "CreateStack",
+				Arguments: map[string]any{"name": name, "file": file, "endpointId": endpointId, "env": stackEnvVars(env)},
+			})
+		}
+
+		id, err := s.client(ctx).CreateStack(ctx, name, file, endpointId, env)
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("error creating stack", err), nil
 		}
 
-		return mcp.NewToolResultText(fmt.Sprintf("Stack created successfully with ID: %d", id)), nil
+		return mcp.NewToolResultText(withWarnings(fmt.Sprintf("Stack created successfully with ID: %d", id), warnings)), nil
 	}
 }
 
@@ -111,12 +179,52 @@ func (s *PortainerMCPServer) HandleUpdateStack() server.ToolHandlerFunc {
 			pullImage = false
 		}
 
-		err = s.cli.UpdateStack(id, file, endpointId, pullImage)
+		// env is nil when the parameter is not set, the current variables are then kept
+		env, err := parseStackEnv(request)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid env parameter", err), nil
+		}
+
+		fileEnv, err := s.stackFileEnv(ctx, id, file, env)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to get stack environment variables", err), nil
+		}
+
+		warnings, invalid := validateStackFile(file, fileEnv)
+		if invalid != nil {
+			return invalid, nil
+		}
+
+		if s.isDryRun(ctx) {
+			stack, err := s.findStackOnEnvironment(ctx, id, endpointId)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("failed to resolve stack", err), nil
+			}
+
+			currentFile, err := s.client(ctx).GetStackFile(ctx, id)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("failed to get stack file", err), nil
+			}
+
+			before := stackState{Stack: stack, File: currentFile}
+			after := stackState{Stack: stack, File: file, Warnings: warnings}
+			arguments := map[string]any{"id": id, "file": file, "endpointId": endpointId, "pullImage": pullImage}
+			if env != nil {
+				after.Env = stackEnvVars(env)
+				arguments["env"] = stackEnvVars(env)
+			}
+			return dryRunResult(before, after, dryRunCall{
+				Operation: "UpdateStack",
+				Arguments: arguments,
+			})
+		}
+
+		err = s.client(ctx).UpdateStack(ctx, id, file, endpointId, pullImage, env)
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("failed to update stack", err), nil
 		}
 
-		return mcp.NewToolResultText("Stack updated successfully"), nil
+		return mcp.NewToolResultText(withWarnings("Stack updated successfully", warnings)), nil
 	}
 }
 
@@ -134,7 +242,21 @@ func (s *PortainerMCPServer) HandleStartStack() server.ToolHandlerFunc {
 			return mcp.NewToolResultErrorFromErr("invalid endpointId parameter", err), nil
 		}
 
-		err = s.cli.StartStack(id, endpointId)
+		if s.isDryRun(ctx) {
+			before, err := s.findStackOnEnvironment(ctx, id, endpointId)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("failed to resolve stack", err), nil
+			}
+
+			after := before
+			after.Status = models.StackStatusActive
+			return dryRunResult(before, after, dryRunCall{
+				Operation: "StartStack",
+				Arguments: map[string]any{"id": id, "endpointId": endpointId},
+			})
+		}
+
+		err = s.client(ctx).StartStack(ctx, id, endpointId)
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("failed to start stack", err), nil
 		}
@@ -157,7 +279,21 @@ func (s *PortainerMCPServer) HandleStopStack() server.ToolHandlerFunc {
 			return mcp.NewToolResultErrorFromErr("invalid endpointId parameter", err), nil
 		}
 
-		err = s.cli.StopStack(id, endpointId)
+		if s.isDryRun(ctx) {
+			before, err := s.findStackOnEnvironment(ctx, id, endpointId)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("failed to resolve stack", err), nil
+			}
+
+			after := before
+			after.Status = models.StackStatusInactive
+			return dryRunResult(before, after, dryRunCall{
+				Operation: "StopStack",
+				Arguments: map[string]any{"id": id, "endpointId": endpointId},
+			})
+		}
+
+		err = s.client(ctx).StopStack(ctx, id, endpointId)
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("failed to stop stack", err), nil
 		}
@@ -180,7 +316,25 @@ func (s *PortainerMCPServer) HandleDeleteStack() server.ToolHandlerFunc {
 			return mcp.NewToolResultErrorFromErr("invalid endpointId parameter", err), nil
 		}
 
-		err = s.cli.DeleteStack(id, endpointId)
+		if s.isDryRun(ctx) {
+			stack, err := s.findStackOnEnvironment(ctx, id, endpointId)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("failed to resolve stack", err), nil
+			}
+
+			currentFile, err := s.client(ctx).GetStackFile(ctx, id)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("failed to get stack file", err), nil
+			}
+
+			before := stackState{Stack: stack, File: currentFile}
+			return dryRunResult(before, nil, dryRunCall{
+				Operation: "DeleteStack",
+				Arguments: map[string]any{"id": id, "endpointId": endpointId},
+			})
+		}
+
+		err = s.client(ctx).DeleteStack(ctx, id, endpointId)
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("failed to delete stack", err), nil
 		}
@@ -188,3 +342,29 @@ func (s *PortainerMCPServer) HandleDeleteStack() server.ToolHandlerFunc {
 		return mcp.NewToolResultText("Stack deleted successfully"), nil
 	}
 }
+
+// parseStackEnv returns the environment variables of the env parameter, a list of key/value
+// pairs, or nil when the parameter is not set
+func parseStackEnv(request mcp.CallToolRequest) (map[string]string, error) {
+	if value, ok := request.GetArguments()["env"]; !ok || value == nil {
+		return nil, nil
+	}
+
+	items, err := toolgen.NewParameterParser(request).GetArrayOfObjects("env", false)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseKeyValueMap(items)
+}
+
+// stackEnvVars converts environment variables to the variables of a stack, sorted by name.
+// Their values are masked once marshalled.
+func stackEnvVars(env map[string]string) []models.StackEnvVar {
+	vars := make([]models.StackEnvVar, 0, len(env))
+	for _, name := range slices.Sorted(maps.Keys(env)) {
+		vars = append(vars, models.StackEnvVar{Name: name, Value: env[name]})
+	}
+
+	return vars
+}
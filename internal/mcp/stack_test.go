@@ -163,16 +163,85 @@ func TestHandleGetStackFile(t *testing.T) {
 	}
 }
 
+func TestHandleDiffStack(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          map[string]any
+		mockFile      string
+		mockError     error
+		expected      []string
+		errorContains string
+	}{
+		{
+			name:     "image tag change",
+			args:     map[string]any{"id": float64(1), "file": "services:\n  web:\n    image: nginx:1.27\n"},
+			mockFile: "services:\n  web:\n    image: nginx:1.25\n",
+			expected: []string{
+				`"changed":true`,
+				`-    image: nginx:1.25\n+    image: nginx:1.27\n`,
+				`"services_changed":[{"service":"web","image":{"from":"nginx:1.25","to":"nginx:1.27"}}]`,
+			},
+		},
+		{
+			name:     "unparsable current file",
+			args:     map[string]any{"id": float64(1), "file": "services: {}"},
+			mockFile: "services: [",
+			expected: []string{`"changed":true`, `"summary_error":"invalid compose file: `},
+		},
+		{
+			name:          "api error",
+			args:          map[string]any{"id": float64(1), "file": "services: {}"},
+			mockError:     fmt.Errorf("stack not found"),
+			errorContains: "stack not found",
+		},
+		{
+			name:          "missing file parameter",
+			args:          map[string]any{"id": float64(1)},
+			errorContains: "file is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockPortainerClient{}
+			if tt.mockFile != "" || tt.mockError != nil {
+				mockClient.On("GetStackFile", 1).Return(tt.mockFile, tt.mockError)
+			}
+
+			server := &PortainerMCPServer{cli: mockClient}
+
+			result, err := server.HandleDiffStack()(context.Background(), CreateMCPRequest(tt.args))
+			assert.NoError(t, err)
+
+			textContent, ok := result.Content[0].(mcp.TextContent)
+			assert.True(t, ok)
+
+			if tt.errorContains != "" {
+				assert.True(t, result.IsError)
+				assert.Contains(t, textContent.Text, tt.errorContains)
+			} else {
+				assert.False(t, result.IsError)
+				for _, expected := range tt.expected {
+					assert.Contains(t, textContent.Text, expected)
+				}
+			}
+
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
 func TestHandleCreateStack(t *testing.T) {
 	tests := []struct {
-		name           string
-		inputName      string
-		inputFile      string
-		inputEndpoint  int
-		mockID         int
-		mockError      error
-		expectError    bool
-		setupParams    func(request *mcp.CallToolRequest)
+		name          string
+		inputName     string
+		inputFile     string
+		inputEndpoint int
+		inputEnv      map[string]string
+		mockID        int
+		mockError     error
+		expectError   bool
+		setupParams   func(request *mcp.CallToolRequest)
 	}{
 		{
 			name:          "successful stack creation",
@@ -190,6 +259,40 @@ func TestHandleCreateStack(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:          "stack creation with environment variables",
+			inputName:     "test-stack",
+			inputFile:     "services:\n  db:\n    image: postgres:${PG_VERSION}",
+			inputEndpoint: 8,
+			inputEnv:      map[string]string{"PG_VERSION": "16", "POSTGRES_PASSWORD": "s3cret"},
+			mockID:        2,
+			setupParams: func(request *mcp.CallToolRequest) {
+				request.Params.Arguments = map[string]any{
+					"name":       "test-stack",
+					"file":       "services:\n  db:\n    image: postgres:${PG_VERSION}",
+					"endpointId": float64(8),
+					"env": []any{
+						map[string]any{"key": "PG_VERSION", "value": "16"},
+						map[string]any{"key": "POSTGRES_PASSWORD", "value": "s3cret"},
+					},
+				}
+			},
+		},
+		{
+			name:          "invalid env parameter",
+			inputName:     "test-stack",
+			inputFile:     "services: {}",
+			inputEndpoint: 8,
+			expectError:   true,
+			setupParams: func(request *mcp.CallToolRequest) {
+				request.Params.Arguments = map[string]any{
+					"name":       "test-stack",
+					"file":       "services: {}",
+					"endpointId": float64(8),
+					"env":        []any{map[string]any{"key": "PG_VERSION", "value": float64(16)}},
+				}
+			},
+		},
 		{
 			name:          "api error",
 			inputName:     "test-stack",
@@ -257,7 +360,7 @@ func TestHandleCreateStack(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			mockClient := &MockPortainerClient{}
 			if !tt.expectError || tt.mockError != nil {
-				mockClient.On("CreateStack", tt.inputName, tt.inputFile, tt.inputEndpoint).Return(tt.mockID, tt.mockError)
+				mockClient.On("CreateStack", tt.inputName, tt.inputFile, tt.inputEndpoint, tt.inputEnv).Return(tt.mockID, tt.mockError)
 			}
 
 			server := &PortainerMCPServer{
@@ -301,6 +404,7 @@ func TestHandleUpdateStack(t *testing.T) {
 		inputID       int
 		inputFile     string
 		inputEndpoint int
+		inputEnv      map[string]string
 		mockError     error
 		expectError   bool
 		setupParams   func(request *mcp.CallToolRequest)
@@ -320,6 +424,36 @@ func TestHandleUpdateStack(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:          "stack update replacing the environment variables",
+			inputID:       1,
+			inputFile:     "services:\n  web:\n    image: nginx",
+			inputEndpoint: 8,
+			inputEnv:      map[string]string{"PG_VERSION": "17"},
+			setupParams: func(request *mcp.CallToolRequest) {
+				request.Params.Arguments = map[string]any{
+					"id":         float64(1),
+					"file":       "services:\n  web:\n    image: nginx",
+					"endpointId": float64(8),
+					"env":        []any{map[string]any{"key": "PG_VERSION", "value": "17"}},
+				}
+			},
+		},
+		{
+			name:          "stack update removing all the environment variables",
+			inputID:       1,
+			inputFile:     "services:\n  web:\n    image: nginx",
+			inputEndpoint: 8,
+			inputEnv:      map[string]string{},
+			setupParams: func(request *mcp.CallToolRequest) {
+				request.Params.Arguments = map[string]any{
+					"id":         float64(1),
+					"file":       "services:\n  web:\n    image: nginx",
+					"endpointId": float64(8),
+					"env":        []any{},
+				}
+			},
+		},
 		{
 			name:          "api error",
 			inputID:       1,
@@ -383,7 +517,7 @@ func TestHandleUpdateStack(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			mockClient := &MockPortainerClient{}
 			if !tt.expectError || tt.mockError != nil {
-				mockClient.On("UpdateStack", tt.inputID, tt.inputFile, tt.inputEndpoint, true).Return(tt.mockError)
+				mockClient.On("UpdateStack", tt.inputID, tt.inputFile, tt.inputEndpoint, true, tt.inputEnv).Return(tt.mockError)
 			}
 
 			server := &PortainerMCPServer{
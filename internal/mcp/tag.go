@@ -7,20 +7,19 @@ import (
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/portainer/portainer-mcp/pkg/portainer/models"
 	"github.com/portainer/portainer-mcp/pkg/toolgen"
 )
 
 func (s *PortainerMCPServer) AddTagFeatures() {
 	s.addToolIfExists(ToolListEnvironmentTags, s.HandleGetEnvironmentTags())
 
-	if !s.readOnly {
-		s.addToolIfExists(ToolCreateEnvironmentTag, s.HandleCreateEnvironmentTag())
-	}
+	s.addWriteToolIfExists(ToolCreateEnvironmentTag, s.HandleCreateEnvironmentTag())
 }
 
 func (s *PortainerMCPServer) HandleGetEnvironmentTags() server.ToolHandlerFunc {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		environmentTags, err := s.cli.GetEnvironmentTags()
+		environmentTags, err := s.client(ctx).GetEnvironmentTags(ctx)
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("failed to get environment tags", err), nil
 		}
@@ -43,7 +42,15 @@ func (s *PortainerMCPServer) HandleCreateEnvironmentTag() server.ToolHandlerFunc
 			return mcp.NewToolResultErrorFromErr("invalid name parameter", err), nil
 		}
 
-		id, err := s.cli.CreateEnvironmentTag(name)
+		if s.isDryRun(ctx) {
+			after := models.EnvironmentTag{Name: name}
+			return dryRunResult(nil, after, dryRunCall{
+				Operation: "CreateEnvironmentTag",
+				Arguments: map[string]any{"name": name},
+			})
+		}
+
+		id, err := s.client(ctx).CreateEnvironmentTag(ctx, name)
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("failed to create environment tag", err), nil
 		}
@@ -7,17 +7,16 @@ import (
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/portainer/portainer-mcp/pkg/portainer/models"
 	"github.com/portainer/portainer-mcp/pkg/toolgen"
 )
 
 func (s *PortainerMCPServer) AddTeamFeatures() {
 	s.addToolIfExists(ToolListTeams, s.HandleGetTeams())
 
-	if !s.readOnly {
-		s.addToolIfExists(ToolCreateTeam, s.HandleCreateTeam())
-		s.addToolIfExists(ToolUpdateTeamName, s.HandleUpdateTeamName())
-		s.addToolIfExists(ToolUpdateTeamMembers, s.HandleUpdateTeamMembers())
-	}
+	s.addWriteToolIfExists(ToolCreateTeam, s.HandleCreateTeam())
+	s.addWriteToolIfExists(ToolUpdateTeamName, s.HandleUpdateTeamName())
+	s.addWriteToolIfExists(ToolUpdateTeamMembers, s.HandleUpdateTeamMembers())
 }
 
 func (s *PortainerMCPServer) HandleCreateTeam() server.ToolHandlerFunc {
@@ -29,7 +28,15 @@ func (s *PortainerMCPServer) HandleCreateTeam() server.ToolHandlerFunc {
 			return mcp.NewToolResultErrorFromErr("invalid name parameter", err), nil
 		}
 
-		teamID, err := s.cli.CreateTeam(name)
+		if s.isDryRun(ctx) {
+			after := models.Team{Name: name}
+			return dryRunResult(nil, after, dryRunCall{
+				Operation: "CreateTeam",
+				Arguments: map[string]any{"name": name},
+			})
+		}
+
+		teamID, err := s.client(ctx).CreateTeam(ctx, name)
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("failed to create team", err), nil
 		}
@@ -40,7 +47,7 @@ func (s *PortainerMCPServer) HandleCreateTeam() server.ToolHandlerFunc {
 
 func (s *PortainerMCPServer) HandleGetTeams() server.ToolHandlerFunc {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		teams, err := s.cli.GetTeams()
+		teams, err := s.client(ctx).GetTeams(ctx)
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("failed to get teams", err), nil
 		}
@@ -68,7 +75,21 @@ func (s *PortainerMCPServer) HandleUpdateTeamName() server.ToolHandlerFunc {
 			return mcp.NewToolResultErrorFromErr("invalid name parameter", err), nil
 		}
 
-		err = s.cli.UpdateTeamName(id, name)
+		if s.isDryRun(ctx) {
+			before, err := s.findTeam(ctx, id)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("failed to resolve team", err), nil
+			}
+
+			after := before
+			after.Name = name
+			return dryRunResult(before, after, dryRunCall{
+				Operation: "UpdateTeamName",
+				Arguments: map[string]any{"id": id, "name": name},
+			})
+		}
+
+		err = s.client(ctx).UpdateTeamName(ctx, id, name)
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("failed to update team name", err), nil
 		}
@@ -91,7 +112,21 @@ func (s *PortainerMCPServer) HandleUpdateTeamMembers() server.ToolHandlerFunc {
 			return mcp.NewToolResultErrorFromErr("invalid userIds parameter", err), nil
 		}
 
-		err = s.cli.UpdateTeamMembers(id, userIDs)
+		if s.isDryRun(ctx) {
+			before, err := s.findTeam(ctx, id)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("failed to resolve team", err), nil
+			}
+
+			after := before
+			after.MemberIDs = userIDs
+			return dryRunResult(before, after, dryRunCall{
+				Operation: "UpdateTeamMembers",
+				Arguments: map[string]any{"id": id, "userIds": userIDs},
+			})
+		}
+
+		err = s.client(ctx).UpdateTeamMembers(ctx, id, userIDs)
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("failed to update team members", err), nil
 		}
@@ -0,0 +1,48 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// DefaultToolTimeout is the time allowed to the calls of a tool that has no timeout in tools.yaml
+const DefaultToolTimeout = 30 * time.Second
+
+// toolTimeout returns the time allowed to the calls of the tool
+func (s *PortainerMCPServer) toolTimeout(toolName string) time.Duration {
+	if timeout, ok := s.timeouts[toolName]; ok {
+		return timeout
+	}
+	return DefaultToolTimeout
+}
+
+// withTimeout wraps a tool handler so that the requests it sends to Portainer are aborted
+// once the timeout of the tool has elapsed. A call that fails because it timed out, or
+// because the client cancelled it, returns an error saying so instead of the error of the
+// aborted request.
+func (s *PortainerMCPServer) withTimeout(toolName string, handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	timeout := s.toolTimeout(toolName)
+
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		result, err := handler(ctx, request)
+		if err == nil && (result == nil || !result.IsError) {
+			return result, err
+		}
+
+		switch ctx.Err() {
+		case context.DeadlineExceeded:
+			return mcp.NewToolResultError(fmt.Sprintf("%s timed out after %s waiting for Portainer", toolName, timeout)), nil
+		case context.Canceled:
+			return mcp.NewToolResultError(fmt.Sprintf("%s was cancelled", toolName)), nil
+		default:
+			return result, err
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolTimeout(t *testing.T) {
+	s := &PortainerMCPServer{timeouts: map[string]time.Duration{ToolCreateStack: 5 * time.Minute}}
+
+	assert.Equal(t, 5*time.Minute, s.toolTimeout(ToolCreateStack))
+	assert.Equal(t, DefaultToolTimeout, s.toolTimeout(ToolListStacks))
+}
+
+func TestWithTimeout(t *testing.T) {
+	// waitForPortainer behaves like a tool whose request to Portainer hangs until it is aborted
+	waitForPortainer := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		<-ctx.Done()
+		return mcp.NewToolResultErrorFromErr("failed to get stacks", ctx.Err()), nil
+	}
+
+	tests := []struct {
+		name           string
+		handler        server.ToolHandlerFunc
+		cancelParent   bool
+		expectError    bool
+		expectedResult string
+	}{
+		{
+			name: "call completes in time",
+			handler: func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				return mcp.NewToolResultText("ok"), nil
+			},
+			expectedResult: "ok",
+		},
+		{
+			name: "error unrelated to the timeout",
+			handler: func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				return mcp.NewToolResultErrorFromErr("failed to get stacks", fmt.Errorf("forbidden")), nil
+			},
+			expectError:    true,
+			expectedResult: "failed to get stacks: forbidden",
+		},
+		{
+			name:           "call times out",
+			handler:        waitForPortainer,
+			expectError:    true,
+			expectedResult: "listStacks timed out after 10ms waiting for Portainer",
+		},
+		{
+			name:           "call cancelled by the client",
+			handler:        waitForPortainer,
+			cancelParent:   true,
+			expectError:    true,
+			expectedResult: "listStacks was cancelled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &PortainerMCPServer{timeouts: map[string]time.Duration{ToolListStacks: 10 * time.Millisecond}}
+			if tt.cancelParent {
+				s.timeouts[ToolListStacks] = time.Hour
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancelParent {
+				cancel()
+			}
+
+			result, err := s.withTimeout(ToolListStacks, tt.handler)(ctx, CreateMCPRequest(nil))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectError, result.IsError)
+			assert.Equal(t, tt.expectedResult, resultText(result))
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package mcp
+
+import (
+	"context"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the name of the tracer recording the spans of the tool calls
+const TracerName = "github.com/portainer/portainer-mcp/internal/mcp"
+
+// withTracing wraps a tool handler so that every call is recorded as a span. The spans of the
+// calls to Portainer made by the handler are its children.
+func (s *PortainerMCPServer) withTracing(toolName string, handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	if s.tracer == nil {
+		return handler
+	}
+
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ctx, span := s.tracer.Start(ctx, "tools/call "+toolName,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("mcp.method.name", "tools/call"),
+				attribute.String("mcp.tool.name", toolName),
+			),
+		)
+		defer span.End()
+
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			span.SetAttributes(attribute.String("mcp.session.id", session.SessionID()))
+		}
+
+		if environmentId, ok := environmentIdFromRequest(toolName, request); ok {
+			span.SetAttributes(attribute.Int("portainer.environment.id", environmentId))
+		}
+
+		result, err := handler(ctx, request)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		} else if result != nil && result.IsError {
+			span.SetStatus(codes.Error, resultText(result))
+		}
+
+		return result, err
+	}
+}
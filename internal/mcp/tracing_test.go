@@ -0,0 +1,85 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestWithTracing(t *testing.T) {
+	tests := []struct {
+		name           string
+		result         *mcp.CallToolResult
+		err            error
+		expectedStatus codes.Code
+	}{
+		{
+			name:           "successful call",
+			result:         mcp.NewToolResultText("ok"),
+			expectedStatus: codes.Unset,
+		},
+		{
+			name:           "error result",
+			result:         mcp.NewToolResultError("failed to get environment: not found"),
+			expectedStatus: codes.Error,
+		},
+		{
+			name:           "handler error",
+			err:            errors.New("unexpected failure"),
+			expectedStatus: codes.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+			s := &PortainerMCPServer{tracer: provider.Tracer(TracerName)}
+
+			// The handler stands in for a call to Portainer, whose span is a child of the span of the tool call
+			var handler server.ToolHandlerFunc = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				_, span := trace.SpanFromContext(ctx).TracerProvider().Tracer("test").Start(ctx, "portainer EndpointUpdate")
+				span.End()
+				return tt.result, tt.err
+			}
+
+			_, _ = s.withTracing(ToolUpdateEnvironmentTags, handler)(context.Background(), CreateMCPRequest(map[string]any{"id": float64(3)}))
+
+			spans := recorder.Ended()
+			require.Len(t, spans, 2)
+			child, root := spans[0], spans[1]
+
+			assert.Equal(t, "tools/call "+ToolUpdateEnvironmentTags, root.Name())
+			assert.False(t, root.Parent().IsValid(), "the span of the tool call is a root span")
+			assert.Equal(t, trace.SpanKindServer, root.SpanKind())
+			assert.Equal(t, tt.expectedStatus, root.Status().Code)
+			assert.Contains(t, root.Attributes(), attribute.String("mcp.tool.name", ToolUpdateEnvironmentTags))
+			assert.Contains(t, root.Attributes(), attribute.Int("portainer.environment.id", 3))
+
+			assert.Equal(t, root.SpanContext().SpanID(), child.Parent().SpanID())
+			assert.Equal(t, root.SpanContext().TraceID(), child.SpanContext().TraceID())
+		})
+	}
+}
+
+func TestWithTracingDisabled(t *testing.T) {
+	s := &PortainerMCPServer{}
+
+	result, err := s.withTracing(ToolListStacks, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		assert.False(t, trace.SpanFromContext(ctx).SpanContext().IsValid())
+		return mcp.NewToolResultText("ok"), nil
+	})(context.Background(), CreateMCPRequest(nil))
+
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+}
@@ -0,0 +1,206 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Transports supported by the server
+const (
+	// TransportStdio serves MCP over standard input/output
+	TransportStdio = "stdio"
+	// TransportSSE serves MCP over HTTP with Server-Sent Events
+	TransportSSE = "sse"
+	// TransportStreamableHTTP serves MCP over the streamable HTTP transport
+	TransportStreamableHTTP = "streamable-http"
+)
+
+// HTTP endpoints exposed by the networked transports
+const (
+	// HealthPath is the path of the health endpoint
+	HealthPath = "/healthz"
+	// StreamableHTTPPath is the path of the streamable HTTP endpoint
+	StreamableHTTPPath = "/mcp"
+	// SSEPath is the path of the SSE endpoint
+	SSEPath = "/sse"
+	// SSEMessagePath is the path of the SSE message endpoint
+	SSEMessagePath = "/message"
+)
+
+// DefaultShutdownTimeout is the maximum time to wait for in-flight tool calls
+// to complete when shutting down a networked transport
+const DefaultShutdownTimeout = 30 * time.Second
+
+// All available transports
+var AllTransports = []string{
+	TransportStdio,
+	TransportSSE,
+	TransportStreamableHTTP,
+}
+
+// IsValidTransport checks if a given string is a supported transport
+func IsValidTransport(transport string) bool {
+	return slices.Contains(AllTransports, transport)
+}
+
+// StartHTTP serves MCP protocol messages over the given networked transport
+// (sse or streamable-http) on the specified address, alongside a health endpoint.
+//
+// This is a blocking call that runs until the context is cancelled. On cancellation,
+// new tool calls are rejected, in-flight tool calls are given up to DefaultShutdownTimeout
+// to complete and the HTTP server is then shut down.
+func (s *PortainerMCPServer) StartHTTP(ctx context.Context, transport, addr string) error {
+	handler, err := s.newHTTPHandler(transport)
+	if err != nil {
+		return err
+	}
+
+	baseCtx, cancelBase := context.WithCancel(context.Background())
+	defer cancelBase()
+
+	httpServer := &http.Server{
+		Addr:        addr,
+		Handler:     handler,
+		BaseContext: func(net.Listener) context.Context { return baseCtx },
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return fmt.Errorf("failed to serve %s transport: %w", transport, err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), DefaultShutdownTimeout)
+	defer cancel()
+
+	drainErr := s.calls.drain(shutdownCtx)
+
+	// Long-lived streams (SSE connections, streamable HTTP listeners) never become idle
+	// on their own, so they are closed once the in-flight tool calls have completed
+	cancelBase()
+
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down HTTP server: %w", err)
+	}
+
+	if drainErr != nil {
+		return fmt.Errorf("failed to drain in-flight tool calls: %w", drainErr)
+	}
+
+	return nil
+}
+
+// newHTTPHandler builds the HTTP handler serving the MCP endpoints of the given transport
+// and the health endpoint
+func (s *PortainerMCPServer) newHTTPHandler(transport string) (http.Handler, error) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(HealthPath, s.handleHealth)
+
+	switch transport {
+	case TransportSSE:
+		sseServer := server.NewSSEServer(s.srv,
+			server.WithSSEEndpoint(SSEPath),
+			server.WithMessageEndpoint(SSEMessagePath),
+			server.WithSSEContextFunc(withSessionToken),
+		)
+		mux.Handle(SSEPath, sseServer)
+		mux.Handle(SSEMessagePath, sseServer)
+	case TransportStreamableHTTP:
+		opts := []server.StreamableHTTPOption{
+			server.WithEndpointPath(StreamableHTTPPath),
+			server.WithHTTPContextFunc(withSessionToken),
+		}
+		if s.sessions != nil {
+			opts = append(opts, server.WithSessionIdManager(&sessionIdManager{clients: s.sessions}))
+		}
+		mux.Handle(StreamableHTTPPath, server.NewStreamableHTTPServer(s.srv, opts...))
+	default:
+		return nil, fmt.Errorf("unsupported HTTP transport: %s", transport)
+	}
+
+	return mux, nil
+}
+
+// handleHealth reports whether the server is accepting tool calls
+func (s *PortainerMCPServer) handleHealth(w http.ResponseWriter, r *http.Request) {
+	status, code := "ok", http.StatusOK
+	if s.calls.isDraining() {
+		status, code = "draining", http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"status": status})
+}
+
+// callTracker keeps track of in-flight tool calls so that they can be drained on shutdown
+type callTracker struct {
+	mu       sync.RWMutex
+	draining bool
+	wg       sync.WaitGroup
+}
+
+// begin registers a new in-flight call. It returns false if the server is draining
+// and the call must be rejected.
+func (t *callTracker) begin() bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if t.draining {
+		return false
+	}
+
+	t.wg.Add(1)
+	return true
+}
+
+// end marks an in-flight call registered with begin as completed
+func (t *callTracker) end() {
+	t.wg.Done()
+}
+
+// isDraining returns true once drain has been called
+func (t *callTracker) isDraining() bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.draining
+}
+
+// drain stops accepting new calls and waits for the in-flight calls to complete
+// or for the context to be done
+func (t *callTracker) drain(ctx context.Context) error {
+	t.mu.Lock()
+	t.draining = true
+	t.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
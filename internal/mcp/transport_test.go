@@ -0,0 +1,189 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTransportServer() *PortainerMCPServer {
+	return &PortainerMCPServer{
+		srv: server.NewMCPServer("Test Server", "1.0.0", server.WithToolCapabilities(true)),
+		tools: map[string]mcp.Tool{
+			"test_tool": mcp.NewTool("test_tool", mcp.WithDescription("Test tool description")),
+		},
+	}
+}
+
+func TestIsValidTransport(t *testing.T) {
+	for _, transport := range AllTransports {
+		assert.True(t, IsValidTransport(transport), transport)
+	}
+	assert.False(t, IsValidTransport("websocket"))
+	assert.False(t, IsValidTransport(""))
+}
+
+func TestNewHTTPHandler(t *testing.T) {
+	tests := []struct {
+		name          string
+		transport     string
+		mcpPath       string
+		expectError   bool
+		errorContains string
+	}{
+		{
+			name:      "streamable http transport",
+			transport: TransportStreamableHTTP,
+			mcpPath:   StreamableHTTPPath,
+		},
+		{
+			name:      "sse transport",
+			transport: TransportSSE,
+			mcpPath:   SSEMessagePath,
+		},
+		{
+			name:          "stdio is not an HTTP transport",
+			transport:     TransportStdio,
+			expectError:   true,
+			errorContains: "unsupported HTTP transport",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestTransportServer()
+
+			handler, err := s.newHTTPHandler(tt.transport)
+			if tt.expectError {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorContains)
+				return
+			}
+			require.NoError(t, err)
+
+			srv := httptest.NewServer(handler)
+			defer srv.Close()
+
+			resp, err := http.Get(srv.URL + HealthPath)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+			// The MCP endpoint is mounted: a request without a valid body is rejected
+			// by the MCP transport rather than by the mux
+			resp, err = http.Post(srv.URL+tt.mcpPath, "text/plain", nil)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.NotEqual(t, http.StatusNotFound, resp.StatusCode)
+		})
+	}
+}
+
+func TestHandleHealth(t *testing.T) {
+	s := newTestTransportServer()
+
+	rec := httptest.NewRecorder()
+	s.handleHealth(rec, httptest.NewRequest(http.MethodGet, HealthPath, nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var body map[string]string
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "ok", body["status"])
+
+	require.NoError(t, s.calls.drain(context.Background()))
+
+	rec = httptest.NewRecorder()
+	s.handleHealth(rec, httptest.NewRequest(http.MethodGet, HealthPath, nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "draining", body["status"])
+}
+
+func TestTrackCallDrain(t *testing.T) {
+	s := newTestTransportServer()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := s.trackCall(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		close(started)
+		<-release
+		return mcp.NewToolResultText("done"), nil
+	})
+
+	resultCh := make(chan *mcp.CallToolResult, 1)
+	go func() {
+		result, _ := handler(context.Background(), mcp.CallToolRequest{})
+		resultCh <- result
+	}()
+	<-started
+
+	drained := make(chan error, 1)
+	go func() {
+		drained <- s.calls.drain(context.Background())
+	}()
+
+	// New calls are rejected while draining
+	require.Eventually(t, s.calls.isDraining, time.Second, 10*time.Millisecond)
+	result, err := handler(context.Background(), mcp.CallToolRequest{})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+
+	// Drain only completes once the in-flight call has returned
+	select {
+	case <-drained:
+		t.Fatal("drain completed while a call was still in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	assert.NoError(t, <-drained)
+	assert.False(t, (<-resultCh).IsError)
+}
+
+func TestCallTrackerDrainTimeout(t *testing.T) {
+	var tracker callTracker
+	require.True(t, tracker.begin())
+	defer tracker.end()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, tracker.drain(ctx), context.DeadlineExceeded)
+}
+
+func TestStartHTTP(t *testing.T) {
+	t.Run("unsupported transport", func(t *testing.T) {
+		s := newTestTransportServer()
+		err := s.StartHTTP(context.Background(), TransportStdio, "127.0.0.1:0")
+		assert.Error(t, err)
+	})
+
+	t.Run("shuts down on context cancellation", func(t *testing.T) {
+		s := newTestTransportServer()
+		ctx, cancel := context.WithCancel(context.Background())
+
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- s.StartHTTP(ctx, TransportStreamableHTTP, "127.0.0.1:0")
+		}()
+
+		cancel()
+
+		select {
+		case err := <-errCh:
+			assert.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("server did not shut down")
+		}
+		assert.True(t, s.calls.isDraining())
+	})
+}
@@ -13,14 +13,12 @@ import (
 func (s *PortainerMCPServer) AddUserFeatures() {
 	s.addToolIfExists(ToolListUsers, s.HandleGetUsers())
 
-	if !s.readOnly {
-		s.addToolIfExists(ToolUpdateUserRole, s.HandleUpdateUserRole())
-	}
+	s.addWriteToolIfExists(ToolUpdateUserRole, s.HandleUpdateUserRole())
 }
 
 func (s *PortainerMCPServer) HandleGetUsers() server.ToolHandlerFunc {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		users, err := s.cli.GetUsers()
+		users, err := s.client(ctx).GetUsers(ctx)
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("failed to get users", err), nil
 		}
@@ -52,7 +50,21 @@ func (s *PortainerMCPServer) HandleUpdateUserRole() server.ToolHandlerFunc {
 			return mcp.NewToolResultError(fmt.Sprintf("invalid role %s: must be one of: %v", role, AllUserRoles)), nil
 		}
 
-		err = s.cli.UpdateUserRole(id, role)
+		if s.isDryRun(ctx) {
+			before, err := s.findUser(ctx, id)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("failed to resolve user", err), nil
+			}
+
+			after := before
+			after.Role = role
+			return dryRunResult(before, after, dryRunCall{
+				Operation: "UpdateUserRole",
+				Arguments: map[string]any{"id": id, "role": role},
+			})
+		}
+
+		err = s.client(ctx).UpdateUserRole(ctx, id, role)
 		if err != nil {
 			return mcp.NewToolResultErrorFromErr("failed to update user role", err), nil
 		}
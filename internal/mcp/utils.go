@@ -69,6 +69,11 @@ func isValidHTTPMethod(method string) bool {
 	return slices.Contains(validMethods, method)
 }
 
+// isReadOnlyHTTPMethod returns true for the HTTP methods that do not modify resources
+func isReadOnlyHTTPMethod(method string) bool {
+	return method == "GET" || method == "HEAD"
+}
+
 // CreateMCPRequest creates a new MCP tool request with the given arguments
 func CreateMCPRequest(args map[string]any) mcp.CallToolRequest {
 	return mcp.CallToolRequest{
@@ -77,3 +82,29 @@ func CreateMCPRequest(args map[string]any) mcp.CallToolRequest {
 		},
 	}
 }
+
+// environmentTools are the tools whose id parameter identifies an environment
+var environmentTools = []string{
+	ToolUpdateEnvironmentTags,
+	ToolUpdateEnvironmentUserAccesses,
+	ToolUpdateEnvironmentTeamAccesses,
+}
+
+// environmentIdFromRequest returns the ID of the environment targeted by a tool call, if any.
+// It does not validate the request, invalid values are reported as absent.
+func environmentIdFromRequest(toolName string, request mcp.CallToolRequest) (int, bool) {
+	args := request.GetArguments()
+
+	names := []string{"environmentId", "endpointId"}
+	if slices.Contains(environmentTools, toolName) {
+		names = append(names, "id")
+	}
+
+	for _, name := range names {
+		if value, ok := args[name].(float64); ok {
+			return int(value), true
+		}
+	}
+
+	return 0, false
+}
@@ -244,3 +244,56 @@ func TestParseKeyValueMap(t *testing.T) {
 		})
 	}
 }
+
+func TestEnvironmentIdFromRequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		toolName   string
+		args       map[string]any
+		expectedId int
+		expectedOk bool
+	}{
+		{
+			name:       "environmentId parameter",
+			toolName:   ToolDockerProxy,
+			args:       map[string]any{"environmentId": float64(1)},
+			expectedId: 1,
+			expectedOk: true,
+		},
+		{
+			name:       "endpointId parameter",
+			toolName:   ToolDeleteStack,
+			args:       map[string]any{"id": float64(4), "endpointId": float64(2)},
+			expectedId: 2,
+			expectedOk: true,
+		},
+		{
+			name:       "id parameter of an environment tool",
+			toolName:   ToolUpdateEnvironmentTags,
+			args:       map[string]any{"id": float64(3)},
+			expectedId: 3,
+			expectedOk: true,
+		},
+		{
+			name:       "id parameter of another tool",
+			toolName:   ToolUpdateTeamName,
+			args:       map[string]any{"id": float64(3)},
+			expectedOk: false,
+		},
+		{
+			name:       "invalid value",
+			toolName:   ToolDockerProxy,
+			args:       map[string]any{"environmentId": "one"},
+			expectedOk: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, ok := environmentIdFromRequest(tt.toolName, CreateMCPRequest(tt.args))
+			if ok != tt.expectedOk || id != tt.expectedId {
+				t.Errorf("environmentIdFromRequest() = %v, %v, want %v, %v", id, ok, tt.expectedId, tt.expectedOk)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Namespace prefixes the names of the metrics
+const Namespace = "portainer_mcp"
+
+// Path is the path of the metrics endpoint
+const Path = "/metrics"
+
+// shutdownTimeout bounds the time given to the scrapes in progress when the endpoint is shut down
+const shutdownTimeout = 5 * time.Second
+
+// Outcomes of a tool call
+const (
+	OutcomeSuccess = "success"
+	OutcomeError   = "error"
+)
+
+// statusError labels the requests to Portainer that did not receive a response
+const statusError = "error"
+
+// proxyResponseSizeBuckets range from 256 bytes to 64 MiB
+var proxyResponseSizeBuckets = prometheus.ExponentialBuckets(256, 4, 10)
+
+// Metrics records the tool calls and the requests sent to Portainer as Prometheus metrics.
+// It implements client.Observer.
+type Metrics struct {
+	registry          *prometheus.Registry
+	toolCalls         *prometheus.CounterVec
+	toolDuration      *prometheus.HistogramVec
+	requestDuration   *prometheus.HistogramVec
+	proxyResponseSize *prometheus.HistogramVec
+}
+
+// New creates the metrics, registered with the Go runtime and process metrics in a dedicated registry
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		toolCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "tool_calls_total",
+			Help:      "Number of MCP tool calls by tool and outcome.",
+		}, []string{"tool", "outcome"}),
+		toolDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "tool_call_duration_seconds",
+			Help:      "Duration of the MCP tool calls by tool.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"tool"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "portainer_request_duration_seconds",
+			Help:      "Latency of the requests to the Portainer API by client operation and HTTP status, retries included.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation", "status"}),
+		proxyResponseSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "proxy_response_size_bytes",
+			Help:      "Size of the responses proxied from the Docker and Kubernetes APIs of the environments.",
+			Buckets:   proxyResponseSizeBuckets,
+		}, []string{"operation"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.toolCalls,
+		m.toolDuration,
+		m.requestDuration,
+		m.proxyResponseSize,
+	)
+
+	return m
+}
+
+// ObserveToolCall records the outcome and the duration of a tool call
+func (m *Metrics) ObserveToolCall(tool, outcome string, duration time.Duration) {
+	m.toolCalls.WithLabelValues(tool, outcome).Inc()
+	m.toolDuration.WithLabelValues(tool).Observe(duration.Seconds())
+}
+
+// ObserveRequest records the latency of a request to Portainer, the status is 0 when no response was received
+func (m *Metrics) ObserveRequest(operation string, status int, duration time.Duration) {
+	statusLabel := statusError
+	if status != 0 {
+		statusLabel = strconv.Itoa(status)
+	}
+
+	m.requestDuration.WithLabelValues(operation, statusLabel).Observe(duration.Seconds())
+}
+
+// ObserveProxyResponse records the size of a response proxied from an environment
+func (m *Metrics) ObserveProxyResponse(operation string, size int64) {
+	m.proxyResponseSize.WithLabelValues(operation).Observe(float64(size))
+}
+
+// Handler returns the HTTP handler exposing the metrics in the Prometheus text format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Serve exposes the metrics on the given address until the context is done
+func (m *Metrics) Serve(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle(Path, m.Handler())
+
+	httpServer := &http.Server{
+		Addr:    addr,
+		Handler: mux,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return fmt.Errorf("failed to serve metrics: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	return httpServer.Shutdown(shutdownCtx)
+}
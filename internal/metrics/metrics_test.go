@@ -0,0 +1,125 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObserveToolCall(t *testing.T) {
+	m := New()
+
+	m.ObserveToolCall("listStacks", OutcomeSuccess, 20*time.Millisecond)
+	m.ObserveToolCall("listStacks", OutcomeSuccess, 40*time.Millisecond)
+	m.ObserveToolCall("listStacks", OutcomeError, time.Millisecond)
+	m.ObserveToolCall("createStack", OutcomeError, time.Second)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.toolCalls.WithLabelValues("listStacks", OutcomeSuccess)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.toolCalls.WithLabelValues("listStacks", OutcomeError)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.toolCalls.WithLabelValues("createStack", OutcomeError)))
+	assert.Equal(t, 2, testutil.CollectAndCount(m.toolDuration), "one histogram per tool")
+}
+
+func TestObserveRequest(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		expectedLabel string
+	}{
+		{
+			name:          "response received",
+			status:        http.StatusOK,
+			expectedLabel: "200",
+		},
+		{
+			name:          "error status",
+			status:        http.StatusServiceUnavailable,
+			expectedLabel: "503",
+		},
+		{
+			name:          "no response",
+			status:        0,
+			expectedLabel: "error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New()
+			m.ObserveRequest("GetStacks", tt.status, 10*time.Millisecond)
+
+			body := scrape(t, m.Handler())
+			assert.Contains(t, body, `portainer_mcp_portainer_request_duration_seconds_count{operation="GetStacks",status="`+tt.expectedLabel+`"} 1`)
+		})
+	}
+}
+
+func TestObserveProxyResponse(t *testing.T) {
+	m := New()
+
+	m.ObserveProxyResponse("ProxyDockerRequest", 1024)
+	m.ObserveProxyResponse("ProxyDockerRequest", 4096)
+
+	body := scrape(t, m.Handler())
+	assert.Contains(t, body, `portainer_mcp_proxy_response_size_bytes_sum{operation="ProxyDockerRequest"} 5120`)
+	assert.Contains(t, body, `portainer_mcp_proxy_response_size_bytes_count{operation="ProxyDockerRequest"} 2`)
+}
+
+func TestHandlerIncludesRuntimeMetrics(t *testing.T) {
+	body := scrape(t, New().Handler())
+
+	assert.Contains(t, body, "go_goroutines")
+}
+
+func TestServe(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	m := New()
+	m.ObserveToolCall("listStacks", OutcomeSuccess, time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- m.Serve(ctx, addr)
+	}()
+
+	var resp *http.Response
+	require.Eventually(t, func() bool {
+		resp, err = http.Get("http://" + addr + Path)
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), `portainer_mcp_tool_calls_total{outcome="success",tool="listStacks"} 1`)
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("the metrics endpoint did not shut down")
+	}
+}
+
+// scrape returns the metrics exposed by the handler
+func scrape(t *testing.T, handler http.Handler) string {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, Path, nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	return recorder.Body.String()
+}
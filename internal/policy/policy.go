@@ -0,0 +1,244 @@
+package policy
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Rule effects
+const (
+	EffectAllow = "allow"
+	EffectDeny  = "deny"
+)
+
+// Policy is an ordered list of allow and deny rules evaluated for each tool call.
+// The first matching rule decides; when no rule matches, the default effect applies.
+type Policy struct {
+	Default string `yaml:"default"`
+	Rules   []Rule `yaml:"rules"`
+}
+
+// Rule allows or denies the tool calls matching all of its selectors.
+// An empty selector matches any call.
+type Rule struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+	Effect      string `yaml:"effect"`
+	// Tools are tool names, shell patterns such as "update*" are supported
+	Tools []string `yaml:"tools,omitempty"`
+	// Methods are the HTTP methods of proxy tools (dockerProxy, kubernetesProxy).
+	// Calls to tools without a method parameter are not filtered by method.
+	Methods []string `yaml:"methods,omitempty"`
+	// Instances are the names of the Portainer instances, see mcp.WithInstance
+	Instances       []string `yaml:"instances,omitempty"`
+	EnvironmentIDs  []int    `yaml:"environmentIds,omitempty"`
+	EnvironmentTags []string `yaml:"environmentTags,omitempty"`
+	AccessGroups    []string `yaml:"accessGroups,omitempty"`
+}
+
+// Call describes the tool call being evaluated, for a single targeted environment.
+// EnvironmentTags and AccessGroups only need to be resolved when the policy
+// references them, see RequiresEnvironmentTags and RequiresAccessGroups.
+type Call struct {
+	Tool   string
+	Method string
+	// Instance is the name of the Portainer instance of the call
+	Instance      string
+	EnvironmentID *int
+	// EnvironmentUnknown is set when the call targets environments that could not be resolved,
+	// such as the environment of a stack that does not exist. The deny rules with environment
+	// selectors then match the call, and the allow rules with environment selectors do not.
+	EnvironmentUnknown bool
+	EnvironmentTags    []string
+	AccessGroups       []string
+}
+
+// Decision is the result of the evaluation of a tool call
+type Decision struct {
+	Allowed bool
+	// Rule is the rule that decided, nil when the default effect applied
+	Rule *Rule
+}
+
+// Reason returns a human readable explanation of a decision
+func (d Decision) Reason() string {
+	if d.Rule == nil {
+		return "the default policy"
+	}
+
+	if d.Rule.Description != "" {
+		return fmt.Sprintf("policy rule %q (%s)", d.Rule.Name, d.Rule.Description)
+	}
+
+	return fmt.Sprintf("policy rule %q", d.Rule.Name)
+}
+
+// Load reads and validates a policy file
+func Load(filePath string) (*Policy, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+
+	return Parse(data)
+}
+
+// Parse parses and validates a YAML policy
+func Parse(data []byte) (*Policy, error) {
+	var policy Policy
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse policy: %w", err)
+	}
+
+	if policy.Default == "" {
+		policy.Default = EffectAllow
+	}
+
+	if err := policy.validate(); err != nil {
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+func (p *Policy) validate() error {
+	if !isValidEffect(p.Default) {
+		return fmt.Errorf("invalid default effect %q, must be allow or deny", p.Default)
+	}
+
+	names := map[string]bool{}
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+
+		if rule.Name == "" {
+			return fmt.Errorf("rule %d: name is required", i+1)
+		}
+
+		if names[rule.Name] {
+			return fmt.Errorf("rule %q: duplicate rule name", rule.Name)
+		}
+		names[rule.Name] = true
+
+		if !isValidEffect(rule.Effect) {
+			return fmt.Errorf("rule %q: invalid effect %q, must be allow or deny", rule.Name, rule.Effect)
+		}
+
+		for _, pattern := range rule.Tools {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("rule %q: invalid tool pattern %q", rule.Name, pattern)
+			}
+		}
+
+		for j, method := range rule.Methods {
+			rule.Methods[j] = strings.ToUpper(method)
+		}
+	}
+
+	return nil
+}
+
+// Evaluate returns the decision for a tool call
+func (p *Policy) Evaluate(call Call) Decision {
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		if rule.matches(call) {
+			return Decision{Allowed: rule.Effect == EffectAllow, Rule: rule}
+		}
+	}
+
+	return Decision{Allowed: p.Default == EffectAllow}
+}
+
+// EvaluateAll returns the decision for a tool call targeting several environments, described by
+// one call per environment. The call is denied when it is denied on any of the environments.
+// calls must not be empty, a call without environment is described by a single call.
+func (p *Policy) EvaluateAll(calls []Call) Decision {
+	var decision Decision
+	for _, call := range calls {
+		decision = p.Evaluate(call)
+		if !decision.Allowed {
+			return decision
+		}
+	}
+
+	return decision
+}
+
+// RequiresEnvironments reports whether any rule matches on the targeted environments,
+// by ID, tag or access group
+func (p *Policy) RequiresEnvironments() bool {
+	return slices.ContainsFunc(p.Rules, func(rule Rule) bool {
+		return rule.selectsEnvironments()
+	})
+}
+
+// RequiresEnvironmentTags reports whether any rule matches on environment tags
+func (p *Policy) RequiresEnvironmentTags() bool {
+	return slices.ContainsFunc(p.Rules, func(rule Rule) bool {
+		return len(rule.EnvironmentTags) > 0
+	})
+}
+
+// RequiresAccessGroups reports whether any rule matches on access groups
+func (p *Policy) RequiresAccessGroups() bool {
+	return slices.ContainsFunc(p.Rules, func(rule Rule) bool {
+		return len(rule.AccessGroups) > 0
+	})
+}
+
+func (r *Rule) matches(call Call) bool {
+	if len(r.Tools) > 0 && !slices.ContainsFunc(r.Tools, func(pattern string) bool {
+		matched, _ := path.Match(pattern, call.Tool)
+		return matched
+	}) {
+		return false
+	}
+
+	if len(r.Methods) > 0 && call.Method != "" && !slices.Contains(r.Methods, strings.ToUpper(call.Method)) {
+		return false
+	}
+
+	if len(r.Instances) > 0 && !slices.Contains(r.Instances, call.Instance) {
+		return false
+	}
+
+	if call.EnvironmentUnknown && r.selectsEnvironments() {
+		return r.Effect == EffectDeny
+	}
+
+	if len(r.EnvironmentIDs) > 0 && (call.EnvironmentID == nil || !slices.Contains(r.EnvironmentIDs, *call.EnvironmentID)) {
+		return false
+	}
+
+	if len(r.EnvironmentTags) > 0 && (call.EnvironmentID == nil || !containsAny(r.EnvironmentTags, call.EnvironmentTags)) {
+		return false
+	}
+
+	if len(r.AccessGroups) > 0 && (call.EnvironmentID == nil || !containsAny(r.AccessGroups, call.AccessGroups)) {
+		return false
+	}
+
+	return true
+}
+
+func (r *Rule) selectsEnvironments() bool {
+	return len(r.EnvironmentIDs) > 0 || len(r.EnvironmentTags) > 0 || len(r.AccessGroups) > 0
+}
+
+func isValidEffect(effect string) bool {
+	return effect == EffectAllow || effect == EffectDeny
+}
+
+func containsAny(selectors, values []string) bool {
+	for _, value := range values {
+		if slices.Contains(selectors, value) {
+			return true
+		}
+	}
+	return false
+}
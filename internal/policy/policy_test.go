@@ -0,0 +1,269 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPolicy = `
+default: allow
+rules:
+  - name: allow-staging-proxy
+    effect: allow
+    tools: [dockerProxy]
+    environmentIds: [5]
+  - name: no-prod-writes
+    description: production is managed by CI
+    effect: deny
+    tools: [createStack, updateStack, deleteStack, dockerProxy]
+    methods: [post, put, delete]
+    environmentTags: [prod]
+  - name: no-team-changes
+    effect: deny
+    tools: ["updateTeam*"]
+  - name: no-audit-instance-writes
+    effect: deny
+    tools: ["create*"]
+    instances: [audit]
+  - name: restricted-group
+    effect: deny
+    accessGroups: [finance]
+`
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestEvaluate(t *testing.T) {
+	p, err := Parse([]byte(testPolicy))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		call          Call
+		expectedAllow bool
+		expectedRule  string
+	}{
+		{
+			name:          "no rule matches",
+			call:          Call{Tool: "listStacks"},
+			expectedAllow: true,
+		},
+		{
+			name:          "stack write on prod environment",
+			call:          Call{Tool: "deleteStack", EnvironmentID: intPtr(1), EnvironmentTags: []string{"prod", "eu"}},
+			expectedAllow: false,
+			expectedRule:  "no-prod-writes",
+		},
+		{
+			name:          "stack write on dev environment",
+			call:          Call{Tool: "deleteStack", EnvironmentID: intPtr(2), EnvironmentTags: []string{"dev"}},
+			expectedAllow: true,
+		},
+		{
+			name:          "proxy write on prod environment",
+			call:          Call{Tool: "dockerProxy", Method: "POST", EnvironmentID: intPtr(1), EnvironmentTags: []string{"prod"}},
+			expectedAllow: false,
+			expectedRule:  "no-prod-writes",
+		},
+		{
+			name:          "proxy read on prod environment",
+			call:          Call{Tool: "dockerProxy", Method: "GET", EnvironmentID: intPtr(1), EnvironmentTags: []string{"prod"}},
+			expectedAllow: true,
+		},
+		{
+			name:          "first matching rule wins",
+			call:          Call{Tool: "dockerProxy", Method: "POST", EnvironmentID: intPtr(5), EnvironmentTags: []string{"prod"}},
+			expectedAllow: true,
+			expectedRule:  "allow-staging-proxy",
+		},
+		{
+			name:          "tool pattern",
+			call:          Call{Tool: "updateTeamMembers"},
+			expectedAllow: false,
+			expectedRule:  "no-team-changes",
+		},
+		{
+			name:          "instance",
+			call:          Call{Tool: "createEnvironmentTag", Instance: "audit"},
+			expectedAllow: false,
+			expectedRule:  "no-audit-instance-writes",
+		},
+		{
+			name:          "other instance",
+			call:          Call{Tool: "createEnvironmentTag", Instance: "production"},
+			expectedAllow: true,
+		},
+		{
+			name:          "access group",
+			call:          Call{Tool: "listEnvironments", EnvironmentID: intPtr(7), AccessGroups: []string{"finance"}},
+			expectedAllow: false,
+			expectedRule:  "restricted-group",
+		},
+		{
+			name:          "environment selectors do not match calls without environment",
+			call:          Call{Tool: "createStack"},
+			expectedAllow: true,
+		},
+		{
+			name:          "deny rules with environment selectors match unknown environments",
+			call:          Call{Tool: "deleteStack", EnvironmentUnknown: true},
+			expectedAllow: false,
+			expectedRule:  "no-prod-writes",
+		},
+		{
+			name:          "allow rules with environment selectors do not match unknown environments",
+			call:          Call{Tool: "dockerProxy", Method: "GET", EnvironmentUnknown: true},
+			expectedAllow: false,
+			expectedRule:  "restricted-group",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := p.Evaluate(tt.call)
+			assert.Equal(t, tt.expectedAllow, decision.Allowed)
+			if tt.expectedRule == "" {
+				assert.Nil(t, decision.Rule)
+			} else {
+				require.NotNil(t, decision.Rule)
+				assert.Equal(t, tt.expectedRule, decision.Rule.Name)
+			}
+		})
+	}
+}
+
+func TestEvaluateAll(t *testing.T) {
+	p, err := Parse([]byte(`
+rules:
+  - name: no-prod-edge-stacks
+    effect: deny
+    tools: ["*EdgeStack"]
+    environmentTags: [prod]
+`))
+	require.NoError(t, err)
+
+	decision := p.EvaluateAll([]Call{
+		{Tool: "deleteEdgeStack", EnvironmentID: intPtr(1), EnvironmentTags: []string{"dev"}},
+		{Tool: "deleteEdgeStack", EnvironmentID: intPtr(2), EnvironmentTags: []string{"prod"}},
+	})
+	assert.False(t, decision.Allowed)
+	require.NotNil(t, decision.Rule)
+	assert.Equal(t, "no-prod-edge-stacks", decision.Rule.Name)
+
+	assert.True(t, p.EvaluateAll([]Call{
+		{Tool: "deleteEdgeStack", EnvironmentID: intPtr(1), EnvironmentTags: []string{"dev"}},
+		{Tool: "deleteEdgeStack", EnvironmentID: intPtr(3)},
+	}).Allowed)
+
+	assert.False(t, p.EvaluateAll([]Call{{Tool: "deleteEdgeStack", EnvironmentUnknown: true}}).Allowed)
+	assert.True(t, p.EvaluateAll([]Call{{Tool: "listEdgeStacks", EnvironmentUnknown: true}}).Allowed)
+}
+
+func TestDefaultDeny(t *testing.T) {
+	p, err := Parse([]byte(`
+default: deny
+rules:
+  - name: reads
+    effect: allow
+    tools: ["list*", "get*"]
+`))
+	require.NoError(t, err)
+
+	assert.True(t, p.Evaluate(Call{Tool: "listStacks"}).Allowed)
+
+	decision := p.Evaluate(Call{Tool: "deleteStack"})
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, "the default policy", decision.Reason())
+}
+
+func TestDecisionReason(t *testing.T) {
+	p, err := Parse([]byte(testPolicy))
+	require.NoError(t, err)
+
+	assert.Equal(t, `policy rule "no-team-changes"`, p.Evaluate(Call{Tool: "updateTeamName"}).Reason())
+	assert.Equal(t, `policy rule "no-prod-writes" (production is managed by CI)`,
+		p.Evaluate(Call{Tool: "createStack", EnvironmentID: intPtr(1), EnvironmentTags: []string{"prod"}}).Reason())
+}
+
+func TestRequires(t *testing.T) {
+	p, err := Parse([]byte(testPolicy))
+	require.NoError(t, err)
+	assert.True(t, p.RequiresEnvironments())
+	assert.True(t, p.RequiresEnvironmentTags())
+	assert.True(t, p.RequiresAccessGroups())
+
+	p, err = Parse([]byte("rules:\n  - name: r\n    effect: deny\n    environmentIds: [1]\n"))
+	require.NoError(t, err)
+	assert.True(t, p.RequiresEnvironments())
+	assert.False(t, p.RequiresEnvironmentTags())
+	assert.False(t, p.RequiresAccessGroups())
+	assert.Equal(t, EffectAllow, p.Default)
+
+	p, err = Parse([]byte("rules:\n  - name: r\n    effect: deny\n    tools: [deleteStack]\n"))
+	require.NoError(t, err)
+	assert.False(t, p.RequiresEnvironments())
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        string
+		errorContains string
+	}{
+		{
+			name:          "invalid yaml",
+			policy:        "rules: [",
+			errorContains: "failed to parse policy",
+		},
+		{
+			name:          "invalid default",
+			policy:        "default: maybe",
+			errorContains: "invalid default effect",
+		},
+		{
+			name:          "missing name",
+			policy:        "rules:\n  - effect: deny\n",
+			errorContains: "name is required",
+		},
+		{
+			name:          "duplicate name",
+			policy:        "rules:\n  - name: a\n    effect: deny\n  - name: a\n    effect: allow\n",
+			errorContains: "duplicate rule name",
+		},
+		{
+			name:          "invalid effect",
+			policy:        "rules:\n  - name: a\n    effect: block\n",
+			errorContains: "invalid effect",
+		},
+		{
+			name:          "invalid tool pattern",
+			policy:        "rules:\n  - name: a\n    effect: deny\n    tools: [\"[\"]\n",
+			errorContains: "invalid tool pattern",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.policy))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errorContains)
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testPolicy), 0600))
+
+	p, err := Load(path)
+	require.NoError(t, err)
+	assert.Len(t, p.Rules, 5)
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,44 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// ServiceName is the service name of the exported spans
+const ServiceName = "portainer-mcp"
+
+// NewProvider returns a tracer provider exporting the spans over OTLP/HTTP to the given endpoint URL,
+// e.g. http://localhost:4318. The standard OTEL_EXPORTER_OTLP_* environment variables configure the
+// exporter further, for example its headers. The provider must be shut down to flush the last spans.
+func NewProvider(ctx context.Context, endpoint, version string) (*sdktrace.TracerProvider, error) {
+	// The exporter ignores an invalid endpoint, falling back to the default one
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid tracing endpoint %q, expected a URL such as http://localhost:4318", endpoint)
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", ServiceName),
+		attribute.String("service.version", version),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tracing resource: %w", err)
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	), nil
+}
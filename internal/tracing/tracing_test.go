@@ -0,0 +1,39 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProvider(t *testing.T) {
+	var exports atomic.Int32
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+		exports.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+
+	provider, err := NewProvider(context.Background(), collector.URL, "1.2.3")
+	require.NoError(t, err)
+
+	_, span := provider.Tracer("test").Start(context.Background(), "tools/call listStacks")
+	span.End()
+
+	require.NoError(t, provider.Shutdown(context.Background()), "shutting down flushes the spans")
+	assert.Equal(t, int32(1), exports.Load())
+}
+
+func TestNewProviderInvalidEndpoint(t *testing.T) {
+	for _, endpoint := range []string{"://collector", "localhost:4318", "grpc://localhost:4317", "http://"} {
+		_, err := NewProvider(context.Background(), endpoint, "1.2.3")
+		assert.ErrorContains(t, err, "invalid tracing endpoint", endpoint)
+	}
+}
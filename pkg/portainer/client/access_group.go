@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/portainer/portainer-mcp/pkg/portainer/models"
@@ -13,13 +14,15 @@ import (
 // Returns:
 //   - A slice of AccessGroup objects
 //   - An error if the operation fails
-func (c *PortainerClient) GetAccessGroups() ([]models.AccessGroup, error) {
-	groups, err := c.cli.ListEndpointGroups()
+func (c *PortainerClient) GetAccessGroups(ctx context.Context) ([]models.AccessGroup, error) {
+	ctx = withOperation(ctx, "GetAccessGroups")
+
+	groups, err := c.cli.ListEndpointGroups(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	endpoints, err := c.cli.ListEndpoints()
+	endpoints, err := c.cli.ListEndpoints(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -35,13 +38,16 @@ func (c *PortainerClient) GetAccessGroups() ([]models.AccessGroup, error) {
 // CreateAccessGroup creates a new access group in Portainer.
 //
 // Parameters:
+//   - ctx: The context of the request, cancelling it aborts the call to Portainer
 //   - name: The name of the access group
 //   - environmentIds: The IDs of the environments that are part of the access group
 //
 // Returns:
 //   - An error if the operation fails
-func (c *PortainerClient) CreateAccessGroup(name string, environmentIds []int) (int, error) {
-	groupID, err := c.cli.CreateEndpointGroup(name, utils.IntToInt64Slice(environmentIds))
+func (c *PortainerClient) CreateAccessGroup(ctx context.Context, name string, environmentIds []int) (int, error) {
+	ctx = withOperation(ctx, "CreateAccessGroup")
+
+	groupID, err := c.cli.CreateEndpointGroup(ctx, name, utils.IntToInt64Slice(environmentIds))
 	if err != nil {
 		return 0, fmt.Errorf("failed to create access group: %w", err)
 	}
@@ -52,13 +58,16 @@ func (c *PortainerClient) CreateAccessGroup(name string, environmentIds []int) (
 // UpdateAccessGroupName updates the name of an existing access group in Portainer.
 //
 // Parameters:
+//   - ctx: The context of the request, cancelling it aborts the call to Portainer
 //   - id: The ID of the access group
 //   - name: The new name for the access group
 //
 // Returns:
 //   - An error if the operation fails
-func (c *PortainerClient) UpdateAccessGroupName(id int, name string) error {
-	err := c.cli.UpdateEndpointGroup(int64(id), &name, nil, nil)
+func (c *PortainerClient) UpdateAccessGroupName(ctx context.Context, id int, name string) error {
+	ctx = withOperation(ctx, "UpdateAccessGroupName")
+
+	err := c.cli.UpdateEndpointGroup(ctx, int64(id), &name, nil, nil)
 	if err != nil {
 		return fmt.Errorf("failed to update access group name: %w", err)
 	}
@@ -68,6 +77,7 @@ func (c *PortainerClient) UpdateAccessGroupName(id int, name string) error {
 // UpdateAccessGroupUserAccesses updates the user access policies of an existing access group in Portainer.
 //
 // Parameters:
+//   - ctx: The context of the request, cancelling it aborts the call to Portainer
 //   - id: The ID of the access group
 //   - userAccesses: Map of user IDs to their access level
 //
@@ -80,9 +90,11 @@ func (c *PortainerClient) UpdateAccessGroupName(id int, name string) error {
 //
 // Returns:
 //   - An error if the operation fails
-func (c *PortainerClient) UpdateAccessGroupUserAccesses(id int, userAccesses map[int]string) error {
+func (c *PortainerClient) UpdateAccessGroupUserAccesses(ctx context.Context, id int, userAccesses map[int]string) error {
+	ctx = withOperation(ctx, "UpdateAccessGroupUserAccesses")
+
 	uac := utils.IntToInt64Map(userAccesses)
-	err := c.cli.UpdateEndpointGroup(int64(id), nil, &uac, nil)
+	err := c.cli.UpdateEndpointGroup(ctx, int64(id), nil, &uac, nil)
 	if err != nil {
 		return fmt.Errorf("failed to update access group user accesses: %w", err)
 	}
@@ -92,6 +104,7 @@ func (c *PortainerClient) UpdateAccessGroupUserAccesses(id int, userAccesses map
 // UpdateAccessGroupTeamAccesses updates the team access policies of an existing access group in Portainer.
 //
 // Parameters:
+//   - ctx: The context of the request, cancelling it aborts the call to Portainer
 //   - id: The ID of the access group
 //   - teamAccesses: Map of team IDs to their access level
 //
@@ -104,9 +117,11 @@ func (c *PortainerClient) UpdateAccessGroupUserAccesses(id int, userAccesses map
 //
 // Returns:
 //   - An error if the operation fails
-func (c *PortainerClient) UpdateAccessGroupTeamAccesses(id int, teamAccesses map[int]string) error {
+func (c *PortainerClient) UpdateAccessGroupTeamAccesses(ctx context.Context, id int, teamAccesses map[int]string) error {
+	ctx = withOperation(ctx, "UpdateAccessGroupTeamAccesses")
+
 	tac := utils.IntToInt64Map(teamAccesses)
-	err := c.cli.UpdateEndpointGroup(int64(id), nil, nil, &tac)
+	err := c.cli.UpdateEndpointGroup(ctx, int64(id), nil, nil, &tac)
 	if err != nil {
 		return fmt.Errorf("failed to update access group team accesses: %w", err)
 	}
@@ -116,23 +131,29 @@ func (c *PortainerClient) UpdateAccessGroupTeamAccesses(id int, teamAccesses map
 // AddEnvironmentToAccessGroup adds an environment to an access group
 //
 // Parameters:
+//   - ctx: The context of the request, cancelling it aborts the call to Portainer
 //   - id: The ID of the access group
 //   - environmentId: The ID of the environment to add to the access group
 //
 // Returns:
 //   - An error if the operation fails
-func (c *PortainerClient) AddEnvironmentToAccessGroup(id int, environmentId int) error {
-	return c.cli.AddEnvironmentToEndpointGroup(int64(id), int64(environmentId))
+func (c *PortainerClient) AddEnvironmentToAccessGroup(ctx context.Context, id int, environmentId int) error {
+	ctx = withOperation(ctx, "AddEnvironmentToAccessGroup")
+
+	return c.cli.AddEnvironmentToEndpointGroup(ctx, int64(id), int64(environmentId))
 }
 
 // RemoveEnvironmentFromAccessGroup removes an environment from an access group
 //
 // Parameters:
+//   - ctx: The context of the request, cancelling it aborts the call to Portainer
 //   - id: The ID of the access group
 //   - environmentId: The ID of the environment to remove from the access group
 //
 // Returns:
 //   - An error if the operation fails
-func (c *PortainerClient) RemoveEnvironmentFromAccessGroup(id int, environmentId int) error {
-	return c.cli.RemoveEnvironmentFromEndpointGroup(int64(id), int64(environmentId))
+func (c *PortainerClient) RemoveEnvironmentFromAccessGroup(ctx context.Context, id int, environmentId int) error {
+	ctx = withOperation(ctx, "RemoveEnvironmentFromAccessGroup")
+
+	return c.cli.RemoveEnvironmentFromEndpointGroup(ctx, int64(id), int64(environmentId))
 }
@@ -0,0 +1,424 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/portainer/client-api-go/v2/client"
+	sdkutils "github.com/portainer/client-api-go/v2/client/utils"
+	sdkclient "github.com/portainer/client-api-go/v2/pkg/client"
+	"github.com/portainer/client-api-go/v2/pkg/client/edge_groups"
+	"github.com/portainer/client-api-go/v2/pkg/client/edge_stacks"
+	"github.com/portainer/client-api-go/v2/pkg/client/endpoint_groups"
+	"github.com/portainer/client-api-go/v2/pkg/client/endpoints"
+	"github.com/portainer/client-api-go/v2/pkg/client/settings"
+	"github.com/portainer/client-api-go/v2/pkg/client/system"
+	"github.com/portainer/client-api-go/v2/pkg/client/tags"
+	"github.com/portainer/client-api-go/v2/pkg/client/team_memberships"
+	"github.com/portainer/client-api-go/v2/pkg/client/teams"
+	"github.com/portainer/client-api-go/v2/pkg/client/users"
+	apimodels "github.com/portainer/client-api-go/v2/pkg/models"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// apiClient implements PortainerAPIClient on top of the generated Portainer API services.
+// It replaces the client of the SDK, whose HTTP transports cannot be configured, so that
+// the API and the proxied requests share the HTTP client and its TLS settings.
+type apiClient struct {
+	cli    *sdkclient.PortainerClientAPI
+	http   *http.Client
+	host   string
+	token  string
+	tracer trace.Tracer
+}
+
+func (c *apiClient) ListEdgeGroups(ctx context.Context) ([]*apimodels.EdgegroupsDecoratedEdgeGroup, error) {
+	resp, err := c.cli.EdgeGroups.EdgeGroupList(edge_groups.NewEdgeGroupListParamsWithContext(ctx), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list edge groups: %w", err)
+	}
+
+	return resp.Payload, nil
+}
+
+func (c *apiClient) CreateEdgeGroup(ctx context.Context, name string, environmentIds []int64) (int64, error) {
+	params := edge_groups.NewEdgeGroupCreateParamsWithContext(ctx).WithBody(&apimodels.EdgegroupsEdgeGroupCreatePayload{
+		Name:      name,
+		Endpoints: environmentIds,
+		Dynamic:   false,
+	})
+
+	resp, err := c.cli.EdgeGroups.EdgeGroupCreate(params, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create edge group: %w", err)
+	}
+
+	return resp.Payload.ID, nil
+}
+
+// UpdateEdgeGroup updates the edge group fields that are not nil.
+// Setting the tags makes the edge group dynamic.
+func (c *apiClient) UpdateEdgeGroup(ctx context.Context, id int64, name *string, environmentIds *[]int64, tagIds *[]int64) error {
+	params := edge_groups.NewEdgeGroupUpdateParamsWithContext(ctx).WithID(id).WithBody(&apimodels.EdgegroupsEdgeGroupUpdatePayload{})
+
+	if name != nil {
+		params.Body.Name = *name
+	}
+
+	if environmentIds != nil {
+		params.Body.Endpoints = *environmentIds
+	}
+
+	if tagIds != nil {
+		params.Body.TagIDs = *tagIds
+		params.Body.Dynamic = true
+	}
+
+	if _, err := c.cli.EdgeGroups.EdgeGroupUpdate(params, nil); err != nil {
+		return fmt.Errorf("failed to update edge group: %w", err)
+	}
+
+	return nil
+}
+
+func (c *apiClient) ListEdgeStacks(ctx context.Context) ([]*apimodels.PortainereeEdgeStack, error) {
+	resp, err := c.cli.EdgeStacks.EdgeStackList(edge_stacks.NewEdgeStackListParamsWithContext(ctx), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list edge stacks: %w", err)
+	}
+
+	return resp.Payload, nil
+}
+
+func (c *apiClient) CreateEdgeStack(ctx context.Context, name string, file string, environmentGroupIds []int64) (int64, error) {
+	params := edge_stacks.NewEdgeStackCreateStringParamsWithContext(ctx).WithBody(&apimodels.EdgestacksEdgeStackFromStringPayload{
+		Name:             &name,
+		StackFileContent: &file,
+		EdgeGroups:       environmentGroupIds,
+		DeploymentType:   0,
+	})
+
+	resp, err := c.cli.EdgeStacks.EdgeStackCreateString(params, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create edge stack: %w", err)
+	}
+
+	return resp.Payload.ID, nil
+}
+
+func (c *apiClient) UpdateEdgeStack(ctx context.Context, id int64, file string, environmentGroupIds []int64, deploymentType int64) error {
+	params := edge_stacks.NewEdgeStackUpdateParamsWithContext(ctx).WithID(id).WithBody(&apimodels.EdgestacksUpdateEdgeStackPayload{
+		StackFileContent: file,
+		EdgeGroups:       environmentGroupIds,
+		DeploymentType:   deploymentType,
+		UpdateVersion:    true,
+	})
+
+	if _, err := c.cli.EdgeStacks.EdgeStackUpdate(params, nil); err != nil {
+		return fmt.Errorf("failed to update edge stack: %w", err)
+	}
+
+	return nil
+}
+
+func (c *apiClient) GetEdgeStack(ctx context.Context, id int64) (*apimodels.PortainereeEdgeStack, error) {
+	resp, err := c.cli.EdgeStacks.EdgeStackInspect(edge_stacks.NewEdgeStackInspectParamsWithContext(ctx).WithID(id), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get edge stack: %w", err)
+	}
+
+	return resp.Payload, nil
+}
+
+func (c *apiClient) DeleteEdgeStack(ctx context.Context, id int64) error {
+	if _, err := c.cli.EdgeStacks.EdgeStackDelete(edge_stacks.NewEdgeStackDeleteParamsWithContext(ctx).WithID(id), nil); err != nil {
+		return fmt.Errorf("failed to delete edge stack: %w", err)
+	}
+
+	return nil
+}
+
+func (c *apiClient) GetEdgeStackFile(ctx context.Context, id int64) (string, error) {
+	resp, err := c.cli.EdgeStacks.EdgeStackFile(edge_stacks.NewEdgeStackFileParamsWithContext(ctx).WithID(id), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get edge stack file: %w", err)
+	}
+
+	return resp.Payload.StackFileContent, nil
+}
+
+func (c *apiClient) ListEndpointGroups(ctx context.Context) ([]*apimodels.PortainerEndpointGroup, error) {
+	resp, err := c.cli.EndpointGroups.EndpointGroupList(endpoint_groups.NewEndpointGroupListParamsWithContext(ctx), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list endpoint groups: %w", err)
+	}
+
+	return resp.Payload, nil
+}
+
+func (c *apiClient) CreateEndpointGroup(ctx context.Context, name string, associatedEndpoints []int64) (int64, error) {
+	params := endpoint_groups.NewPostEndpointGroupsParamsWithContext(ctx).WithBody(&apimodels.EndpointgroupsEndpointGroupCreatePayload{
+		Name:                &name,
+		AssociatedEndpoints: associatedEndpoints,
+	})
+
+	resp, err := c.cli.EndpointGroups.PostEndpointGroups(params, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create endpoint group: %w", err)
+	}
+
+	return resp.Payload.ID, nil
+}
+
+// UpdateEndpointGroup updates the endpoint group fields that are not nil.
+// Access maps are keyed by user or team ID and hold role names, invalid roles are ignored.
+func (c *apiClient) UpdateEndpointGroup(ctx context.Context, id int64, name *string, userAccesses *map[int64]string, teamAccesses *map[int64]string) error {
+	params := endpoint_groups.NewEndpointGroupUpdateParamsWithContext(ctx).WithID(id).WithBody(&apimodels.EndpointgroupsEndpointGroupUpdatePayload{})
+
+	if name != nil {
+		params.Body.Name = *name
+	}
+
+	if userAccesses != nil {
+		params.Body.UserAccessPolicies = sdkutils.BuildAccessPolicies[apimodels.PortainerUserAccessPolicies](*userAccesses)
+	}
+
+	if teamAccesses != nil {
+		params.Body.TeamAccessPolicies = sdkutils.BuildAccessPolicies[apimodels.PortainerTeamAccessPolicies](*teamAccesses)
+	}
+
+	if _, err := c.cli.EndpointGroups.EndpointGroupUpdate(params, nil); err != nil {
+		return fmt.Errorf("failed to update endpoint group: %w", err)
+	}
+
+	return nil
+}
+
+func (c *apiClient) AddEnvironmentToEndpointGroup(ctx context.Context, groupId int64, environmentId int64) error {
+	params := endpoint_groups.NewEndpointGroupAddEndpointParamsWithContext(ctx).WithID(groupId).WithEndpointID(environmentId)
+	if _, err := c.cli.EndpointGroups.EndpointGroupAddEndpoint(params, nil); err != nil {
+		return fmt.Errorf("failed to add environment to endpoint group: %w", err)
+	}
+
+	return nil
+}
+
+func (c *apiClient) RemoveEnvironmentFromEndpointGroup(ctx context.Context, groupId int64, environmentId int64) error {
+	params := endpoint_groups.NewEndpointGroupDeleteEndpointParamsWithContext(ctx).WithID(groupId).WithEndpointID(environmentId)
+	if _, err := c.cli.EndpointGroups.EndpointGroupDeleteEndpoint(params, nil); err != nil {
+		return fmt.Errorf("failed to remove environment from endpoint group: %w", err)
+	}
+
+	return nil
+}
+
+func (c *apiClient) ListEndpoints(ctx context.Context) ([]*apimodels.PortainereeEndpoint, error) {
+	resp, err := c.cli.Endpoints.EndpointList(endpoints.NewEndpointListParamsWithContext(ctx), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list endpoints: %w", err)
+	}
+
+	return resp.Payload, nil
+}
+
+func (c *apiClient) GetEndpoint(ctx context.Context, id int64) (*apimodels.PortainereeEndpoint, error) {
+	resp, err := c.cli.Endpoints.EndpointInspect(endpoints.NewEndpointInspectParamsWithContext(ctx).WithID(id), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get endpoint: %w", err)
+	}
+
+	return resp.Payload, nil
+}
+
+// UpdateEndpoint updates the endpoint fields that are not nil.
+// Access maps are keyed by user or team ID and hold role names, invalid roles are ignored.
+func (c *apiClient) UpdateEndpoint(ctx context.Context, id int64, tagIds *[]int64, userAccesses *map[int64]string, teamAccesses *map[int64]string) error {
+	params := endpoints.NewEndpointUpdateParamsWithContext(ctx).WithID(id).WithBody(&apimodels.EndpointsEndpointUpdatePayload{})
+
+	if tagIds != nil {
+		params.Body.TagIDs = *tagIds
+	}
+
+	if userAccesses != nil {
+		params.Body.UserAccessPolicies = sdkutils.BuildAccessPolicies[apimodels.PortainerUserAccessPolicies](*userAccesses)
+	}
+
+	if teamAccesses != nil {
+		params.Body.TeamAccessPolicies = sdkutils.BuildAccessPolicies[apimodels.PortainerTeamAccessPolicies](*teamAccesses)
+	}
+
+	_, err := c.cli.Endpoints.EndpointUpdate(params, nil)
+	return err
+}
+
+func (c *apiClient) GetSettings(ctx context.Context) (*apimodels.PortainereeSettings, error) {
+	resp, err := c.cli.Settings.SettingsInspect(settings.NewSettingsInspectParamsWithContext(ctx), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get settings: %w", err)
+	}
+
+	return resp.Payload, nil
+}
+
+func (c *apiClient) ListTags(ctx context.Context) ([]*apimodels.PortainerTag, error) {
+	resp, err := c.cli.Tags.TagList(tags.NewTagListParamsWithContext(ctx), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	return resp.Payload, nil
+}
+
+func (c *apiClient) CreateTag(ctx context.Context, name string) (int64, error) {
+	params := tags.NewTagCreateParamsWithContext(ctx).WithBody(&apimodels.TagsTagCreatePayload{
+		Name: &name,
+	})
+
+	resp, err := c.cli.Tags.TagCreate(params, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create tag: %w", err)
+	}
+
+	return resp.Payload.ID, nil
+}
+
+func (c *apiClient) ListTeams(ctx context.Context) ([]*apimodels.PortainerTeam, error) {
+	resp, err := c.cli.Teams.TeamList(teams.NewTeamListParamsWithContext(ctx), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list teams: %w", err)
+	}
+
+	return resp.Payload, nil
+}
+
+func (c *apiClient) ListTeamMemberships(ctx context.Context) ([]*apimodels.PortainerTeamMembership, error) {
+	resp, err := c.cli.TeamMemberships.TeamMembershipList(team_memberships.NewTeamMembershipListParamsWithContext(ctx), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list team memberships: %w", err)
+	}
+
+	return resp.Payload, nil
+}
+
+func (c *apiClient) CreateTeam(ctx context.Context, name string) (int64, error) {
+	params := teams.NewTeamCreateParamsWithContext(ctx).WithBody(&apimodels.TeamsTeamCreatePayload{
+		Name: &name,
+	})
+
+	resp, err := c.cli.Teams.TeamCreate(params, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create team: %w", err)
+	}
+
+	return resp.Payload.ID, nil
+}
+
+func (c *apiClient) UpdateTeamName(ctx context.Context, id int, name string) error {
+	params := teams.NewTeamUpdateParamsWithContext(ctx).WithID(int64(id)).WithBody(&apimodels.TeamsTeamUpdatePayload{
+		Name: name,
+	})
+
+	_, err := c.cli.Teams.TeamUpdate(params, nil)
+	return err
+}
+
+func (c *apiClient) DeleteTeamMembership(ctx context.Context, id int) error {
+	_, err := c.cli.TeamMemberships.TeamMembershipDelete(team_memberships.NewTeamMembershipDeleteParamsWithContext(ctx).WithID(int64(id)), nil)
+	return err
+}
+
+// CreateTeamMembership adds the user to the team with the team member role
+func (c *apiClient) CreateTeamMembership(ctx context.Context, teamId int, userId int) error {
+	teamID := int64(teamId)
+	userID := int64(userId)
+	role := int64(2)
+
+	params := team_memberships.NewTeamMembershipCreateParamsWithContext(ctx).WithBody(&apimodels.TeammembershipsTeamMembershipCreatePayload{
+		Role:   &role,
+		TeamID: &teamID,
+		UserID: &userID,
+	})
+
+	_, err := c.cli.TeamMemberships.TeamMembershipCreate(params, nil)
+	return err
+}
+
+func (c *apiClient) ListUsers(ctx context.Context) ([]*apimodels.PortainereeUser, error) {
+	resp, err := c.cli.Users.UserList(users.NewUserListParamsWithContext(ctx), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	return resp.Payload, nil
+}
+
+func (c *apiClient) UpdateUserRole(ctx context.Context, id int, role int64) error {
+	params := users.NewUserUpdateParamsWithContext(ctx).WithID(int64(id)).WithBody(&apimodels.UsersUserUpdatePayload{
+		Role: &role,
+	})
+
+	_, err := c.cli.Users.UserUpdate(params, nil)
+	return err
+}
+
+func (c *apiClient) GetVersion(ctx context.Context) (string, error) {
+	resp, err := c.cli.System.SystemStatus(system.NewSystemStatusParamsWithContext(ctx))
+	if err != nil {
+		return "", fmt.Errorf("failed to get version: %w", err)
+	}
+
+	return resp.Payload.Version, nil
+}
+
+func (c *apiClient) ProxyDockerRequest(ctx context.Context, environmentId int, opts client.ProxyRequestOptions) (*http.Response, error) {
+	return c.proxyRequest(ctx, "docker", environmentId, opts)
+}
+
+func (c *apiClient) ProxyKubernetesRequest(ctx context.Context, environmentId int, opts client.ProxyRequestOptions) (*http.Response, error) {
+	return c.proxyRequest(ctx, "kubernetes", environmentId, opts)
+}
+
+// proxyRequest sends a request to the given API (docker or kubernetes) of an environment through Portainer
+func (c *apiClient) proxyRequest(ctx context.Context, api string, environmentId int, opts client.ProxyRequestOptions) (*http.Response, error) {
+	ctx, span := startProxySpan(ctx, c.tracer, api, environmentId, opts)
+	defer span.End()
+
+	url := fmt.Sprintf("https://%s/api/endpoints/%d/%s%s", c.host, environmentId, api, opts.APIPath)
+	req, err := http.NewRequestWithContext(ctx, opts.Method, url, opts.Body)
+	if err != nil {
+		endSpan(span, err)
+		return nil, fmt.Errorf("failed to create proxy request: %w", err)
+	}
+
+	if opts.QueryParams != nil {
+		q := req.URL.Query()
+		for k, v := range opts.QueryParams {
+			q.Set(k, v)
+		}
+		req.URL.RawQuery = q.Encode()
+	}
+
+	if c.token != "" {
+		req.Header.Set("x-api-key", c.token)
+	}
+
+	for k, v := range opts.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		endSpan(span, err)
+		return nil, fmt.Errorf("failed to send proxy request: %w", err)
+	}
+
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+
+	return resp, nil
+}
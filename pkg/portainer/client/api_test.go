@@ -0,0 +1,74 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/portainer/portainer-mcp/pkg/portainer/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIClientContext(t *testing.T) {
+	// The server hangs until the client gives up, like an unreachable edge environment
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	c := NewPortainerClient(strings.TrimPrefix(srv.URL, "https://"), "token",
+		WithSkipTLSVerify(true),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 1}),
+	)
+
+	tests := []struct {
+		name string
+		call func(ctx context.Context) error
+	}{
+		{
+			name: "API request",
+			call: func(ctx context.Context) error {
+				_, err := c.GetEnvironments(ctx)
+				return err
+			},
+		},
+		{
+			name: "stacks request",
+			call: func(ctx context.Context) error {
+				_, err := c.GetStacks(ctx)
+				return err
+			},
+		},
+		{
+			name: "proxied Docker request",
+			call: func(ctx context.Context) error {
+				_, err := c.ProxyDockerRequest(ctx, models.DockerProxyRequestOptions{
+					EnvironmentID: 1,
+					Method:        http.MethodGet,
+					Path:          "/containers/json",
+				})
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+
+			done := make(chan error, 1)
+			go func() { done <- tt.call(ctx) }()
+
+			select {
+			case err := <-done:
+				assert.ErrorIs(t, err, context.DeadlineExceeded)
+			case <-time.After(5 * time.Second):
+				t.Fatal("the request was not aborted when its context expired")
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenRenewalMargin renews the JWT slightly before it expires, so that a request
+// is not sent with a token expiring on its way to Portainer
+const tokenRenewalMargin = time.Minute
+
+// WithCredentials authenticates to Portainer with a username and a password instead of
+// an API key, for the accounts that cannot use API keys. The client logs in with /api/auth,
+// keeps the JWT in memory and logs in again when it expires or when Portainer rejects it.
+// The token given to NewPortainerClient is then ignored.
+func WithCredentials(username, password string) ClientOption {
+	return func(o *clientOptions) {
+		o.username = username
+		o.password = password
+	}
+}
+
+// jwtAuth logs in to Portainer and caches the resulting JWT until it expires
+type jwtAuth struct {
+	loginURL string
+	username string
+	password string
+	http     *http.Client
+	now      func() time.Time
+
+	mu        sync.Mutex
+	jwt       string
+	expiresAt time.Time
+}
+
+func newJWTAuth(loginURL, username, password string, httpClient *http.Client) *jwtAuth {
+	return &jwtAuth{
+		loginURL: loginURL,
+		username: username,
+		password: password,
+		http:     httpClient,
+		now:      time.Now,
+	}
+}
+
+// token returns the cached JWT, logging in first when there is none or it is about to expire.
+// Concurrent callers wait for a single login.
+func (a *jwtAuth) token(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.jwt != "" && (a.expiresAt.IsZero() || a.now().Add(tokenRenewalMargin).Before(a.expiresAt)) {
+		return a.jwt, nil
+	}
+
+	jwt, err := a.login(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	a.jwt = jwt
+	a.expiresAt = jwtExpiry(jwt)
+	return jwt, nil
+}
+
+// invalidate drops the given JWT after Portainer rejected it, unless it was already renewed
+func (a *jwtAuth) invalidate(jwt string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.jwt == jwt {
+		a.jwt = ""
+	}
+}
+
+// login authenticates with the username and the password and returns the JWT
+func (a *jwtAuth) login(ctx context.Context) (string, error) {
+	body, err := json.Marshal(map[string]string{
+		"username": a.username,
+		"password": a.password,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode credentials: %w", err)
+	}
+
+	// The login is reported as its own operation rather than as part of the call that triggered it
+	ctx = context.WithValue(ctx, operationKey{}, "Login")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.loginURL, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create login request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to log in to Portainer as %s: %w", a.username, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to log in to Portainer as %s: %s", a.username, resp.Status)
+	}
+
+	var payload struct {
+		JWT string `json:"jwt"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return "", fmt.Errorf("failed to decode login response: %w", err)
+	}
+
+	if payload.JWT == "" {
+		return "", fmt.Errorf("login response does not contain a token")
+	}
+
+	return payload.JWT, nil
+}
+
+// jwtExpiry returns the expiry of a JWT, or the zero time when it cannot be read.
+// The signature is not verified, the expiry only schedules the renewal of the token.
+func jwtExpiry(jwt string) time.Time {
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}
+	}
+
+	var claims struct {
+		ExpiresAt int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(data, &claims); err != nil || claims.ExpiresAt == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(claims.ExpiresAt, 0)
+}
+
+// authTransport authenticates the requests sent to Portainer, including the proxied ones, with
+// the JWT of a jwtAuth. A request rejected with a 401 is sent again once with a new JWT, as long
+// as its body can be replayed.
+type authTransport struct {
+	next http.RoundTripper
+	auth *jwtAuth
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	jwt, err := t.auth.token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(withBearerToken(req, jwt))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || !isReplayable(req) {
+		return resp, err
+	}
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	t.auth.invalidate(jwt)
+	jwt, err = t.auth.token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	retry := withBearerToken(req, jwt)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("failed to replay request body: %w", err)
+		}
+		retry.Body = body
+	}
+
+	return t.next.RoundTrip(retry)
+}
+
+// withBearerToken returns a copy of the request authenticated with the given JWT,
+// as a RoundTripper must not modify the request it is given
+func withBearerToken(req *http.Request, jwt string) *http.Request {
+	authenticated := req.Clone(req.Context())
+	authenticated.Header.Set("Authorization", "Bearer "+jwt)
+	return authenticated
+}
+
+// isReplayable reports whether the body of the request can be sent again
+func isReplayable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
@@ -0,0 +1,208 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/portainer/portainer-mcp/pkg/portainer/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testJWT returns an unsigned JWT expiring at the given time
+func testJWT(id int, expiresAt time.Time) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	claims := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"id":%d,"exp":%d}`, id, expiresAt.Unix())))
+	return header + "." + claims + ".signature"
+}
+
+// authServer is a fake Portainer issuing JWTs on /api/auth and accepting the last issued one
+type authServer struct {
+	mu       sync.Mutex
+	logins   int
+	valid    string
+	expiry   time.Duration
+	password string
+	bodies   []string
+}
+
+func (s *authServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.URL.Path == "/api/auth" {
+		var credentials struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&credentials); err != nil || credentials.Username != "admin" || credentials.Password != s.password {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+
+		s.logins++
+		s.valid = testJWT(s.logins, time.Now().Add(s.expiry))
+		_ = json.NewEncoder(w).Encode(map[string]string{"jwt": s.valid})
+		return
+	}
+
+	if r.Header.Get("Authorization") != "Bearer "+s.valid {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	s.bodies = append(s.bodies, string(body))
+	w.WriteHeader(http.StatusOK)
+}
+
+// revoke makes the server reject the current JWT, as Portainer does after a restart
+func (s *authServer) revoke() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.valid = "revoked"
+}
+
+func newAuthTestClient(t *testing.T, s *authServer) (*http.Client, string) {
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+
+	auth := newJWTAuth(srv.URL+"/api/auth", "admin", "secret", srv.Client())
+	return &http.Client{Transport: &authTransport{next: http.DefaultTransport, auth: auth}}, srv.URL
+}
+
+func TestAuthTransport(t *testing.T) {
+	tests := []struct {
+		name           string
+		password       string
+		expiry         time.Duration
+		revoke         bool
+		expectedLogins int
+		errorContains  string
+	}{
+		{
+			name:           "JWT reused until it expires",
+			password:       "secret",
+			expiry:         8 * time.Hour,
+			expectedLogins: 1,
+		},
+		{
+			name:           "expired JWT renewed before each request",
+			password:       "secret",
+			expiry:         30 * time.Second,
+			expectedLogins: 3,
+		},
+		{
+			name:           "rejected JWT renewed and request retried",
+			password:       "secret",
+			expiry:         8 * time.Hour,
+			revoke:         true,
+			expectedLogins: 3,
+		},
+		{
+			name:          "invalid credentials",
+			password:      "other",
+			expiry:        8 * time.Hour,
+			errorContains: "failed to log in to Portainer as admin: 422 Unprocessable Entity",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &authServer{password: tt.password, expiry: tt.expiry}
+			httpClient, url := newAuthTestClient(t, s)
+
+			for i := range 3 {
+				if tt.revoke && i > 0 {
+					s.revoke()
+				}
+
+				body := fmt.Sprintf(`{"request":%d}`, i)
+				resp, err := httpClient.Post(url+"/api/stacks", "application/json", strings.NewReader(body))
+				if tt.errorContains != "" {
+					assert.ErrorContains(t, err, tt.errorContains)
+					return
+				}
+
+				require.NoError(t, err)
+				resp.Body.Close()
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+			}
+
+			assert.Equal(t, tt.expectedLogins, s.logins)
+			assert.Equal(t, []string{`{"request":0}`, `{"request":1}`, `{"request":2}`}, s.bodies, "bodies are replayed on retry")
+		})
+	}
+}
+
+func TestAuthTransportDoesNotReplayStreamedBodies(t *testing.T) {
+	s := &authServer{password: "secret", expiry: 8 * time.Hour}
+	httpClient, url := newAuthTestClient(t, s)
+
+	resp, err := httpClient.Get(url + "/api/stacks")
+	require.NoError(t, err)
+	resp.Body.Close()
+	s.revoke()
+
+	// A body without GetBody cannot be sent twice, the 401 is returned as is
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, url+"/api/stacks", io.NopCloser(strings.NewReader("{}")))
+	require.NoError(t, err)
+
+	resp, err = httpClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, 1, s.logins)
+}
+
+func TestJWTExpiry(t *testing.T) {
+	expiresAt := time.Unix(1893456000, 0)
+
+	tests := []struct {
+		name     string
+		jwt      string
+		expected time.Time
+	}{
+		{name: "valid JWT", jwt: testJWT(1, expiresAt), expected: expiresAt},
+		{name: "not a JWT", jwt: "opaque-token"},
+		{name: "invalid claims", jwt: "header.not-base64!.signature"},
+		{name: "no expiry", jwt: "header." + base64.RawURLEncoding.EncodeToString([]byte(`{"id":1}`)) + ".signature"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.True(t, tt.expected.Equal(jwtExpiry(tt.jwt)))
+		})
+	}
+}
+
+func TestNewPortainerClientWithCredentials(t *testing.T) {
+	s := &authServer{password: "secret", expiry: 8 * time.Hour}
+	srv := httptest.NewTLSServer(s)
+	defer srv.Close()
+
+	host := strings.TrimPrefix(srv.URL, "https://")
+	c := NewPortainerClient(host, "ignored-token", WithSkipTLSVerify(true), WithCredentials("admin", "secret"))
+
+	resp, err := c.ProxyDockerRequest(context.Background(), models.DockerProxyRequestOptions{
+		EnvironmentID: 1,
+		Method:        http.MethodGet,
+		Path:          "/containers/json",
+	})
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "proxied requests carry the JWT")
+
+	_, err = c.GetEnvironments(context.Background())
+	assert.NoError(t, err, "API requests carry the JWT")
+	assert.Equal(t, 1, s.logins)
+}
@@ -1,9 +1,12 @@
 package client
 
 import (
+	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"net/http"
+	"time"
 
 	goruntime "github.com/go-openapi/runtime"
 	httptransport "github.com/go-openapi/runtime/client"
@@ -11,40 +14,45 @@ import (
 	"github.com/portainer/client-api-go/v2/client"
 	apimodels "github.com/portainer/client-api-go/v2/pkg/models"
 
+	sdkclient "github.com/portainer/client-api-go/v2/pkg/client"
 	sdkstacks "github.com/portainer/client-api-go/v2/pkg/client/stacks"
+	sdksystem "github.com/portainer/client-api-go/v2/pkg/client/system"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // PortainerAPIClient defines the interface for the underlying Portainer API client
 type PortainerAPIClient interface {
-	ListEdgeGroups() ([]*apimodels.EdgegroupsDecoratedEdgeGroup, error)
-	CreateEdgeGroup(name string, environmentIds []int64) (int64, error)
-	UpdateEdgeGroup(id int64, name *string, environmentIds *[]int64, tagIds *[]int64) error
-	ListEdgeStacks() ([]*apimodels.PortainereeEdgeStack, error)
-	CreateEdgeStack(name string, file string, environmentGroupIds []int64) (int64, error)
-	UpdateEdgeStack(id int64, file string, environmentGroupIds []int64) error
-	GetEdgeStackFile(id int64) (string, error)
-	ListEndpointGroups() ([]*apimodels.PortainerEndpointGroup, error)
-	CreateEndpointGroup(name string, associatedEndpoints []int64) (int64, error)
-	UpdateEndpointGroup(id int64, name *string, userAccesses *map[int64]string, teamAccesses *map[int64]string) error
-	AddEnvironmentToEndpointGroup(groupId int64, environmentId int64) error
-	RemoveEnvironmentFromEndpointGroup(groupId int64, environmentId int64) error
-	ListEndpoints() ([]*apimodels.PortainereeEndpoint, error)
-	GetEndpoint(id int64) (*apimodels.PortainereeEndpoint, error)
-	UpdateEndpoint(id int64, tagIds *[]int64, userAccesses *map[int64]string, teamAccesses *map[int64]string) error
-	GetSettings() (*apimodels.PortainereeSettings, error)
-	ListTags() ([]*apimodels.PortainerTag, error)
-	CreateTag(name string) (int64, error)
-	ListTeams() ([]*apimodels.PortainerTeam, error)
-	ListTeamMemberships() ([]*apimodels.PortainerTeamMembership, error)
-	CreateTeam(name string) (int64, error)
-	UpdateTeamName(id int, name string) error
-	DeleteTeamMembership(id int) error
-	CreateTeamMembership(teamId int, userId int) error
-	ListUsers() ([]*apimodels.PortainereeUser, error)
-	UpdateUserRole(id int, role int64) error
-	GetVersion() (string, error)
-	ProxyDockerRequest(environmentId int, opts client.ProxyRequestOptions) (*http.Response, error)
-	ProxyKubernetesRequest(environmentId int, opts client.ProxyRequestOptions) (*http.Response, error)
+	ListEdgeGroups(ctx context.Context) ([]*apimodels.EdgegroupsDecoratedEdgeGroup, error)
+	CreateEdgeGroup(ctx context.Context, name string, environmentIds []int64) (int64, error)
+	UpdateEdgeGroup(ctx context.Context, id int64, name *string, environmentIds *[]int64, tagIds *[]int64) error
+	ListEdgeStacks(ctx context.Context) ([]*apimodels.PortainereeEdgeStack, error)
+	CreateEdgeStack(ctx context.Context, name string, file string, environmentGroupIds []int64) (int64, error)
+	UpdateEdgeStack(ctx context.Context, id int64, file string, environmentGroupIds []int64, deploymentType int64) error
+	GetEdgeStack(ctx context.Context, id int64) (*apimodels.PortainereeEdgeStack, error)
+	GetEdgeStackFile(ctx context.Context, id int64) (string, error)
+	DeleteEdgeStack(ctx context.Context, id int64) error
+	ListEndpointGroups(ctx context.Context) ([]*apimodels.PortainerEndpointGroup, error)
+	CreateEndpointGroup(ctx context.Context, name string, associatedEndpoints []int64) (int64, error)
+	UpdateEndpointGroup(ctx context.Context, id int64, name *string, userAccesses *map[int64]string, teamAccesses *map[int64]string) error
+	AddEnvironmentToEndpointGroup(ctx context.Context, groupId int64, environmentId int64) error
+	RemoveEnvironmentFromEndpointGroup(ctx context.Context, groupId int64, environmentId int64) error
+	ListEndpoints(ctx context.Context) ([]*apimodels.PortainereeEndpoint, error)
+	GetEndpoint(ctx context.Context, id int64) (*apimodels.PortainereeEndpoint, error)
+	UpdateEndpoint(ctx context.Context, id int64, tagIds *[]int64, userAccesses *map[int64]string, teamAccesses *map[int64]string) error
+	GetSettings(ctx context.Context) (*apimodels.PortainereeSettings, error)
+	ListTags(ctx context.Context) ([]*apimodels.PortainerTag, error)
+	CreateTag(ctx context.Context, name string) (int64, error)
+	ListTeams(ctx context.Context) ([]*apimodels.PortainerTeam, error)
+	ListTeamMemberships(ctx context.Context) ([]*apimodels.PortainerTeamMembership, error)
+	CreateTeam(ctx context.Context, name string) (int64, error)
+	UpdateTeamName(ctx context.Context, id int, name string) error
+	DeleteTeamMembership(ctx context.Context, id int) error
+	CreateTeamMembership(ctx context.Context, teamId int, userId int) error
+	ListUsers(ctx context.Context) ([]*apimodels.PortainereeUser, error)
+	UpdateUserRole(ctx context.Context, id int, role int64) error
+	GetVersion(ctx context.Context) (string, error)
+	ProxyDockerRequest(ctx context.Context, environmentId int, opts client.ProxyRequestOptions) (*http.Response, error)
+	ProxyKubernetesRequest(ctx context.Context, environmentId int, opts client.ProxyRequestOptions) (*http.Response, error)
 }
 
 // PortainerClient is a wrapper around the Portainer SDK client
@@ -52,6 +60,7 @@ type PortainerAPIClient interface {
 type PortainerClient struct {
 	cli       PortainerAPIClient
 	stacksSvc sdkstacks.ClientService
+	systemSvc sdksystem.ClientService
 	authInfo  goruntime.ClientAuthInfoWriter
 }
 
@@ -60,7 +69,18 @@ type ClientOption func(*clientOptions)
 
 // clientOptions holds configuration options for the PortainerClient.
 type clientOptions struct {
-	skipTLSVerify bool
+	skipTLSVerify     bool
+	rootCAs           *x509.CertPool
+	clientCertificate *tls.Certificate
+	pinnedFingerprint []byte
+	retryPolicy       RetryPolicy
+	failureThreshold  int
+	breakerCooldown   time.Duration
+	observer          Observer
+	tracerProvider    trace.TracerProvider
+	username          string
+	password          string
+	limiter           *Limiter
 }
 
 // WithSkipTLSVerify configures whether to skip TLS certificate verification.
@@ -71,47 +91,139 @@ func WithSkipTLSVerify(skip bool) ClientOption {
 	}
 }
 
+// WithCACertificates verifies the certificate of the Portainer server against
+// the given CA certificates instead of the system roots.
+// See LoadCACertificates to read them from a PEM bundle.
+func WithCACertificates(pool *x509.CertPool) ClientOption {
+	return func(o *clientOptions) {
+		o.rootCAs = pool
+	}
+}
+
+// WithClientCertificate presents the given certificate to the Portainer server, for mutual TLS.
+// See LoadClientCertificate to read it from PEM files.
+func WithClientCertificate(cert tls.Certificate) ClientOption {
+	return func(o *clientOptions) {
+		o.clientCertificate = &cert
+	}
+}
+
+// WithPinnedCertificate only accepts a Portainer server presenting a certificate with
+// the given SHA-256 fingerprint, whoever issued it. See ParseFingerprint.
+func WithPinnedCertificate(fingerprint []byte) ClientOption {
+	return func(o *clientOptions) {
+		o.pinnedFingerprint = fingerprint
+	}
+}
+
+// WithRetryPolicy configures the retries of GET and HEAD requests, including the proxied ones,
+// when Portainer cannot be reached or answers with a 502, 503 or 504 status.
+// Other requests are never retried. A policy with a single attempt disables retries.
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(o *clientOptions) {
+		o.retryPolicy = policy
+	}
+}
+
+// WithCircuitBreaker configures the circuit breaker. After the given number of consecutive
+// failed requests, requests fail immediately with ErrCircuitOpen until the cooldown has elapsed.
+// A threshold of 0 disables the circuit breaker.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		o.failureThreshold = threshold
+		o.breakerCooldown = cooldown
+	}
+}
+
 // NewPortainerClient creates a new PortainerClient instance with the provided
 // server URL and authentication token.
 //
 // Parameters:
 //   - serverURL: The base URL of the Portainer server
-//   - token: The authentication token for API access
+//   - token: The authentication token for API access, unused with WithCredentials
 //   - opts: Optional configuration options for the client
 //
 // Returns:
 //   - A configured PortainerClient ready for API operations
 func NewPortainerClient(serverURL string, token string, opts ...ClientOption) *PortainerClient {
 	options := clientOptions{
-		skipTLSVerify: false, // Default to secure TLS verification
+		skipTLSVerify:    false, // Default to secure TLS verification
+		retryPolicy:      DefaultRetryPolicy(),
+		failureThreshold: DefaultFailureThreshold,
+		breakerCooldown:  DefaultBreakerCooldown,
 	}
 
 	for _, opt := range opts {
 		opt(&options)
 	}
 
-	// Create a shared transport for regular stacks API access
-	transport := httptransport.New(serverURL, "/api", []string{"https"})
-	if options.skipTLSVerify {
-		transport.Transport = &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true,
-			},
+	// Create a shared HTTP client and transport for the API and the proxied requests
+	var baseTransport http.RoundTripper = &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: newTLSConfig(options),
+	}
+	if options.limiter != nil {
+		// Every request sent to Portainer is admitted by the limiter, including the retries,
+		// the replayed requests and the logins
+		baseTransport = &limitedTransport{next: baseTransport, limiter: options.limiter}
+	}
+
+	roundTripper := &resilientTransport{
+		next:   baseTransport,
+		policy: options.retryPolicy,
+	}
+	if options.failureThreshold > 0 {
+		roundTripper.breaker = newCircuitBreaker(options.failureThreshold, options.breakerCooldown)
+	}
+	observe := func(next http.RoundTripper) http.RoundTripper {
+		if options.observer == nil {
+			return next
 		}
+		return &observedTransport{next: next, observer: options.observer}
+	}
+
+	var authenticatedTransport http.RoundTripper = roundTripper
+	if options.username != "" {
+		loginClient := &http.Client{Transport: observe(roundTripper)}
+		auth := newJWTAuth(fmt.Sprintf("https://%s/api/auth", serverURL), options.username, options.password, loginClient)
+		authenticatedTransport = &authTransport{next: roundTripper, auth: auth}
+		// The requests are authenticated by the transport
+		token = ""
 	}
 
+	httpClient := &http.Client{Transport: observe(authenticatedTransport)}
+	transport := httptransport.NewWithClient(serverURL, "/api", []string{"https"}, httpClient)
+
 	apiKeyAuth := goruntime.ClientAuthInfoWriterFunc(func(r goruntime.ClientRequest, _ strfmt.Registry) error {
+		if token == "" {
+			return nil
+		}
 		return r.SetHeaderParam("x-api-key", token)
 	})
 	transport.DefaultAuthentication = apiKeyAuth
 
-	stacksSvc := sdkstacks.New(transport, strfmt.Default)
+	var sdkTransport goruntime.ClientTransport = transport
+	var tracer trace.Tracer
+	if options.tracerProvider != nil {
+		tracer = options.tracerProvider.Tracer(TracerName)
+		sdkTransport = &tracedTransport{next: transport, tracer: tracer}
+	}
 
-	sdkCli := client.NewPortainerClient(serverURL, token, client.WithSkipTLSVerify(options.skipTLSVerify))
+	stacksSvc := sdkstacks.New(sdkTransport, strfmt.Default)
+	systemSvc := sdksystem.New(sdkTransport, strfmt.Default)
+
+	apiCli := &apiClient{
+		cli:    sdkclient.New(sdkTransport, strfmt.Default),
+		http:   httpClient,
+		host:   serverURL,
+		token:  token,
+		tracer: tracer,
+	}
 
 	return &PortainerClient{
-		cli:       sdkCli,
+		cli:       apiCli,
 		stacksSvc: stacksSvc,
+		systemSvc: systemSvc,
 		authInfo:  apiKeyAuth,
 	}
 }
@@ -125,12 +237,14 @@ func NewPortainerClientWithAPIClient(cli PortainerAPIClient) *PortainerClient {
 }
 
 // ListRegularStacks lists all regular (non-edge) stacks from the Portainer API.
-func (c *PortainerClient) ListRegularStacks() ([]*apimodels.PortainereeStack, error) {
+func (c *PortainerClient) ListRegularStacks(ctx context.Context) ([]*apimodels.PortainereeStack, error) {
+	ctx = withOperation(ctx, "ListRegularStacks")
+
 	if c.stacksSvc == nil {
 		return nil, fmt.Errorf("stacks service not initialized")
 	}
 
-	params := sdkstacks.NewStackListParams()
+	params := sdkstacks.NewStackListParamsWithContext(ctx)
 	ok, _, err := c.stacksSvc.StackList(params, c.authInfo)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list stacks: %w", err)
@@ -144,12 +258,14 @@ func (c *PortainerClient) ListRegularStacks() ([]*apimodels.PortainereeStack, er
 }
 
 // GetRegularStackFile retrieves the compose file content for a regular (non-edge) stack.
-func (c *PortainerClient) GetRegularStackFile(id int64) (string, error) {
+func (c *PortainerClient) GetRegularStackFile(ctx context.Context, id int64) (string, error) {
+	ctx = withOperation(ctx, "GetRegularStackFile")
+
 	if c.stacksSvc == nil {
 		return "", fmt.Errorf("stacks service not initialized")
 	}
 
-	params := sdkstacks.NewStackFileInspectParams().WithID(id)
+	params := sdkstacks.NewStackFileInspectParamsWithContext(ctx).WithID(id)
 	resp, err := c.stacksSvc.StackFileInspect(params, c.authInfo)
 	if err != nil {
 		return "", fmt.Errorf("failed to get stack file: %w", err)
@@ -162,8 +278,31 @@ func (c *PortainerClient) GetRegularStackFile(id int64) (string, error) {
 	return resp.Payload.StackFileContent, nil
 }
 
+// GetRegularStack retrieves a regular (non-edge) stack, including its environment variables.
+func (c *PortainerClient) GetRegularStack(ctx context.Context, id int64) (*apimodels.PortainereeStack, error) {
+	ctx = withOperation(ctx, "GetRegularStack")
+
+	if c.stacksSvc == nil {
+		return nil, fmt.Errorf("stacks service not initialized")
+	}
+
+	params := sdkstacks.NewStackInspectParamsWithContext(ctx).WithID(id)
+	resp, err := c.stacksSvc.StackInspect(params, c.authInfo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stack: %w", err)
+	}
+
+	if resp.Payload == nil {
+		return nil, fmt.Errorf("empty stack response")
+	}
+
+	return resp.Payload, nil
+}
+
 // CreateRegularStack creates a new Docker Compose stack via the regular stacks API.
-func (c *PortainerClient) CreateRegularStack(name, file string, endpointId int64) (int64, error) {
+func (c *PortainerClient) CreateRegularStack(ctx context.Context, name, file string, endpointId int64, env []*apimodels.PortainerPair) (int64, error) {
+	ctx = withOperation(ctx, "CreateRegularStack")
+
 	if c.stacksSvc == nil {
 		return 0, fmt.Errorf("stacks service not initialized")
 	}
@@ -171,9 +310,10 @@ func (c *PortainerClient) CreateRegularStack(name, file string, endpointId int64
 	body := &apimodels.StacksComposeStackFromFileContentPayload{
 		Name:             &name,
 		StackFileContent: &file,
+		Env:              env,
 	}
 
-	params := sdkstacks.NewStackCreateDockerStandaloneStringParams().
+	params := sdkstacks.NewStackCreateDockerStandaloneStringParamsWithContext(ctx).
 		WithEndpointID(endpointId).
 		WithBody(body)
 
@@ -190,7 +330,10 @@ func (c *PortainerClient) CreateRegularStack(name, file string, endpointId int64
 }
 
 // UpdateRegularStack updates an existing regular stack with new compose content.
-func (c *PortainerClient) UpdateRegularStack(id, endpointId int64, file string, pullImage bool) error {
+// Portainer replaces the environment variables of the stack with env.
+func (c *PortainerClient) UpdateRegularStack(ctx context.Context, id, endpointId int64, file string, pullImage bool, env []*apimodels.PortainerPair) error {
+	ctx = withOperation(ctx, "UpdateRegularStack")
+
 	if c.stacksSvc == nil {
 		return fmt.Errorf("stacks service not initialized")
 	}
@@ -198,9 +341,10 @@ func (c *PortainerClient) UpdateRegularStack(id, endpointId int64, file string,
 	body := &apimodels.StacksUpdateStackPayload{
 		StackFileContent: file,
 		PullImage:        pullImage,
+		Env:              env,
 	}
 
-	params := sdkstacks.NewStackUpdateParams().
+	params := sdkstacks.NewStackUpdateParamsWithContext(ctx).
 		WithID(id).
 		WithEndpointID(endpointId).
 		WithBody(body)
@@ -213,13 +357,60 @@ func (c *PortainerClient) UpdateRegularStack(id, endpointId int64, file string,
 	return nil
 }
 
+// CreateRegularGitStack creates a new Docker Compose stack from a git repository via the regular stacks API.
+func (c *PortainerClient) CreateRegularGitStack(ctx context.Context, endpointId int64, body *apimodels.StacksComposeStackFromGitRepositoryPayload) (int64, error) {
+	ctx = withOperation(ctx, "CreateRegularGitStack")
+
+	if c.stacksSvc == nil {
+		return 0, fmt.Errorf("stacks service not initialized")
+	}
+
+	params := sdkstacks.NewStackCreateDockerStandaloneRepositoryParamsWithContext(ctx).
+		WithEndpointID(endpointId).
+		WithBody(body)
+
+	resp, err := c.stacksSvc.StackCreateDockerStandaloneRepository(params, c.authInfo)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create git stack: %w", err)
+	}
+
+	if resp.Payload == nil {
+		return 0, fmt.Errorf("empty create stack response")
+	}
+
+	return resp.Payload.ID, nil
+}
+
+// RedeployRegularGitStack pulls the latest commit of the git repository of a stack and redeploys it.
+func (c *PortainerClient) RedeployRegularGitStack(ctx context.Context, id, endpointId int64, body *apimodels.StacksStackGitRedployPayload) error {
+	ctx = withOperation(ctx, "RedeployRegularGitStack")
+
+	if c.stacksSvc == nil {
+		return fmt.Errorf("stacks service not initialized")
+	}
+
+	params := sdkstacks.NewStackGitRedeployParamsWithContext(ctx).
+		WithID(id).
+		WithEndpointID(&endpointId).
+		WithBody(body)
+
+	_, err := c.stacksSvc.StackGitRedeploy(params, c.authInfo)
+	if err != nil {
+		return fmt.Errorf("failed to redeploy git stack: %w", err)
+	}
+
+	return nil
+}
+
 // StartRegularStack starts a stopped stack.
-func (c *PortainerClient) StartRegularStack(id, endpointId int64) error {
+func (c *PortainerClient) StartRegularStack(ctx context.Context, id, endpointId int64) error {
+	ctx = withOperation(ctx, "StartRegularStack")
+
 	if c.stacksSvc == nil {
 		return fmt.Errorf("stacks service not initialized")
 	}
 
-	params := sdkstacks.NewStackStartParams().
+	params := sdkstacks.NewStackStartParamsWithContext(ctx).
 		WithID(id).
 		WithEndpointID(endpointId)
 
@@ -232,12 +423,14 @@ func (c *PortainerClient) StartRegularStack(id, endpointId int64) error {
 }
 
 // StopRegularStack stops a running stack.
-func (c *PortainerClient) StopRegularStack(id, endpointId int64) error {
+func (c *PortainerClient) StopRegularStack(ctx context.Context, id, endpointId int64) error {
+	ctx = withOperation(ctx, "StopRegularStack")
+
 	if c.stacksSvc == nil {
 		return fmt.Errorf("stacks service not initialized")
 	}
 
-	params := sdkstacks.NewStackStopParams().
+	params := sdkstacks.NewStackStopParamsWithContext(ctx).
 		WithID(id).
 		WithEndpointID(endpointId)
 
@@ -250,12 +443,14 @@ func (c *PortainerClient) StopRegularStack(id, endpointId int64) error {
 }
 
 // DeleteRegularStack removes a stack.
-func (c *PortainerClient) DeleteRegularStack(id, endpointId int64) error {
+func (c *PortainerClient) DeleteRegularStack(ctx context.Context, id, endpointId int64) error {
+	ctx = withOperation(ctx, "DeleteRegularStack")
+
 	if c.stacksSvc == nil {
 		return fmt.Errorf("stacks service not initialized")
 	}
 
-	params := sdkstacks.NewStackDeleteParams().
+	params := sdkstacks.NewStackDeleteParamsWithContext(ctx).
 		WithID(id).
 		WithEndpointID(endpointId)
 
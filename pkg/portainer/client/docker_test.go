@@ -2,6 +2,7 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io"
 	"net/http"
@@ -88,7 +89,7 @@ func TestProxyDockerRequest(t *testing.T) {
 
 			client := &PortainerClient{cli: mockAPI}
 
-			resp, err := client.ProxyDockerRequest(tt.opts)
+			resp, err := client.ProxyDockerRequest(context.Background(), tt.opts)
 			if tt.expectedError {
 				assert.Error(t, err)
 				assert.EqualError(t, err, tt.mockError.Error())
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/portainer/portainer-mcp/pkg/portainer/models"
+	"github.com/portainer/portainer-mcp/pkg/portainer/utils"
+)
+
+// GetEdgeStacks retrieves all edge stacks, with their deployment status on each environment.
+func (c *PortainerClient) GetEdgeStacks(ctx context.Context) ([]models.EdgeStack, error) {
+	ctx = withOperation(ctx, "GetEdgeStacks")
+
+	edgeStacks, err := c.cli.ListEdgeStacks(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list edge stacks: %w", err)
+	}
+
+	stacks := make([]models.EdgeStack, len(edgeStacks))
+	for i, es := range edgeStacks {
+		stacks[i] = models.ConvertToEdgeStack(es)
+	}
+
+	return stacks, nil
+}
+
+// GetEdgeStack retrieves an edge stack, with its deployment status on each environment.
+func (c *PortainerClient) GetEdgeStack(ctx context.Context, id int) (models.EdgeStack, error) {
+	ctx = withOperation(ctx, "GetEdgeStack")
+
+	edgeStack, err := c.cli.GetEdgeStack(ctx, int64(id))
+	if err != nil {
+		return models.EdgeStack{}, fmt.Errorf("failed to get edge stack: %w", err)
+	}
+
+	return models.ConvertToEdgeStack(edgeStack), nil
+}
+
+// GetEdgeStackFile retrieves the compose file content of an edge stack.
+func (c *PortainerClient) GetEdgeStackFile(ctx context.Context, id int) (string, error) {
+	ctx = withOperation(ctx, "GetEdgeStackFile")
+
+	file, err := c.cli.GetEdgeStackFile(ctx, int64(id))
+	if err != nil {
+		return "", fmt.Errorf("failed to get edge stack file: %w", err)
+	}
+
+	return file, nil
+}
+
+// CreateEdgeStack creates a new edge stack deployed to the environments of the given environment groups.
+func (c *PortainerClient) CreateEdgeStack(ctx context.Context, name, file string, environmentGroupIds []int) (int, error) {
+	ctx = withOperation(ctx, "CreateEdgeStack")
+
+	id, err := c.cli.CreateEdgeStack(ctx, name, file, utils.IntToInt64Slice(environmentGroupIds))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create edge stack: %w", err)
+	}
+
+	return int(id), nil
+}
+
+// UpdateEdgeStack updates the compose file and the environment groups of an edge stack,
+// which is then redeployed to their environments. The deployment type of the edge stack is kept,
+// as Portainer would otherwise reset it to Compose.
+func (c *PortainerClient) UpdateEdgeStack(ctx context.Context, id int, file string, environmentGroupIds []int) error {
+	ctx = withOperation(ctx, "UpdateEdgeStack")
+
+	edgeStack, err := c.cli.GetEdgeStack(ctx, int64(id))
+	if err != nil {
+		return fmt.Errorf("failed to get edge stack: %w", err)
+	}
+
+	err = c.cli.UpdateEdgeStack(ctx, int64(id), file, utils.IntToInt64Slice(environmentGroupIds), edgeStack.DeploymentType)
+	if err != nil {
+		return fmt.Errorf("failed to update edge stack: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteEdgeStack removes an edge stack from all its environments.
+func (c *PortainerClient) DeleteEdgeStack(ctx context.Context, id int) error {
+	ctx = withOperation(ctx, "DeleteEdgeStack")
+
+	err := c.cli.DeleteEdgeStack(ctx, int64(id))
+	if err != nil {
+		return fmt.Errorf("failed to delete edge stack: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,287 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	apimodels "github.com/portainer/client-api-go/v2/pkg/models"
+	"github.com/portainer/portainer-mcp/pkg/portainer/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetEdgeStacks(t *testing.T) {
+	createdAt := time.Unix(1609459200, 0).Format(time.RFC3339)
+
+	tests := []struct {
+		name          string
+		mockStacks    []*apimodels.PortainereeEdgeStack
+		mockError     error
+		expected      []models.EdgeStack
+		expectedError bool
+	}{
+		{
+			name: "successful retrieval",
+			mockStacks: []*apimodels.PortainereeEdgeStack{
+				{
+					ID:           1,
+					Name:         "web",
+					CreationDate: 1609459200,
+					EdgeGroups:   []int64{1, 2},
+					Status: map[string]apimodels.PortainerEdgeStackStatus{
+						"3": {EndpointID: 3, Status: []*apimodels.PortainerEdgeStackDeploymentStatus{{Type: 7}}},
+					},
+				},
+			},
+			expected: []models.EdgeStack{
+				{
+					ID:                  1,
+					Name:                "web",
+					CreatedAt:           createdAt,
+					EnvironmentGroupIds: []int{1, 2},
+					Deployments:         []models.EdgeStackDeployment{{EnvironmentID: 3, Status: models.EdgeStackStatusRunning}},
+				},
+			},
+		},
+		{
+			name:       "empty stacks",
+			mockStacks: []*apimodels.PortainereeEdgeStack{},
+			expected:   []models.EdgeStack{},
+		},
+		{
+			name:          "list error",
+			mockError:     errors.New("failed to list edge stacks"),
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAPI := new(MockPortainerAPI)
+			mockAPI.On("ListEdgeStacks").Return(tt.mockStacks, tt.mockError)
+
+			client := &PortainerClient{cli: mockAPI}
+
+			stacks, err := client.GetEdgeStacks(context.Background())
+
+			if tt.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, stacks)
+			mockAPI.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetEdgeStack(t *testing.T) {
+	tests := []struct {
+		name          string
+		mockStack     *apimodels.PortainereeEdgeStack
+		mockError     error
+		expected      models.EdgeStack
+		expectedError bool
+	}{
+		{
+			name:      "successful retrieval",
+			mockStack: &apimodels.PortainereeEdgeStack{ID: 1, Name: "web", CreationDate: 1609459200, EdgeGroups: []int64{2}},
+			expected: models.EdgeStack{
+				ID:                  1,
+				Name:                "web",
+				CreatedAt:           time.Unix(1609459200, 0).Format(time.RFC3339),
+				EnvironmentGroupIds: []int{2},
+				Deployments:         []models.EdgeStackDeployment{},
+			},
+		},
+		{
+			name:          "inspect error",
+			mockError:     errors.New("not found"),
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAPI := new(MockPortainerAPI)
+			mockAPI.On("GetEdgeStack", int64(1)).Return(tt.mockStack, tt.mockError)
+
+			client := &PortainerClient{cli: mockAPI}
+
+			stack, err := client.GetEdgeStack(context.Background(), 1)
+
+			if tt.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, stack)
+			mockAPI.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetEdgeStackFile(t *testing.T) {
+	tests := []struct {
+		name          string
+		mockFile      string
+		mockError     error
+		expectedError bool
+	}{
+		{
+			name:     "successful retrieval",
+			mockFile: "services:\n  web:\n    image: nginx",
+		},
+		{
+			name:          "file error",
+			mockError:     errors.New("not found"),
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAPI := new(MockPortainerAPI)
+			mockAPI.On("GetEdgeStackFile", int64(1)).Return(tt.mockFile, tt.mockError)
+
+			client := &PortainerClient{cli: mockAPI}
+
+			file, err := client.GetEdgeStackFile(context.Background(), 1)
+
+			if tt.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.mockFile, file)
+			mockAPI.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreateEdgeStack(t *testing.T) {
+	tests := []struct {
+		name          string
+		mockID        int64
+		mockError     error
+		expectedID    int
+		expectedError bool
+	}{
+		{
+			name:       "successful creation",
+			mockID:     5,
+			expectedID: 5,
+		},
+		{
+			name:          "creation error",
+			mockError:     errors.New("name already in use"),
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAPI := new(MockPortainerAPI)
+			mockAPI.On("CreateEdgeStack", "web", "file", []int64{1, 2}).Return(tt.mockID, tt.mockError)
+
+			client := &PortainerClient{cli: mockAPI}
+
+			id, err := client.CreateEdgeStack(context.Background(), "web", "file", []int{1, 2})
+
+			if tt.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedID, id)
+			mockAPI.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateEdgeStack(t *testing.T) {
+	tests := []struct {
+		name           string
+		deploymentType int64
+		getError       error
+		mockError      error
+		expectedError  bool
+	}{
+		{
+			name: "successful update",
+		},
+		{
+			name:           "kubernetes edge stack keeps its deployment type",
+			deploymentType: 1,
+		},
+		{
+			name:          "get error",
+			getError:      errors.New("failed to get"),
+			expectedError: true,
+		},
+		{
+			name:          "update error",
+			mockError:     errors.New("failed to update"),
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAPI := new(MockPortainerAPI)
+			if tt.getError != nil {
+				mockAPI.On("GetEdgeStack", int64(1)).Return(nil, tt.getError)
+			} else {
+				mockAPI.On("GetEdgeStack", int64(1)).Return(&apimodels.PortainereeEdgeStack{ID: 1, DeploymentType: tt.deploymentType}, nil)
+			}
+			mockAPI.On("UpdateEdgeStack", int64(1), "file", []int64{3}, tt.deploymentType).Return(tt.mockError)
+
+			client := &PortainerClient{cli: mockAPI}
+
+			err := client.UpdateEdgeStack(context.Background(), 1, "file", []int{3})
+
+			if tt.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			mockAPI.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeleteEdgeStack(t *testing.T) {
+	tests := []struct {
+		name          string
+		mockError     error
+		expectedError bool
+	}{
+		{
+			name: "successful deletion",
+		},
+		{
+			name:          "deletion error",
+			mockError:     errors.New("failed to delete"),
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAPI := new(MockPortainerAPI)
+			mockAPI.On("DeleteEdgeStack", int64(1)).Return(tt.mockError)
+
+			client := &PortainerClient{cli: mockAPI}
+
+			err := client.DeleteEdgeStack(context.Background(), 1)
+
+			if tt.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			mockAPI.AssertExpectations(t)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/portainer/portainer-mcp/pkg/portainer/models"
@@ -12,8 +13,10 @@ import (
 // Returns:
 //   - A slice of Environment objects
 //   - An error if the operation fails
-func (c *PortainerClient) GetEnvironments() ([]models.Environment, error) {
-	endpoints, err := c.cli.ListEndpoints()
+func (c *PortainerClient) GetEnvironments(ctx context.Context) ([]models.Environment, error) {
+	ctx = withOperation(ctx, "GetEnvironments")
+
+	endpoints, err := c.cli.ListEndpoints(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list endpoints: %w", err)
 	}
@@ -29,14 +32,17 @@ func (c *PortainerClient) GetEnvironments() ([]models.Environment, error) {
 // UpdateEnvironmentTags updates the tags associated with an environment.
 //
 // Parameters:
+//   - ctx: The context of the request, cancelling it aborts the call to Portainer
 //   - id: The ID of the environment to update
 //   - tagIds: A slice of tag IDs to associate with the environment
 //
 // Returns:
 //   - An error if the operation fails
-func (c *PortainerClient) UpdateEnvironmentTags(id int, tagIds []int) error {
+func (c *PortainerClient) UpdateEnvironmentTags(ctx context.Context, id int, tagIds []int) error {
+	ctx = withOperation(ctx, "UpdateEnvironmentTags")
+
 	tags := utils.IntToInt64Slice(tagIds)
-	err := c.cli.UpdateEndpoint(int64(id),
+	err := c.cli.UpdateEndpoint(ctx, int64(id),
 		&tags,
 		nil,
 		nil,
@@ -50,6 +56,7 @@ func (c *PortainerClient) UpdateEnvironmentTags(id int, tagIds []int) error {
 // UpdateEnvironmentUserAccesses updates the user access policies of an environment.
 //
 // Parameters:
+//   - ctx: The context of the request, cancelling it aborts the call to Portainer
 //   - id: The ID of the environment to update
 //   - userAccesses: Map of user IDs to their access level
 //
@@ -62,9 +69,11 @@ func (c *PortainerClient) UpdateEnvironmentTags(id int, tagIds []int) error {
 //
 // Returns:
 //   - An error if the operation fails
-func (c *PortainerClient) UpdateEnvironmentUserAccesses(id int, userAccesses map[int]string) error {
+func (c *PortainerClient) UpdateEnvironmentUserAccesses(ctx context.Context, id int, userAccesses map[int]string) error {
+	ctx = withOperation(ctx, "UpdateEnvironmentUserAccesses")
+
 	uac := utils.IntToInt64Map(userAccesses)
-	err := c.cli.UpdateEndpoint(int64(id),
+	err := c.cli.UpdateEndpoint(ctx, int64(id),
 		nil,
 		&uac,
 		nil,
@@ -78,6 +87,7 @@ func (c *PortainerClient) UpdateEnvironmentUserAccesses(id int, userAccesses map
 // UpdateEnvironmentTeamAccesses updates the team access policies of an environment.
 //
 // Parameters:
+//   - ctx: The context of the request, cancelling it aborts the call to Portainer
 //   - id: The ID of the environment to update
 //   - teamAccesses: Map of team IDs to their access level
 //
@@ -90,9 +100,11 @@ func (c *PortainerClient) UpdateEnvironmentUserAccesses(id int, userAccesses map
 //
 // Returns:
 //   - An error if the operation fails
-func (c *PortainerClient) UpdateEnvironmentTeamAccesses(id int, teamAccesses map[int]string) error {
+func (c *PortainerClient) UpdateEnvironmentTeamAccesses(ctx context.Context, id int, teamAccesses map[int]string) error {
+	ctx = withOperation(ctx, "UpdateEnvironmentTeamAccesses")
+
 	tac := utils.IntToInt64Map(teamAccesses)
-	err := c.cli.UpdateEndpoint(int64(id),
+	err := c.cli.UpdateEndpoint(ctx, int64(id),
 		nil,
 		nil,
 		&tac,
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"errors"
 	"testing"
 
@@ -119,7 +120,7 @@ func TestGetEnvironments(t *testing.T) {
 
 			client := &PortainerClient{cli: mockAPI}
 
-			environments, err := client.GetEnvironments()
+			environments, err := client.GetEnvironments(context.Background())
 
 			if tt.expectedError {
 				assert.Error(t, err)
@@ -166,7 +167,7 @@ func TestUpdateEnvironmentTags(t *testing.T) {
 
 			client := &PortainerClient{cli: mockAPI}
 
-			err := client.UpdateEnvironmentTags(tt.envID, tt.tagIds)
+			err := client.UpdateEnvironmentTags(context.Background(), tt.envID, tt.tagIds)
 
 			if tt.expectedError {
 				assert.Error(t, err)
@@ -220,7 +221,7 @@ func TestUpdateEnvironmentUserAccesses(t *testing.T) {
 
 			client := &PortainerClient{cli: mockAPI}
 
-			err := client.UpdateEnvironmentUserAccesses(tt.envID, tt.userAccesses)
+			err := client.UpdateEnvironmentUserAccesses(context.Background(), tt.envID, tt.userAccesses)
 
 			if tt.expectedError {
 				assert.Error(t, err)
@@ -274,7 +275,7 @@ func TestUpdateEnvironmentTeamAccesses(t *testing.T) {
 
 			client := &PortainerClient{cli: mockAPI}
 
-			err := client.UpdateEnvironmentTeamAccesses(tt.envID, tt.teamAccesses)
+			err := client.UpdateEnvironmentTeamAccesses(context.Background(), tt.envID, tt.teamAccesses)
 
 			if tt.expectedError {
 				assert.Error(t, err)
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/portainer/portainer-mcp/pkg/portainer/models"
@@ -13,8 +14,10 @@ import (
 // Returns:
 //   - A slice of Group objects
 //   - An error if the operation fails
-func (c *PortainerClient) GetEnvironmentGroups() ([]models.Group, error) {
-	edgeGroups, err := c.cli.ListEdgeGroups()
+func (c *PortainerClient) GetEnvironmentGroups(ctx context.Context) ([]models.Group, error) {
+	ctx = withOperation(ctx, "GetEnvironmentGroups")
+
+	edgeGroups, err := c.cli.ListEdgeGroups(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list edge groups: %w", err)
 	}
@@ -30,14 +33,17 @@ func (c *PortainerClient) GetEnvironmentGroups() ([]models.Group, error) {
 // CreateEnvironmentGroup creates a new environment group on the Portainer server.
 // Environment groups are the equivalent of Edge Groups in Portainer.
 // Parameters:
+//   - ctx: The context of the request, cancelling it aborts the call to Portainer
 //   - name: The name of the environment group
 //   - environmentIds: A slice of environment IDs to include in the group
 //
 // Returns:
 //   - The ID of the created environment group
 //   - An error if the operation fails
-func (c *PortainerClient) CreateEnvironmentGroup(name string, environmentIds []int) (int, error) {
-	id, err := c.cli.CreateEdgeGroup(name, utils.IntToInt64Slice(environmentIds))
+func (c *PortainerClient) CreateEnvironmentGroup(ctx context.Context, name string, environmentIds []int) (int, error) {
+	ctx = withOperation(ctx, "CreateEnvironmentGroup")
+
+	id, err := c.cli.CreateEdgeGroup(ctx, name, utils.IntToInt64Slice(environmentIds))
 	if err != nil {
 		return 0, fmt.Errorf("failed to create environment group: %w", err)
 	}
@@ -49,13 +55,16 @@ func (c *PortainerClient) CreateEnvironmentGroup(name string, environmentIds []i
 // Environment groups are the equivalent of Edge Groups in Portainer.
 //
 // Parameters:
+//   - ctx: The context of the request, cancelling it aborts the call to Portainer
 //   - id: The ID of the environment group to update
 //   - name: The new name for the environment group
 //
 // Returns:
 //   - An error if the operation fails
-func (c *PortainerClient) UpdateEnvironmentGroupName(id int, name string) error {
-	err := c.cli.UpdateEdgeGroup(int64(id), &name, nil, nil)
+func (c *PortainerClient) UpdateEnvironmentGroupName(ctx context.Context, id int, name string) error {
+	ctx = withOperation(ctx, "UpdateEnvironmentGroupName")
+
+	err := c.cli.UpdateEdgeGroup(ctx, int64(id), &name, nil, nil)
 	if err != nil {
 		return fmt.Errorf("failed to update environment group name: %w", err)
 	}
@@ -66,14 +75,17 @@ func (c *PortainerClient) UpdateEnvironmentGroupName(id int, name string) error
 // Environment groups are the equivalent of Edge Groups in Portainer.
 //
 // Parameters:
+//   - ctx: The context of the request, cancelling it aborts the call to Portainer
 //   - id: The ID of the environment group to update
 //   - environmentIds: A slice of environment IDs to include in the group
 //
 // Returns:
 //   - An error if the operation fails
-func (c *PortainerClient) UpdateEnvironmentGroupEnvironments(id int, environmentIds []int) error {
+func (c *PortainerClient) UpdateEnvironmentGroupEnvironments(ctx context.Context, id int, environmentIds []int) error {
+	ctx = withOperation(ctx, "UpdateEnvironmentGroupEnvironments")
+
 	envs := utils.IntToInt64Slice(environmentIds)
-	err := c.cli.UpdateEdgeGroup(int64(id), nil, &envs, nil)
+	err := c.cli.UpdateEdgeGroup(ctx, int64(id), nil, &envs, nil)
 	if err != nil {
 		return fmt.Errorf("failed to update environment group environments: %w", err)
 	}
@@ -84,14 +96,17 @@ func (c *PortainerClient) UpdateEnvironmentGroupEnvironments(id int, environment
 // Environment groups are the equivalent of Edge Groups in Portainer.
 //
 // Parameters:
+//   - ctx: The context of the request, cancelling it aborts the call to Portainer
 //   - id: The ID of the environment group to update
 //   - tagIds: A slice of tag IDs to include in the group
 //
 // Returns:
 //   - An error if the operation fails
-func (c *PortainerClient) UpdateEnvironmentGroupTags(id int, tagIds []int) error {
+func (c *PortainerClient) UpdateEnvironmentGroupTags(ctx context.Context, id int, tagIds []int) error {
+	ctx = withOperation(ctx, "UpdateEnvironmentGroupTags")
+
 	tags := utils.IntToInt64Slice(tagIds)
-	err := c.cli.UpdateEdgeGroup(int64(id), nil, nil, &tags)
+	err := c.cli.UpdateEdgeGroup(ctx, int64(id), nil, nil, &tags)
 	if err != nil {
 		return fmt.Errorf("failed to update environment group tags: %w", err)
 	}
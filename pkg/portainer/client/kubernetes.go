@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/portainer/client-api-go/v2/client"
@@ -10,12 +11,15 @@ import (
 // ProxyKubernetesRequest proxies a Kubernetes API request to a specific Portainer environment.
 //
 // Parameters:
+//   - ctx: The context of the request, cancelling it aborts the call to Portainer
 //   - opts: Options defining the proxied request (environmentID, method, path, query params, headers, body)
 //
 // Returns:
 //   - *http.Response: The response from the Kubernetes API
 //   - error: Any error that occurred during the request
-func (c *PortainerClient) ProxyKubernetesRequest(opts models.KubernetesProxyRequestOptions) (*http.Response, error) {
+func (c *PortainerClient) ProxyKubernetesRequest(ctx context.Context, opts models.KubernetesProxyRequestOptions) (*http.Response, error) {
+	ctx = withOperation(ctx, "ProxyKubernetesRequest")
+
 	proxyOpts := client.ProxyRequestOptions{
 		Method:  opts.Method,
 		APIPath: opts.Path,
@@ -30,5 +34,5 @@ func (c *PortainerClient) ProxyKubernetesRequest(opts models.KubernetesProxyRequ
 		proxyOpts.Headers = opts.Headers
 	}
 
-	return c.cli.ProxyKubernetesRequest(opts.EnvironmentID, proxyOpts)
+	return c.cli.ProxyKubernetesRequest(ctx, opts.EnvironmentID, proxyOpts)
 }
@@ -0,0 +1,237 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ConcurrencyRetryAfter is the delay suggested to the callers of a request rejected because
+// too many requests were in flight
+const ConcurrencyRetryAfter = time.Second
+
+// environmentPathPattern matches the paths of the requests targeting an environment
+var environmentPathPattern = regexp.MustCompile(`^/api/endpoints/(\d+)(/|$)`)
+
+// ThrottledError is returned without contacting Portainer when a request would exceed a limit
+type ThrottledError struct {
+	// Limit describes the limit that was hit
+	Limit string
+	// RetryAfter is the delay after which the request is expected to be accepted
+	RetryAfter time.Duration
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("throttled by the %s, retry after %s", e.Limit, e.RetryAfter.Round(10*time.Millisecond))
+}
+
+// Limits bound the requests sent to a Portainer instance, a zero value disables a limit
+type Limits struct {
+	// MaxConcurrent caps the number of requests in flight. A proxied request is in flight
+	// until its response body is closed.
+	MaxConcurrent int
+	// Rate is the number of requests per second allowed by the token bucket of the instance,
+	// Burst the size of the bucket (defaults to one second of requests)
+	Rate  float64
+	Burst int
+	// EnvironmentRate and EnvironmentBurst configure a token bucket for each environment,
+	// applied to the requests targeting it, such as the Docker and Kubernetes proxy requests
+	EnvironmentRate  float64
+	EnvironmentBurst int
+}
+
+// Limiter enforces Limits. It can be shared by several clients of the same Portainer instance,
+// for example the clients of the MCP sessions, so that the limits apply to the instance as a whole.
+type Limiter struct {
+	limits Limits
+	now    func() time.Time
+
+	mu           sync.Mutex
+	inflight     int
+	instance     *tokenBucket
+	environments map[int]*tokenBucket
+}
+
+// NewLimiter creates a limiter enforcing the given limits
+func NewLimiter(limits Limits) *Limiter {
+	l := &Limiter{
+		limits:       limits,
+		now:          time.Now,
+		environments: make(map[int]*tokenBucket),
+	}
+
+	if limits.Rate > 0 {
+		l.instance = newTokenBucket(limits.Rate, limits.Burst)
+	}
+
+	return l
+}
+
+// WithLimiter rejects the requests that would exceed the limits of the given limiter with a ThrottledError
+func WithLimiter(limiter *Limiter) ClientOption {
+	return func(o *clientOptions) {
+		o.limiter = limiter
+	}
+}
+
+// acquire admits a request, the returned function must be called once the request is over
+func (l *Limiter) acquire(req *http.Request) (func(), error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.limits.MaxConcurrent > 0 && l.inflight >= l.limits.MaxConcurrent {
+		return nil, &ThrottledError{
+			Limit:      fmt.Sprintf("limit of %d concurrent requests to Portainer", l.limits.MaxConcurrent),
+			RetryAfter: ConcurrencyRetryAfter,
+		}
+	}
+
+	now := l.now()
+
+	var environment *tokenBucket
+	if environmentId, ok := requestEnvironmentID(req); ok && l.limits.EnvironmentRate > 0 {
+		environment = l.environments[environmentId]
+		if environment == nil {
+			environment = newTokenBucket(l.limits.EnvironmentRate, l.limits.EnvironmentBurst)
+			l.environments[environmentId] = environment
+		}
+
+		if delay := environment.delay(now); delay > 0 {
+			return nil, &ThrottledError{
+				Limit:      fmt.Sprintf("rate limit of environment %d", environmentId),
+				RetryAfter: delay,
+			}
+		}
+	}
+
+	if l.instance != nil {
+		if delay := l.instance.delay(now); delay > 0 {
+			return nil, &ThrottledError{
+				Limit:      "rate limit of the Portainer instance",
+				RetryAfter: delay,
+			}
+		}
+		l.instance.take()
+	}
+
+	if environment != nil {
+		environment.take()
+	}
+
+	l.inflight++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+
+			l.inflight--
+		})
+	}, nil
+}
+
+// requestEnvironmentID returns the ID of the environment targeted by a request,
+// from its path or from the endpointId query parameter of the stack requests
+func requestEnvironmentID(req *http.Request) (int, bool) {
+	raw := req.URL.Query().Get("endpointId")
+	if matches := environmentPathPattern.FindStringSubmatch(req.URL.Path); matches != nil {
+		raw = matches[1]
+	}
+
+	if raw == "" {
+		return 0, false
+	}
+
+	id, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, false
+	}
+
+	return id, true
+}
+
+// tokenBucket holds up to burst tokens, refilled at rate tokens per second
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst <= 0 {
+		burst = max(1, int(math.Ceil(rate)))
+	}
+
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+	}
+}
+
+// delay refills the bucket and returns the time until a token is available, 0 when there is one
+func (b *tokenBucket) delay(now time.Time) time.Duration {
+	if !b.last.IsZero() {
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+
+	if b.tokens >= 1 {
+		return 0
+	}
+
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// take removes a token, delay must have reported one available
+func (b *tokenBucket) take() {
+	b.tokens--
+}
+
+// isThrottled reports whether a request was rejected by a Limiter without contacting Portainer
+func isThrottled(err error) bool {
+	var throttled *ThrottledError
+	return errors.As(err, &throttled)
+}
+
+// limitedTransport rejects the requests exceeding the limits of a Limiter
+type limitedTransport struct {
+	next    http.RoundTripper
+	limiter *Limiter
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	release, err := t.limiter.acquire(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.Body == nil {
+		release()
+		return resp, err
+	}
+
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// releasingBody ends a request admitted by a Limiter once its response body is closed
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
@@ -0,0 +1,224 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestEnvironmentID(t *testing.T) {
+	tests := []struct {
+		url        string
+		expectedID int
+		expectedOk bool
+	}{
+		{url: "https://portainer/api/endpoints/3/docker/containers/json", expectedID: 3, expectedOk: true},
+		{url: "https://portainer/api/endpoints/12/kubernetes/api/v1/pods", expectedID: 12, expectedOk: true},
+		{url: "https://portainer/api/endpoints/7", expectedID: 7, expectedOk: true},
+		{url: "https://portainer/api/stacks/4/start?endpointId=9", expectedID: 9, expectedOk: true},
+		{url: "https://portainer/api/endpoints", expectedOk: false},
+		{url: "https://portainer/api/stacks?endpointId=abc", expectedOk: false},
+		{url: "https://portainer/api/users", expectedOk: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			id, ok := requestEnvironmentID(req)
+			assert.Equal(t, tt.expectedOk, ok)
+			assert.Equal(t, tt.expectedID, id)
+		})
+	}
+}
+
+// step is a request submitted to a limiter at the given offset from the start of the test
+type step struct {
+	at         time.Duration
+	url        string
+	release    bool
+	throttled  string
+	retryAfter time.Duration
+}
+
+func TestLimiter(t *testing.T) {
+	const (
+		instanceURL = "https://portainer/api/endpoints"
+		env1URL     = "https://portainer/api/endpoints/1/docker/containers/json"
+		env2URL     = "https://portainer/api/endpoints/2/docker/containers/json"
+	)
+
+	tests := []struct {
+		name   string
+		limits Limits
+		steps  []step
+	}{
+		{
+			name:   "concurrency limit",
+			limits: Limits{MaxConcurrent: 2},
+			steps: []step{
+				{url: instanceURL},
+				{url: env1URL},
+				{url: env2URL, throttled: "limit of 2 concurrent requests to Portainer", retryAfter: ConcurrencyRetryAfter},
+			},
+		},
+		{
+			name:   "concurrency slot freed once released",
+			limits: Limits{MaxConcurrent: 1},
+			steps: []step{
+				{url: instanceURL, release: true},
+				{url: instanceURL},
+				{url: instanceURL, throttled: "limit of 1 concurrent requests to Portainer", retryAfter: ConcurrencyRetryAfter},
+			},
+		},
+		{
+			name:   "instance rate limit",
+			limits: Limits{Rate: 2, Burst: 2},
+			steps: []step{
+				{url: instanceURL},
+				{url: env1URL},
+				{url: env2URL, throttled: "rate limit of the Portainer instance", retryAfter: 500 * time.Millisecond},
+				{at: 250 * time.Millisecond, url: env2URL, throttled: "rate limit of the Portainer instance", retryAfter: 250 * time.Millisecond},
+				{at: 500 * time.Millisecond, url: env2URL},
+			},
+		},
+		{
+			name:   "burst defaults to one second of requests",
+			limits: Limits{Rate: 3},
+			steps: []step{
+				{url: instanceURL},
+				{url: instanceURL},
+				{url: instanceURL},
+				{url: instanceURL, throttled: "rate limit of the Portainer instance", retryAfter: 333 * time.Millisecond},
+			},
+		},
+		{
+			name:   "environment rate limit",
+			limits: Limits{EnvironmentRate: 1},
+			steps: []step{
+				{url: env1URL},
+				{url: env1URL, throttled: "rate limit of environment 1", retryAfter: time.Second},
+				{url: env2URL},
+				{url: instanceURL},
+				{at: time.Second, url: env1URL},
+			},
+		},
+		{
+			name:   "throttled environment does not consume the instance rate",
+			limits: Limits{Rate: 2, Burst: 2, EnvironmentRate: 1},
+			steps: []step{
+				{url: env1URL},
+				{url: env1URL, throttled: "rate limit of environment 1", retryAfter: time.Second},
+				{url: env2URL},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			now := start
+
+			limiter := NewLimiter(tt.limits)
+			limiter.now = func() time.Time { return now }
+
+			for i, s := range tt.steps {
+				now = start.Add(s.at)
+
+				release, err := limiter.acquire(httptest.NewRequest(http.MethodGet, s.url, nil))
+				if s.throttled == "" {
+					require.NoError(t, err, "step %d", i)
+					if s.release {
+						release()
+						release()
+					}
+					continue
+				}
+
+				var throttled *ThrottledError
+				require.ErrorAs(t, err, &throttled, "step %d", i)
+				assert.Equal(t, s.throttled, throttled.Limit, "step %d", i)
+				assert.InDelta(t, s.retryAfter, throttled.RetryAfter, float64(time.Millisecond), "step %d", i)
+			}
+		})
+	}
+}
+
+func TestThrottledErrorMessage(t *testing.T) {
+	err := &ThrottledError{Limit: "rate limit of environment 3", RetryAfter: 1234567 * time.Microsecond}
+	assert.Equal(t, "throttled by the rate limit of environment 3, retry after 1.23s", err.Error())
+}
+
+func TestLimitedTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	httpClient := &http.Client{Transport: &limitedTransport{
+		next:    http.DefaultTransport,
+		limiter: NewLimiter(Limits{MaxConcurrent: 1}),
+	}}
+
+	first, err := httpClient.Get(srv.URL + "/api/endpoints/1/docker/containers/json")
+	require.NoError(t, err)
+
+	_, err = httpClient.Get(srv.URL + "/api/endpoints/1/docker/containers/json")
+	var throttled *ThrottledError
+	assert.True(t, errors.As(err, &throttled), "the request is in flight until its body is closed")
+
+	require.NoError(t, first.Body.Close())
+
+	second, err := httpClient.Get(srv.URL + "/api/endpoints/1/docker/containers/json")
+	require.NoError(t, err)
+	second.Body.Close()
+}
+
+func TestNewPortainerClientLimiterRetries(t *testing.T) {
+	tests := []struct {
+		name          string
+		burst         int
+		expectedCalls int32
+		expectedError bool
+	}{
+		{name: "each attempt takes a token", burst: 3, expectedCalls: 3},
+		{name: "retry throttled once the tokens are spent", burst: 2, expectedCalls: 2, expectedError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) <= 2 {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"Version":"2.31.2"}`))
+			}))
+			defer srv.Close()
+
+			c := NewPortainerClient(strings.TrimPrefix(srv.URL, "https://"), "token",
+				WithSkipTLSVerify(true),
+				WithRetryPolicy(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}),
+				WithLimiter(NewLimiter(Limits{Rate: 0.01, Burst: tt.burst})),
+			)
+
+			_, err := c.GetVersion(context.Background())
+			if tt.expectedError {
+				var throttled *ThrottledError
+				assert.ErrorAs(t, err, &throttled)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedCalls, calls.Load(), "upstream requests")
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/portainer/client-api-go/v2/client"
@@ -54,7 +55,7 @@ type MockPortainerAPI struct {
 }
 
 // ListEdgeGroups mocks the ListEdgeGroups method
-func (m *MockPortainerAPI) ListEdgeGroups() ([]*apimodels.EdgegroupsDecoratedEdgeGroup, error) {
+func (m *MockPortainerAPI) ListEdgeGroups(ctx context.Context) ([]*apimodels.EdgegroupsDecoratedEdgeGroup, error) {
 	args := m.Called()
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -63,19 +64,19 @@ func (m *MockPortainerAPI) ListEdgeGroups() ([]*apimodels.EdgegroupsDecoratedEdg
 }
 
 // CreateEdgeGroup mocks the CreateEdgeGroup method
-func (m *MockPortainerAPI) CreateEdgeGroup(name string, environmentIds []int64) (int64, error) {
+func (m *MockPortainerAPI) CreateEdgeGroup(ctx context.Context, name string, environmentIds []int64) (int64, error) {
 	args := m.Called(name, environmentIds)
 	return args.Get(0).(int64), args.Error(1)
 }
 
 // UpdateEdgeGroup mocks the UpdateEdgeGroup method
-func (m *MockPortainerAPI) UpdateEdgeGroup(id int64, name *string, environmentIds *[]int64, tagIds *[]int64) error {
+func (m *MockPortainerAPI) UpdateEdgeGroup(ctx context.Context, id int64, name *string, environmentIds *[]int64, tagIds *[]int64) error {
 	args := m.Called(id, name, environmentIds, tagIds)
 	return args.Error(0)
 }
 
 // ListEdgeStacks mocks the ListEdgeStacks method
-func (m *MockPortainerAPI) ListEdgeStacks() ([]*apimodels.PortainereeEdgeStack, error) {
+func (m *MockPortainerAPI) ListEdgeStacks(ctx context.Context) ([]*apimodels.PortainereeEdgeStack, error) {
 	args := m.Called()
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -84,25 +85,40 @@ func (m *MockPortainerAPI) ListEdgeStacks() ([]*apimodels.PortainereeEdgeStack,
 }
 
 // CreateEdgeStack mocks the CreateEdgeStack method
-func (m *MockPortainerAPI) CreateEdgeStack(name string, file string, environmentGroupIds []int64) (int64, error) {
+func (m *MockPortainerAPI) CreateEdgeStack(ctx context.Context, name string, file string, environmentGroupIds []int64) (int64, error) {
 	args := m.Called(name, file, environmentGroupIds)
 	return args.Get(0).(int64), args.Error(1)
 }
 
 // UpdateEdgeStack mocks the UpdateEdgeStack method
-func (m *MockPortainerAPI) UpdateEdgeStack(id int64, file string, environmentGroupIds []int64) error {
-	args := m.Called(id, file, environmentGroupIds)
+func (m *MockPortainerAPI) UpdateEdgeStack(ctx context.Context, id int64, file string, environmentGroupIds []int64, deploymentType int64) error {
+	args := m.Called(id, file, environmentGroupIds, deploymentType)
 	return args.Error(0)
 }
 
 // GetEdgeStackFile mocks the GetEdgeStackFile method
-func (m *MockPortainerAPI) GetEdgeStackFile(id int64) (string, error) {
+func (m *MockPortainerAPI) GetEdgeStackFile(ctx context.Context, id int64) (string, error) {
 	args := m.Called(id)
 	return args.String(0), args.Error(1)
 }
 
+// GetEdgeStack mocks the GetEdgeStack method
+func (m *MockPortainerAPI) GetEdgeStack(ctx context.Context, id int64) (*apimodels.PortainereeEdgeStack, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*apimodels.PortainereeEdgeStack), args.Error(1)
+}
+
+// DeleteEdgeStack mocks the DeleteEdgeStack method
+func (m *MockPortainerAPI) DeleteEdgeStack(ctx context.Context, id int64) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
 // ListEndpointGroups mocks the ListEndpointGroups method
-func (m *MockPortainerAPI) ListEndpointGroups() ([]*apimodels.PortainerEndpointGroup, error) {
+func (m *MockPortainerAPI) ListEndpointGroups(ctx context.Context) ([]*apimodels.PortainerEndpointGroup, error) {
 	args := m.Called()
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -111,31 +127,31 @@ func (m *MockPortainerAPI) ListEndpointGroups() ([]*apimodels.PortainerEndpointG
 }
 
 // CreateEndpointGroup mocks the CreateEndpointGroup method
-func (m *MockPortainerAPI) CreateEndpointGroup(name string, associatedEndpoints []int64) (int64, error) {
+func (m *MockPortainerAPI) CreateEndpointGroup(ctx context.Context, name string, associatedEndpoints []int64) (int64, error) {
 	args := m.Called(name, associatedEndpoints)
 	return args.Get(0).(int64), args.Error(1)
 }
 
 // UpdateEndpointGroup mocks the UpdateEndpointGroup method
-func (m *MockPortainerAPI) UpdateEndpointGroup(id int64, name *string, userAccesses *map[int64]string, teamAccesses *map[int64]string) error {
+func (m *MockPortainerAPI) UpdateEndpointGroup(ctx context.Context, id int64, name *string, userAccesses *map[int64]string, teamAccesses *map[int64]string) error {
 	args := m.Called(id, name, userAccesses, teamAccesses)
 	return args.Error(0)
 }
 
 // AddEnvironmentToEndpointGroup mocks the AddEnvironmentToEndpointGroup method
-func (m *MockPortainerAPI) AddEnvironmentToEndpointGroup(groupId int64, environmentId int64) error {
+func (m *MockPortainerAPI) AddEnvironmentToEndpointGroup(ctx context.Context, groupId int64, environmentId int64) error {
 	args := m.Called(groupId, environmentId)
 	return args.Error(0)
 }
 
 // RemoveEnvironmentFromEndpointGroup mocks the RemoveEnvironmentFromEndpointGroup method
-func (m *MockPortainerAPI) RemoveEnvironmentFromEndpointGroup(groupId int64, environmentId int64) error {
+func (m *MockPortainerAPI) RemoveEnvironmentFromEndpointGroup(ctx context.Context, groupId int64, environmentId int64) error {
 	args := m.Called(groupId, environmentId)
 	return args.Error(0)
 }
 
 // ListEndpoints mocks the ListEndpoints method
-func (m *MockPortainerAPI) ListEndpoints() ([]*apimodels.PortainereeEndpoint, error) {
+func (m *MockPortainerAPI) ListEndpoints(ctx context.Context) ([]*apimodels.PortainereeEndpoint, error) {
 	args := m.Called()
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -144,7 +160,7 @@ func (m *MockPortainerAPI) ListEndpoints() ([]*apimodels.PortainereeEndpoint, er
 }
 
 // GetEndpoint mocks the GetEndpoint method
-func (m *MockPortainerAPI) GetEndpoint(id int64) (*apimodels.PortainereeEndpoint, error) {
+func (m *MockPortainerAPI) GetEndpoint(ctx context.Context, id int64) (*apimodels.PortainereeEndpoint, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -153,13 +169,13 @@ func (m *MockPortainerAPI) GetEndpoint(id int64) (*apimodels.PortainereeEndpoint
 }
 
 // UpdateEndpoint mocks the UpdateEndpoint method
-func (m *MockPortainerAPI) UpdateEndpoint(id int64, tagIds *[]int64, userAccesses *map[int64]string, teamAccesses *map[int64]string) error {
+func (m *MockPortainerAPI) UpdateEndpoint(ctx context.Context, id int64, tagIds *[]int64, userAccesses *map[int64]string, teamAccesses *map[int64]string) error {
 	args := m.Called(id, tagIds, userAccesses, teamAccesses)
 	return args.Error(0)
 }
 
 // GetSettings mocks the GetSettings method
-func (m *MockPortainerAPI) GetSettings() (*apimodels.PortainereeSettings, error) {
+func (m *MockPortainerAPI) GetSettings(ctx context.Context) (*apimodels.PortainereeSettings, error) {
 	args := m.Called()
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -168,7 +184,7 @@ func (m *MockPortainerAPI) GetSettings() (*apimodels.PortainereeSettings, error)
 }
 
 // ListTags mocks the ListTags method
-func (m *MockPortainerAPI) ListTags() ([]*apimodels.PortainerTag, error) {
+func (m *MockPortainerAPI) ListTags(ctx context.Context) ([]*apimodels.PortainerTag, error) {
 	args := m.Called()
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -177,13 +193,13 @@ func (m *MockPortainerAPI) ListTags() ([]*apimodels.PortainerTag, error) {
 }
 
 // CreateTag mocks the CreateTag method
-func (m *MockPortainerAPI) CreateTag(name string) (int64, error) {
+func (m *MockPortainerAPI) CreateTag(ctx context.Context, name string) (int64, error) {
 	args := m.Called(name)
 	return args.Get(0).(int64), args.Error(1)
 }
 
 // ListTeams mocks the ListTeams method
-func (m *MockPortainerAPI) ListTeams() ([]*apimodels.PortainerTeam, error) {
+func (m *MockPortainerAPI) ListTeams(ctx context.Context) ([]*apimodels.PortainerTeam, error) {
 	args := m.Called()
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -192,7 +208,7 @@ func (m *MockPortainerAPI) ListTeams() ([]*apimodels.PortainerTeam, error) {
 }
 
 // ListTeamMemberships mocks the ListTeamMemberships method
-func (m *MockPortainerAPI) ListTeamMemberships() ([]*apimodels.PortainerTeamMembership, error) {
+func (m *MockPortainerAPI) ListTeamMemberships(ctx context.Context) ([]*apimodels.PortainerTeamMembership, error) {
 	args := m.Called()
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -201,31 +217,31 @@ func (m *MockPortainerAPI) ListTeamMemberships() ([]*apimodels.PortainerTeamMemb
 }
 
 // CreateTeam mocks the CreateTeam method
-func (m *MockPortainerAPI) CreateTeam(name string) (int64, error) {
+func (m *MockPortainerAPI) CreateTeam(ctx context.Context, name string) (int64, error) {
 	args := m.Called(name)
 	return args.Get(0).(int64), args.Error(1)
 }
 
 // UpdateTeamName mocks the UpdateTeamName method
-func (m *MockPortainerAPI) UpdateTeamName(id int, name string) error {
+func (m *MockPortainerAPI) UpdateTeamName(ctx context.Context, id int, name string) error {
 	args := m.Called(id, name)
 	return args.Error(0)
 }
 
 // DeleteTeamMembership mocks the DeleteTeamMembership method
-func (m *MockPortainerAPI) DeleteTeamMembership(id int) error {
+func (m *MockPortainerAPI) DeleteTeamMembership(ctx context.Context, id int) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
 // CreateTeamMembership mocks the CreateTeamMembership method
-func (m *MockPortainerAPI) CreateTeamMembership(teamId int, userId int) error {
+func (m *MockPortainerAPI) CreateTeamMembership(ctx context.Context, teamId int, userId int) error {
 	args := m.Called(teamId, userId)
 	return args.Error(0)
 }
 
 // ListUsers mocks the ListUsers method
-func (m *MockPortainerAPI) ListUsers() ([]*apimodels.PortainereeUser, error) {
+func (m *MockPortainerAPI) ListUsers(ctx context.Context) ([]*apimodels.PortainereeUser, error) {
 	args := m.Called()
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -234,19 +250,19 @@ func (m *MockPortainerAPI) ListUsers() ([]*apimodels.PortainereeUser, error) {
 }
 
 // UpdateUserRole mocks the UpdateUserRole method
-func (m *MockPortainerAPI) UpdateUserRole(id int, role int64) error {
+func (m *MockPortainerAPI) UpdateUserRole(ctx context.Context, id int, role int64) error {
 	args := m.Called(id, role)
 	return args.Error(0)
 }
 
 // GetVersion mocks the GetVersion method
-func (m *MockPortainerAPI) GetVersion() (string, error) {
+func (m *MockPortainerAPI) GetVersion(ctx context.Context) (string, error) {
 	args := m.Called()
 	return args.String(0), args.Error(1)
 }
 
 // ProxyDockerRequest mocks the ProxyDockerRequest method
-func (m *MockPortainerAPI) ProxyDockerRequest(environmentId int, opts client.ProxyRequestOptions) (*http.Response, error) {
+func (m *MockPortainerAPI) ProxyDockerRequest(ctx context.Context, environmentId int, opts client.ProxyRequestOptions) (*http.Response, error) {
 	args := m.Called(environmentId, opts)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -255,7 +271,7 @@ func (m *MockPortainerAPI) ProxyDockerRequest(environmentId int, opts client.Pro
 }
 
 // ProxyKubernetesRequest mocks the ProxyKubernetesRequest method
-func (m *MockPortainerAPI) ProxyKubernetesRequest(environmentId int, opts client.ProxyRequestOptions) (*http.Response, error) {
+func (m *MockPortainerAPI) ProxyKubernetesRequest(ctx context.Context, environmentId int, opts client.ProxyRequestOptions) (*http.Response, error) {
 	args := m.Called(environmentId, opts)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
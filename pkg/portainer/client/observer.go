@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// unknownOperation names the requests sent outside of a PortainerClient method
+const unknownOperation = "unknown"
+
+// Observer is notified of the requests sent to Portainer, for example to record metrics.
+// The operation is the name of the PortainerClient method that sent the request.
+type Observer interface {
+	// ObserveRequest is called once the response headers are received or the request failed,
+	// the status is 0 when no response was received. The duration includes the retries.
+	ObserveRequest(operation string, status int, duration time.Duration)
+	// ObserveProxyResponse is called with the size of the body of a response proxied from
+	// the Docker or Kubernetes API of an environment, once the body is closed
+	ObserveProxyResponse(operation string, size int64)
+}
+
+// WithObserver reports the requests sent to Portainer to the given observer
+func WithObserver(observer Observer) ClientOption {
+	return func(o *clientOptions) {
+		o.observer = observer
+	}
+}
+
+type operationKey struct{}
+
+// withOperation names the operation of the requests sent with the context.
+// The name of the outermost method is kept when a method calls another one.
+func withOperation(ctx context.Context, operation string) context.Context {
+	if _, ok := ctx.Value(operationKey{}).(string); ok {
+		return ctx
+	}
+
+	return context.WithValue(ctx, operationKey{}, operation)
+}
+
+// operationFromContext returns the name of the operation of the requests sent with the context
+func operationFromContext(ctx context.Context) string {
+	if operation, ok := ctx.Value(operationKey{}).(string); ok {
+		return operation
+	}
+
+	return unknownOperation
+}
+
+// observedTransport reports the requests sent to Portainer to an observer
+type observedTransport struct {
+	next     http.RoundTripper
+	observer Observer
+}
+
+func (t *observedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	operation := operationFromContext(req.Context())
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	if isThrottled(err) {
+		// Throttled requests never reach Portainer, they are not observed
+		return resp, err
+	}
+
+	status := 0
+	if resp != nil {
+		status = resp.StatusCode
+	}
+	t.observer.ObserveRequest(operation, status, time.Since(start))
+
+	if resp != nil && resp.Body != nil && isProxyRequest(req) {
+		resp.Body = &countingBody{ReadCloser: resp.Body, onClose: func(size int64) {
+			t.observer.ObserveProxyResponse(operation, size)
+		}}
+	}
+
+	return resp, err
+}
+
+// countingBody counts the bytes read from a response body and reports the count once closed
+type countingBody struct {
+	io.ReadCloser
+	size    int64
+	onClose func(size int64)
+	once    sync.Once
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.size += int64(n)
+	return n, err
+}
+
+func (b *countingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() { b.onClose(b.size) })
+	return err
+}
@@ -0,0 +1,129 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type observedRequest struct {
+	operation string
+	status    int
+}
+
+type observedProxyResponse struct {
+	operation string
+	size      int64
+}
+
+// recordingObserver records the requests it is notified of
+type recordingObserver struct {
+	mu             sync.Mutex
+	requests       []observedRequest
+	proxyResponses []observedProxyResponse
+}
+
+func (o *recordingObserver) ObserveRequest(operation string, status int, duration time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.requests = append(o.requests, observedRequest{operation: operation, status: status})
+}
+
+func (o *recordingObserver) ObserveProxyResponse(operation string, size int64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.proxyResponses = append(o.proxyResponses, observedProxyResponse{operation: operation, size: size})
+}
+
+func TestWithOperation(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, unknownOperation, operationFromContext(ctx))
+
+	ctx = withOperation(ctx, "GetStacks")
+	assert.Equal(t, "GetStacks", operationFromContext(ctx))
+
+	ctx = withOperation(ctx, "ListRegularStacks")
+	assert.Equal(t, "GetStacks", operationFromContext(ctx), "the outermost operation is kept")
+}
+
+func TestObservedTransport(t *testing.T) {
+	tests := []struct {
+		name                   string
+		path                   string
+		next                   roundTripFunc
+		expectedRequest        observedRequest
+		expectedProxyResponses []observedProxyResponse
+	}{
+		{
+			name: "API request",
+			path: "/api/endpoints",
+			next: func(req *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("[]"))}, nil
+			},
+			expectedRequest: observedRequest{operation: "GetEnvironments", status: http.StatusOK},
+		},
+		{
+			name: "proxied request",
+			path: "/api/endpoints/1/docker/containers/json",
+			next: func(req *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("no such container"))}, nil
+			},
+			expectedRequest:        observedRequest{operation: "GetEnvironments", status: http.StatusNotFound},
+			expectedProxyResponses: []observedProxyResponse{{operation: "GetEnvironments", size: 17}},
+		},
+		{
+			name: "request failure",
+			path: "/api/endpoints",
+			next: func(req *http.Request) (*http.Response, error) {
+				return nil, errors.New("connection refused")
+			},
+			expectedRequest: observedRequest{operation: "GetEnvironments", status: 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			observer := &recordingObserver{}
+			transport := &observedTransport{next: tt.next, observer: observer}
+
+			ctx := withOperation(context.Background(), "GetEnvironments")
+			req := httptest.NewRequest(http.MethodGet, "https://portainer.example.com"+tt.path, nil).WithContext(ctx)
+			resp, err := transport.RoundTrip(req)
+			if err == nil {
+				_, _ = io.ReadAll(resp.Body)
+				require.NoError(t, resp.Body.Close())
+				require.NoError(t, resp.Body.Close(), "closing the body twice reports it once")
+			}
+
+			assert.Equal(t, []observedRequest{tt.expectedRequest}, observer.requests)
+			assert.Equal(t, tt.expectedProxyResponses, observer.proxyResponses)
+		})
+	}
+}
+
+func TestNewPortainerClientObserver(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"Version":"2.31.2"}`))
+	}))
+	defer srv.Close()
+
+	observer := &recordingObserver{}
+	c := NewPortainerClient(strings.TrimPrefix(srv.URL, "https://"), "token",
+		WithSkipTLSVerify(true),
+		WithObserver(observer),
+	)
+
+	_, err := c.GetVersion(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []observedRequest{{operation: "GetVersion", status: http.StatusOK}}, observer.requests)
+}
@@ -0,0 +1,226 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// Default retry and circuit breaker settings of the client
+const (
+	DefaultMaxAttempts      = 3
+	DefaultInitialBackoff   = 250 * time.Millisecond
+	DefaultMaxBackoff       = 2 * time.Second
+	DefaultFailureThreshold = 5
+	DefaultBreakerCooldown  = 30 * time.Second
+)
+
+// proxyPathPattern matches the paths of the requests proxied to the Docker or Kubernetes API of an environment
+var proxyPathPattern = regexp.MustCompile(`^/api/endpoints/\d+/(docker|kubernetes)(/|$)`)
+
+// ErrCircuitOpen is returned without contacting Portainer while the circuit breaker is open
+var ErrCircuitOpen = errors.New("Portainer is unavailable, failing fast until it recovers")
+
+// RetryPolicy configures the retries of idempotent requests
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, 1 disables retries
+	MaxAttempts int
+	// InitialBackoff is the upper bound of the delay before the first retry,
+	// it doubles for each following retry up to MaxBackoff
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultRetryPolicy returns the retry policy used by NewPortainerClient
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    DefaultMaxAttempts,
+		InitialBackoff: DefaultInitialBackoff,
+		MaxBackoff:     DefaultMaxBackoff,
+	}
+}
+
+// backoff returns a random delay before the given retry (starting at 1), using full jitter
+// so that clients retrying after a Portainer restart do not all come back at once
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	ceiling := p.InitialBackoff << (retry - 1)
+	if ceiling > p.MaxBackoff || ceiling <= 0 {
+		ceiling = p.MaxBackoff
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+
+	return rand.N(ceiling)
+}
+
+// circuitBreaker stops sending requests to Portainer after a number of consecutive failures.
+// Once the cooldown has elapsed a single trial request is let through: it closes the circuit
+// when it succeeds and opens it again when it fails.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// allow reports whether a request can be sent
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return true
+	}
+
+	if b.probing || b.now().Before(b.openUntil) {
+		return false
+	}
+
+	b.probing = true
+	return true
+}
+
+// release lets another trial request through when the outcome of a request is unknown
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+// record updates the breaker with the outcome of a request
+func (b *circuitBreaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+
+	if !failed {
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openUntil = b.now().Add(b.cooldown)
+	}
+}
+
+// resilientTransport retries idempotent requests with jittered backoff and fails fast
+// through a circuit breaker while Portainer is unavailable.
+// Only GET and HEAD requests without a body are retried, writes are sent once.
+type resilientTransport struct {
+	next    http.RoundTripper
+	policy  RetryPolicy
+	breaker *circuitBreaker
+}
+
+func (t *resilientTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	attempts := 1
+	if isRetryableRequest(req) && t.policy.MaxAttempts > 1 {
+		attempts = t.policy.MaxAttempts
+	}
+
+	for attempt := 1; ; attempt++ {
+		if t.breaker != nil && !t.breaker.allow() {
+			return nil, ErrCircuitOpen
+		}
+
+		resp, err := t.next.RoundTrip(req)
+		if req.Context().Err() != nil || isThrottled(err) {
+			// A canceled or throttled request says nothing about the health of Portainer,
+			// and retrying a throttled request would only hit the limit again
+			if t.breaker != nil {
+				t.breaker.release()
+			}
+			return resp, err
+		}
+
+		failed := isUnavailable(resp, err)
+		if t.breaker != nil {
+			// A proxied request failing with a status comes from an unreachable environment,
+			// for example a slow edge tunnel, while Portainer itself is available. A rejected
+			// certificate is not an outage either, its error must not be hidden by an open circuit.
+			t.breaker.record(failed && (err != nil || !isProxyRequest(req)))
+		}
+
+		if !failed || attempt >= attempts {
+			return resp, err
+		}
+
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, fmt.Errorf("request canceled while waiting to retry: %w", req.Context().Err())
+		case <-time.After(t.policy.backoff(attempt)):
+		}
+	}
+}
+
+// isRetryableRequest reports whether the request can be sent again without side effects
+func isRetryableRequest(req *http.Request) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+
+	return req.Body == nil || req.Body == http.NoBody
+}
+
+// isUnavailable reports whether the outcome of a request shows that Portainer, or the
+// environment behind it, is unavailable rather than rejecting the request
+func isUnavailable(resp *http.Response, err error) bool {
+	if err != nil {
+		return !isTLSError(err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// isProxyRequest reports whether the request is proxied by Portainer to an environment
+func isProxyRequest(req *http.Request) bool {
+	return proxyPathPattern.MatchString(req.URL.Path)
+}
+
+// isTLSError reports whether the error comes from the TLS handshake being rejected,
+// which retrying cannot fix
+func isTLSError(err error) bool {
+	var verificationErr *tls.CertificateVerificationError
+	var alertErr tls.AlertError
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+
+	return errors.As(err, &verificationErr) ||
+		errors.As(err, &alertErr) ||
+		errors.As(err, &unknownAuthorityErr) ||
+		errors.As(err, &hostnameErr) ||
+		errors.Is(err, errFingerprintMismatch)
+}
@@ -0,0 +1,257 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// roundTripFunc adapts a function to an http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// flakyTransport fails the first failures requests with the given status, or a connection error when 0
+func flakyTransport(failures int32, status int, calls *atomic.Int32) http.RoundTripper {
+	return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if calls.Add(1) <= failures {
+			if status == 0 {
+				return nil, errors.New("connection refused")
+			}
+			return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader("unavailable"))}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+	})
+}
+
+func TestResilientTransportRetries(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		failures      int32
+		status        int
+		expectedCalls int32
+		expectedCode  int
+		expectError   bool
+	}{
+		{
+			name:          "read succeeds after connection errors",
+			method:        http.MethodGet,
+			path:          "/api/endpoints",
+			failures:      2,
+			expectedCalls: 3,
+			expectedCode:  http.StatusOK,
+		},
+		{
+			name:          "proxied read succeeds after bad gateway",
+			method:        http.MethodGet,
+			path:          "/api/endpoints/1/docker/containers/json",
+			failures:      1,
+			status:        http.StatusBadGateway,
+			expectedCalls: 2,
+			expectedCode:  http.StatusOK,
+		},
+		{
+			name:          "read gives up after max attempts",
+			method:        http.MethodGet,
+			path:          "/api/endpoints",
+			failures:      5,
+			status:        http.StatusServiceUnavailable,
+			expectedCalls: 3,
+			expectedCode:  http.StatusServiceUnavailable,
+		},
+		{
+			name:          "client errors are not retried",
+			method:        http.MethodGet,
+			path:          "/api/endpoints/42",
+			failures:      5,
+			status:        http.StatusNotFound,
+			expectedCalls: 1,
+			expectedCode:  http.StatusNotFound,
+		},
+		{
+			name:          "writes are never retried",
+			method:        http.MethodPost,
+			path:          "/api/stacks/create/standalone/string",
+			failures:      1,
+			expectedCalls: 1,
+			expectError:   true,
+		},
+		{
+			name:          "deletes are never retried",
+			method:        http.MethodDelete,
+			path:          "/api/stacks/1",
+			failures:      1,
+			status:        http.StatusGatewayTimeout,
+			expectedCalls: 1,
+			expectedCode:  http.StatusGatewayTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			transport := &resilientTransport{
+				next:   flakyTransport(tt.failures, tt.status, &calls),
+				policy: policy,
+			}
+
+			req := httptest.NewRequest(tt.method, "https://portainer.example.com"+tt.path, nil)
+			resp, err := transport.RoundTrip(req)
+
+			assert.Equal(t, tt.expectedCalls, calls.Load())
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedCode, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestResilientTransportCanceledWhileWaiting(t *testing.T) {
+	var calls atomic.Int32
+	transport := &resilientTransport{
+		next:   flakyTransport(5, 0, &calls),
+		policy: RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Hour, MaxBackoff: time.Hour},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	req := httptest.NewRequest(http.MethodGet, "https://portainer.example.com/api/endpoints", nil).WithContext(ctx)
+	_, err := transport.RoundTrip(req)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	breaker := newCircuitBreaker(2, time.Minute)
+	breaker.now = func() time.Time { return now }
+
+	var down atomic.Bool
+	down.Store(true)
+	var calls atomic.Int32
+	transport := &resilientTransport{
+		next: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			calls.Add(1)
+			if down.Load() {
+				return nil, errors.New("connection refused")
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+		}),
+		policy:  RetryPolicy{MaxAttempts: 1},
+		breaker: breaker,
+	}
+
+	get := func() error {
+		_, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://portainer.example.com/api/endpoints", nil))
+		return err
+	}
+
+	assert.Error(t, get())
+	assert.Error(t, get())
+	assert.Equal(t, int32(2), calls.Load())
+
+	assert.ErrorIs(t, get(), ErrCircuitOpen, "the circuit opens after the threshold")
+	assert.Equal(t, int32(2), calls.Load(), "no request is sent while the circuit is open")
+
+	now = now.Add(time.Minute)
+	assert.Error(t, get(), "a trial request is sent after the cooldown")
+	assert.Equal(t, int32(3), calls.Load())
+	assert.ErrorIs(t, get(), ErrCircuitOpen, "a failed trial opens the circuit again")
+
+	now = now.Add(time.Minute)
+	down.Store(false)
+	assert.NoError(t, get())
+	assert.NoError(t, get(), "a successful trial closes the circuit")
+	assert.Equal(t, int32(5), calls.Load())
+}
+
+func TestCircuitBreakerIgnoresProxiedEnvironmentErrors(t *testing.T) {
+	breaker := newCircuitBreaker(1, time.Minute)
+	transport := &resilientTransport{
+		next:    flakyTransport(10, http.StatusBadGateway, &atomic.Int32{}),
+		policy:  RetryPolicy{MaxAttempts: 1},
+		breaker: breaker,
+	}
+
+	for i := 0; i < 3; i++ {
+		resp, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://portainer.example.com/api/endpoints/3/docker/info", nil))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	}
+
+	assert.True(t, breaker.allow(), "an unreachable environment must not open the circuit")
+}
+
+func TestCircuitBreakerIgnoresTLSErrors(t *testing.T) {
+	breaker := newCircuitBreaker(1, time.Minute)
+	transport := &resilientTransport{
+		next: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			return nil, fmt.Errorf("tls: %w", errFingerprintMismatch)
+		}),
+		policy:  RetryPolicy{MaxAttempts: 3},
+		breaker: breaker,
+	}
+
+	for i := 0; i < 3; i++ {
+		_, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://portainer.example.com/api/endpoints", nil))
+		assert.ErrorIs(t, err, errFingerprintMismatch, "the certificate error is returned instead of an open circuit")
+	}
+
+	assert.True(t, breaker.allow(), "a rejected certificate must not open the circuit")
+}
+
+func TestBackoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}
+
+	for retry := 1; retry <= 40; retry++ {
+		delay := policy.backoff(retry)
+		assert.GreaterOrEqual(t, delay, time.Duration(0))
+		assert.Less(t, delay, policy.MaxBackoff, "retry %d", retry)
+	}
+
+	assert.Equal(t, time.Duration(0), RetryPolicy{}.backoff(1))
+}
+
+func TestNewPortainerClientRetries(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"Version":"2.31.2"}`))
+	}))
+	defer srv.Close()
+
+	c := NewPortainerClient(strings.TrimPrefix(srv.URL, "https://"), "token",
+		WithSkipTLSVerify(true),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}),
+	)
+
+	version, err := c.GetVersion(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "2.31.2", version)
+	assert.Equal(t, int32(2), calls.Load())
+}
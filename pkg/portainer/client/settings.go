@@ -1,13 +1,16 @@
 package client
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/portainer/portainer-mcp/pkg/portainer/models"
 )
 
-func (c *PortainerClient) GetSettings() (models.PortainerSettings, error) {
-	settings, err := c.cli.GetSettings()
+func (c *PortainerClient) GetSettings(ctx context.Context) (models.PortainerSettings, error) {
+	ctx = withOperation(ctx, "GetSettings")
+
+	settings, err := c.cli.GetSettings(ctx)
 	if err != nil {
 		return models.PortainerSettings{}, fmt.Errorf("failed to get settings: %w", err)
 	}
@@ -1,14 +1,20 @@
 package client
 
 import (
+	"context"
 	"fmt"
+	"maps"
+	"slices"
 
+	apimodels "github.com/portainer/client-api-go/v2/pkg/models"
 	"github.com/portainer/portainer-mcp/pkg/portainer/models"
 )
 
 // GetStacks retrieves all regular (non-edge) stacks from the Portainer server.
-func (c *PortainerClient) GetStacks() ([]models.Stack, error) {
-	regularStacks, err := c.ListRegularStacks()
+func (c *PortainerClient) GetStacks(ctx context.Context) ([]models.Stack, error) {
+	ctx = withOperation(ctx, "GetStacks")
+
+	regularStacks, err := c.ListRegularStacks(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list stacks: %w", err)
 	}
@@ -22,8 +28,10 @@ func (c *PortainerClient) GetStacks() ([]models.Stack, error) {
 }
 
 // GetStackFile retrieves the compose file content of a stack.
-func (c *PortainerClient) GetStackFile(id int) (string, error) {
-	file, err := c.GetRegularStackFile(int64(id))
+func (c *PortainerClient) GetStackFile(ctx context.Context, id int) (string, error) {
+	ctx = withOperation(ctx, "GetStackFile")
+
+	file, err := c.GetRegularStackFile(ctx, int64(id))
 	if err != nil {
 		return "", fmt.Errorf("failed to get stack file: %w", err)
 	}
@@ -31,9 +39,24 @@ func (c *PortainerClient) GetStackFile(id int) (string, error) {
 	return file, nil
 }
 
-// CreateStack creates a new Docker Compose stack on the specified endpoint.
-func (c *PortainerClient) CreateStack(name, file string, endpointId int) (int, error) {
-	id, err := c.CreateRegularStack(name, file, int64(endpointId))
+// GetStack retrieves a stack, including its environment variables and git configuration.
+func (c *PortainerClient) GetStack(ctx context.Context, id int) (models.Stack, error) {
+	ctx = withOperation(ctx, "GetStack")
+
+	stack, err := c.GetRegularStack(ctx, int64(id))
+	if err != nil {
+		return models.Stack{}, fmt.Errorf("failed to get stack: %w", err)
+	}
+
+	return models.ConvertRegularStackToStack(stack), nil
+}
+
+// CreateStack creates a new Docker Compose stack on the specified endpoint,
+// with the given environment variables.
+func (c *PortainerClient) CreateStack(ctx context.Context, name, file string, endpointId int, env map[string]string) (int, error) {
+	ctx = withOperation(ctx, "CreateStack")
+
+	id, err := c.CreateRegularStack(ctx, name, file, int64(endpointId), envPairs(env))
 	if err != nil {
 		return 0, fmt.Errorf("failed to create stack: %w", err)
 	}
@@ -41,9 +64,22 @@ func (c *PortainerClient) CreateStack(name, file string, endpointId int) (int, e
 	return int(id), nil
 }
 
-// UpdateStack updates an existing stack with new compose file content.
-func (c *PortainerClient) UpdateStack(id int, file string, endpointId int, pullImage bool) error {
-	err := c.UpdateRegularStack(int64(id), int64(endpointId), file, pullImage)
+// UpdateStack updates an existing stack with new compose file content. The environment variables
+// of the stack are replaced with env, a nil env keeps the current ones.
+func (c *PortainerClient) UpdateStack(ctx context.Context, id int, file string, endpointId int, pullImage bool, env map[string]string) error {
+	ctx = withOperation(ctx, "UpdateStack")
+
+	// Portainer clears the variables that are not sent with the update
+	pairs := envPairs(env)
+	if env == nil {
+		stack, err := c.GetRegularStack(ctx, int64(id))
+		if err != nil {
+			return fmt.Errorf("failed to get stack environment variables: %w", err)
+		}
+		pairs = stack.Env
+	}
+
+	err := c.UpdateRegularStack(ctx, int64(id), int64(endpointId), file, pullImage, pairs)
 	if err != nil {
 		return fmt.Errorf("failed to update stack: %w", err)
 	}
@@ -51,9 +87,73 @@ func (c *PortainerClient) UpdateStack(id int, file string, endpointId int, pullI
 	return nil
 }
 
+// CreateGitStack creates a new Docker Compose stack on the specified endpoint from the compose
+// file of a git repository, with the given environment variables.
+func (c *PortainerClient) CreateGitStack(ctx context.Context, name string, endpointId int, repository models.StackGitRepository, env map[string]string) (int, error) {
+	ctx = withOperation(ctx, "CreateGitStack")
+
+	body := &apimodels.StacksComposeStackFromGitRepositoryPayload{
+		Name:                     &name,
+		RepositoryURL:            &repository.URL,
+		RepositoryReferenceName:  repository.ReferenceName,
+		ComposeFile:              &repository.ComposeFilePath,
+		RepositoryAuthentication: repository.Username != "" || repository.Password != "",
+		RepositoryUsername:       repository.Username,
+		RepositoryPassword:       repository.Password,
+		Env:                      envPairs(env),
+	}
+
+	id, err := c.CreateRegularGitStack(ctx, int64(endpointId), body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create git stack: %w", err)
+	}
+
+	return int(id), nil
+}
+
+// RedeployGitStack pulls the latest commit of the git repository of a stack and redeploys it.
+// The environment variables of the stack are replaced with env, a nil env keeps the current ones.
+func (c *PortainerClient) RedeployGitStack(ctx context.Context, id int, endpointId int, pullImage bool, env map[string]string) error {
+	ctx = withOperation(ctx, "RedeployGitStack")
+
+	stack, err := c.GetRegularStack(ctx, int64(id))
+	if err != nil {
+		return fmt.Errorf("failed to get stack: %w", err)
+	}
+
+	if stack.GitConfig == nil || stack.IsDetachedFromGit {
+		return fmt.Errorf("stack %d is not deployed from a git repository", id)
+	}
+
+	body := &apimodels.StacksStackGitRedployPayload{
+		PullImage: pullImage,
+		// Like updates, redeployments clear the variables that are not sent
+		Env: stack.Env,
+	}
+	if env != nil {
+		body.Env = envPairs(env)
+	}
+
+	// The stored password is reused by Portainer when the credentials are flagged without one
+	if auth := stack.GitConfig.Authentication; auth != nil {
+		body.RepositoryAuthentication = true
+		body.RepositoryUsername = auth.Username
+		body.RepositoryGitCredentialID = auth.GitCredentialID
+	}
+
+	err = c.RedeployRegularGitStack(ctx, int64(id), int64(endpointId), body)
+	if err != nil {
+		return fmt.Errorf("failed to redeploy git stack: %w", err)
+	}
+
+	return nil
+}
+
 // StartStack starts a stopped stack.
-func (c *PortainerClient) StartStack(id int, endpointId int) error {
-	err := c.StartRegularStack(int64(id), int64(endpointId))
+func (c *PortainerClient) StartStack(ctx context.Context, id int, endpointId int) error {
+	ctx = withOperation(ctx, "StartStack")
+
+	err := c.StartRegularStack(ctx, int64(id), int64(endpointId))
 	if err != nil {
 		return fmt.Errorf("failed to start stack: %w", err)
 	}
@@ -62,8 +162,10 @@ func (c *PortainerClient) StartStack(id int, endpointId int) error {
 }
 
 // StopStack stops a running stack.
-func (c *PortainerClient) StopStack(id int, endpointId int) error {
-	err := c.StopRegularStack(int64(id), int64(endpointId))
+func (c *PortainerClient) StopStack(ctx context.Context, id int, endpointId int) error {
+	ctx = withOperation(ctx, "StopStack")
+
+	err := c.StopRegularStack(ctx, int64(id), int64(endpointId))
 	if err != nil {
 		return fmt.Errorf("failed to stop stack: %w", err)
 	}
@@ -72,11 +174,23 @@ func (c *PortainerClient) StopStack(id int, endpointId int) error {
 }
 
 // DeleteStack removes a stack.
-func (c *PortainerClient) DeleteStack(id int, endpointId int) error {
-	err := c.DeleteRegularStack(int64(id), int64(endpointId))
+func (c *PortainerClient) DeleteStack(ctx context.Context, id int, endpointId int) error {
+	ctx = withOperation(ctx, "DeleteStack")
+
+	err := c.DeleteRegularStack(ctx, int64(id), int64(endpointId))
 	if err != nil {
 		return fmt.Errorf("failed to delete stack: %w", err)
 	}
 
 	return nil
 }
+
+// envPairs converts environment variables to the pairs of the Portainer API, sorted by name
+func envPairs(env map[string]string) []*apimodels.PortainerPair {
+	pairs := make([]*apimodels.PortainerPair, 0, len(env))
+	for _, name := range slices.Sorted(maps.Keys(env)) {
+		pairs = append(pairs, &apimodels.PortainerPair{Name: name, Value: env[name]})
+	}
+
+	return pairs
+}
@@ -1,14 +1,22 @@
 package client
 
 import (
+	"context"
+	"fmt"
 	"testing"
 
+	"github.com/go-openapi/runtime"
+	sdkstacks "github.com/portainer/client-api-go/v2/pkg/client/stacks"
+	apimodels "github.com/portainer/client-api-go/v2/pkg/models"
+	"github.com/portainer/portainer-mcp/pkg/portainer/models"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // Note: GetStacks, GetStackFile, CreateStack, UpdateStack, StartStack, StopStack, DeleteStack
 // now use the SDK stacks service (stacksSvc) directly rather than the edge stacks API (cli).
 // Unit testing these requires mocking the SDK transport, which is covered by integration tests.
+// The payloads of the git stack operations are checked against a mock of the stacks service.
 // Handler-level tests in internal/mcp/stack_test.go cover the interface contract.
 
 func TestClientStackMethodsRequireStacksSvc(t *testing.T) {
@@ -16,44 +24,221 @@ func TestClientStackMethodsRequireStacksSvc(t *testing.T) {
 	client := &PortainerClient{cli: nil, stacksSvc: nil}
 
 	t.Run("GetStacks without stacksSvc", func(t *testing.T) {
-		_, err := client.GetStacks()
+		_, err := client.GetStacks(context.Background())
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "stacks service not initialized")
 	})
 
 	t.Run("GetStackFile without stacksSvc", func(t *testing.T) {
-		_, err := client.GetStackFile(1)
+		_, err := client.GetStackFile(context.Background(), 1)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "stacks service not initialized")
 	})
 
 	t.Run("CreateStack without stacksSvc", func(t *testing.T) {
-		_, err := client.CreateStack("test", "file", 8)
+		_, err := client.CreateStack(context.Background(), "test", "file", 8, nil)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "stacks service not initialized")
 	})
 
 	t.Run("UpdateStack without stacksSvc", func(t *testing.T) {
-		err := client.UpdateStack(1, "file", 8, true)
+		err := client.UpdateStack(context.Background(), 1, "file", 8, true, nil)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "stacks service not initialized")
+	})
+
+	t.Run("GetStack without stacksSvc", func(t *testing.T) {
+		_, err := client.GetStack(context.Background(), 1)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "stacks service not initialized")
+	})
+
+	t.Run("CreateGitStack without stacksSvc", func(t *testing.T) {
+		_, err := client.CreateGitStack(context.Background(), "test", 8, models.StackGitRepository{URL: "https://github.com/acme/stacks.git"}, nil)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "stacks service not initialized")
+	})
+
+	t.Run("RedeployGitStack without stacksSvc", func(t *testing.T) {
+		err := client.RedeployGitStack(context.Background(), 1, 8, true, nil)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "stacks service not initialized")
 	})
 
 	t.Run("StartStack without stacksSvc", func(t *testing.T) {
-		err := client.StartStack(1, 8)
+		err := client.StartStack(context.Background(), 1, 8)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "stacks service not initialized")
 	})
 
 	t.Run("StopStack without stacksSvc", func(t *testing.T) {
-		err := client.StopStack(1, 8)
+		err := client.StopStack(context.Background(), 1, 8)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "stacks service not initialized")
 	})
 
 	t.Run("DeleteStack without stacksSvc", func(t *testing.T) {
-		err := client.DeleteStack(1, 8)
+		err := client.DeleteStack(context.Background(), 1, 8)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "stacks service not initialized")
 	})
 }
+
+// mockStacksService implements the git operations of the SDK stacks service, recording the payloads it receives
+type mockStacksService struct {
+	sdkstacks.ClientService
+	stack            *apimodels.PortainereeStack
+	err              error
+	createBody       *apimodels.StacksComposeStackFromGitRepositoryPayload
+	redeployBody     *apimodels.StacksStackGitRedployPayload
+	redeployEndpoint *int64
+}
+
+func (m *mockStacksService) StackInspect(params *sdkstacks.StackInspectParams, authInfo runtime.ClientAuthInfoWriter, opts ...sdkstacks.ClientOption) (*sdkstacks.StackInspectOK, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &sdkstacks.StackInspectOK{Payload: m.stack}, nil
+}
+
+func (m *mockStacksService) StackCreateDockerStandaloneRepository(params *sdkstacks.StackCreateDockerStandaloneRepositoryParams, authInfo runtime.ClientAuthInfoWriter, opts ...sdkstacks.ClientOption) (*sdkstacks.StackCreateDockerStandaloneRepositoryOK, error) {
+	m.createBody = params.Body
+	return &sdkstacks.StackCreateDockerStandaloneRepositoryOK{Payload: &apimodels.PortainereeStack{ID: 5}}, nil
+}
+
+func (m *mockStacksService) StackGitRedeploy(params *sdkstacks.StackGitRedeployParams, authInfo runtime.ClientAuthInfoWriter, opts ...sdkstacks.ClientOption) (*sdkstacks.StackGitRedeployOK, error) {
+	m.redeployBody = params.Body
+	m.redeployEndpoint = params.EndpointID
+	return &sdkstacks.StackGitRedeployOK{}, nil
+}
+
+func TestCreateGitStack(t *testing.T) {
+	tests := []struct {
+		name       string
+		repository models.StackGitRepository
+		env        map[string]string
+		expected   *apimodels.StacksComposeStackFromGitRepositoryPayload
+	}{
+		{
+			name:       "public repository",
+			repository: models.StackGitRepository{URL: "https://github.com/acme/stacks.git", ComposeFilePath: "docker-compose.yml"},
+			expected: &apimodels.StacksComposeStackFromGitRepositoryPayload{
+				Name:          strPtr("web"),
+				RepositoryURL: strPtr("https://github.com/acme/stacks.git"),
+				ComposeFile:   strPtr("docker-compose.yml"),
+				Env:           []*apimodels.PortainerPair{},
+			},
+		},
+		{
+			name: "private repository with environment variables",
+			repository: models.StackGitRepository{
+				URL:             "https://github.com/acme/stacks.git",
+				ReferenceName:   "refs/heads/main",
+				ComposeFilePath: "web/docker-compose.yml",
+				Username:        "deploy",
+				Password:        "t0ken",
+			},
+			env: map[string]string{"DOMAIN": "acme.com"},
+			expected: &apimodels.StacksComposeStackFromGitRepositoryPayload{
+				Name:                     strPtr("web"),
+				RepositoryURL:            strPtr("https://github.com/acme/stacks.git"),
+				RepositoryReferenceName:  "refs/heads/main",
+				ComposeFile:              strPtr("web/docker-compose.yml"),
+				RepositoryAuthentication: true,
+				RepositoryUsername:       "deploy",
+				RepositoryPassword:       "t0ken",
+				Env:                      []*apimodels.PortainerPair{{Name: "DOMAIN", Value: "acme.com"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stacksSvc := &mockStacksService{}
+			client := &PortainerClient{stacksSvc: stacksSvc}
+
+			id, err := client.CreateGitStack(context.Background(), "web", 2, tt.repository, tt.env)
+
+			require.NoError(t, err)
+			assert.Equal(t, 5, id)
+			assert.Equal(t, tt.expected, stacksSvc.createBody)
+		})
+	}
+}
+
+func TestRedeployGitStack(t *testing.T) {
+	currentEnv := []*apimodels.PortainerPair{{Name: "DOMAIN", Value: "acme.com"}}
+
+	tests := []struct {
+		name          string
+		stack         *apimodels.PortainereeStack
+		inspectError  error
+		env           map[string]string
+		expected      *apimodels.StacksStackGitRedployPayload
+		errorContains string
+	}{
+		{
+			name: "current variables and credentials kept",
+			stack: &apimodels.PortainereeStack{
+				ID:  1,
+				Env: currentEnv,
+				GitConfig: &apimodels.GittypesRepoConfig{
+					URL:            "https://github.com/acme/stacks.git",
+					Authentication: &apimodels.GittypesGitAuthentication{Username: "deploy", Password: "t0ken"},
+				},
+			},
+			expected: &apimodels.StacksStackGitRedployPayload{
+				PullImage:                true,
+				Env:                      currentEnv,
+				RepositoryAuthentication: true,
+				RepositoryUsername:       "deploy",
+			},
+		},
+		{
+			name:  "variables replaced",
+			stack: &apimodels.PortainereeStack{ID: 1, Env: currentEnv, GitConfig: &apimodels.GittypesRepoConfig{URL: "https://github.com/acme/stacks.git"}},
+			env:   map[string]string{"DOMAIN": "acme.org"},
+			expected: &apimodels.StacksStackGitRedployPayload{
+				PullImage: true,
+				Env:       []*apimodels.PortainerPair{{Name: "DOMAIN", Value: "acme.org"}},
+			},
+		},
+		{
+			name:          "stack not deployed from git",
+			stack:         &apimodels.PortainereeStack{ID: 1},
+			errorContains: "stack 1 is not deployed from a git repository",
+		},
+		{
+			name:          "stack detached from git",
+			stack:         &apimodels.PortainereeStack{ID: 1, GitConfig: &apimodels.GittypesRepoConfig{}, IsDetachedFromGit: true},
+			errorContains: "stack 1 is not deployed from a git repository",
+		},
+		{
+			name:          "inspect error",
+			inspectError:  fmt.Errorf("stack not found"),
+			errorContains: "stack not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stacksSvc := &mockStacksService{stack: tt.stack, err: tt.inspectError}
+			client := &PortainerClient{stacksSvc: stacksSvc}
+
+			err := client.RedeployGitStack(context.Background(), 1, 2, true, tt.env)
+
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+				assert.Nil(t, stacksSvc.redeployBody)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stacksSvc.redeployBody)
+			assert.Equal(t, int64(2), *stacksSvc.redeployEndpoint)
+		})
+	}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/portainer/portainer-mcp/pkg/portainer/models"
@@ -12,8 +13,10 @@ import (
 // Returns:
 //   - A slice of EnvironmentTag objects
 //   - An error if the operation fails
-func (c *PortainerClient) GetEnvironmentTags() ([]models.EnvironmentTag, error) {
-	tags, err := c.cli.ListTags()
+func (c *PortainerClient) GetEnvironmentTags(ctx context.Context) ([]models.EnvironmentTag, error) {
+	ctx = withOperation(ctx, "GetEnvironmentTags")
+
+	tags, err := c.cli.ListTags(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list environment tags: %w", err)
 	}
@@ -30,13 +33,16 @@ func (c *PortainerClient) GetEnvironmentTags() ([]models.EnvironmentTag, error)
 // Environment tags are the equivalent of Tags in Portainer.
 //
 // Parameters:
+//   - ctx: The context of the request, cancelling it aborts the call to Portainer
 //   - name: The name of the environment tag
 //
 // Returns:
 //   - The ID of the created environment tag
 //   - An error if the operation fails
-func (c *PortainerClient) CreateEnvironmentTag(name string) (int, error) {
-	id, err := c.cli.CreateTag(name)
+func (c *PortainerClient) CreateEnvironmentTag(ctx context.Context, name string) (int, error) {
+	ctx = withOperation(ctx, "CreateEnvironmentTag")
+
+	id, err := c.cli.CreateTag(ctx, name)
 	if err != nil {
 		return 0, fmt.Errorf("failed to create environment tag: %w", err)
 	}
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/portainer/portainer-mcp/pkg/portainer/models"
@@ -11,14 +12,16 @@ import (
 // Returns:
 //   - A slice of Team objects containing team information
 //   - An error if the operation fails
-func (c *PortainerClient) GetTeams() ([]models.Team, error) {
-	portainerTeams, err := c.cli.ListTeams()
+func (c *PortainerClient) GetTeams(ctx context.Context) ([]models.Team, error) {
+	ctx = withOperation(ctx, "GetTeams")
+
+	portainerTeams, err := c.cli.ListTeams(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list teams: %w", err)
 	}
 
 	// Get team memberships to populate team members
-	memberships, err := c.cli.ListTeamMemberships()
+	memberships, err := c.cli.ListTeamMemberships(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list team memberships: %w", err)
 	}
@@ -34,22 +37,28 @@ func (c *PortainerClient) GetTeams() ([]models.Team, error) {
 // UpdateTeamName updates the name of a team.
 //
 // Parameters:
+//   - ctx: The context of the request, cancelling it aborts the call to Portainer
 //   - id: The ID of the team to update
 //   - name: The new name for the team
-func (c *PortainerClient) UpdateTeamName(id int, name string) error {
-	return c.cli.UpdateTeamName(id, name)
+func (c *PortainerClient) UpdateTeamName(ctx context.Context, id int, name string) error {
+	ctx = withOperation(ctx, "UpdateTeamName")
+
+	return c.cli.UpdateTeamName(ctx, id, name)
 }
 
 // CreateTeam creates a new team.
 //
 // Parameters:
+//   - ctx: The context of the request, cancelling it aborts the call to Portainer
 //   - name: The name of the team
 //
 // Returns:
 //   - The ID of the created team
 //   - An error if the operation fails
-func (c *PortainerClient) CreateTeam(name string) (int, error) {
-	id, err := c.cli.CreateTeam(name)
+func (c *PortainerClient) CreateTeam(ctx context.Context, name string) (int, error) {
+	ctx = withOperation(ctx, "CreateTeam")
+
+	id, err := c.cli.CreateTeam(ctx, name)
 	if err != nil {
 		return 0, fmt.Errorf("failed to create team: %w", err)
 	}
@@ -60,10 +69,13 @@ func (c *PortainerClient) CreateTeam(name string) (int, error) {
 // UpdateTeamMembers updates the members of a team.
 //
 // Parameters:
+//   - ctx: The context of the request, cancelling it aborts the call to Portainer
 //   - teamId: The ID of the team to update
 //   - userIds: The IDs of the users associated with the team
-func (c *PortainerClient) UpdateTeamMembers(teamId int, userIds []int) error {
-	memberships, err := c.cli.ListTeamMemberships()
+func (c *PortainerClient) UpdateTeamMembers(ctx context.Context, teamId int, userIds []int) error {
+	ctx = withOperation(ctx, "UpdateTeamMembers")
+
+	memberships, err := c.cli.ListTeamMemberships(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to list team memberships: %w", err)
 	}
@@ -88,7 +100,7 @@ func (c *PortainerClient) UpdateTeamMembers(teamId int, userIds []int) error {
 
 			// If user should not remain in the team, delete the membership
 			if !shouldKeep {
-				if err := c.cli.DeleteTeamMembership(int(membership.ID)); err != nil {
+				if err := c.cli.DeleteTeamMembership(ctx, int(membership.ID)); err != nil {
 					return fmt.Errorf("failed to delete team membership for user %d: %w", userID, err)
 				}
 			}
@@ -103,7 +115,7 @@ func (c *PortainerClient) UpdateTeamMembers(teamId int, userIds []int) error {
 		}
 
 		// Create new membership for this user
-		if err := c.cli.CreateTeamMembership(teamId, userID); err != nil {
+		if err := c.cli.CreateTeamMembership(ctx, teamId, userID); err != nil {
 			return fmt.Errorf("failed to create team membership for user %d: %w", userID, err)
 		}
 	}
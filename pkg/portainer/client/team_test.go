@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"errors"
 	"testing"
 
@@ -119,7 +120,7 @@ func TestGetTeams(t *testing.T) {
 
 			client := &PortainerClient{cli: mockAPI}
 
-			teams, err := client.GetTeams()
+			teams, err := client.GetTeams(context.Background())
 
 			if tt.expectedError {
 				assert.Error(t, err)
@@ -161,7 +162,7 @@ func TestUpdateTeamName(t *testing.T) {
 
 			client := &PortainerClient{cli: mockAPI}
 
-			err := client.UpdateTeamName(tt.teamID, tt.teamName)
+			err := client.UpdateTeamName(context.Background(), tt.teamID, tt.teamName)
 
 			if tt.expectedError {
 				assert.Error(t, err)
@@ -203,7 +204,7 @@ func TestCreateTeam(t *testing.T) {
 
 			client := &PortainerClient{cli: mockAPI}
 
-			id, err := client.CreateTeam(tt.teamName)
+			id, err := client.CreateTeam(context.Background(), tt.teamName)
 
 			if tt.expectedError {
 				assert.Error(t, err)
@@ -327,7 +328,7 @@ func TestUpdateTeamMembers(t *testing.T) {
 
 			client := &PortainerClient{cli: mockAPI}
 
-			err := client.UpdateTeamMembers(tt.teamID, tt.userIDs)
+			err := client.UpdateTeamMembers(context.Background(), tt.teamID, tt.userIDs)
 
 			if tt.expectedError {
 				assert.Error(t, err)
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// errFingerprintMismatch is returned when the certificate of the server does not match the pinned fingerprint
+var errFingerprintMismatch = errors.New("the certificate of the Portainer server does not match the pinned fingerprint")
+
+// LoadCACertificates reads a PEM bundle of CA certificates to trust instead of the system roots
+func LoadCACertificates(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificates: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no PEM certificate found in %s", path)
+	}
+
+	return pool, nil
+}
+
+// LoadClientCertificate reads the PEM certificate and private key used for mutual TLS
+func LoadClientCertificate(certFile, keyFile string) (tls.Certificate, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to load client certificate: %w", err)
+	}
+
+	return cert, nil
+}
+
+// ParseFingerprint parses a SHA-256 certificate fingerprint in hexadecimal.
+// The bytes can be separated by colons, as printed by openssl x509 -fingerprint -sha256.
+func ParseFingerprint(fingerprint string) ([]byte, error) {
+	raw, err := hex.DecodeString(strings.ReplaceAll(strings.TrimSpace(fingerprint), ":", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate fingerprint: %w", err)
+	}
+
+	if len(raw) != sha256.Size {
+		return nil, fmt.Errorf("invalid certificate fingerprint: expected a SHA-256 fingerprint of %d bytes, got %d", sha256.Size, len(raw))
+	}
+
+	return raw, nil
+}
+
+// newTLSConfig builds the TLS configuration of the connection to Portainer from the client options.
+// The server certificate is verified against the system roots, or the configured CA certificates.
+// When a fingerprint is pinned, the server certificate must match it instead, which allows
+// self-signed certificates.
+func newTLSConfig(options clientOptions) *tls.Config {
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		RootCAs:            options.rootCAs,
+		InsecureSkipVerify: options.skipTLSVerify,
+	}
+
+	if options.clientCertificate != nil {
+		config.Certificates = []tls.Certificate{*options.clientCertificate}
+	}
+
+	if len(options.pinnedFingerprint) > 0 && !options.skipTLSVerify {
+		pinned := options.pinnedFingerprint
+
+		// Chain verification is replaced by the fingerprint check below
+		config.InsecureSkipVerify = true
+		config.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return fmt.Errorf("the Portainer server did not present a certificate")
+			}
+
+			fingerprint := sha256.Sum256(rawCerts[0])
+			if !bytes.Equal(fingerprint[:], pinned) {
+				return fmt.Errorf("%w, got %s", errFingerprintMismatch, hex.EncodeToString(fingerprint[:]))
+			}
+
+			return nil
+		}
+	}
+
+	return config
+}
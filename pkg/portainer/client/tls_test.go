@@ -0,0 +1,198 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStatusServer starts a TLS server answering the Portainer system status endpoint
+func newStatusServer(t *testing.T, configure func(*httptest.Server)) (*httptest.Server, string) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/system/status" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"Version":"2.31.2"}`))
+	}))
+	if configure != nil {
+		configure(srv)
+	}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	return srv, strings.TrimPrefix(srv.URL, "https://")
+}
+
+// writeCertificate generates a self-signed client certificate and writes it and its key as PEM files
+func writeCertificate(t *testing.T) (*x509.Certificate, string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "portainer-mcp"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "client.pem")
+	keyFile := filepath.Join(dir, "client-key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+
+	return cert, certFile, keyFile
+}
+
+func TestTLSVerification(t *testing.T) {
+	srv, host := newStatusServer(t, nil)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0600))
+	pool, err := LoadCACertificates(caFile)
+	require.NoError(t, err)
+
+	fingerprint := sha256.Sum256(srv.Certificate().Raw)
+	otherFingerprint := sha256.Sum256([]byte("another certificate"))
+
+	tests := []struct {
+		name          string
+		opts          []ClientOption
+		errorContains string
+	}{
+		{
+			name:          "verifies the certificate by default",
+			errorContains: "certificate",
+		},
+		{
+			name: "skip verification",
+			opts: []ClientOption{WithSkipTLSVerify(true)},
+		},
+		{
+			name: "custom CA certificates",
+			opts: []ClientOption{WithCACertificates(pool)},
+		},
+		{
+			name: "pinned fingerprint",
+			opts: []ClientOption{WithPinnedCertificate(fingerprint[:])},
+		},
+		{
+			name:          "pinned fingerprint mismatch",
+			opts:          []ClientOption{WithPinnedCertificate(otherFingerprint[:])},
+			errorContains: "does not match the pinned fingerprint",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, err := NewPortainerClient(host, "token", tt.opts...).GetVersion(context.Background())
+
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "2.31.2", version)
+			}
+		})
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	clientCert, certFile, keyFile := writeCertificate(t)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+	_, host := newStatusServer(t, func(srv *httptest.Server) {
+		srv.TLS = &tls.Config{
+			ClientAuth: tls.RequireAndVerifyClientCert,
+			ClientCAs:  clientCAs,
+		}
+	})
+
+	_, err := NewPortainerClient(host, "token", WithSkipTLSVerify(true)).GetVersion(context.Background())
+	assert.Error(t, err, "the server requires a client certificate")
+
+	cert, err := LoadClientCertificate(certFile, keyFile)
+	require.NoError(t, err)
+
+	version, err := NewPortainerClient(host, "token", WithSkipTLSVerify(true), WithClientCertificate(cert)).GetVersion(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "2.31.2", version)
+}
+
+func TestLoadTLSFilesErrors(t *testing.T) {
+	dir := t.TempDir()
+	invalidFile := filepath.Join(dir, "invalid.pem")
+	require.NoError(t, os.WriteFile(invalidFile, []byte("not a certificate"), 0600))
+
+	_, err := LoadCACertificates(filepath.Join(dir, "missing.pem"))
+	assert.ErrorContains(t, err, "failed to read CA certificates")
+
+	_, err = LoadCACertificates(invalidFile)
+	assert.ErrorContains(t, err, "no PEM certificate found")
+
+	_, err = LoadClientCertificate(invalidFile, invalidFile)
+	assert.ErrorContains(t, err, "failed to load client certificate")
+}
+
+func TestParseFingerprint(t *testing.T) {
+	sum := sha256.Sum256([]byte("certificate"))
+	plain := hex.EncodeToString(sum[:])
+
+	var colons []string
+	for i := 0; i < len(plain); i += 2 {
+		colons = append(colons, strings.ToUpper(plain[i:i+2]))
+	}
+
+	tests := []struct {
+		name          string
+		fingerprint   string
+		errorContains string
+	}{
+		{name: "plain hexadecimal", fingerprint: plain},
+		{name: "openssl format", fingerprint: strings.Join(colons, ":")},
+		{name: "not hexadecimal", fingerprint: "zz", errorContains: "invalid certificate fingerprint"},
+		{name: "wrong length", fingerprint: "ab:cd", errorContains: "expected a SHA-256 fingerprint"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fingerprint, err := ParseFingerprint(tt.fingerprint)
+
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, sum[:], fingerprint)
+			}
+		})
+	}
+}
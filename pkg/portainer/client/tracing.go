@@ -0,0 +1,100 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	goruntime "github.com/go-openapi/runtime"
+	"github.com/portainer/client-api-go/v2/client"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// TracerName is the name of the tracer recording the spans of the client
+const TracerName = "github.com/portainer/portainer-mcp/pkg/portainer/client"
+
+// WithTracerProvider records a span for each call to the Portainer API, and for each request proxied
+// to the Docker or Kubernetes API of an environment. The spans are children of the span of the
+// context of the call.
+func WithTracerProvider(provider trace.TracerProvider) ClientOption {
+	return func(o *clientOptions) {
+		o.tracerProvider = provider
+	}
+}
+
+// tracedTransport records a span for each operation submitted to the Portainer SDK,
+// whether it is sent through PortainerAPIClient or through the stacks and system services
+type tracedTransport struct {
+	next   goruntime.ClientTransport
+	tracer trace.Tracer
+}
+
+func (t *tracedTransport) Submit(operation *goruntime.ClientOperation) (any, error) {
+	ctx := operation.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	ctx, span := t.tracer.Start(ctx, "portainer "+operation.ID,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("portainer.operation", operation.ID),
+			attribute.String("http.request.method", operation.Method),
+			attribute.String("url.template", operation.PathPattern),
+		),
+	)
+	defer span.End()
+
+	operation.Context = ctx
+	result, err := t.next.Submit(operation)
+
+	if status, ok := statusCode(err); ok {
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+	}
+	endSpan(span, err)
+
+	return result, err
+}
+
+// startProxySpan starts the span of a request proxied to the given API of an environment.
+// It returns a non-recording span when tracing is disabled.
+func startProxySpan(ctx context.Context, tracer trace.Tracer, api string, environmentId int, opts client.ProxyRequestOptions) (context.Context, trace.Span) {
+	if tracer == nil {
+		return ctx, noop.Span{}
+	}
+
+	return tracer.Start(ctx, "portainer proxy "+api,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("portainer.proxy.api", api),
+			attribute.Int("portainer.environment.id", environmentId),
+			attribute.String("http.request.method", opts.Method),
+			attribute.String("portainer.proxy.path", opts.APIPath),
+		),
+	)
+}
+
+// endSpan marks the span as failed when the call returned an error
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// statusCode returns the HTTP status of an error returned by the Portainer SDK
+func statusCode(err error) (int, bool) {
+	var apiErr *goruntime.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code, true
+	}
+
+	var coded interface{ Code() int }
+	if errors.As(err, &coded) {
+		return coded.Code(), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,196 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	goruntime "github.com/go-openapi/runtime"
+	"github.com/portainer/client-api-go/v2/client"
+	"github.com/portainer/portainer-mcp/pkg/portainer/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// submitFunc adapts a function to a goruntime.ClientTransport
+type submitFunc func(*goruntime.ClientOperation) (any, error)
+
+func (f submitFunc) Submit(operation *goruntime.ClientOperation) (any, error) {
+	return f(operation)
+}
+
+// newRecordedTracerProvider returns a tracer provider recording the spans in memory
+func newRecordedTracerProvider() (*sdktrace.TracerProvider, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	return sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)), recorder
+}
+
+// spanAttributes returns the attributes of a span as a map
+func spanAttributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attributes := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		attributes[kv.Key] = kv.Value
+	}
+	return attributes
+}
+
+func TestTracedTransport(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus codes.Code
+		expectedCode   int64
+	}{
+		{
+			name:           "successful operation",
+			expectedStatus: codes.Unset,
+		},
+		{
+			name:           "API error",
+			err:            goruntime.NewAPIError("EndpointList", nil, http.StatusForbidden),
+			expectedStatus: codes.Error,
+			expectedCode:   http.StatusForbidden,
+		},
+		{
+			name:           "connection error",
+			err:            errors.New("connection refused"),
+			expectedStatus: codes.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, recorder := newRecordedTracerProvider()
+			tracer := provider.Tracer(TracerName)
+
+			parentCtx, parent := tracer.Start(context.Background(), "tools/call listEnvironments")
+
+			var submittedCtx context.Context
+			transport := &tracedTransport{
+				next: submitFunc(func(operation *goruntime.ClientOperation) (any, error) {
+					submittedCtx = operation.Context
+					return nil, tt.err
+				}),
+				tracer: tracer,
+			}
+
+			_, err := transport.Submit(&goruntime.ClientOperation{
+				ID:          "EndpointList",
+				Method:      http.MethodGet,
+				PathPattern: "/endpoints",
+				Context:     parentCtx,
+			})
+			parent.End()
+			assert.Equal(t, tt.err, err)
+
+			spans := recorder.Ended()
+			require.Len(t, spans, 2)
+			span := spans[0]
+
+			assert.Equal(t, "portainer EndpointList", span.Name())
+			assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID(), "the span is a child of the span of the context")
+			assert.Equal(t, span.SpanContext().SpanID(), trace.SpanContextFromContext(submittedCtx).SpanID(), "the operation is sent with the context of the span")
+			assert.Equal(t, tt.expectedStatus, span.Status().Code)
+
+			attributes := spanAttributes(span)
+			assert.Equal(t, "EndpointList", attributes["portainer.operation"].AsString())
+			assert.Equal(t, http.MethodGet, attributes["http.request.method"].AsString())
+			assert.Equal(t, "/endpoints", attributes["url.template"].AsString())
+			assert.Equal(t, tt.expectedCode, attributes["http.response.status_code"].AsInt64())
+		})
+	}
+}
+
+func TestProxyRequestSpan(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/api/endpoints/7/docker/") {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	provider, recorder := newRecordedTracerProvider()
+	c := NewPortainerClient(strings.TrimPrefix(srv.URL, "https://"), "token",
+		WithSkipTLSVerify(true),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 1}),
+		WithCircuitBreaker(0, 0),
+		WithTracerProvider(provider),
+	)
+
+	resp, err := c.ProxyKubernetesRequest(context.Background(), models.KubernetesProxyRequestOptions{
+		EnvironmentID: 3,
+		Method:        http.MethodGet,
+		Path:          "/api/v1/namespaces",
+	})
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	resp, err = c.ProxyDockerRequest(context.Background(), models.DockerProxyRequestOptions{
+		EnvironmentID: 7,
+		Method:        http.MethodPost,
+		Path:          "/containers/web/restart",
+	})
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	assert.Equal(t, "portainer proxy kubernetes", spans[0].Name())
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	attributes := spanAttributes(spans[0])
+	assert.Equal(t, int64(3), attributes["portainer.environment.id"].AsInt64())
+	assert.Equal(t, http.MethodGet, attributes["http.request.method"].AsString())
+	assert.Equal(t, "/api/v1/namespaces", attributes["portainer.proxy.path"].AsString())
+	assert.Equal(t, int64(http.StatusOK), attributes["http.response.status_code"].AsInt64())
+
+	assert.Equal(t, "portainer proxy docker", spans[1].Name())
+	assert.Equal(t, codes.Error, spans[1].Status().Code, "a server error of the environment fails the span")
+	attributes = spanAttributes(spans[1])
+	assert.Equal(t, int64(7), attributes["portainer.environment.id"].AsInt64())
+	assert.Equal(t, http.MethodPost, attributes["http.request.method"].AsString())
+	assert.Equal(t, "/containers/web/restart", attributes["portainer.proxy.path"].AsString())
+	assert.Equal(t, int64(http.StatusBadGateway), attributes["http.response.status_code"].AsInt64())
+}
+
+func TestNewPortainerClientTracing(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+
+	provider, recorder := newRecordedTracerProvider()
+	c := NewPortainerClient(strings.TrimPrefix(srv.URL, "https://"), "token",
+		WithSkipTLSVerify(true),
+		WithTracerProvider(provider),
+	)
+
+	_, err := c.GetEnvironments(context.Background())
+	require.NoError(t, err)
+	_, err = c.GetStacks(context.Background())
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2, "the calls of PortainerAPIClient and of the stacks service are traced")
+	assert.Equal(t, "portainer EndpointList", spans[0].Name())
+	assert.Equal(t, "portainer StackList", spans[1].Name())
+}
+
+func TestStartProxySpanWithoutTracer(t *testing.T) {
+	ctx := context.Background()
+	spanCtx, span := startProxySpan(ctx, nil, "docker", 1, client.ProxyRequestOptions{Method: http.MethodGet, APIPath: "/info"})
+
+	assert.Equal(t, ctx, spanCtx)
+	assert.False(t, span.IsRecording())
+}
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/portainer/portainer-mcp/pkg/portainer/models"
@@ -11,8 +12,10 @@ import (
 // Returns:
 //   - A slice of User objects containing user information
 //   - An error if the operation fails
-func (c *PortainerClient) GetUsers() ([]models.User, error) {
-	portainerUsers, err := c.cli.ListUsers()
+func (c *PortainerClient) GetUsers(ctx context.Context) ([]models.User, error) {
+	ctx = withOperation(ctx, "GetUsers")
+
+	portainerUsers, err := c.cli.ListUsers(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list users: %w", err)
 	}
@@ -28,18 +31,21 @@ func (c *PortainerClient) GetUsers() ([]models.User, error) {
 // UpdateUserRole updates the role of a user.
 //
 // Parameters:
+//   - ctx: The context of the request, cancelling it aborts the call to Portainer
 //   - id: The ID of the user to update
 //   - role: The new role for the user. Must be one of: admin, user, edge_admin
 //
 // Returns:
 //   - An error if the operation fails
-func (c *PortainerClient) UpdateUserRole(id int, role string) error {
+func (c *PortainerClient) UpdateUserRole(ctx context.Context, id int, role string) error {
+	ctx = withOperation(ctx, "UpdateUserRole")
+
 	roleInt := convertRole(role)
 	if roleInt == 0 {
 		return fmt.Errorf("invalid role: must be admin, user or edge_admin")
 	}
 
-	return c.cli.UpdateUserRole(id, roleInt)
+	return c.cli.UpdateUserRole(ctx, id, roleInt)
 }
 
 func convertRole(role string) int64 {
@@ -1,12 +1,39 @@
 package client
 
-import "fmt"
+import (
+	"context"
+	"fmt"
 
-func (c *PortainerClient) GetVersion() (string, error) {
-	version, err := c.cli.GetVersion()
+	sdksystem "github.com/portainer/client-api-go/v2/pkg/client/system"
+)
+
+func (c *PortainerClient) GetVersion(ctx context.Context) (string, error) {
+	ctx = withOperation(ctx, "GetVersion")
+
+	version, err := c.cli.GetVersion(ctx)
 	if err != nil {
 		return "", fmt.Errorf("failed to get version: %w", err)
 	}
 
 	return version, nil
 }
+
+// GetEdition returns the edition of the Portainer server, CE or EE.
+func (c *PortainerClient) GetEdition(ctx context.Context) (string, error) {
+	ctx = withOperation(ctx, "GetEdition")
+
+	if c.systemSvc == nil {
+		return "", fmt.Errorf("system service not initialized")
+	}
+
+	resp, err := c.systemSvc.SystemVersion(sdksystem.NewSystemVersionParamsWithContext(ctx), c.authInfo)
+	if err != nil {
+		return "", fmt.Errorf("failed to get edition: %w", err)
+	}
+
+	if resp.Payload == nil {
+		return "", fmt.Errorf("empty system version response")
+	}
+
+	return resp.Payload.ServerEdition, nil
+}
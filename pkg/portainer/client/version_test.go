@@ -1,9 +1,13 @@
 package client
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
+	"github.com/go-openapi/runtime"
+	sdksystem "github.com/portainer/client-api-go/v2/pkg/client/system"
+	apimodels "github.com/portainer/client-api-go/v2/pkg/models"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -40,7 +44,7 @@ func TestGetVersion(t *testing.T) {
 				cli: mockAPI,
 			}
 
-			version, err := client.GetVersion()
+			version, err := client.GetVersion(context.Background())
 
 			if tt.expectedError {
 				assert.Error(t, err)
@@ -54,3 +58,67 @@ func TestGetVersion(t *testing.T) {
 		})
 	}
 }
+
+// mockSystemService implements the SystemVersion operation of the SDK system service
+type mockSystemService struct {
+	sdksystem.ClientService
+	payload *apimodels.GithubComPortainerPortainerEeAPIHTTPHandlerSystemVersionResponse
+	err     error
+}
+
+func (m *mockSystemService) SystemVersion(params *sdksystem.SystemVersionParams, authInfo runtime.ClientAuthInfoWriter, opts ...sdksystem.ClientOption) (*sdksystem.SystemVersionOK, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &sdksystem.SystemVersionOK{Payload: m.payload}, nil
+}
+
+func TestGetEdition(t *testing.T) {
+	tests := []struct {
+		name           string
+		systemSvc      sdksystem.ClientService
+		expectedResult string
+		errorContains  string
+	}{
+		{
+			name: "enterprise edition",
+			systemSvc: &mockSystemService{
+				payload: &apimodels.GithubComPortainerPortainerEeAPIHTTPHandlerSystemVersionResponse{ServerEdition: "EE"},
+			},
+			expectedResult: "EE",
+		},
+		{
+			name:          "api error",
+			systemSvc:     &mockSystemService{err: fmt.Errorf("api error")},
+			errorContains: "failed to get edition",
+		},
+		{
+			name:          "empty response",
+			systemSvc:     &mockSystemService{},
+			errorContains: "empty system version response",
+		},
+		{
+			name:          "system service not initialized",
+			errorContains: "system service not initialized",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &PortainerClient{}
+			if tt.systemSvc != nil {
+				client.systemSvc = tt.systemSvc
+			}
+
+			edition, err := client.GetEdition(context.Background())
+
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+				assert.Empty(t, edition)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedResult, edition)
+			}
+		})
+	}
+}
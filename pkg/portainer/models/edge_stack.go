@@ -0,0 +1,114 @@
+package models
+
+import (
+	"slices"
+	"strconv"
+	"time"
+
+	apimodels "github.com/portainer/client-api-go/v2/pkg/models"
+	"github.com/portainer/portainer-mcp/pkg/portainer/utils"
+)
+
+// EdgeStack is a stack deployed by Portainer to the environments of one or more environment groups
+type EdgeStack struct {
+	ID                  int                   `json:"id"`
+	Name                string                `json:"name"`
+	CreatedAt           string                `json:"created_at"`
+	EnvironmentGroupIds []int                 `json:"group_ids"`
+	Deployments         []EdgeStackDeployment `json:"deployments"`
+}
+
+// EdgeStackDeployment is the deployment status of an edge stack on one environment
+type EdgeStackDeployment struct {
+	EnvironmentID int    `json:"environment_id"`
+	Status        string `json:"status"`
+	Error         string `json:"error,omitempty"`
+	UpdatedAt     string `json:"updated_at,omitempty"`
+}
+
+// Edge stack deployment status constants, in the order of the status types of the Portainer API
+const (
+	EdgeStackStatusPending             = "pending"
+	EdgeStackStatusDeploymentReceived  = "deployment_received"
+	EdgeStackStatusError               = "error"
+	EdgeStackStatusAcknowledged        = "acknowledged"
+	EdgeStackStatusRemoved             = "removed"
+	EdgeStackStatusRemoteUpdateSuccess = "remote_update_success"
+	EdgeStackStatusImagesPulled        = "images_pulled"
+	EdgeStackStatusRunning             = "running"
+	EdgeStackStatusDeploying           = "deploying"
+	EdgeStackStatusRemoving            = "removing"
+	EdgeStackStatusPausedDeploying     = "paused_deploying"
+	EdgeStackStatusRollingBack         = "rolling_back"
+	EdgeStackStatusRolledBack          = "rolled_back"
+	EdgeStackStatusCompleted           = "completed"
+	EdgeStackStatusUnknown             = "unknown"
+)
+
+var edgeStackStatuses = []string{
+	EdgeStackStatusPending,
+	EdgeStackStatusDeploymentReceived,
+	EdgeStackStatusError,
+	EdgeStackStatusAcknowledged,
+	EdgeStackStatusRemoved,
+	EdgeStackStatusRemoteUpdateSuccess,
+	EdgeStackStatusImagesPulled,
+	EdgeStackStatusRunning,
+	EdgeStackStatusDeploying,
+	EdgeStackStatusRemoving,
+	EdgeStackStatusPausedDeploying,
+	EdgeStackStatusRollingBack,
+	EdgeStackStatusRolledBack,
+	EdgeStackStatusCompleted,
+}
+
+func ConvertToEdgeStack(rawEdgeStack *apimodels.PortainereeEdgeStack) EdgeStack {
+	deployments := make([]EdgeStackDeployment, 0, len(rawEdgeStack.Status))
+	for key, status := range rawEdgeStack.Status {
+		environmentId := int(status.EndpointID)
+		if environmentId == 0 {
+			environmentId, _ = strconv.Atoi(key)
+		}
+
+		deployments = append(deployments, convertEdgeStackStatus(environmentId, status))
+	}
+
+	slices.SortFunc(deployments, func(a, b EdgeStackDeployment) int {
+		return a.EnvironmentID - b.EnvironmentID
+	})
+
+	return EdgeStack{
+		ID:                  int(rawEdgeStack.ID),
+		Name:                rawEdgeStack.Name,
+		CreatedAt:           time.Unix(rawEdgeStack.CreationDate, 0).Format(time.RFC3339),
+		EnvironmentGroupIds: utils.Int64ToIntSlice(rawEdgeStack.EdgeGroups),
+		Deployments:         deployments,
+	}
+}
+
+// convertEdgeStackStatus reports the latest entry of the status history of an environment,
+// an environment without history has not picked up the stack yet
+func convertEdgeStackStatus(environmentId int, rawStatus apimodels.PortainerEdgeStackStatus) EdgeStackDeployment {
+	deployment := EdgeStackDeployment{
+		EnvironmentID: environmentId,
+		Status:        EdgeStackStatusPending,
+	}
+
+	if len(rawStatus.Status) == 0 || rawStatus.Status[len(rawStatus.Status)-1] == nil {
+		return deployment
+	}
+
+	latest := rawStatus.Status[len(rawStatus.Status)-1]
+
+	deployment.Status = EdgeStackStatusUnknown
+	if latest.Type >= 0 && int(latest.Type) < len(edgeStackStatuses) {
+		deployment.Status = edgeStackStatuses[latest.Type]
+	}
+
+	deployment.Error = latest.Error
+	if latest.Time > 0 {
+		deployment.UpdatedAt = time.Unix(latest.Time, 0).Format(time.RFC3339)
+	}
+
+	return deployment
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/portainer/client-api-go/v2/pkg/models"
+)
+
+func TestConvertToEdgeStack(t *testing.T) {
+	tests := []struct {
+		name      string
+		edgeStack *models.PortainereeEdgeStack
+		want      EdgeStack
+	}{
+		{
+			name: "edge stack without deployments",
+			edgeStack: &models.PortainereeEdgeStack{
+				ID:           1,
+				Name:         "web",
+				CreationDate: 1609459200, // 2021-01-01 00:00:00 UTC
+				EdgeGroups:   []int64{1, 2},
+			},
+			want: EdgeStack{
+				ID:                  1,
+				Name:                "web",
+				CreatedAt:           time.Unix(1609459200, 0).Format(time.RFC3339),
+				EnvironmentGroupIds: []int{1, 2},
+				Deployments:         []EdgeStackDeployment{},
+			},
+		},
+		{
+			name: "latest status of each environment sorted by environment",
+			edgeStack: &models.PortainereeEdgeStack{
+				ID:           2,
+				Name:         "agent",
+				CreationDate: 1609459200,
+				EdgeGroups:   []int64{3},
+				Status: map[string]models.PortainerEdgeStackStatus{
+					"12": {
+						EndpointID: 12,
+						Status: []*models.PortainerEdgeStackDeploymentStatus{
+							{Type: 1, Time: 1609459300},
+							{Type: 2, Time: 1609459400, Error: "image not found"},
+						},
+					},
+					"4": {
+						EndpointID: 4,
+						Status: []*models.PortainerEdgeStackDeploymentStatus{
+							{Type: 8, Time: 1609459300},
+							{Type: 7, Time: 1609459500},
+						},
+					},
+					"7": {},
+					"9": {
+						EndpointID: 9,
+						Status:     []*models.PortainerEdgeStackDeploymentStatus{{Type: 42, Time: 1609459600}},
+					},
+				},
+			},
+			want: EdgeStack{
+				ID:                  2,
+				Name:                "agent",
+				CreatedAt:           time.Unix(1609459200, 0).Format(time.RFC3339),
+				EnvironmentGroupIds: []int{3},
+				Deployments: []EdgeStackDeployment{
+					{EnvironmentID: 4, Status: EdgeStackStatusRunning, UpdatedAt: time.Unix(1609459500, 0).Format(time.RFC3339)},
+					{EnvironmentID: 7, Status: EdgeStackStatusPending},
+					{EnvironmentID: 9, Status: EdgeStackStatusUnknown, UpdatedAt: time.Unix(1609459600, 0).Format(time.RFC3339)},
+					{EnvironmentID: 12, Status: EdgeStackStatusError, Error: "image not found", UpdatedAt: time.Unix(1609459400, 0).Format(time.RFC3339)},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ConvertToEdgeStack(tt.edgeStack)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ConvertToEdgeStack() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
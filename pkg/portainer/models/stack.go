@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	apimodels "github.com/portainer/client-api-go/v2/pkg/models"
@@ -8,14 +9,65 @@ import (
 )
 
 type Stack struct {
-	ID                  int    `json:"id"`
-	Name                string `json:"name"`
-	Status              string `json:"status"`
-	CreatedAt           string `json:"created_at"`
-	EndpointID          int    `json:"endpoint_id,omitempty"`
-	EnvironmentGroupIds []int  `json:"group_ids,omitempty"`
+	ID                  int             `json:"id"`
+	Name                string          `json:"name"`
+	Status              string          `json:"status"`
+	CreatedAt           string          `json:"created_at"`
+	EndpointID          int             `json:"endpoint_id,omitempty"`
+	EnvironmentGroupIds []int           `json:"group_ids,omitempty"`
+	Env                 []StackEnvVar   `json:"env,omitempty"`
+	GitConfig           *StackGitConfig `json:"git_config,omitempty"`
 }
 
+// StackGitConfig is the git repository a stack is deployed from, with the commit of its last deployment
+type StackGitConfig struct {
+	URL             string `json:"url"`
+	ReferenceName   string `json:"reference_name,omitempty"`
+	ComposeFilePath string `json:"compose_file_path"`
+	Authenticated   bool   `json:"authenticated"`
+	Username        string `json:"username,omitempty"`
+	TLSSkipVerify   bool   `json:"tls_skip_verify,omitempty"`
+	DeployedCommit  string `json:"deployed_commit,omitempty"`
+}
+
+// StackGitRepository locates the compose file of a new stack in a git repository, with the
+// optional credentials used to clone it
+type StackGitRepository struct {
+	URL             string
+	ReferenceName   string
+	ComposeFilePath string
+	Username        string
+	Password        string
+}
+
+// MaskedEnvValue replaces the values of the stack environment variables when they are marshalled
+const MaskedEnvValue = "********"
+
+// StackEnvVar is an environment variable of a stack, used for the ${VAR} interpolation of its
+// compose file. Its value often holds a secret, so only its name is exposed when marshalled.
+type StackEnvVar struct {
+	Name  string
+	Value string
+}
+
+func (v StackEnvVar) MarshalJSON() ([]byte, error) {
+	value := ""
+	if v.Value != "" {
+		value = MaskedEnvValue
+	}
+
+	return json.Marshal(struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}{Name: v.Name, Value: value})
+}
+
+// Stack status constants
+const (
+	StackStatusActive   = "active"
+	StackStatusInactive = "inactive"
+)
+
 func ConvertEdgeStackToStack(rawEdgeStack *apimodels.PortainereeEdgeStack) Stack {
 	createdAt := time.Unix(rawEdgeStack.CreationDate, 0).Format(time.RFC3339)
 
@@ -30,9 +82,16 @@ func ConvertEdgeStackToStack(rawEdgeStack *apimodels.PortainereeEdgeStack) Stack
 func ConvertRegularStackToStack(rawStack *apimodels.PortainereeStack) Stack {
 	createdAt := time.Unix(rawStack.CreationDate, 0).Format(time.RFC3339)
 
-	status := "inactive"
+	status := StackStatusInactive
 	if rawStack.Status == 1 {
-		status = "active"
+		status = StackStatusActive
+	}
+
+	var env []StackEnvVar
+	for _, pair := range rawStack.Env {
+		if pair != nil {
+			env = append(env, StackEnvVar{Name: pair.Name, Value: pair.Value})
+		}
 	}
 
 	return Stack{
@@ -41,5 +100,30 @@ func ConvertRegularStackToStack(rawStack *apimodels.PortainereeStack) Stack {
 		Status:     status,
 		CreatedAt:  createdAt,
 		EndpointID: int(rawStack.EndpointID),
+		Env:        env,
+		GitConfig:  convertStackGitConfig(rawStack),
+	}
+}
+
+// convertStackGitConfig returns the git configuration of a stack, or nil when the stack is
+// not deployed from a git repository
+func convertStackGitConfig(rawStack *apimodels.PortainereeStack) *StackGitConfig {
+	if rawStack.GitConfig == nil || rawStack.IsDetachedFromGit {
+		return nil
 	}
+
+	gitConfig := &StackGitConfig{
+		URL:             rawStack.GitConfig.URL,
+		ReferenceName:   rawStack.GitConfig.ReferenceName,
+		ComposeFilePath: rawStack.GitConfig.ConfigFilePath,
+		TLSSkipVerify:   rawStack.GitConfig.TlsskipVerify,
+		DeployedCommit:  rawStack.GitConfig.ConfigHash,
+	}
+
+	if auth := rawStack.GitConfig.Authentication; auth != nil {
+		gitConfig.Authenticated = true
+		gitConfig.Username = auth.Username
+	}
+
+	return gitConfig
 }
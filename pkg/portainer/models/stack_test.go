@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -86,3 +87,130 @@ func TestConvertEdgeStackToStack(t *testing.T) {
 		})
 	}
 }
+
+func TestConvertRegularStackToStack(t *testing.T) {
+	tests := []struct {
+		name  string
+		stack *models.PortainereeStack
+		want  Stack
+	}{
+		{
+			name: "active stack without environment variables",
+			stack: &models.PortainereeStack{
+				ID:           1,
+				Name:         "web",
+				Status:       1,
+				CreationDate: 1609459200, // 2021-01-01 00:00:00 UTC
+				EndpointID:   2,
+			},
+			want: Stack{
+				ID:         1,
+				Name:       "web",
+				Status:     StackStatusActive,
+				CreatedAt:  "2021-01-01T00:00:00Z",
+				EndpointID: 2,
+			},
+		},
+		{
+			name: "inactive stack with environment variables",
+			stack: &models.PortainereeStack{
+				ID:           2,
+				Name:         "db",
+				Status:       2,
+				CreationDate: 1609459200,
+				EndpointID:   2,
+				Env: []*models.PortainerPair{
+					{Name: "DB_PASSWORD", Value: "s3cret"},
+					nil,
+					{Name: "DEBUG"},
+				},
+			},
+			want: Stack{
+				ID:         2,
+				Name:       "db",
+				Status:     StackStatusInactive,
+				CreatedAt:  "2021-01-01T00:00:00Z",
+				EndpointID: 2,
+				Env:        []StackEnvVar{{Name: "DB_PASSWORD", Value: "s3cret"}, {Name: "DEBUG"}},
+			},
+		},
+		{
+			name: "stack deployed from a private git repository",
+			stack: &models.PortainereeStack{
+				ID:           3,
+				Name:         "web",
+				Status:       1,
+				CreationDate: 1609459200,
+				EndpointID:   2,
+				GitConfig: &models.GittypesRepoConfig{
+					URL:            "https://github.com/acme/stacks.git",
+					ReferenceName:  "refs/heads/main",
+					ConfigFilePath: "web/docker-compose.yml",
+					ConfigHash:     "bc4c183d756879ea4d173315338110b31004b8e0",
+					Authentication: &models.GittypesGitAuthentication{Username: "deploy", Password: "t0ken"},
+				},
+			},
+			want: Stack{
+				ID:         3,
+				Name:       "web",
+				Status:     StackStatusActive,
+				CreatedAt:  "2021-01-01T00:00:00Z",
+				EndpointID: 2,
+				GitConfig: &StackGitConfig{
+					URL:             "https://github.com/acme/stacks.git",
+					ReferenceName:   "refs/heads/main",
+					ComposeFilePath: "web/docker-compose.yml",
+					Authenticated:   true,
+					Username:        "deploy",
+					DeployedCommit:  "bc4c183d756879ea4d173315338110b31004b8e0",
+				},
+			},
+		},
+		{
+			name: "stack detached from its git repository",
+			stack: &models.PortainereeStack{
+				ID:                4,
+				Name:              "web",
+				Status:            2,
+				CreationDate:      1609459200,
+				EndpointID:        2,
+				GitConfig:         &models.GittypesRepoConfig{URL: "https://github.com/acme/stacks.git"},
+				IsDetachedFromGit: true,
+			},
+			want: Stack{
+				ID:         4,
+				Name:       "web",
+				Status:     StackStatusInactive,
+				CreatedAt:  "2021-01-01T00:00:00Z",
+				EndpointID: 2,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ConvertRegularStackToStack(tt.stack)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ConvertRegularStackToStack() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStackEnvVarMarshalJSON(t *testing.T) {
+	stack := Stack{
+		ID:   1,
+		Name: "db",
+		Env:  []StackEnvVar{{Name: "DB_PASSWORD", Value: "s3cret"}, {Name: "DEBUG"}},
+	}
+
+	data, err := json.Marshal(stack)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	want := `{"id":1,"name":"db","status":"","created_at":"","env":[{"name":"DB_PASSWORD","value":"********"},{"name":"DEBUG","value":""}]}`
+	if string(data) != want {
+		t.Errorf("json.Marshal() = %s, want %s", data, want)
+	}
+}
@@ -0,0 +1,143 @@
+package toolgen
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog/log"
+)
+
+// PromptDefinition represents a single prompt template in the YAML config
+type PromptDefinition struct {
+	Name        string                     `yaml:"name"`
+	Description string                     `yaml:"description"`
+	Arguments   []PromptArgumentDefinition `yaml:"arguments"`
+	Messages    []PromptMessageDefinition  `yaml:"messages"`
+}
+
+// PromptArgumentDefinition represents a prompt argument in the YAML config
+type PromptArgumentDefinition struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Required    bool   `yaml:"required"`
+}
+
+// PromptMessageDefinition represents a prompt message in the YAML config.
+// The content can reference arguments using the {argumentName} syntax.
+type PromptMessageDefinition struct {
+	Role    string `yaml:"role"`
+	Content string `yaml:"content"`
+}
+
+// Prompt is a parameterised prompt template loaded from the YAML config
+type Prompt struct {
+	Prompt   mcp.Prompt
+	Messages []PromptMessageDefinition
+}
+
+// placeholderPattern matches {argumentName} placeholders in prompt messages
+var placeholderPattern = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// LoadPromptsFromYAML loads prompt definitions from a YAML file.
+// Prompts are optional: a file without a prompts section returns an empty map.
+func LoadPromptsFromYAML(filePath string, minimumVersion string) (map[string]Prompt, error) {
+	config, err := loadConfig(filePath, minimumVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertPromptDefinitions(config.Prompts), nil
+}
+
+// Render returns the messages of the prompt with the {argumentName} placeholders
+// replaced by the given argument values
+func (p Prompt) Render(args map[string]string) ([]mcp.PromptMessage, error) {
+	for _, arg := range p.Prompt.Arguments {
+		if arg.Required && args[arg.Name] == "" {
+			return nil, fmt.Errorf("%s is required", arg.Name)
+		}
+	}
+
+	messages := make([]mcp.PromptMessage, 0, len(p.Messages))
+	for _, message := range p.Messages {
+		content := placeholderPattern.ReplaceAllStringFunc(message.Content, func(placeholder string) string {
+			return args[strings.Trim(placeholder, "{}")]
+		})
+
+		messages = append(messages, mcp.NewPromptMessage(mcp.Role(message.Role), mcp.NewTextContent(content)))
+	}
+
+	return messages, nil
+}
+
+// convertPromptDefinitions converts YAML prompt definitions to Prompt objects
+func convertPromptDefinitions(defs []PromptDefinition) map[string]Prompt {
+	prompts := make(map[string]Prompt, len(defs))
+
+	for _, def := range defs {
+		prompt, err := convertPromptDefinition(def)
+		if err != nil {
+			log.Warn().Err(err).Str("prompt", def.Name).Msg("skipping invalid prompt definition")
+			continue
+		}
+
+		prompts[def.Name] = prompt
+	}
+
+	return prompts
+}
+
+// convertPromptDefinition converts a single YAML prompt definition to a Prompt
+func convertPromptDefinition(def PromptDefinition) (Prompt, error) {
+	if def.Name == "" {
+		return Prompt{}, fmt.Errorf("prompt name is required")
+	}
+
+	if def.Description == "" {
+		return Prompt{}, fmt.Errorf("prompt description is required for prompt '%s'", def.Name)
+	}
+
+	if len(def.Messages) == 0 {
+		return Prompt{}, fmt.Errorf("at least one message is required for prompt '%s'", def.Name)
+	}
+
+	options := []mcp.PromptOption{
+		mcp.WithPromptDescription(def.Description),
+	}
+
+	arguments := make(map[string]bool, len(def.Arguments))
+	for _, arg := range def.Arguments {
+		if arg.Name == "" {
+			return Prompt{}, fmt.Errorf("argument name is required for prompt '%s'", def.Name)
+		}
+		arguments[arg.Name] = true
+
+		argOptions := []mcp.ArgumentOption{
+			mcp.ArgumentDescription(arg.Description),
+		}
+		if arg.Required {
+			argOptions = append(argOptions, mcp.RequiredArgument())
+		}
+
+		options = append(options, mcp.WithArgument(arg.Name, argOptions...))
+	}
+
+	for _, message := range def.Messages {
+		if message.Role != string(mcp.RoleUser) && message.Role != string(mcp.RoleAssistant) {
+			return Prompt{}, fmt.Errorf("invalid message role '%s' for prompt '%s', must be user or assistant", message.Role, def.Name)
+		}
+
+		for _, match := range placeholderPattern.FindAllStringSubmatch(message.Content, -1) {
+			if !arguments[match[1]] {
+				return Prompt{}, fmt.Errorf("message references undefined argument '%s' in prompt '%s'", match[1], def.Name)
+			}
+		}
+	}
+
+	return Prompt{
+		Prompt:   mcp.NewPrompt(def.Name, options...),
+		Messages: def.Messages,
+	}, nil
+}
@@ -0,0 +1,180 @@
+package toolgen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadPromptsFromYAML(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	withPromptsPath := filepath.Join(tmpDir, "prompts.yaml")
+	withPromptsContent := `version: "v1.3.0"
+tools: []
+prompts:
+  - name: troubleshootStack
+    description: Troubleshoot a stack
+    arguments:
+      - name: stackId
+        description: The ID of the stack
+        required: true
+      - name: focus
+        description: What to focus on
+    messages:
+      - role: user
+        content: Troubleshoot stack {stackId}, focus on {focus}
+  - name: invalidRole
+    description: Prompt with an invalid role
+    messages:
+      - role: system
+        content: Hello
+  - name: undefinedArgument
+    description: Prompt referencing an undefined argument
+    messages:
+      - role: user
+        content: Audit environment {environmentId}`
+	require.NoError(t, os.WriteFile(withPromptsPath, []byte(withPromptsContent), 0644))
+
+	withoutPromptsPath := filepath.Join(tmpDir, "no-prompts.yaml")
+	require.NoError(t, os.WriteFile(withoutPromptsPath, []byte(`version: "v1.0.0"
+tools: []`), 0644))
+
+	t.Run("valid and invalid prompts", func(t *testing.T) {
+		prompts, err := LoadPromptsFromYAML(withPromptsPath, "v1.0.0")
+		require.NoError(t, err)
+		require.Len(t, prompts, 1, "invalid prompts should be skipped")
+
+		prompt, ok := prompts["troubleshootStack"]
+		require.True(t, ok)
+		assert.Equal(t, "Troubleshoot a stack", prompt.Prompt.Description)
+		require.Len(t, prompt.Prompt.Arguments, 2)
+		assert.Equal(t, "stackId", prompt.Prompt.Arguments[0].Name)
+		assert.True(t, prompt.Prompt.Arguments[0].Required)
+		assert.False(t, prompt.Prompt.Arguments[1].Required)
+	})
+
+	t.Run("no prompts section", func(t *testing.T) {
+		prompts, err := LoadPromptsFromYAML(withoutPromptsPath, "v1.0.0")
+		require.NoError(t, err)
+		assert.Empty(t, prompts)
+	})
+
+	t.Run("version below minimum", func(t *testing.T) {
+		_, err := LoadPromptsFromYAML(withoutPromptsPath, "v1.3.0")
+		assert.Error(t, err)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := LoadPromptsFromYAML(filepath.Join(tmpDir, "missing.yaml"), "v1.0.0")
+		assert.Error(t, err)
+	})
+}
+
+func TestConvertPromptDefinition(t *testing.T) {
+	tests := []struct {
+		name          string
+		def           PromptDefinition
+		errorContains string
+	}{
+		{
+			name: "valid prompt",
+			def: PromptDefinition{
+				Name:        "prompt",
+				Description: "A prompt",
+				Arguments:   []PromptArgumentDefinition{{Name: "id", Required: true}},
+				Messages:    []PromptMessageDefinition{{Role: "user", Content: "Look at {id}"}},
+			},
+		},
+		{
+			name:          "missing name",
+			def:           PromptDefinition{Description: "A prompt"},
+			errorContains: "prompt name is required",
+		},
+		{
+			name:          "missing description",
+			def:           PromptDefinition{Name: "prompt"},
+			errorContains: "prompt description is required",
+		},
+		{
+			name:          "missing messages",
+			def:           PromptDefinition{Name: "prompt", Description: "A prompt"},
+			errorContains: "at least one message is required",
+		},
+		{
+			name: "missing argument name",
+			def: PromptDefinition{
+				Name:        "prompt",
+				Description: "A prompt",
+				Arguments:   []PromptArgumentDefinition{{Description: "no name"}},
+				Messages:    []PromptMessageDefinition{{Role: "user", Content: "Hello"}},
+			},
+			errorContains: "argument name is required",
+		},
+		{
+			name: "invalid role",
+			def: PromptDefinition{
+				Name:        "prompt",
+				Description: "A prompt",
+				Messages:    []PromptMessageDefinition{{Role: "system", Content: "Hello"}},
+			},
+			errorContains: "invalid message role",
+		},
+		{
+			name: "undefined argument",
+			def: PromptDefinition{
+				Name:        "prompt",
+				Description: "A prompt",
+				Messages:    []PromptMessageDefinition{{Role: "assistant", Content: "Look at {id}"}},
+			},
+			errorContains: "undefined argument 'id'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := convertPromptDefinition(tt.def)
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPromptRender(t *testing.T) {
+	prompt, err := convertPromptDefinition(PromptDefinition{
+		Name:        "auditEnvironmentAccess",
+		Description: "Audit access",
+		Arguments: []PromptArgumentDefinition{
+			{Name: "environmentId", Required: true},
+			{Name: "note"},
+		},
+		Messages: []PromptMessageDefinition{
+			{Role: "user", Content: "Audit environment {environmentId}.{note}"},
+			{Role: "assistant", Content: "Auditing environment {environmentId}"},
+		},
+	})
+	require.NoError(t, err)
+
+	t.Run("renders placeholders", func(t *testing.T) {
+		messages, err := prompt.Render(map[string]string{"environmentId": "3"})
+		require.NoError(t, err)
+		require.Len(t, messages, 2)
+
+		assert.Equal(t, mcp.RoleUser, messages[0].Role)
+		assert.Equal(t, "Audit environment 3.", messages[0].Content.(mcp.TextContent).Text)
+		assert.Equal(t, mcp.RoleAssistant, messages[1].Role)
+		assert.Equal(t, "Auditing environment 3", messages[1].Content.(mcp.TextContent).Text)
+	})
+
+	t.Run("missing required argument", func(t *testing.T) {
+		_, err := prompt.Render(map[string]string{})
+		assert.ErrorContains(t, err, "environmentId is required")
+	})
+}
@@ -0,0 +1,54 @@
+package toolgen
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// LoadToolTimeoutsFromYAML loads the timeouts of the tools from a YAML file.
+// Only the tools with a timeout are returned, invalid timeouts are logged and skipped.
+func LoadToolTimeoutsFromYAML(filePath string, minimumVersion string) (map[string]time.Duration, error) {
+	config, err := loadConfig(filePath, minimumVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertToolTimeouts(config.Tools), nil
+}
+
+// convertToolTimeouts parses the timeouts of the YAML tool definitions
+func convertToolTimeouts(defs []ToolDefinition) map[string]time.Duration {
+	timeouts := make(map[string]time.Duration)
+
+	for _, def := range defs {
+		if def.Timeout == "" {
+			continue
+		}
+
+		timeout, err := parseTimeout(def.Timeout)
+		if err != nil {
+			log.Warn().Err(err).Str("tool", def.Name).Msg("skipping invalid tool timeout")
+			continue
+		}
+
+		timeouts[def.Name] = timeout
+	}
+
+	return timeouts
+}
+
+// parseTimeout parses a timeout, which must be a positive Go duration
+func parseTimeout(value string) (time.Duration, error) {
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout %q: %w", value, err)
+	}
+
+	if timeout <= 0 {
+		return 0, fmt.Errorf("invalid timeout %q: must be positive", value)
+	}
+
+	return timeout, nil
+}
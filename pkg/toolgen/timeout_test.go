@@ -0,0 +1,92 @@
+package toolgen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadToolTimeoutsFromYAML(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	path := filepath.Join(tmpDir, "tools.yaml")
+	content := `version: "v1.3.0"
+tools:
+  - name: listStacks
+    description: List stacks
+    annotations:
+      title: List Stacks
+      readOnlyHint: true
+  - name: createStack
+    description: Create a stack
+    timeout: 5m
+    annotations:
+      title: Create Stack
+  - name: dockerProxy
+    description: Proxy Docker requests
+    timeout: 1m30s
+    annotations:
+      title: Docker Proxy
+  - name: invalidTimeout
+    description: Tool with an invalid timeout
+    timeout: forever
+    annotations:
+      title: Invalid Timeout
+  - name: negativeTimeout
+    description: Tool with a negative timeout
+    timeout: -1s
+    annotations:
+      title: Negative Timeout`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	t.Run("valid and invalid timeouts", func(t *testing.T) {
+		timeouts, err := LoadToolTimeoutsFromYAML(path, "v1.0.0")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]time.Duration{
+			"createStack": 5 * time.Minute,
+			"dockerProxy": 90 * time.Second,
+		}, timeouts, "tools without a valid timeout should be skipped")
+	})
+
+	t.Run("version below minimum", func(t *testing.T) {
+		_, err := LoadToolTimeoutsFromYAML(path, "v2.0.0")
+		assert.Error(t, err)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := LoadToolTimeoutsFromYAML(filepath.Join(tmpDir, "missing.yaml"), "v1.0.0")
+		assert.Error(t, err)
+	})
+}
+
+func TestParseTimeout(t *testing.T) {
+	tests := []struct {
+		name          string
+		value         string
+		expected      time.Duration
+		errorContains string
+	}{
+		{name: "seconds", value: "30s", expected: 30 * time.Second},
+		{name: "minutes and seconds", value: "2m30s", expected: 150 * time.Second},
+		{name: "not a duration", value: "30", errorContains: "invalid timeout"},
+		{name: "zero", value: "0s", errorContains: "must be positive"},
+		{name: "negative", value: "-5s", errorContains: "must be positive"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timeout, err := parseTimeout(tt.value)
+
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, timeout)
+			}
+		})
+	}
+}
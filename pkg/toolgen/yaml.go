@@ -2,18 +2,19 @@ package toolgen
 
 import (
 	"fmt"
-	"log"
 	"os"
 
 	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog/log"
 	"golang.org/x/mod/semver"
 	"gopkg.in/yaml.v3"
 )
 
 // ToolsConfig represents the entire YAML configuration
 type ToolsConfig struct {
-	Version string           `yaml:"version"`
-	Tools   []ToolDefinition `yaml:"tools"`
+	Version string             `yaml:"version"`
+	Tools   []ToolDefinition   `yaml:"tools"`
+	Prompts []PromptDefinition `yaml:"prompts,omitempty"`
 }
 
 // ToolDefinition represents a single tool in the YAML config
@@ -22,6 +23,9 @@ type ToolDefinition struct {
 	Description string                `yaml:"description"`
 	Parameters  []ParameterDefinition `yaml:"parameters"`
 	Annotations Annotations           `yaml:"annotations"`
+	// Timeout is the time allowed to the tool calls, as a Go duration (e.g. 2m).
+	// It is optional, the server applies its default timeout when it is not set.
+	Timeout string `yaml:"timeout,omitempty"`
 }
 
 // ParameterDefinition represents a tool parameter in the YAML config
@@ -46,6 +50,16 @@ type Annotations struct {
 // LoadToolsFromYAML loads tool definitions from a YAML file
 // It returns the tools and the version of the tools.yaml file
 func LoadToolsFromYAML(filePath string, minimumVersion string) (map[string]mcp.Tool, error) {
+	config, err := loadConfig(filePath, minimumVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertToolDefinitions(config.Tools), nil
+}
+
+// loadConfig reads a YAML config file and validates its version
+func loadConfig(filePath string, minimumVersion string) (*ToolsConfig, error) {
 	data, err := os.ReadFile(filePath)
 	if err != nil {
 		return nil, err
@@ -68,7 +82,7 @@ func LoadToolsFromYAML(filePath string, minimumVersion string) (map[string]mcp.T
 		return nil, fmt.Errorf("tools.yaml version %s is below the minimum required version %s", config.Version, minimumVersion)
 	}
 
-	return convertToolDefinitions(config.Tools), nil
+	return &config, nil
 }
 
 // convertToolDefinitions converts YAML tool definitions to mcp.Tool objects
@@ -78,7 +92,7 @@ func convertToolDefinitions(defs []ToolDefinition) map[string]mcp.Tool {
 	for _, def := range defs {
 		tool, err := convertToolDefinition(def)
 		if err != nil {
-			log.Printf("skipping invalid tool definition %s: %s", def.Name, err)
+			log.Warn().Err(err).Str("tool", def.Name).Msg("skipping invalid tool definition")
 			continue
 		}
 